@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/knative/serving/cmd/util"
@@ -34,14 +36,19 @@ import (
 	"github.com/knative/serving/pkg/activator"
 	activatorhandler "github.com/knative/serving/pkg/activator/handler"
 	activatorutil "github.com/knative/serving/pkg/activator/util"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	clientset "github.com/knative/serving/pkg/client/clientset/versioned"
 	"github.com/knative/serving/pkg/http/h2c"
 	"github.com/knative/serving/pkg/logging"
 	"github.com/knative/serving/pkg/metrics"
 	"github.com/knative/serving/pkg/system"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/knative/serving/pkg/websocket"
 )
@@ -61,6 +68,16 @@ const (
 
 	// Add enough buffer to not block request serving on stats collection
 	requestCountingQueueLength = 100
+
+	// How often the connections open to each revision are reported.
+	connectionReportingPeriod = 10 * time.Second
+
+	// How often the memory used by the activator is reported.
+	memoryReportingPeriod = 30 * time.Second
+
+	// How often the skew of the latencies of the pods of each revision is
+	// reported.
+	podLatencyReportingPeriod = 30 * time.Second
 )
 
 var (
@@ -85,6 +102,75 @@ func statReporter() {
 	}
 }
 
+// reportConnectionPool reports the connections open to each revision, and
+// records a Warning event on the revisions whose connections keep growing.
+func reportConnectionPool(connections *activatorutil.ConnectionCounter, reporter activator.StatsReporter, recorder record.EventRecorder) {
+	for range time.NewTicker(connectionReportingPeriod).C {
+		for _, stat := range connections.Report() {
+			if err := reporter.ReportConnectionPoolSize(stat.Namespace, stat.Revision, stat.Open); err != nil {
+				logger.Error("Failed to report connection pool size", zap.Error(err))
+			}
+			if !stat.Growing {
+				continue
+			}
+			logger.Warnf("Connections to revision %s/%s have grown to %d", stat.Namespace, stat.Revision, stat.Open)
+			recorder.Eventf(&corev1.ObjectReference{
+				Kind:       "Revision",
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Namespace:  stat.Namespace,
+				Name:       stat.Revision,
+			}, corev1.EventTypeWarning, "ConnectionPoolGrowing",
+				"The activator connections to the revision grew for %d consecutive periods to %d",
+				activatorutil.ConnectionGrowthPeriods, stat.Open)
+		}
+	}
+}
+
+// reportPodLatencySkew reports how much the latencies of the pods of each
+// revision differ.
+func reportPodLatencySkew(podLatencies *activatorutil.PodLatencies, reporter activator.StatsReporter) {
+	for range time.NewTicker(podLatencyReportingPeriod).C {
+		for _, skew := range podLatencies.Report() {
+			if err := reporter.ReportPodLatencyCoV(skew.Namespace, skew.Revision, skew.CoefficientOfVariation); err != nil {
+				logger.Error("Failed to report pod latency skew", zap.Error(err))
+			}
+		}
+	}
+}
+
+// reportMemoryUsage reports the memory used by the activator, and records a
+// Warning event on the activator pod when it comes under memory pressure.
+func reportMemoryUsage(pressure *activatorutil.MemoryPressure, reporter activator.StatsReporter, recorder record.EventRecorder, podName string) {
+	for range time.NewTicker(memoryReportingPeriod).C {
+		rss, err := activatorutil.ReadRSS("/proc/self/status")
+		if err != nil {
+			logger.Error("Failed to read the memory usage", zap.Error(err))
+			continue
+		}
+		if err := reporter.ReportMemoryUsage(rss); err != nil {
+			logger.Error("Failed to report memory usage", zap.Error(err))
+		}
+		if !pressure.Update(rss) {
+			continue
+		}
+		logger.Warnf("Memory usage of %d bytes is above %v of the limit, rejecting new requests", rss, activatorutil.MemoryPressureRatio)
+		recorder.Eventf(&corev1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  system.Namespace,
+			Name:       podName,
+		}, corev1.EventTypeWarning, "MemoryPressure",
+			"The activator uses %d bytes, above %v of its memory limit; new requests are rejected until the requests in flight drain",
+			rss, activatorutil.MemoryPressureRatio)
+	}
+}
+
+func newEventRecorder(kubeClient kubernetes.Interface) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	return eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+}
+
 func main() {
 	flag.Parse()
 	cm, err := configmap.Load("/etc/config-logging")
@@ -131,7 +217,27 @@ func main() {
 		Factor:   exponentialBackoffBase,
 		Steps:    maxRetries,
 	}
-	rt := activatorutil.NewRetryRoundTripper(activatorutil.AutoTransport, logger, backoffSettings, shouldRetry)
+	connections := activatorutil.NewConnectionCounter()
+	reportAttempt := func(rev activatorutil.RevisionKey, attempt int, latency time.Duration) {
+		if err := reporter.ReportFanoutLatency(rev.Namespace, rev.Revision, attempt, latency); err != nil {
+			logger.Error("Failed to report attempt latency", zap.Error(err))
+		}
+	}
+	reportBackoffs := func(reasons []string, total time.Duration) {
+		for i, reason := range reasons {
+			if err := reporter.ReportBackoff(i+1, reason); err != nil {
+				logger.Error("Failed to report backoff", zap.Error(err))
+			}
+		}
+		if err := reporter.ReportBackoffDuration(total); err != nil {
+			logger.Error("Failed to report backoff duration", zap.Error(err))
+		}
+	}
+	rt := activatorutil.NewObservedRetryRoundTripper(
+		activatorutil.NewAttemptTimingRoundTripper(activatorutil.NewCountingTransport(connections), reportAttempt),
+		logger, backoffSettings, reportBackoffs, shouldRetry)
+	recorder := newEventRecorder(kubeClient)
+	go reportConnectionPool(connections, reporter, recorder)
 
 	// Open a websocket connection to the autoscaler
 	autoscalerEndpoint := fmt.Sprintf("ws://%s.%s.svc.cluster.local:%s", "autoscaler", system.Namespace, "8080")
@@ -140,6 +246,18 @@ func main() {
 	go statReporter()
 
 	podName := util.GetRequiredEnvOrFatal("POD_NAME", logger)
+
+	// Without a memory limit the activator is never under memory pressure.
+	var memoryLimit int64
+	if v := os.Getenv("MEMORY_LIMIT_BYTES"); v != "" {
+		if memoryLimit, err = strconv.ParseInt(v, 10, 64); err != nil {
+			logger.Fatalf("Invalid MEMORY_LIMIT_BYTES %q: %v", v, err)
+		}
+	}
+	memoryPressure := activatorutil.NewMemoryPressure(memoryLimit)
+	go reportMemoryUsage(memoryPressure, reporter, recorder, podName)
+	podLatencies := activatorutil.NewPodLatencies()
+	go reportPodLatencySkew(podLatencies, reporter)
 	activatorhandler.NewConcurrencyReporter(podName, activatorhandler.Channels{
 		ReqChan:    reqChan,
 		StatChan:   statChan,
@@ -147,17 +265,33 @@ func main() {
 	})
 
 	ah := &activatorhandler.FilteringHandler{
-		NextHandler: activatorhandler.NewRequestEventHandler(reqChan,
-			&activatorhandler.EnforceMaxContentLengthHandler{
-				MaxContentLengthBytes: maxUploadBytes,
-				NextHandler: &activatorhandler.ActivationHandler{
-					Activator: a,
-					Transport: rt,
-					Logger:    logger,
-					Reporter:  reporter,
+		NextHandler: &activatorhandler.MemoryPressureHandler{
+			Pressure: memoryPressure,
+			NextHandler: &activatorhandler.RequestIDHandler{
+				Reporter: reporter,
+				Logger:   logger,
+				NextHandler: &activatorhandler.TagRoutingHandler{
+					Reporter: reporter,
+					Logger:   logger,
+					NextHandler: &activatorhandler.ABTestMetricExtractor{
+						Reporter: reporter,
+						Logger:   logger,
+						NextHandler: activatorhandler.NewRequestEventHandler(reqChan,
+							&activatorhandler.EnforceMaxContentLengthHandler{
+								MaxContentLengthBytes: maxUploadBytes,
+								NextHandler: &activatorhandler.ActivationHandler{
+									Activator:    a,
+									Transport:    rt,
+									Logger:       logger,
+									Reporter:     reporter,
+									PodLatencies: podLatencies,
+								},
+							},
+						),
+					},
 				},
 			},
-		),
+		},
 	}
 
 	// set up signals so we handle the first shutdown signal gracefully
@@ -165,6 +299,7 @@ func main() {
 	go func() {
 		<-stopCh
 		a.Shutdown()
+		metrics.FlushExporter()
 	}()
 
 	// Watch the logging config map and dynamically update logging levels.
@@ -172,6 +307,8 @@ func main() {
 	configMapWatcher.Watch(logging.ConfigName, logging.UpdateLevelFromConfigMap(logger, atomicLevel, component))
 	// Watch the observability config map and dynamically update metrics exporter.
 	configMapWatcher.Watch(metrics.ObservabilityConfigName, metrics.UpdateExporterFromConfigMap(component, logger))
+	// Measure the latency of the metrics pipeline up to the exporter.
+	go metrics.RunPipelineLatencyProbe(stopCh)
 	if err = configMapWatcher.Start(stopCh); err != nil {
 		logger.Fatalf("failed to start configuration manager: %v", err)
 	}
@@ -36,15 +36,19 @@ import (
 	"github.com/knative/serving/pkg/system"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery/cached"
 	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -93,6 +97,8 @@ func main() {
 	configMapWatcher.Watch(logging.ConfigName, logging.UpdateLevelFromConfigMap(logger, atomicLevel, component))
 	// Watch the observability config map and dynamically update metrics exporter.
 	configMapWatcher.Watch(metrics.ObservabilityConfigName, metrics.UpdateExporterFromConfigMap(component, logger))
+	// Measure the latency of the metrics pipeline up to the exporter.
+	go metrics.RunPipelineLatencyProbe(stopCh)
 	// This is based on how Kubernetes sets up its scale client based on discovery:
 	// https://github.com/kubernetes/kubernetes/blob/94c2c6c84/cmd/kube-controller-manager/app/autoscaling.go#L75-L81
 	restMapper := buildRESTMapper(kubeClientSet, stopCh)
@@ -118,7 +124,7 @@ func main() {
 	// Watch the autoscaler config map and dynamically update autoscaler config.
 	configMapWatcher.Watch(autoscaler.ConfigName, dynConfig.Update)
 
-	multiScaler := autoscaler.NewMultiScaler(dynConfig, stopCh, uniScalerFactory, logger)
+	multiScaler := autoscaler.NewMultiScaler(dynConfig, stopCh, uniScalerFactory(newEventRecorder(kubeClientSet)), logger)
 
 	opt := reconciler.Options{
 		KubeClientSet:    kubeClientSet,
@@ -131,9 +137,12 @@ func main() {
 
 	kpaInformer := servingInformerFactory.Autoscaling().V1alpha1().PodAutoscalers()
 	endpointsInformer := kubeInformerFactory.Core().V1().Endpoints()
+	hpaInformer := kubeInformerFactory.Autoscaling().V1().HorizontalPodAutoscalers()
+	podInformer := kubeInformerFactory.Core().V1().Pods()
 
 	kpaScaler := autoscaling.NewKPAScaler(servingClientSet, scaleClient, logger, configMapWatcher)
-	ctl := autoscaling.NewController(&opt, kpaInformer, endpointsInformer, multiScaler, kpaScaler)
+	ctl := autoscaling.NewController(&opt, kpaInformer, endpointsInformer, hpaInformer, multiScaler, kpaScaler)
+	autoscaling.WatchClusterScale(podInformer, logger)
 
 	// Start the serving informer factory.
 	kubeInformerFactory.Start(stopCh)
@@ -147,6 +156,8 @@ func main() {
 	for i, synced := range []cache.InformerSynced{
 		kpaInformer.Informer().HasSynced,
 		endpointsInformer.Informer().HasSynced,
+		hpaInformer.Informer().HasSynced,
+		podInformer.Informer().HasSynced,
 	} {
 		if ok := cache.WaitForCacheSync(stopCh, synced); !ok {
 			logger.Fatalf("failed to wait for cache at index %v to sync", i)
@@ -190,6 +201,7 @@ func main() {
 	}
 
 	statsServer.Shutdown(time.Second * 5)
+	metrics.FlushExporter()
 }
 
 func buildRESTMapper(kubeClientSet kubernetes.Interface, stopCh <-chan struct{}) *restmapper.DeferredDiscoveryRESTMapper {
@@ -204,15 +216,31 @@ func buildRESTMapper(kubeClientSet kubernetes.Interface, stopCh <-chan struct{})
 	return rm
 }
 
-func uniScalerFactory(kpa *kpa.PodAutoscaler, dynamicConfig *autoscaler.DynamicConfig) (autoscaler.UniScaler, error) {
-	// Create a stats reporter which tags statistics by KPA namespace, configuration name, and KPA name.
-	reporter, err := autoscaler.NewStatsReporter(kpa.Namespace,
-		labelValueOrEmpty(kpa, serving.ServiceLabelKey), labelValueOrEmpty(kpa, serving.ConfigurationLabelKey), kpa.Name)
-	if err != nil {
-		return nil, err
-	}
+// newEventRecorder creates the recorder of the events of the KPAs.
+func newEventRecorder(kubeClient kubernetes.Interface) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	return eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+}
 
-	return autoscaler.New(dynamicConfig, kpa.Spec.ContainerConcurrency, reporter), nil
+// uniScalerFactory returns a UniScalerFactory creating autoscalers which
+// record the bursts of the request rate of their KPA as events.
+func uniScalerFactory(recorder record.EventRecorder) autoscaler.UniScalerFactory {
+	return func(kpa *kpa.PodAutoscaler, dynamicConfig *autoscaler.DynamicConfig) (autoscaler.UniScaler, error) {
+		// Create a stats reporter which tags statistics by KPA namespace, configuration name, and KPA name.
+		reporter, err := autoscaler.NewStatsReporter(kpa.Namespace,
+			labelValueOrEmpty(kpa, serving.ServiceLabelKey), labelValueOrEmpty(kpa, serving.ConfigurationLabelKey), kpa.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		reportBurst := func(magnitude float64) {
+			recorder.Eventf(kpa, corev1.EventTypeNormal, "RequestRateBurst",
+				"Request rate of the last %v is %.0fx the average of the previous %v",
+				autoscaler.RequestRateBurstWindow, magnitude, autoscaler.RequestRateBaselineWindow)
+		}
+		return autoscaler.New(dynamicConfig, kpa.Spec.ContainerConcurrency, reporter, reportBurst), nil
+	}
 }
 
 func labelValueOrEmpty(kpa *kpa.PodAutoscaler, labelKey string) string {
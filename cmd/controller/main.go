@@ -19,13 +19,20 @@ package main
 import (
 	"flag"
 	"log"
+	"net/http"
 	"time"
 
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 
 	// Uncomment the following line to load the gcp plugin (only required to authenticate against GKE clusters).
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -40,7 +47,9 @@ import (
 	clientset "github.com/knative/serving/pkg/client/clientset/versioned"
 	informers "github.com/knative/serving/pkg/client/informers/externalversions"
 	"github.com/knative/serving/pkg/logging"
+	"github.com/knative/serving/pkg/metrics"
 	"github.com/knative/serving/pkg/reconciler"
+	"github.com/knative/serving/pkg/reconciler/configschema"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/configuration"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/labeler"
@@ -81,6 +90,19 @@ func main() {
 		logger.Fatalf("Error building kubeconfig: %v", err)
 	}
 
+	// Observe the API server calls of all the clients below for throttling.
+	throttleReporter, err := reconciler.NewAPIServerThrottleReporter(component)
+	if err != nil {
+		logger.Fatalf("Error building API server throttle reporter: %v", err)
+	}
+	wrapTransport := cfg.WrapTransport
+	cfg.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if wrapTransport != nil {
+			rt = wrapTransport(rt)
+		}
+		return throttleReporter.WrapTransport(rt)
+	}
+
 	kubeClient, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		logger.Fatalf("Error building kubernetes clientset: %v", err)
@@ -122,7 +144,9 @@ func main() {
 
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, opt.ResyncPeriod)
 	sharedInformerFactory := sharedinformers.NewSharedInformerFactory(sharedClient, opt.ResyncPeriod)
-	servingInformerFactory := informers.NewSharedInformerFactory(servingClient, opt.ResyncPeriod)
+	// The controllers resync their serving resources themselves every
+	// opt.ResyncPeriod, to measure how long the resyncs take.
+	servingInformerFactory := informers.NewSharedInformerFactory(servingClient, 0)
 	cachingInformerFactory := cachinginformers.NewSharedInformerFactory(cachingClient, opt.ResyncPeriod)
 	buildInformerFactory := revision.KResourceTypedInformerFactory(opt)
 
@@ -136,6 +160,7 @@ func main() {
 	coreServiceInformer := kubeInformerFactory.Core().V1().Services()
 	endpointsInformer := kubeInformerFactory.Core().V1().Endpoints()
 	configMapInformer := kubeInformerFactory.Core().V1().ConfigMaps()
+	podInformer := kubeInformerFactory.Core().V1().Pods()
 	virtualServiceInformer := sharedInformerFactory.Networking().V1alpha3().VirtualServices()
 	imageInformer := cachingInformerFactory.Caching().V1alpha1().Images()
 
@@ -156,6 +181,7 @@ func main() {
 			coreServiceInformer,
 			endpointsInformer,
 			configMapInformer,
+			podInformer,
 			buildInformerFactory,
 		),
 		route.NewController(
@@ -187,6 +213,33 @@ func main() {
 
 	// Watch the logging config map and dynamically update logging levels.
 	configMapWatcher.Watch(logging.ConfigName, logging.UpdateLevelFromConfigMap(logger, atomicLevel, component))
+	// Watch the observability config map and dynamically update metrics exporter.
+	configMapWatcher.Watch(metrics.ObservabilityConfigName, metrics.UpdateExporterFromConfigMap(component, logger))
+	// Measure the latency of the metrics pipeline up to the exporter.
+	go metrics.RunPipelineLatencyProbe(stopCh)
+	// Watch the schema version of all config maps.
+	configschema.NewWatcher(opt).Watch(configMapWatcher)
+	// Warn when the API server throttles the controller.
+	go throttleReporter.Watch(stopCh, reportAPIServerThrottling(kubeClient, logger))
+	// Report the sizes of the informer caches of the serving resources.
+	go reconciler.ReportInformerCacheSizes(map[string]reconciler.CacheSizeFunc{
+		"Revision": func() (int, error) {
+			l, err := revisionInformer.Lister().List(labels.Everything())
+			return len(l), err
+		},
+		"Route": func() (int, error) {
+			l, err := routeInformer.Lister().List(labels.Everything())
+			return len(l), err
+		},
+		"Service": func() (int, error) {
+			l, err := serviceInformer.Lister().List(labels.Everything())
+			return len(l), err
+		},
+		"Configuration": func() (int, error) {
+			l, err := configurationInformer.Lister().List(labels.Everything())
+			return len(l), err
+		},
+	}, logger, stopCh)
 
 	// These are non-blocking.
 	kubeInformerFactory.Start(stopCh)
@@ -211,6 +264,7 @@ func main() {
 		coreServiceInformer.Informer().HasSynced,
 		endpointsInformer.Informer().HasSynced,
 		configMapInformer.Informer().HasSynced,
+		podInformer.Informer().HasSynced,
 		virtualServiceInformer.Informer().HasSynced,
 	} {
 		if ok := cache.WaitForCacheSync(stopCh, synced); !ok {
@@ -230,4 +284,24 @@ func main() {
 	}
 
 	<-stopCh
+	metrics.FlushExporter()
+}
+
+// reportAPIServerThrottling returns a function warning, with an event on the
+// controller Deployment, that the API server throttled the controller.
+func reportAPIServerThrottling(kubeClient kubernetes.Interface, logger *zap.SugaredLogger) func(throttled, total int64) {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+	ref := &corev1.ObjectReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  system.Namespace,
+		Name:       component,
+	}
+	return func(throttled, total int64) {
+		logger.Warnf("The API server throttled %d of the last %d calls, reconciliation may be stalling", throttled, total)
+		recorder.Eventf(ref, corev1.EventTypeWarning, "APIServerThrottling",
+			"The API server throttled %d of the last %d calls", throttled, total)
+	}
 }
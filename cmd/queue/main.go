@@ -21,11 +21,13 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -33,13 +35,16 @@ import (
 
 	"github.com/knative/pkg/logging/logkey"
 	"github.com/knative/serving/cmd/util"
+	"github.com/knative/serving/pkg/activator"
 	activatorutil "github.com/knative/serving/pkg/activator/util"
 	"github.com/knative/serving/pkg/autoscaler"
 	"github.com/knative/serving/pkg/http/h2c"
 	"github.com/knative/serving/pkg/logging"
+	"github.com/knative/serving/pkg/metrics"
 	"github.com/knative/serving/pkg/queue"
 	"github.com/knative/serving/pkg/system"
 	"github.com/knative/serving/pkg/websocket"
+	promclient "github.com/prometheus/client_golang/prometheus"
 	"go.opencensus.io/exporter/prometheus"
 	"go.opencensus.io/stats/view"
 	"go.uber.org/zap"
@@ -69,11 +74,24 @@ var (
 	servingAutoscalerPort  string
 	containerConcurrency   int
 	revisionTimeoutSeconds int
+	userContainerCPULimit  int64 // in millicores
+	userContainerMemLimit  int64 // in bytes
+	metricsHeapBytes       int64 // heap allocated by setting up metrics
 	statChan               = make(chan *autoscaler.Stat, statReportingQueueLength)
 	reqChan                = make(chan queue.ReqEvent, requestCountingQueueLength)
 	statSink               *websocket.ManagedConnection
 	logger                 *zap.SugaredLogger
 	breaker                *queue.Breaker
+	timeoutBudget          *queue.TimeoutBudget
+	drainTracker           *queue.DrainTracker
+	latencySLO             time.Duration
+	maxRequestBodySize     int64
+	rateLimiter            *queue.TokenBucket
+	upgradeAllowlist       map[string]bool
+	bufferPool             = queue.NewBufferPool(queue.DefaultBufferSize)
+	connectionReuse        = &queue.ConnectionReuse{}
+	idleConnections        = &queue.IdleConnections{}
+	fullVolumes            = &queue.FullVolumes{}
 
 	h2cProxy  *httputil.ReverseProxy
 	httpProxy *httputil.ReverseProxy
@@ -92,15 +110,80 @@ func initEnv() {
 	servingAutoscalerPort = util.GetRequiredEnvOrFatal("SERVING_AUTOSCALER_PORT", logger)
 	containerConcurrency = util.MustParseIntEnvOrFatal("CONTAINER_CONCURRENCY", logger)
 	revisionTimeoutSeconds = util.MustParseIntEnvOrFatal("REVISION_TIMEOUT_SECONDS", logger)
+	// The user container limits are optional, as queue-proxies may be started
+	// by an older controller that does not set them.
+	if v := os.Getenv("USER_CONTAINER_CPU_LIMIT"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			logger.Error("Failed to parse USER_CONTAINER_CPU_LIMIT", zap.Error(err))
+		} else {
+			userContainerCPULimit = limit
+		}
+	}
+	if v := os.Getenv("USER_CONTAINER_MEMORY_LIMIT"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			logger.Error("Failed to parse USER_CONTAINER_MEMORY_LIMIT", zap.Error(err))
+		} else {
+			userContainerMemLimit = limit
+		}
+	}
+
+	// The maximum request body size is only set when the revision has one.
+	if v := os.Getenv("MAX_REQUEST_BODY_SIZE_BYTES"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			logger.Error("Failed to parse MAX_REQUEST_BODY_SIZE_BYTES", zap.Error(err))
+		} else {
+			maxRequestBodySize = size
+		}
+	}
+
+	// The rate limit is only set when the revision has one.
+	if v := os.Getenv("RATE_LIMIT_REQUESTS_PER_SECOND"); v != "" {
+		rps, err := strconv.Atoi(v)
+		if err != nil {
+			logger.Error("Failed to parse RATE_LIMIT_REQUESTS_PER_SECOND", zap.Error(err))
+		} else {
+			// Allow bursts of up to a second's worth of requests.
+			rateLimiter = queue.NewTokenBucket(float64(rps), rps, time.Now())
+		}
+	}
+
+	// Without an allowlist requests may upgrade to any protocol.
+	if v, ok := os.LookupEnv("UPGRADE_ALLOWLIST"); ok {
+		upgradeAllowlist = queue.ParseUpgradeAllowlist(v)
+	}
 
 	// TODO(mattmoor): Move this key to be in terms of the KPA.
 	servingRevisionKey = autoscaler.NewKpaKey(servingNamespace, servingRevision)
 	health = &healthServer{alive: true}
+	timeoutBudget = queue.NewTimeoutBudget(time.Duration(revisionTimeoutSeconds) * time.Second)
+	latencySLO = time.Duration(float64(revisionTimeoutSeconds) * queue.LatencySLOFraction * float64(time.Second))
+	heapBefore := queue.HeapBytes()
 	_reporter, err := queue.NewStatsReporter(servingNamespace, servingConfig, servingRevision)
 	if err != nil {
 		logger.Fatal("Failed to create stats reporter", zap.Error(err))
 	}
 	reporter = _reporter
+	if err := queue.RegisterQueueProxyViews(nil); err != nil {
+		logger.Fatal("Failed to register the request latency view", zap.Error(err))
+	}
+	metricsHeapBytes += queue.HeapBytes() - heapBefore
+	drainTracker = queue.NewDrainTracker(reportDrainingRequestCount, reportDrainCompletion)
+}
+
+func reportDrainingRequestCount(count int) {
+	if err := reporter.ReportDrainingRequestCount(count); err != nil {
+		logger.Error("Failed to report draining request count", zap.Error(err))
+	}
+}
+
+func reportDrainCompletion(latency time.Duration) {
+	logger.Infof("Drained the requests in flight in %v", latency)
+	if err := reporter.ReportDrainCompletion(latency); err != nil {
+		logger.Error("Failed to report drain completion", zap.Error(err))
+	}
 }
 
 func statReporter() {
@@ -120,6 +203,8 @@ func sendStat(s *autoscaler.Stat) error {
 	if !health.isAlive() {
 		s.LameDuck = true
 	}
+	s.TimeoutBudgetExceeded = timeoutBudget.Exceeded()
+	s.FullVolumes = fullVolumes.Names()
 	reporter.Report(
 		s.LameDuck,
 		float64(s.RequestCount),
@@ -132,6 +217,120 @@ func sendStat(s *autoscaler.Stat) error {
 	return statSink.Send(sm)
 }
 
+// reportObservabilityOverhead periodically reports the time spent recording,
+// exporting and serving metrics over the last period, and the heap allocated
+// by setting them up, relative to the user container's limits.
+func reportObservabilityOverhead() {
+	// The garbage collector may free more than metrics allocated meanwhile.
+	memoryBytes := metricsHeapBytes
+	if memoryBytes < 0 {
+		memoryBytes = 0
+	}
+	timer := reporter.ObservabilityTimer()
+	last, lastSpent := time.Now(), timer.Spent()
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		now, spent := time.Now(), timer.Spent()
+		cpuPercent, memoryPercent := queue.ObservabilityOverhead(spent-lastSpent, now.Sub(last),
+			userContainerCPULimit, memoryBytes, userContainerMemLimit)
+		if err := reporter.ReportObservabilityOverhead(cpuPercent, memoryBytes, memoryPercent); err != nil {
+			logger.Error("Failed to report observability overhead", zap.Error(err))
+		}
+		last, lastSpent = now, spent
+	}
+}
+
+// reportTimeoutBudget periodically reports how much of the revision timeout
+// requests have been using.
+func reportTimeoutBudget() {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		used, exceeded := timeoutBudget.Report()
+		if exceeded {
+			logger.Warnf("95th percentile request latency has exceeded %v%% of the revision timeout for several periods",
+				queue.TimeoutBudgetThreshold*100)
+		}
+		if err := reporter.ReportTimeoutBudgetUsed(used * 100); err != nil {
+			logger.Error("Failed to report timeout budget", zap.Error(err))
+		}
+	}
+}
+
+func reportBufferPool() {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		if err := reporter.ReportBufferPool(bufferPool.Report()); err != nil {
+			logger.Error("Failed to report buffer pool usage", zap.Error(err))
+		}
+	}
+}
+
+// reportConnectionReuse periodically reports how many of the requests to the
+// user container reused a keep-alive connection.
+func reportConnectionReuse() {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		if err := reporter.ReportConnectionReuse(connectionReuse.Report()); err != nil {
+			logger.Error("Failed to report connection reuse", zap.Error(err))
+		}
+	}
+}
+
+// reportIdleConnections periodically reports the idle connections to the
+// user container and how many of them were closed while idle.
+func reportIdleConnections() {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		if err := reporter.ReportIdleConnections(idleConnections.Report()); err != nil {
+			logger.Error("Failed to report idle connections", zap.Error(err))
+		}
+	}
+}
+
+// reportRateLimitTokens periodically reports the tokens left in the rate
+// limiter.
+func reportRateLimitTokens() {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		tokens := rateLimiter.Tokens(time.Now())
+		if err := reporter.ReportRateLimitTokens(queue.RateLimitRequestsPerSecond, tokens); err != nil {
+			logger.Error("Failed to report rate limit tokens", zap.Error(err))
+		}
+	}
+}
+
+// reportMemoryUsage periodically reports the memory usage and limit of the
+// queue-proxy container.
+func reportMemoryUsage(r *queue.CgroupsMemoryReader) {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		usage, limit, err := r.Read()
+		if err != nil {
+			logger.Error("Failed to read the memory usage", zap.Error(err))
+			continue
+		}
+		if err := reporter.ReportMemoryUsage(usage, limit); err != nil {
+			logger.Error("Failed to report the memory usage", zap.Error(err))
+		}
+	}
+}
+
+// reportVolumeUsage periodically reports the usage of the volumes mounted in
+// the container, and tracks the volumes close to full. Those are sent to the
+// autoscaler with the stats of the pod, so that the controller records an
+// event on the pod.
+func reportVolumeUsage() {
+	for range time.NewTicker(queue.ReportingPeriod).C {
+		volumes, err := queue.ReadVolumeUsage()
+		if err != nil {
+			logger.Error("Failed to read volume usage", zap.Error(err))
+			continue
+		}
+		for _, v := range volumes {
+			if err := reporter.ReportVolumeUsage(v.Name, v.UsedBytes, v.CapacityBytes); err != nil {
+				logger.Error("Failed to report volume usage", zap.Error(err))
+			}
+		}
+		for _, v := range fullVolumes.Update(volumes) {
+			logger.Warnf("Volume %s is %.0f%% full (%d of %d bytes used)",
+				v.Name, v.Utilization()*100, v.UsedBytes, v.CapacityBytes)
+		}
+	}
+}
+
 func proxyForRequest(req *http.Request) *httputil.ReverseProxy {
 	if req.ProtoMajor == 2 {
 		return h2cProxy
@@ -151,28 +350,146 @@ func handler(w http.ResponseWriter, r *http.Request) {
 
 	if isProbe(r) {
 		// Do not count health checks for concurrency metrics
-		proxy.ServeHTTP(w, r)
+		start := time.Now()
+		capture := &statusCapture{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		proxy.ServeHTTP(capture, r)
+		if err := reporter.ReportReadinessProbe(queue.ProbeType(r), queue.ProbeResult(capture.statusCode), time.Since(start)); err != nil {
+			logger.Error("Failed to report readiness probe", zap.Error(err))
+		}
+		return
+	}
+
+	// Tell the activator which pod served its request, so that it can spot
+	// pods slower than the others of the revision.
+	if r.Header.Get(activator.RequestCountHTTPHeader) != "" {
+		w.Header().Set(activator.PodHeaderName, podName)
+	}
+
+	external := queue.IsExternalRequest(r)
+	if err := reporter.ReportRequest(external); err != nil {
+		logger.Error("Failed to report request", zap.Error(err))
+	}
+	// Calls from other services are the edges of the service graph.
+	if target, namespace, ok := queue.ParseServiceHost(r.Host); ok && !external {
+		if err := reporter.ReportUpstreamCall(queue.SourceService(r), target, namespace); err != nil {
+			logger.Error("Failed to report upstream call", zap.Error(err))
+		}
+	}
+
+	if maxRequestBodySize > 0 && !queue.LimitRequestBody(r, maxRequestBodySize, reportRequestBodyTruncated) {
+		if err := reporter.ReportRequestBodyTooLarge(); err != nil {
+			logger.Error("Failed to report request body too large", zap.Error(err))
+		}
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
 		return
 	}
 
+	if rateLimiter != nil {
+		accepted := rateLimiter.Allow(time.Now())
+		if err := reporter.ReportRateLimit(queue.RateLimitRequestsPerSecond, accepted); err != nil {
+			logger.Error("Failed to report rate limit", zap.Error(err))
+		}
+		if !accepted {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+	}
+
 	// Metrics for autoscaling
-	reqChan <- queue.ReqEvent{Time: time.Now(), EventType: queue.ReqIn}
+	start := time.Now()
+	capture := &statusCapture{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+	reqChan <- queue.ReqEvent{Time: start, EventType: queue.ReqIn}
+	drainTracker.RequestStarted()
 	defer func() {
-		reqChan <- queue.ReqEvent{Time: time.Now(), EventType: queue.ReqOut}
+		drainTracker.RequestDone()
+		now := time.Now()
+		latency := now.Sub(start)
+		reqChan <- queue.ReqEvent{
+			Time:      now,
+			EventType: queue.ReqOut,
+			Failed:    capture.statusCode >= http.StatusInternalServerError,
+			Slow:      latency > latencySLO,
+		}
+		timeoutBudget.Record(latency)
+		if err := reporter.ReportRequestLatency(capture.statusCode, latency); err != nil {
+			logger.Error("Failed to report request latency", zap.Error(err))
+		}
+		if requested, actual, failed := queue.EncodingNegotiation(r, capture.statusCode, capture.Header()); failed {
+			if err := reporter.ReportEncodingNegotiationFailure(requested, actual); err != nil {
+				logger.Error("Failed to report encoding negotiation failure", zap.Error(err))
+			}
+		}
+		if queue.IsShadowRequest(r) {
+			if err := reporter.ReportShadowRequest(queue.ShadowPrimaryRevision(r), latency); err != nil {
+				logger.Error("Failed to report shadow request", zap.Error(err))
+			}
+		}
 	}()
 	// Enforce queuing and concurrency limits
 	if breaker != nil {
 		ok := breaker.Maybe(func() {
-			proxy.ServeHTTP(w, r)
+			proxy.ServeHTTP(capture, r)
 		})
 		if !ok {
-			http.Error(w, "overload", http.StatusServiceUnavailable)
+			http.Error(capture, "overload", http.StatusServiceUnavailable)
 		}
 	} else {
-		proxy.ServeHTTP(w, r)
+		proxy.ServeHTTP(capture, r)
+	}
+}
+
+func reportRequestBodyTruncated() {
+	if err := reporter.ReportRequestBodyTruncated(); err != nil {
+		logger.Error("Failed to report request body truncated", zap.Error(err))
 	}
 }
 
+func reportUpgradeRejected(protocol string) {
+	if err := reporter.ReportUpgradeRejected(protocol); err != nil {
+		logger.Error("Failed to report rejected upgrade", zap.Error(err))
+	}
+}
+
+func reportRSTStream(direction, code string) {
+	if err := reporter.ReportHTTP2RSTStream(direction, code); err != nil {
+		logger.Error("Failed to report RST_STREAM", zap.Error(err))
+	}
+}
+
+func reportTimeoutCascade(upstream string) {
+	if err := reporter.ReportTimeoutCascade(upstream); err != nil {
+		logger.Error("Failed to report timeout cascade", zap.Error(err))
+	}
+}
+
+func reportClientTimeout(phase string) {
+	if err := reporter.ReportClientTimeout(phase); err != nil {
+		logger.Error("Failed to report client timeout", zap.Error(err))
+	}
+}
+
+func reportGRPCStream(direction string, duration time.Duration) {
+	if err := reporter.ReportGRPCStream(direction, duration); err != nil {
+		logger.Error("Failed to report gRPC stream", zap.Error(err))
+	}
+}
+
+type statusCapture struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (s *statusCapture) WriteHeader(statusCode int) {
+	s.statusCode = statusCode
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
 // healthServer registers whether a PreStop hook has been called.
 type healthServer struct {
 	alive bool
@@ -212,6 +529,7 @@ func (h *healthServer) healthHandler(w http.ResponseWriter, r *http.Request) {
 func (h *healthServer) quitHandler(w http.ResponseWriter, r *http.Request) {
 	// First mark the server as unhealthy to cause lameduck metrics being sent
 	h.kill()
+	drainTracker.StartDrain()
 
 	// Force send one (empty) metric to mark the pod as a lameduck before shutting
 	// it down.
@@ -268,10 +586,15 @@ func main() {
 
 	httpProxy = httputil.NewSingleHostReverseProxy(target)
 	h2cProxy = httputil.NewSingleHostReverseProxy(target)
-	h2cProxy.Transport = h2c.DefaultTransport
+	h2cProxy.Transport = connectionReuse.Transport(h2c.DefaultTransport)
+	httpProxy.Transport = connectionReuse.Transport(idleConnections.Transport())
 
 	activatorutil.SetupHeaderPruning(httpProxy)
 	activatorutil.SetupHeaderPruning(h2cProxy)
+	httpProxy.ModifyResponse = queue.RecordUpstreamResponse
+	h2cProxy.ModifyResponse = queue.RecordUpstreamResponse
+	httpProxy.BufferPool = bufferPool
+	h2cProxy.BufferPool = bufferPool
 
 	// If containerConcurrency == 0 then concurrency is unlimited.
 	if containerConcurrency > 0 {
@@ -286,17 +609,42 @@ func main() {
 	}
 
 	logger.Info("Initializing OpenCensus Prometheus exporter.")
-	promExporter, err := prometheus.NewExporter(prometheus.Options{Namespace: "queue"})
+	heapBefore := queue.HeapBytes()
+	// The topology metrics have their own prefix but are served from the
+	// same registry as the other metrics.
+	registry := promclient.NewRegistry()
+	promExporter, err := prometheus.NewExporter(prometheus.Options{Namespace: "queue", Registry: registry})
 	if err != nil {
 		logger.Fatal("Failed to create the Prometheus exporter", zap.Error(err))
 	}
-	view.RegisterExporter(promExporter)
+	topologyExporter, err := prometheus.NewExporter(prometheus.Options{Namespace: queue.TopologyNamespace, Registry: registry})
+	if err != nil {
+		logger.Fatal("Failed to create the Prometheus topology exporter", zap.Error(err))
+	}
+	timer := reporter.ObservabilityTimer()
+	view.RegisterExporter(timer.Exporter(queue.NewTopologyExporter(promExporter, topologyExporter)))
 	view.SetReportingPeriod(queue.ReportingPeriod)
+	metricsHeapBytes += queue.HeapBytes() - heapBefore
 	go func() {
 		mux := http.NewServeMux()
-		mux.Handle("/metrics", promExporter)
+		mux.Handle("/metrics", timer.Handler(promExporter))
+		mux.Handle(metrics.ViewsPath, metrics.ViewsHandler())
 		http.ListenAndServe(":9090", mux)
 	}()
+	go reportObservabilityOverhead()
+	go reportTimeoutBudget()
+	go reportBufferPool()
+	go reportConnectionReuse()
+	go reportIdleConnections()
+	go reportVolumeUsage()
+	if rateLimiter != nil {
+		go reportRateLimitTokens()
+	}
+	if memoryReader, err := queue.NewCgroupsMemoryReader(); err != nil {
+		logger.Error("Failed to read the memory usage", zap.Error(err))
+	} else {
+		go reportMemoryUsage(memoryReader)
+	}
 
 	// Open a websocket connection to the autoscaler
 	autoscalerEndpoint := fmt.Sprintf("ws://%s.%s:%s", servingAutoscaler, system.Namespace, servingAutoscalerPort)
@@ -318,9 +666,27 @@ func main() {
 
 	server = h2c.NewServer(
 		fmt.Sprintf(":%d", queue.RequestQueuePort),
-		http.TimeoutHandler(http.HandlerFunc(handler), time.Duration(revisionTimeoutSeconds)*time.Second, "request timeout"))
+		&queue.ClientTimeoutHandler{
+			Next: &queue.TimeoutCascadeHandler{
+				Next: http.TimeoutHandler(&queue.UpgradeHandler{
+					Next: &queue.GRPCStreamHandler{
+						Next:   http.HandlerFunc(handler),
+						Closed: reportGRPCStream,
+					},
+					Allowed:  upgradeAllowlist,
+					Rejected: reportUpgradeRejected,
+				}, time.Duration(revisionTimeoutSeconds)*time.Second, "request timeout"),
+				Cascaded: reportTimeoutCascade,
+			},
+			TimedOut: reportClientTimeout,
+		})
 
-	go server.ListenAndServe()
+	// The listener finds the RST_STREAM frames of the HTTP/2 connections.
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		logger.Fatal("Failed to listen on the queue port", zap.Error(err))
+	}
+	go server.Serve(queue.NewRSTStreamListener(ln, reportRSTStream))
 	go setupAdminHandlers(adminServer)
 
 	// Shutdown logic and signal handling
@@ -328,6 +694,7 @@ func main() {
 	signal.Notify(sigTermChan, syscall.SIGTERM)
 	// Blocks until we actually receive a TERM signal.
 	<-sigTermChan
+	drainTracker.StartDrain()
 	// Calling server.Shutdown() allows pending requests to
 	// complete, while no new work is accepted.
 	logger.Debug("Received TERM signal, attempting to gracefully shutdown servers.")
@@ -30,7 +30,9 @@ import (
 	net "github.com/knative/serving/pkg/apis/networking/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/logging"
+	"github.com/knative/serving/pkg/metrics"
 	"github.com/knative/serving/pkg/system"
+	servingwebhook "github.com/knative/serving/pkg/webhook"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -72,15 +74,19 @@ func main() {
 	// Watch the logging config map and dynamically update logging levels.
 	configMapWatcher := configmap.NewInformedWatcher(kubeClient, system.Namespace)
 	configMapWatcher.Watch(logging.ConfigName, logging.UpdateLevelFromConfigMap(logger, atomicLevel, component))
+	// Watch the observability config map and dynamically update metrics exporter.
+	configMapWatcher.Watch(metrics.ObservabilityConfigName, metrics.UpdateExporterFromConfigMap(component, logger))
 	if err = configMapWatcher.Start(stopCh); err != nil {
 		logger.Fatalf("failed to start configuration manager: %v", err)
 	}
 
+	// The admission controller registers the webhook on port 443 of the
+	// service, where its handler is served wrapped in the metrics handler.
 	options := webhook.ControllerOptions{
 		ServiceName:    "webhook",
 		DeploymentName: "webhook",
 		Namespace:      system.Namespace,
-		Port:           443,
+		Port:           servingwebhook.InternalPort,
 		SecretName:     "webhook-certs",
 		WebhookName:    "webhook.serving.knative.dev",
 	}
@@ -100,5 +106,11 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to create the admission controller", zap.Error(err))
 	}
+	go func() {
+		handler := servingwebhook.NewMetricsHandler(&controller, logger)
+		if err := servingwebhook.Serve(kubeClient, options.Namespace, options.SecretName, 443, handler, stopCh, logger); err != nil {
+			logger.Fatal("Failed to serve the admission webhook", zap.Error(err))
+		}
+	}()
 	controller.Run(stopCh)
 }
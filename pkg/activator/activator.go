@@ -25,6 +25,15 @@ const (
 	RevisionHeaderName string = "knative-serving-revision"
 	// RevisionHeaderNamespace is the header key for revision's namespace
 	RevisionHeaderNamespace string = "knative-serving-namespace"
+	// RouteHeaderName is the header key for the name of the route a request
+	// was routed through by one of its traffic tags
+	RouteHeaderName string = "knative-serving-route"
+	// RouteTagHeaderName is the header key for the traffic tag a request was
+	// routed through
+	RouteTagHeaderName string = "knative-serving-route-tag"
+	// PodHeaderName is the header key for the name of the pod that served a
+	// request, which the queue-proxy returns to the activator
+	PodHeaderName string = "knative-serving-pod"
 )
 
 // Activator provides an active endpoint for a revision or an error and
@@ -52,4 +61,7 @@ type ActivationResult struct {
 	ServiceName       string
 	ConfigurationName string
 	Error             error
+	// Deferred is true when the revision was scaled to zero and the request
+	// had to wait for it to become ready.
+	Deferred bool
 }
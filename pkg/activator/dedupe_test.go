@@ -68,8 +68,8 @@ func TestSingleRevision_MultipleRequests_Success(t *testing.T) {
 	})
 
 	want := []ActivationResult{
-		{http.StatusOK, ep, "", "", nil},
-		{http.StatusOK, ep, "", "", nil},
+		{http.StatusOK, ep, "", "", nil, false},
+		{http.StatusOK, ep, "", "", nil, false},
 	}
 	if !reflect.DeepEqual(want, got) {
 		t.Errorf("Unexpected results. Wanted %+v. Got %+v.", want, got)
@@ -108,10 +108,10 @@ func TestMultipleRevisions_MultipleRequests_Success(t *testing.T) {
 	})
 
 	want := []ActivationResult{
-		{http.StatusOK, ep1, "", "", nil},
-		{http.StatusOK, ep2, "", "", nil},
-		{http.StatusOK, ep1, "", "", nil},
-		{http.StatusOK, ep2, "", "", nil},
+		{http.StatusOK, ep1, "", "", nil, false},
+		{http.StatusOK, ep2, "", "", nil, false},
+		{http.StatusOK, ep1, "", "", nil, false},
+		{http.StatusOK, ep2, "", "", nil, false},
 	}
 	if !reflect.DeepEqual(want, got) {
 		t.Errorf("Unexpected results. \nWant %+v. \nGot %+v", want, got)
@@ -152,10 +152,10 @@ func TestMultipleRevisions_MultipleRequests_PartialSuccess(t *testing.T) {
 	})
 
 	want := []ActivationResult{
-		{http.StatusOK, ep1, "", "", nil},
-		{status2, Endpoint{}, "", "", error2},
-		{http.StatusOK, ep1, "", "", nil},
-		{status2, Endpoint{}, "", "", error2},
+		{http.StatusOK, ep1, "", "", nil, false},
+		{status2, Endpoint{}, "", "", error2, false},
+		{http.StatusOK, ep1, "", "", nil, false},
+		{status2, Endpoint{}, "", "", error2, false},
 	}
 	if !reflect.DeepEqual(want, got) {
 		t.Errorf("Unexpected results. \nWant %+v. \nGot %+v", want, got)
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/knative/serving/pkg/activator"
+	pkghttp "github.com/knative/serving/pkg/http"
+	"go.uber.org/zap"
+)
+
+const (
+	// ABExperimentHeaderName is the header the ingress layer sets on the
+	// requests taking part in an A/B test. Its value has the form
+	// "<experiment>=<variant>".
+	ABExperimentHeaderName = "X-AB-Experiment"
+
+	// ABTestVariantControl is the variant of the requests served by the
+	// baseline revision of an A/B test.
+	ABTestVariantControl = "control"
+	// ABTestVariantTreatment is the variant of the requests served by the
+	// revision under test.
+	ABTestVariantTreatment = "treatment"
+)
+
+// ABTestMetricExtractor reports the requests and latencies of the variants
+// of the A/B tests, so that operators can compare the revisions under test.
+// Requests without a well-formed ABExperimentHeaderName header are passed
+// through untouched.
+type ABTestMetricExtractor struct {
+	NextHandler http.Handler
+	Reporter    activator.StatsReporter
+	Logger      *zap.SugaredLogger
+}
+
+func (h *ABTestMetricExtractor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := pkghttp.LastHeaderValue(r.Header, ABExperimentHeaderName)
+	if header == "" {
+		h.NextHandler.ServeHTTP(w, r)
+		return
+	}
+	experiment, variant, ok := parseABExperiment(header)
+	if !ok {
+		h.Logger.Warnf("Ignoring malformed %s header %q", ABExperimentHeaderName, header)
+		h.NextHandler.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+	capture := &statusCapture{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+	h.NextHandler.ServeHTTP(capture, r)
+
+	namespace := pkghttp.LastHeaderValue(r.Header, activator.RevisionHeaderNamespace)
+	if err := h.Reporter.ReportABTestVariant(namespace, experiment, variant, capture.statusCode, time.Since(start)); err != nil {
+		h.Logger.Errorf("Failed to report A/B test variant: %v", err)
+	}
+}
+
+// parseABExperiment splits the value of an ABExperimentHeaderName header into
+// the experiment name and a known variant.
+func parseABExperiment(header string) (experiment, variant string, ok bool) {
+	parts := strings.SplitN(header, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	experiment, variant = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if experiment == "" || (variant != ABTestVariantControl && variant != ABTestVariantTreatment) {
+		return "", "", false
+	}
+	return experiment, variant, true
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/activator"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+func TestABTestMetricExtractor(t *testing.T) {
+	reporter := &fakeReporter{}
+	served := 0
+	handler := &ABTestMetricExtractor{
+		Reporter: reporter,
+		Logger:   TestLogger(t),
+		NextHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served++
+			if r.URL.Path == "/fail" {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}),
+	}
+	request := func(path, experiment string) *http.Request {
+		req := httptest.NewRequest("GET", "http://example.com"+path, nil)
+		req.Header.Set(activator.RevisionHeaderNamespace, "test-namespace")
+		req.Header.Set(activator.RevisionHeaderName, "test-revision")
+		if experiment != "" {
+			req.Header.Set(ABExperimentHeaderName, experiment)
+		}
+		return req
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("/", "checkout=control"))
+	handler.ServeHTTP(httptest.NewRecorder(), request("/fail", "checkout=treatment"))
+	handler.ServeHTTP(httptest.NewRecorder(), request("/", ""))
+	handler.ServeHTTP(httptest.NewRecorder(), request("/", "checkout"))
+	handler.ServeHTTP(httptest.NewRecorder(), request("/", "checkout=canary"))
+	handler.ServeHTTP(httptest.NewRecorder(), request("/", "=control"))
+
+	want := []reporterCall{{
+		Op:         "ReportABTestVariant",
+		Namespace:  "test-namespace",
+		Experiment: "checkout",
+		Variant:    "control",
+		StatusCode: http.StatusOK,
+	}, {
+		Op:         "ReportABTestVariant",
+		Namespace:  "test-namespace",
+		Experiment: "checkout",
+		Variant:    "treatment",
+		StatusCode: http.StatusInternalServerError,
+	}}
+	if diff := cmp.Diff(want, reporter.calls, ignoreDurationOption); diff != "" {
+		t.Errorf("Reporter calls (-want, +got) = %v", diff)
+	}
+	if served != 6 {
+		t.Errorf("Served requests = %d, want 6", served)
+	}
+}
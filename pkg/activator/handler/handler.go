@@ -24,6 +24,7 @@ import (
 	"github.com/knative/serving/pkg/activator"
 	"github.com/knative/serving/pkg/activator/util"
 	pkghttp "github.com/knative/serving/pkg/http"
+	"github.com/knative/serving/pkg/queue"
 	"go.uber.org/zap"
 )
 
@@ -34,6 +35,9 @@ type ActivationHandler struct {
 	Logger    *zap.SugaredLogger
 	Transport http.RoundTripper
 	Reporter  activator.StatsReporter
+	// PodLatencies, if set, accumulates the latencies of the pods serving
+	// the requests.
+	PodLatencies *util.PodLatencies
 }
 
 func (a *ActivationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -47,6 +51,12 @@ func (a *ActivationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ar := a.Activator.ActiveEndpoint(namespace, name)
+	if ar.Deferred {
+		timedOut := ar.Error == activator.ErrActivationTimeout
+		if err := a.Reporter.ReportDeferredRequest(namespace, name, time.Since(start), timedOut); err != nil {
+			a.Logger.Errorf("Failed to report deferred request: %v", err)
+		}
+	}
 	if ar.Error != nil {
 		msg := fmt.Sprintf("Error getting active endpoint: %v", ar.Error)
 		a.Logger.Errorf(msg)
@@ -62,7 +72,11 @@ func (a *ActivationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	proxy.Transport = a.Transport
 
 	attempts := int(1) // one attempt is always needed
+	var pod string
+	// answered is when the pod answered, the end of a scale from zero.
+	var answered time.Time
 	proxy.ModifyResponse = func(r *http.Response) error {
+		answered = time.Now()
 		if numTries := r.Header.Get(activator.RequestCountHTTPHeader); numTries != "" {
 			if count, err := strconv.Atoi(numTries); err == nil {
 				a.Logger.Infof("got %d attempts", count)
@@ -75,19 +89,41 @@ func (a *ActivationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// We don't return this header to the user. It's only used to transport
 		// state in the activator.
 		r.Header.Del(activator.RequestCountHTTPHeader)
+		pod = r.Header.Get(activator.PodHeaderName)
+		r.Header.Del(activator.PodHeaderName)
 
 		return nil
 	}
 	util.SetupHeaderPruning(proxy)
 
-	proxy.ServeHTTP(capture, r)
+	// The sidecar of the activator names the activator as the caller of the
+	// requests it forwards, so name the original caller explicitly.
+	if source := queue.SourceService(r); source != queue.UnknownSourceService {
+		r.Header.Set(queue.ServiceHeaderName, source)
+	}
+
+	// Attribute the connections dialed for the request to the revision.
+	proxy.ServeHTTP(capture, r.WithContext(util.WithRevision(r.Context(), namespace, name)))
 
 	// Report the metrics
 	httpStatus := capture.statusCode
 	duration := time.Now().Sub(start)
 
+	if ar.Deferred && !answered.IsZero() {
+		if err := a.Reporter.ReportScaleFromZeroDuration(namespace, name, answered.Sub(start)); err != nil {
+			a.Logger.Errorf("Failed to report scale from zero duration: %v", err)
+		}
+	}
 	a.Reporter.ReportRequestCount(namespace, ar.ServiceName, ar.ConfigurationName, name, httpStatus, attempts, 1.0)
 	a.Reporter.ReportResponseTime(namespace, ar.ServiceName, ar.ConfigurationName, name, httpStatus, duration)
+	if pod != "" {
+		if err := a.Reporter.ReportPodLatency(namespace, name, pod, duration); err != nil {
+			a.Logger.Errorf("Failed to report pod latency: %v", err)
+		}
+		if a.PodLatencies != nil {
+			a.PodLatencies.Record(util.RevisionKey{Namespace: namespace, Revision: name}, pod, duration)
+		}
+	}
 }
 
 type statusCapture struct {
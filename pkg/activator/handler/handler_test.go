@@ -29,6 +29,7 @@ import (
 	. "github.com/knative/pkg/logging/testing"
 	"github.com/knative/serving/pkg/activator"
 	"github.com/knative/serving/pkg/activator/util"
+	"github.com/knative/serving/pkg/queue"
 )
 
 type stubActivator struct {
@@ -268,6 +269,181 @@ func TestActivationHandler(t *testing.T) {
 
 }
 
+type deferringActivator struct {
+	result activator.ActivationResult
+}
+
+func (d *deferringActivator) ActiveEndpoint(namespace, name string) activator.ActivationResult {
+	return d.result
+}
+
+func (d *deferringActivator) Shutdown() {
+}
+
+func TestActivationHandlerDeferred(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "everything good!")
+		}),
+	)
+	defer server.Close()
+	endpoint := newStubActivator("real-namespace", "real-name", server).(*stubActivator).endpoint
+
+	examples := []struct {
+		label         string
+		result        activator.ActivationResult
+		wantCode      int
+		wantCall      reporterCall
+		wantScaleCall bool
+	}{{
+		label: "scaled from zero",
+		result: activator.ActivationResult{
+			Status:   http.StatusOK,
+			Endpoint: endpoint,
+			Deferred: true,
+		},
+		wantCode: http.StatusOK,
+		wantCall: reporterCall{
+			Op:        "ReportDeferredRequest",
+			Namespace: "real-namespace",
+			Revision:  "real-name",
+		},
+		wantScaleCall: true,
+	}, {
+		label: "timed out",
+		result: activator.ActivationResult{
+			Status:   http.StatusInternalServerError,
+			Error:    activator.ErrActivationTimeout,
+			Deferred: true,
+		},
+		wantCode: http.StatusInternalServerError,
+		wantCall: reporterCall{
+			Op:        "ReportDeferredRequest",
+			Namespace: "real-namespace",
+			Revision:  "real-name",
+			TimedOut:  true,
+		},
+	}}
+
+	for _, e := range examples {
+		t.Run(e.label, func(t *testing.T) {
+			reporter := &fakeReporter{}
+			handler := ActivationHandler{
+				Activator: &deferringActivator{result: e.result},
+				Transport: http.DefaultTransport,
+				Logger:    TestLogger(t),
+				Reporter:  reporter,
+			}
+
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "http://example.com", nil)
+			req.Header.Set(activator.RevisionHeaderNamespace, "real-namespace")
+			req.Header.Set(activator.RevisionHeaderName, "real-name")
+			handler.ServeHTTP(resp, req)
+
+			if resp.Code != e.wantCode {
+				t.Errorf("Unexpected response status. Want %d, got %d", e.wantCode, resp.Code)
+			}
+			if len(reporter.calls) == 0 {
+				t.Fatal("No reporting calls, want a deferred request")
+			}
+			if diff := cmp.Diff(e.wantCall, reporter.calls[0], ignoreDurationOption); diff != "" {
+				t.Errorf("Deferred request call is different (-want, +got) = %v", diff)
+			}
+			var scaleCall *reporterCall
+			for i, call := range reporter.calls {
+				if call.Op == "ReportScaleFromZeroDuration" {
+					scaleCall = &reporter.calls[i]
+				}
+			}
+			if got := scaleCall != nil; got != e.wantScaleCall {
+				t.Fatalf("Reported scale from zero duration = %v, want %v", got, e.wantScaleCall)
+			}
+			if scaleCall != nil && scaleCall.Duration < reporter.calls[0].Duration {
+				t.Errorf("Scale from zero duration = %v, want at least the deferral of %v", scaleCall.Duration, reporter.calls[0].Duration)
+			}
+		})
+	}
+}
+
+func TestActivationHandlerPodLatency(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(activator.PodHeaderName, "real-pod")
+			io.WriteString(w, "everything good!")
+		}),
+	)
+	defer server.Close()
+
+	reporter := &fakeReporter{}
+	podLatencies := util.NewPodLatencies()
+	handler := ActivationHandler{
+		Activator:    newStubActivator("real-namespace", "real-name", server),
+		Transport:    http.DefaultTransport,
+		Logger:       TestLogger(t),
+		Reporter:     reporter,
+		PodLatencies: podLatencies,
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "http://example.com", nil)
+	req.Header.Set(activator.RevisionHeaderNamespace, "real-namespace")
+	req.Header.Set(activator.RevisionHeaderName, "real-name")
+	handler.ServeHTTP(resp, req)
+
+	if got := resp.Header().Get(activator.PodHeaderName); got != "" {
+		t.Errorf("%s header = %q, want it removed", activator.PodHeaderName, got)
+	}
+	want := reporterCall{
+		Op:        "ReportPodLatency",
+		Namespace: "real-namespace",
+		Revision:  "real-name",
+		Pod:       "real-pod",
+	}
+	var got []reporterCall
+	for _, call := range reporter.calls {
+		if call.Op == want.Op {
+			got = append(got, call)
+		}
+	}
+	if diff := cmp.Diff([]reporterCall{want}, got, ignoreDurationOption); diff != "" {
+		t.Errorf("Pod latency calls are different (-want, +got) = %v", diff)
+	}
+
+	// The latency of a single pod is recorded, but shows no skew.
+	podLatencies.Record(util.RevisionKey{Namespace: "real-namespace", Revision: "real-name"}, "other-pod", 0)
+	if skews := podLatencies.Report(); len(skews) != 1 {
+		t.Errorf("Report() = %v, want the skew of real-name", skews)
+	}
+}
+
+func TestActivationHandlerSourceService(t *testing.T) {
+	var got string
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get(queue.ServiceHeaderName)
+		}),
+	)
+	defer server.Close()
+
+	handler := ActivationHandler{
+		Activator: newStubActivator("real-namespace", "real-name", server),
+		Transport: http.DefaultTransport,
+		Logger:    TestLogger(t),
+		Reporter:  &fakeReporter{},
+	}
+
+	req := httptest.NewRequest("POST", "http://example.com", nil)
+	req.Header.Set(activator.RevisionHeaderNamespace, "real-namespace")
+	req.Header.Set(activator.RevisionHeaderName, "real-name")
+	req.Header.Set(queue.DownstreamServiceClusterHeaderName, "caller.ns")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := "caller"; got != want {
+		t.Errorf("%s header = %q, want %q", queue.ServiceHeaderName, got, want)
+	}
+}
+
 var ignoreDurationOption = cmpopts.IgnoreFields(reporterCall{}, "Duration")
 
 type reporterCall struct {
@@ -276,10 +452,17 @@ type reporterCall struct {
 	Service    string
 	Config     string
 	Revision   string
+	Route      string
+	Tag        string
+	Experiment string
+	Variant    string
+	Reason     string
 	StatusCode int
 	Attempts   int
 	Value      float64
 	Duration   time.Duration
+	TimedOut   bool
+	Pod        string
 }
 
 type fakeReporter struct {
@@ -314,3 +497,134 @@ func (f *fakeReporter) ReportResponseTime(ns, service, config, rev string, respo
 
 	return nil
 }
+
+func (f *fakeReporter) ReportTagRoutingHit(ns, route, tag string) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportTagRoutingHit",
+		Namespace: ns,
+		Route:     route,
+		Tag:       tag,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportConnectionPoolSize(ns, rev string, size int64) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportConnectionPoolSize",
+		Namespace: ns,
+		Revision:  rev,
+		Value:     float64(size),
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportFanoutLatency(ns, rev string, attempt int, d time.Duration) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportFanoutLatency",
+		Namespace: ns,
+		Revision:  rev,
+		Attempts:  attempt,
+		Duration:  d,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportABTestVariant(ns, experiment, variant string, responseCode int, d time.Duration) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:         "ReportABTestVariant",
+		Namespace:  ns,
+		Experiment: experiment,
+		Variant:    variant,
+		StatusCode: responseCode,
+		Duration:   d,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportBackoff(attempt int, reason string) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:       "ReportBackoff",
+		Attempts: attempt,
+		Reason:   reason,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportBackoffDuration(d time.Duration) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:       "ReportBackoffDuration",
+		Duration: d,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportRequestIDCollision(ns, rev string) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportRequestIDCollision",
+		Namespace: ns,
+		Revision:  rev,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportMemoryUsage(bytes int64) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:    "ReportMemoryUsage",
+		Value: float64(bytes),
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportPodLatency(ns, rev, pod string, d time.Duration) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportPodLatency",
+		Namespace: ns,
+		Revision:  rev,
+		Pod:       pod,
+		Duration:  d,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportPodLatencyCoV(ns, rev string, cov float64) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportPodLatencyCoV",
+		Namespace: ns,
+		Revision:  rev,
+		Value:     cov,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportScaleFromZeroDuration(ns, rev string, d time.Duration) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportScaleFromZeroDuration",
+		Namespace: ns,
+		Revision:  rev,
+		Duration:  d,
+	})
+
+	return nil
+}
+
+func (f *fakeReporter) ReportDeferredRequest(ns, rev string, wait time.Duration, timedOut bool) error {
+	f.calls = append(f.calls, reporterCall{
+		Op:        "ReportDeferredRequest",
+		Namespace: ns,
+		Revision:  rev,
+		Duration:  wait,
+		TimedOut:  timedOut,
+	})
+
+	return nil
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/knative/serving/pkg/activator/util"
+)
+
+// MemoryPressureHandler rejects requests with a 503 while the activator is
+// under memory pressure, so that the requests in flight drain before the
+// activator is killed for running out of memory. The rejected requests are
+// retried by the ingress.
+type MemoryPressureHandler struct {
+	NextHandler http.Handler
+	Pressure    *util.MemoryPressure
+}
+
+func (h *MemoryPressureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Pressure.UnderPressure() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	h.NextHandler.ServeHTTP(w, r)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/knative/serving/pkg/activator/util"
+)
+
+func TestMemoryPressureHandler(t *testing.T) {
+	pressure := util.NewMemoryPressure(1000)
+	handler := &MemoryPressureHandler{
+		Pressure: pressure,
+		NextHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	}
+
+	for _, step := range []struct {
+		rss  int64
+		want int
+	}{
+		{500, http.StatusOK},
+		{900, http.StatusServiceUnavailable},
+		{700, http.StatusOK},
+	} {
+		pressure.Update(step.rss)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest("GET", "http://example.com", nil))
+		if resp.Code != step.want {
+			t.Errorf("Status with %d bytes used = %d, want %d", step.rss, resp.Code, step.want)
+		}
+	}
+}
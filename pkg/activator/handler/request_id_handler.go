@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/knative/serving/pkg/activator"
+	pkghttp "github.com/knative/serving/pkg/http"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeaderName is the header carrying the ID of a request.
+const RequestIDHeaderName = "X-Request-ID"
+
+// RequestIDHandler tracks the IDs of the requests in flight and reports the
+// requests whose ID collides with one of them. IDs are meant to be unique, so
+// any collision points at a bug in the upstream ID generation.
+type RequestIDHandler struct {
+	NextHandler http.Handler
+	Reporter    activator.StatsReporter
+	Logger      *zap.SugaredLogger
+
+	mux      sync.Mutex
+	inFlight map[string]int
+}
+
+func (h *RequestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(RequestIDHeaderName)
+	if id == "" {
+		h.NextHandler.ServeHTTP(w, r)
+		return
+	}
+
+	if h.track(id) {
+		namespace := pkghttp.LastHeaderValue(r.Header, activator.RevisionHeaderNamespace)
+		name := pkghttp.LastHeaderValue(r.Header, activator.RevisionHeaderName)
+		h.Logger.Warnf("Request ID %q collides with a request in flight for revision %s/%s", id, namespace, name)
+		if err := h.Reporter.ReportRequestIDCollision(namespace, name); err != nil {
+			h.Logger.Errorf("Failed to report request ID collision: %v", err)
+		}
+	}
+	defer h.untrack(id)
+
+	h.NextHandler.ServeHTTP(w, r)
+}
+
+// track adds the ID to the requests in flight, returning whether another
+// request in flight has the same ID.
+func (h *RequestIDHandler) track(id string) bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.inFlight == nil {
+		h.inFlight = make(map[string]int)
+	}
+	h.inFlight[id]++
+	return h.inFlight[id] > 1
+}
+
+func (h *RequestIDHandler) untrack(id string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.inFlight[id]--; h.inFlight[id] == 0 {
+		delete(h.inFlight, id)
+	}
+}
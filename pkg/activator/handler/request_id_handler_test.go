@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/activator"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	reporter := &fakeReporter{}
+	handler := &RequestIDHandler{
+		Reporter: reporter,
+		Logger:   TestLogger(t),
+		NextHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Block") != "" {
+				entered <- struct{}{}
+				<-release
+			}
+		}),
+	}
+	request := func(id string, block bool) *http.Request {
+		req := httptest.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set(activator.RevisionHeaderNamespace, "test-namespace")
+		req.Header.Set(activator.RevisionHeaderName, "test-revision")
+		if id != "" {
+			req.Header.Set(RequestIDHeaderName, id)
+		}
+		if block {
+			req.Header.Set("Block", "true")
+		}
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), request("abc", true))
+		close(done)
+	}()
+	<-entered
+
+	// Requests with other or no IDs do not collide.
+	handler.ServeHTTP(httptest.NewRecorder(), request("def", false))
+	handler.ServeHTTP(httptest.NewRecorder(), request("", false))
+	handler.ServeHTTP(httptest.NewRecorder(), request("", false))
+	// A request with the ID of the request in flight does.
+	handler.ServeHTTP(httptest.NewRecorder(), request("abc", false))
+
+	close(release)
+	<-done
+	// Once the request is done, its ID can be used again.
+	handler.ServeHTTP(httptest.NewRecorder(), request("abc", false))
+
+	want := []reporterCall{{
+		Op:        "ReportRequestIDCollision",
+		Namespace: "test-namespace",
+		Revision:  "test-revision",
+	}}
+	if diff := cmp.Diff(want, reporter.calls); diff != "" {
+		t.Errorf("Reporter calls (-want, +got) = %v", diff)
+	}
+	if len(handler.inFlight) != 0 {
+		t.Errorf("Requests in flight = %v, want none", handler.inFlight)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/knative/serving/pkg/activator"
+	pkghttp "github.com/knative/serving/pkg/http"
+	"go.uber.org/zap"
+)
+
+// TagRoutingHandler reports the requests routed through a traffic tag of a
+// route, so that operators can tell which tags clients actually use.
+type TagRoutingHandler struct {
+	NextHandler http.Handler
+	Reporter    activator.StatsReporter
+	Logger      *zap.SugaredLogger
+}
+
+func (h *TagRoutingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if tag := pkghttp.LastHeaderValue(r.Header, activator.RouteTagHeaderName); tag != "" {
+		namespace := pkghttp.LastHeaderValue(r.Header, activator.RevisionHeaderNamespace)
+		route := pkghttp.LastHeaderValue(r.Header, activator.RouteHeaderName)
+		if err := h.Reporter.ReportTagRoutingHit(namespace, route, tag); err != nil {
+			h.Logger.Errorf("Failed to report tag routing hit: %v", err)
+		}
+	}
+
+	h.NextHandler.ServeHTTP(w, r)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/activator"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+func TestTagRoutingHandler(t *testing.T) {
+	reporter := &fakeReporter{}
+	served := 0
+	handler := &TagRoutingHandler{
+		Reporter: reporter,
+		Logger:   TestLogger(t),
+		NextHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served++
+		}),
+	}
+	request := func(tag string) *http.Request {
+		req := httptest.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set(activator.RevisionHeaderNamespace, "test-namespace")
+		req.Header.Set(activator.RevisionHeaderName, "test-revision")
+		req.Header.Set(activator.RouteHeaderName, "test-route")
+		if tag != "" {
+			req.Header.Set(activator.RouteTagHeaderName, tag)
+		}
+		return req
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("stable"))
+	handler.ServeHTTP(httptest.NewRecorder(), request(""))
+	handler.ServeHTTP(httptest.NewRecorder(), request("latest"))
+
+	want := []reporterCall{{
+		Op:        "ReportTagRoutingHit",
+		Namespace: "test-namespace",
+		Route:     "test-route",
+		Tag:       "stable",
+	}, {
+		Op:        "ReportTagRoutingHit",
+		Namespace: "test-namespace",
+		Route:     "test-route",
+		Tag:       "latest",
+	}}
+	if diff := cmp.Diff(want, reporter.calls); diff != "" {
+		t.Errorf("Reporter calls (-want, +got) = %v", diff)
+	}
+	if served != 3 {
+		t.Errorf("Served %d requests, want 3", served)
+	}
+}
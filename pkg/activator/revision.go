@@ -35,6 +35,10 @@ import (
 
 var _ Activator = (*revisionActivator)(nil)
 
+// ErrActivationTimeout is returned when a revision does not become ready
+// within the activation timeout.
+var ErrActivationTimeout = errors.New("Timeout waiting for revision to become ready")
+
 type revisionActivator struct {
 	readyTimout time.Duration // for testing
 	kubeClient  kubernetes.Interface
@@ -60,7 +64,9 @@ func (r *revisionActivator) Shutdown() {
 	// nothing to do
 }
 
-func (r *revisionActivator) activateRevision(namespace, name string) (*v1alpha1.Revision, error) {
+// activateRevision waits for the revision to not require activation. It also
+// returns whether the revision required activation.
+func (r *revisionActivator) activateRevision(namespace, name string) (*v1alpha1.Revision, bool, error) {
 	key := fmt.Sprintf("%s/%s", namespace, name)
 	logger := r.logger.With(zap.String(logkey.Key, key))
 	rev := revisionID{
@@ -72,16 +78,17 @@ func (r *revisionActivator) activateRevision(namespace, name string) (*v1alpha1.
 	revisionClient := r.knaClient.ServingV1alpha1().Revisions(rev.namespace)
 	revision, err := revisionClient.Get(rev.name, metav1.GetOptions{})
 	if err != nil {
-		return nil, errors.Wrap(err, "Unable to get revision")
+		return nil, false, errors.Wrap(err, "Unable to get revision")
 	}
 
 	// Wait for the revision to not require activation.
-	if revision.Status.IsActivationRequired() {
+	deferred := revision.Status.IsActivationRequired()
+	if deferred {
 		wi, err := r.knaClient.ServingV1alpha1().Revisions(rev.namespace).Watch(metav1.ListOptions{
 			FieldSelector: fmt.Sprintf("metadata.name=%s", rev.name),
 		})
 		if err != nil {
-			return nil, fmt.Errorf("Failed to watch the revision")
+			return nil, true, fmt.Errorf("Failed to watch the revision")
 		}
 		defer wi.Stop()
 		ch := wi.ResultChan()
@@ -93,7 +100,7 @@ func (r *revisionActivator) activateRevision(namespace, name string) (*v1alpha1.
 				if !revision.Status.IsActivationRequired() {
 					break RevisionActive
 				}
-				return nil, errors.New("Timeout waiting for revision to become ready")
+				return nil, true, ErrActivationTimeout
 			case event := <-ch:
 				if revision, ok := event.Object.(*v1alpha1.Revision); ok {
 					if revision.Status.IsActivationRequired() {
@@ -104,12 +111,12 @@ func (r *revisionActivator) activateRevision(namespace, name string) (*v1alpha1.
 					}
 					break RevisionActive
 				} else {
-					return nil, fmt.Errorf("Unexpected result type for revision: %v", event)
+					return nil, true, fmt.Errorf("Unexpected result type for revision: %v", event)
 				}
 			}
 		}
 	}
-	return revision, nil
+	return revision, deferred, nil
 }
 
 func (r *revisionActivator) getRevisionEndpoint(revision *v1alpha1.Revision) (end Endpoint, err error) {
@@ -144,12 +151,13 @@ func (r *revisionActivator) getRevisionEndpoint(revision *v1alpha1.Revision) (en
 func (r *revisionActivator) ActiveEndpoint(namespace, name string) ActivationResult {
 	key := fmt.Sprintf("%s/%s", namespace, name)
 	logger := r.logger.With(zap.String(logkey.Key, key))
-	revision, err := r.activateRevision(namespace, name)
+	revision, deferred, err := r.activateRevision(namespace, name)
 	if err != nil {
 		logger.Error("Failed to activate the revision.", zap.Error(err))
 		return ActivationResult{
-			Status: http.StatusInternalServerError,
-			Error:  err,
+			Status:   http.StatusInternalServerError,
+			Error:    err,
+			Deferred: deferred,
 		}
 	}
 
@@ -162,6 +170,7 @@ func (r *revisionActivator) ActiveEndpoint(namespace, name string) ActivationRes
 			ServiceName:       serviceName,
 			ConfigurationName: configurationName,
 			Error:             err,
+			Deferred:          deferred,
 		}
 	}
 
@@ -171,6 +180,7 @@ func (r *revisionActivator) ActiveEndpoint(namespace, name string) ActivationRes
 		ServiceName:       serviceName,
 		ConfigurationName: configurationName,
 		Error:             nil,
+		Deferred:          deferred,
 	}
 }
 
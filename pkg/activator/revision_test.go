@@ -60,6 +60,54 @@ func (r *mockReporter) ReportResponseTime(ns, service, config, rev string, respo
 	return nil
 }
 
+func (r *mockReporter) ReportRequestIDCollision(ns, rev string) error {
+	return nil
+}
+
+func (r *mockReporter) ReportTagRoutingHit(ns, route, tag string) error {
+	return nil
+}
+
+func (r *mockReporter) ReportConnectionPoolSize(ns, rev string, size int64) error {
+	return nil
+}
+
+func (r *mockReporter) ReportFanoutLatency(ns, rev string, attempt int, d time.Duration) error {
+	return nil
+}
+
+func (r *mockReporter) ReportABTestVariant(ns, experiment, variant string, responseCode int, d time.Duration) error {
+	return nil
+}
+
+func (r *mockReporter) ReportBackoff(attempt int, reason string) error {
+	return nil
+}
+
+func (r *mockReporter) ReportBackoffDuration(d time.Duration) error {
+	return nil
+}
+
+func (r *mockReporter) ReportMemoryUsage(bytes int64) error {
+	return nil
+}
+
+func (r *mockReporter) ReportDeferredRequest(ns, rev string, wait time.Duration, timedOut bool) error {
+	return nil
+}
+
+func (r *mockReporter) ReportPodLatency(ns, rev, pod string, d time.Duration) error {
+	return nil
+}
+
+func (r *mockReporter) ReportPodLatencyCoV(ns, rev string, cov float64) error {
+	return nil
+}
+
+func (r *mockReporter) ReportScaleFromZeroDuration(ns, rev string, d time.Duration) error {
+	return nil
+}
+
 func TestActiveEndpoint_Reserve_WaitsForReady(t *testing.T) {
 	k8s, kna := fakeClients()
 	kna.ServingV1alpha1().Revisions(testNamespace).Create(
@@ -106,6 +154,9 @@ func TestActiveEndpoint_Reserve_WaitsForReady(t *testing.T) {
 		if ar.Error != nil {
 			t.Errorf("Unexpected error. Want nil. Got %v.", ar.Error)
 		}
+		if !ar.Deferred {
+			t.Error("Expected the request to be deferred until the revision is ready.")
+		}
 	default:
 		t.Errorf("Expected result after revision ready.")
 	}
@@ -148,8 +199,11 @@ func TestActiveEndpoint_Reserve_ReadyTimeoutWithError(t *testing.T) {
 		if ar.ConfigurationName != "" {
 			t.Errorf("Unexpected configuration name. Want empty. Got %v.", ar.ConfigurationName)
 		}
-		if ar.Error == nil {
-			t.Errorf("Expected error. Want error. Got nil.")
+		if ar.Error != ErrActivationTimeout {
+			t.Errorf("Unexpected error. Want %v. Got %v.", ErrActivationTimeout, ar.Error)
+		}
+		if !ar.Deferred {
+			t.Error("Expected the request to be deferred until the timeout.")
 		}
 	default:
 		t.Errorf("Expected result after timeout.")
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
@@ -34,6 +35,64 @@ const (
 
 	// ResponseTimeInMsecM is the response time in millisecond
 	ResponseTimeInMsecM
+
+	// RequestIDCollisionCountM is the number of requests whose ID collided
+	// with a request in flight
+	RequestIDCollisionCountM
+
+	// TagRoutingHitCountM is the number of requests routed through a traffic
+	// tag of a route
+	TagRoutingHitCountM
+
+	// ConnectionPoolSizeM is the number of connections the activator keeps
+	// open to a revision
+	ConnectionPoolSizeM
+
+	// FanoutRevisionLatencyM is the latency of each attempt of a request to
+	// a revision
+	FanoutRevisionLatencyM
+
+	// ABTestVariantRequestCountM is the number of requests served for a
+	// variant of an A/B test
+	ABTestVariantRequestCountM
+
+	// ABTestVariantLatencyM is the latency of the requests served for a
+	// variant of an A/B test
+	ABTestVariantLatencyM
+
+	// RequestBackoffCountM is the number of times a request backed off
+	// before being retried
+	RequestBackoffCountM
+
+	// BackoffDurationM is the total time a request spent backing off
+	BackoffDurationM
+
+	// MemoryUsageBytesM is the resident set size of the activator
+	MemoryUsageBytesM
+
+	// DeferredRequestCountM is the number of requests that waited for their
+	// revision to scale from zero
+	DeferredRequestCountM
+
+	// DeferredRequestWaitM is the time requests waited for their revision to
+	// scale from zero
+	DeferredRequestWaitM
+
+	// DeferredRequestTimeoutCountM is the number of requests that timed out
+	// waiting for their revision to scale from zero
+	DeferredRequestTimeoutCountM
+
+	// PodLatencyM is the latency of the requests served by each pod of a
+	// revision
+	PodLatencyM
+
+	// PodLatencyCoVM is the coefficient of variation of the mean latencies
+	// of the pods of a revision
+	PodLatencyCoVM
+
+	// ScaleFromZeroDurationM is the time from a request being held for its
+	// revision to scale from zero to a pod answering it
+	ScaleFromZeroDurationM
 )
 
 var (
@@ -46,6 +105,66 @@ var (
 			"response_time_msec",
 			"The response time in millisecond",
 			stats.UnitNone),
+		RequestIDCollisionCountM: stats.Float64(
+			"request_id_collision_total",
+			"The number of requests whose X-Request-ID collided with a request in flight",
+			stats.UnitNone),
+		TagRoutingHitCountM: stats.Float64(
+			"tag_routing_hit_total",
+			"The number of requests routed through a traffic tag of a route",
+			stats.UnitNone),
+		ConnectionPoolSizeM: stats.Float64(
+			"activator_pool_size",
+			"The number of connections the activator keeps open to a revision",
+			stats.UnitNone),
+		FanoutRevisionLatencyM: stats.Float64(
+			"fanout_revision_latency_ms",
+			"The latency of each attempt of a request to a revision in milliseconds",
+			stats.UnitMilliseconds),
+		ABTestVariantRequestCountM: stats.Float64(
+			"ab_test_variant_request_total",
+			"The number of requests served for a variant of an A/B test",
+			stats.UnitNone),
+		ABTestVariantLatencyM: stats.Float64(
+			"ab_test_variant_latency_ms",
+			"The latency of the requests served for a variant of an A/B test in milliseconds",
+			stats.UnitMilliseconds),
+		RequestBackoffCountM: stats.Float64(
+			"request_backoff_total",
+			"The number of times a request backed off before being retried",
+			stats.UnitNone),
+		BackoffDurationM: stats.Float64(
+			"backoff_duration_ms",
+			"The total time a request spent backing off in milliseconds",
+			stats.UnitMilliseconds),
+		MemoryUsageBytesM: stats.Float64(
+			"activator_memory_usage_bytes",
+			"The resident set size of the activator in bytes",
+			stats.UnitBytes),
+		DeferredRequestCountM: stats.Float64(
+			"deferred_request_total",
+			"The number of requests that waited for their revision to scale from zero",
+			stats.UnitNone),
+		DeferredRequestWaitM: stats.Float64(
+			"deferred_request_wait_ms",
+			"The time requests waited for their revision to scale from zero in milliseconds",
+			stats.UnitMilliseconds),
+		DeferredRequestTimeoutCountM: stats.Float64(
+			"deferred_request_timeout_total",
+			"The number of requests that timed out waiting for their revision to scale from zero",
+			stats.UnitNone),
+		PodLatencyM: stats.Float64(
+			"per_pod_latency_ms",
+			"The latency of the requests served by a pod of a revision in milliseconds",
+			stats.UnitMilliseconds),
+		PodLatencyCoVM: stats.Float64(
+			"pod_latency_coefficient_of_variation",
+			"The coefficient of variation of the mean latencies of the pods of a revision",
+			stats.UnitNone),
+		ScaleFromZeroDurationM: stats.Float64(
+			"scale_from_zero_duration_ms",
+			"The time from a request being held for its revision to scale from zero to a pod answering it in milliseconds",
+			stats.UnitMilliseconds),
 	}
 )
 
@@ -53,6 +172,18 @@ var (
 type StatsReporter interface {
 	ReportRequestCount(ns, service, config, rev string, responseCode, numTries int, v float64) error
 	ReportResponseTime(ns, service, config, rev string, responseCode int, d time.Duration) error
+	ReportRequestIDCollision(ns, rev string) error
+	ReportTagRoutingHit(ns, route, tag string) error
+	ReportConnectionPoolSize(ns, rev string, size int64) error
+	ReportFanoutLatency(ns, rev string, attempt int, d time.Duration) error
+	ReportABTestVariant(ns, experiment, variant string, responseCode int, d time.Duration) error
+	ReportBackoff(attempt int, reason string) error
+	ReportBackoffDuration(d time.Duration) error
+	ReportMemoryUsage(bytes int64) error
+	ReportDeferredRequest(ns, rev string, wait time.Duration, timedOut bool) error
+	ReportPodLatency(ns, rev, pod string, d time.Duration) error
+	ReportPodLatencyCoV(ns, rev string, cov float64) error
+	ReportScaleFromZeroDuration(ns, rev string, d time.Duration) error
 }
 
 // Reporter holds cached metric objects to report autoscaler metrics
@@ -65,6 +196,14 @@ type Reporter struct {
 	responseCodeKey      tag.Key
 	responseCodeClassKey tag.Key
 	numTriesKey          tag.Key
+	routeTagKey          tag.Key
+	trafficTagKey        tag.Key
+	attemptKey           tag.Key
+	experimentKey        tag.Key
+	variantKey           tag.Key
+	backoffAttemptKey    tag.Key
+	backoffReasonKey     tag.Key
+	podKey               tag.Key
 }
 
 // NewStatsReporter creates a reporter that collects and reports activator metrics
@@ -108,8 +247,48 @@ func NewStatsReporter() (*Reporter, error) {
 		return nil, err
 	}
 	r.numTriesKey = numTriesTag
+	routeTag, err := tag.NewKey("route_name")
+	if err != nil {
+		return nil, err
+	}
+	r.routeTagKey = routeTag
+	trafficTag, err := tag.NewKey("tag_name")
+	if err != nil {
+		return nil, err
+	}
+	r.trafficTagKey = trafficTag
+	attemptTag, err := tag.NewKey("fanout_attempt_number")
+	if err != nil {
+		return nil, err
+	}
+	r.attemptKey = attemptTag
+	experimentTag, err := tag.NewKey("experiment_name")
+	if err != nil {
+		return nil, err
+	}
+	r.experimentKey = experimentTag
+	variantTag, err := tag.NewKey("variant")
+	if err != nil {
+		return nil, err
+	}
+	r.variantKey = variantTag
+	backoffAttemptTag, err := tag.NewKey("backoff_attempt")
+	if err != nil {
+		return nil, err
+	}
+	r.backoffAttemptKey = backoffAttemptTag
+	backoffReasonTag, err := tag.NewKey("backoff_reason")
+	if err != nil {
+		return nil, err
+	}
+	r.backoffReasonKey = backoffReasonTag
+	podTag, err := tag.NewKey("pod_name")
+	if err != nil {
+		return nil, err
+	}
+	r.podKey = podTag
 	// Create view to see our measurements.
-	err = view.Register(
+	err = metrics.RegisterViews(
 		&view.View{
 			Description: "The number of requests that are routed to Activator",
 			Measure:     measurements[RequestCountM],
@@ -122,6 +301,94 @@ func NewStatsReporter() (*Reporter, error) {
 			Aggregation: view.Distribution(1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000, 10000, 11000, 12000, 13000, 14000, 15000),
 			TagKeys:     []tag.Key{r.namespaceTagKey, r.serviceTagKey, r.configTagKey, r.revisionTagKey, r.responseCodeClassKey, r.responseCodeKey},
 		},
+		&view.View{
+			Description: "The number of requests whose X-Request-ID collided with a request in flight",
+			Measure:     measurements[RequestIDCollisionCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "The number of requests routed through a traffic tag of a route",
+			Measure:     measurements[TagRoutingHitCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.routeTagKey, r.trafficTagKey},
+		},
+		&view.View{
+			Description: "The number of connections the activator keeps open to a revision",
+			Measure:     measurements[ConnectionPoolSizeM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "The latency of each attempt of a request to a revision in milliseconds",
+			Measure:     measurements[FanoutRevisionLatencyM],
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey, r.attemptKey},
+		},
+		&view.View{
+			Description: "The number of requests served for a variant of an A/B test",
+			Measure:     measurements[ABTestVariantRequestCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.experimentKey, r.variantKey, r.responseCodeClassKey},
+		},
+		&view.View{
+			Description: "The latency of the requests served for a variant of an A/B test in milliseconds",
+			Measure:     measurements[ABTestVariantLatencyM],
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.experimentKey, r.variantKey},
+		},
+		&view.View{
+			Description: "The number of times a request backed off before being retried",
+			Measure:     measurements[RequestBackoffCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.backoffAttemptKey, r.backoffReasonKey},
+		},
+		&view.View{
+			Description: "The total time a request spent backing off in milliseconds",
+			Measure:     measurements[BackoffDurationM],
+			Aggregation: view.Distribution(100, 250, 500, 1000, 2500, 5000, 10000, 20000, 30000, 60000),
+		},
+		&view.View{
+			Description: "The resident set size of the activator in bytes",
+			Measure:     measurements[MemoryUsageBytesM],
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "The number of requests that waited for their revision to scale from zero",
+			Measure:     measurements[DeferredRequestCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "The time requests waited for their revision to scale from zero in milliseconds",
+			Measure:     measurements[DeferredRequestWaitM],
+			Aggregation: view.Distribution(100, 250, 500, 1000, 2500, 5000, 10000, 20000, 30000, 60000, 120000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "The number of requests that timed out waiting for their revision to scale from zero",
+			Measure:     measurements[DeferredRequestTimeoutCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "The latency of the requests served by a pod of a revision in milliseconds",
+			Measure:     measurements[PodLatencyM],
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey, r.podKey},
+		},
+		&view.View{
+			Description: "The coefficient of variation of the mean latencies of the pods of a revision",
+			Measure:     measurements[PodLatencyCoVM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "The time from a request being held for its revision to scale from zero to a pod answering it in milliseconds",
+			Measure:     measurements[ScaleFromZeroDurationM],
+			Aggregation: view.Distribution(100, 250, 500, 1000, 2500, 5000, 10000, 20000, 30000, 60000, 120000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
 	)
 	if err != nil {
 		return nil, err
@@ -177,9 +444,241 @@ func (r *Reporter) ReportResponseTime(ns, service, config, rev string, responseC
 	return nil
 }
 
+// getBackoffAttemptBucket converts the number of a backoff to a string,
+// folding the fourth and later backoffs together.
+func getBackoffAttemptBucket(attempt int) string {
+	if attempt >= 4 {
+		return "4+"
+	}
+	return strconv.Itoa(attempt)
+}
+
 // getResponseCodeClass converts response code to a string of response code class.
 // e.g. The response code class is "5xx" for response code 503.
 func getResponseCodeClass(responseCode int) string {
 	// Get the hundred digit of the response code and concatenate "xx"
 	return strconv.Itoa((responseCode/100)%10) + "xx"
 }
+
+// ReportRequestIDCollision captures a request whose ID collided with a
+// request in flight
+func (r *Reporter) ReportRequestIDCollision(ns, rev string) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RequestIDCollisionCountM].M(1))
+	return nil
+}
+
+// ReportTagRoutingHit captures a request routed through the given traffic tag
+// of a route
+func (r *Reporter) ReportTagRoutingHit(ns, route, trafficTag string) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.routeTagKey, route),
+		tag.Insert(r.trafficTagKey, trafficTag))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[TagRoutingHitCountM].M(1))
+	return nil
+}
+
+// ReportConnectionPoolSize captures the number of connections open to a
+// revision
+func (r *Reporter) ReportConnectionPoolSize(ns, rev string, size int64) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[ConnectionPoolSizeM].M(float64(size)))
+	return nil
+}
+
+// ReportFanoutLatency captures the latency of an attempt of a request to a
+// revision
+func (r *Reporter) ReportFanoutLatency(ns, rev string, attempt int, d time.Duration) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev),
+		tag.Insert(r.attemptKey, strconv.Itoa(attempt)))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[FanoutRevisionLatencyM].M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportABTestVariant captures a request served for a variant of an A/B test
+// and its latency
+func (r *Reporter) ReportABTestVariant(ns, experiment, variant string, responseCode int, d time.Duration) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.experimentKey, experiment),
+		tag.Insert(r.variantKey, variant))
+	if err != nil {
+		return err
+	}
+	stats.Record(ctx, measurements[ABTestVariantLatencyM].M(float64(d/time.Millisecond)))
+
+	ctx, err = tag.New(ctx, tag.Insert(r.responseCodeClassKey, getResponseCodeClass(responseCode)))
+	if err != nil {
+		return err
+	}
+	stats.Record(ctx, measurements[ABTestVariantRequestCountM].M(1))
+	return nil
+}
+
+// ReportBackoff captures a backoff of a request before it is retried, given
+// the number of the backoff and its reason
+func (r *Reporter) ReportBackoff(attempt int, reason string) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.backoffAttemptKey, getBackoffAttemptBucket(attempt)),
+		tag.Insert(r.backoffReasonKey, reason))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RequestBackoffCountM].M(1))
+	return nil
+}
+
+// ReportBackoffDuration captures the total time a request spent backing off
+func (r *Reporter) ReportBackoffDuration(d time.Duration) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	stats.Record(context.Background(), measurements[BackoffDurationM].M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportMemoryUsage captures the resident set size of the activator
+func (r *Reporter) ReportMemoryUsage(bytes int64) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	stats.Record(context.Background(), measurements[MemoryUsageBytesM].M(float64(bytes)))
+	return nil
+}
+
+// ReportDeferredRequest captures a request that waited for its revision to
+// scale from zero, and whether it timed out waiting
+func (r *Reporter) ReportDeferredRequest(ns, rev string, wait time.Duration, timedOut bool) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[DeferredRequestCountM].M(1),
+		measurements[DeferredRequestWaitM].M(float64(wait/time.Millisecond)))
+	if timedOut {
+		stats.Record(ctx, measurements[DeferredRequestTimeoutCountM].M(1))
+	}
+	return nil
+}
+
+// ReportPodLatency captures the latency of a request served by the given pod
+// of a revision
+func (r *Reporter) ReportPodLatency(ns, rev, pod string, d time.Duration) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev),
+		tag.Insert(r.podKey, pod))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[PodLatencyM].M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportPodLatencyCoV captures the coefficient of variation of the mean
+// latencies of the pods of a revision
+func (r *Reporter) ReportPodLatencyCoV(ns, rev string, cov float64) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[PodLatencyCoVM].M(cov))
+	return nil
+}
+
+// ReportScaleFromZeroDuration captures the time from a request being held for
+// its revision to scale from zero to a pod answering it
+func (r *Reporter) ReportScaleFromZeroDuration(ns, rev string, d time.Duration) error {
+	if !r.initialized {
+		return errors.New("StatsReporter is not initialized yet")
+	}
+
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(r.namespaceTagKey, ns),
+		tag.Insert(r.revisionTagKey, rev))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[ScaleFromZeroDurationM].M(float64(d/time.Millisecond)))
+	return nil
+}
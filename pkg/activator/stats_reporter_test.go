@@ -14,6 +14,7 @@ limitations under the License.
 package activator
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -64,6 +65,151 @@ func TestActivatorReporter(t *testing.T) {
 		return r.ReportResponseTime("testns", "testsvc", "testconfig", "testrev", 200, 9100*time.Millisecond)
 	})
 	checkDistributionData(t, "response_time_msec", wantTags3, 2, 1100, 9100)
+
+	// test ReportRequestIDCollision
+	expectSuccess(t, func() error { return r.ReportRequestIDCollision("testns", "testrev") })
+	if d, err := view.RetrieveData("request_id_collision_total"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if c := d[0].Data.(*view.CountData); c.Value != 1 {
+		t.Errorf("Reporter expected 1 got %v. metric: request_id_collision_total", c.Value)
+	}
+
+	// test ReportTagRoutingHit
+	expectSuccess(t, func() error { return r.ReportTagRoutingHit("testns", "testroute", "stable") })
+	if d, err := view.RetrieveData("tag_routing_hit_total"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if c := d[0].Data.(*view.CountData); c.Value != 1 {
+		t.Errorf("Reporter expected 1 got %v. metric: tag_routing_hit_total", c.Value)
+	}
+
+	// test ReportConnectionPoolSize
+	expectSuccess(t, func() error { return r.ReportConnectionPoolSize("testns", "testrev", 3) })
+	if d, err := view.RetrieveData("activator_pool_size"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if v := d[0].Data.(*view.LastValueData); v.Value != 3 {
+		t.Errorf("Reporter expected 3 got %v. metric: activator_pool_size", v.Value)
+	}
+
+	// test ReportFanoutLatency
+	expectSuccess(t, func() error { return r.ReportFanoutLatency("testns", "testrev", 2, 1100*time.Millisecond) })
+	if d, err := view.RetrieveData("fanout_revision_latency_ms"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if v := d[0].Data.(*view.DistributionData); v.Count != 1 || v.Mean != 1100 {
+		t.Errorf("Reporter expected 1 attempt of 1100ms got %d of %v. metric: fanout_revision_latency_ms", v.Count, v.Mean)
+	}
+
+	// test ReportABTestVariant
+	expectSuccess(t, func() error {
+		return r.ReportABTestVariant("testns", "testexp", "treatment", 200, 300*time.Millisecond)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportABTestVariant("testns", "testexp", "treatment", 204, 500*time.Millisecond)
+	})
+	if d, err := view.RetrieveData("ab_test_variant_request_total"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if c := d[0].Data.(*view.CountData); c.Value != 2 {
+		t.Errorf("Reporter expected 2 got %v. metric: ab_test_variant_request_total", c.Value)
+	}
+	wantTags4 := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		"experiment_name":             "testexp",
+		"variant":                     "treatment",
+	}
+	checkDistributionData(t, "ab_test_variant_latency_ms", wantTags4, 2, 300, 500)
+
+	// test ReportBackoff
+	for attempt := 1; attempt <= 5; attempt++ {
+		expectSuccess(t, func() error { return r.ReportBackoff(attempt, "503") })
+	}
+	if d, err := view.RetrieveData("request_backoff_total"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 4 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 4)
+	} else {
+		got := make(map[string]int64, len(d))
+		for _, row := range d {
+			for _, tag := range row.Tags {
+				if tag.Key.Name() == "backoff_attempt" {
+					got[tag.Value] = row.Data.(*view.CountData).Value
+				}
+			}
+		}
+		if want := map[string]int64{"1": 1, "2": 1, "3": 1, "4+": 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Reporter expected backoffs %v got %v. metric: request_backoff_total", want, got)
+		}
+	}
+
+	// test ReportBackoffDuration
+	expectSuccess(t, func() error { return r.ReportBackoffDuration(700 * time.Millisecond) })
+	checkDistributionData(t, "backoff_duration_ms", map[string]string{}, 1, 700, 700)
+
+	// test ReportMemoryUsage
+	expectSuccess(t, func() error { return r.ReportMemoryUsage(1 << 20) })
+	if d, err := view.RetrieveData("activator_memory_usage_bytes"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if v := d[0].Data.(*view.LastValueData); v.Value != 1<<20 {
+		t.Errorf("Reporter expected %v got %v. metric: activator_memory_usage_bytes", 1<<20, v.Value)
+	}
+
+	// test ReportDeferredRequest
+	wantTags6 := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelRevisionName:  "testrev",
+	}
+	expectSuccess(t, func() error { return r.ReportDeferredRequest("testns", "testrev", 1500*time.Millisecond, false) })
+	expectSuccess(t, func() error { return r.ReportDeferredRequest("testns", "testrev", 60*time.Second, true) })
+	if d, err := view.RetrieveData("deferred_request_total"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if c := d[0].Data.(*view.CountData); c.Value != 2 {
+		t.Errorf("Reporter expected 2 got %v. metric: deferred_request_total", c.Value)
+	}
+	checkDistributionData(t, "deferred_request_wait_ms", wantTags6, 2, 1500, 60000)
+	if d, err := view.RetrieveData("deferred_request_timeout_total"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if c := d[0].Data.(*view.CountData); c.Value != 1 {
+		t.Errorf("Reporter expected 1 got %v. metric: deferred_request_timeout_total", c.Value)
+	}
+
+	// test ReportPodLatency
+	wantTags7 := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelRevisionName:  "testrev",
+		"pod_name":                    "testpod",
+	}
+	expectSuccess(t, func() error { return r.ReportPodLatency("testns", "testrev", "testpod", 20*time.Millisecond) })
+	expectSuccess(t, func() error { return r.ReportPodLatency("testns", "testrev", "testpod", 80*time.Millisecond) })
+	checkDistributionData(t, "per_pod_latency_ms", wantTags7, 2, 20, 80)
+
+	// test ReportPodLatencyCoV
+	expectSuccess(t, func() error { return r.ReportPodLatencyCoV("testns", "testrev", 0.5) })
+	if d, err := view.RetrieveData("pod_latency_coefficient_of_variation"); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else if len(d) != 1 {
+		t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+	} else if v := d[0].Data.(*view.LastValueData); v.Value != 0.5 {
+		t.Errorf("Reporter expected %v got %v. metric: pod_latency_coefficient_of_variation", 0.5, v.Value)
+	}
+
+	// test ReportScaleFromZeroDuration
+	expectSuccess(t, func() error { return r.ReportScaleFromZeroDuration("testns", "testrev", 2*time.Second) })
+	expectSuccess(t, func() error { return r.ReportScaleFromZeroDuration("testns", "testrev", 7*time.Second) })
+	checkDistributionData(t, "scale_from_zero_duration_ms", wantTags6, 2, 2000, 7000)
 }
 
 func expectSuccess(t *testing.T, f func() error) {
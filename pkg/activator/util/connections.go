@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	h2cutil "github.com/knative/serving/pkg/http/h2c"
+)
+
+// ConnectionGrowthPeriods is the number of consecutive reporting periods the
+// open connections to a revision have to grow before the growth is flagged.
+const ConnectionGrowthPeriods = 10
+
+type revisionContextKey struct{}
+
+// RevisionKey identifies the revision a request is sent to.
+type RevisionKey struct {
+	Namespace string
+	Revision  string
+}
+
+// WithRevision returns a context attributing the requests and connections
+// made with it to the given revision.
+func WithRevision(ctx context.Context, namespace, revision string) context.Context {
+	return context.WithValue(ctx, revisionContextKey{}, RevisionKey{Namespace: namespace, Revision: revision})
+}
+
+// RevisionFrom returns the revision the context is attributed to, see
+// WithRevision.
+func RevisionFrom(ctx context.Context) (RevisionKey, bool) {
+	rev, ok := ctx.Value(revisionContextKey{}).(RevisionKey)
+	return rev, ok
+}
+
+// ConnectionPoolStat is the number of connections open to a revision.
+type ConnectionPoolStat struct {
+	RevisionKey
+	Open int64
+	// Growing is true each time the number of open connections has grown
+	// for ConnectionGrowthPeriods consecutive reporting periods.
+	Growing bool
+}
+
+type connectionCount struct {
+	open     int64
+	last     int64
+	grownFor int
+}
+
+// ConnectionCounter counts the connections a transport keeps open to each
+// revision.
+type ConnectionCounter struct {
+	mux    sync.RWMutex
+	counts map[RevisionKey]*connectionCount
+}
+
+// NewConnectionCounter creates a ConnectionCounter.
+func NewConnectionCounter() *ConnectionCounter {
+	return &ConnectionCounter{counts: make(map[RevisionKey]*connectionCount)}
+}
+
+// opened counts a connection opened to the revision and returns the counter
+// to decrement when it is closed. The counter is incremented under the lock
+// so that Report cannot forget it in between.
+func (c *ConnectionCounter) opened(rev RevisionKey) *int64 {
+	c.mux.RLock()
+	if count, ok := c.counts[rev]; ok {
+		atomic.AddInt64(&count.open, 1)
+		c.mux.RUnlock()
+		return &count.open
+	}
+	c.mux.RUnlock()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	count, ok := c.counts[rev]
+	if !ok {
+		count = &connectionCount{}
+		c.counts[rev] = count
+	}
+	atomic.AddInt64(&count.open, 1)
+	return &count.open
+}
+
+// DialContext wraps dial to count the connections it opens for the revision
+// of the context, see WithRevision. Connections dialed for no revision are
+// not counted.
+func (c *ConnectionCounter) DialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return conn, err
+		}
+		rev, ok := RevisionFrom(ctx)
+		if !ok {
+			return conn, nil
+		}
+		return &countedConn{Conn: conn, open: c.opened(rev)}, nil
+	}
+}
+
+// Report returns the number of connections open to each revision. Revisions
+// without open connections are forgotten once reported.
+func (c *ConnectionCounter) Report() []ConnectionPoolStat {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	stats := make([]ConnectionPoolStat, 0, len(c.counts))
+	for rev, count := range c.counts {
+		open := atomic.LoadInt64(&count.open)
+		if open > count.last {
+			count.grownFor++
+		} else {
+			count.grownFor = 0
+		}
+		count.last = open
+		growing := count.grownFor >= ConnectionGrowthPeriods
+		if growing {
+			count.grownFor = 0
+		}
+		stats = append(stats, ConnectionPoolStat{
+			RevisionKey: rev,
+			Open:        open,
+			Growing:     growing,
+		})
+		if open == 0 {
+			delete(c.counts, rev)
+		}
+	}
+	return stats
+}
+
+// countedConn decrements the number of open connections when closed.
+type countedConn struct {
+	net.Conn
+	open *int64
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { atomic.AddInt64(c.open, -1) })
+	return c.Conn.Close()
+}
+
+// NewCountingTransport returns a transport like AutoTransport whose HTTP/1
+// connections are counted by c. The h2c transport dials without a context,
+// so its connections cannot be attributed to a revision.
+func NewCountingTransport(c *ConnectionCounter) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	// The settings of http.DefaultTransport.
+	v1 := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           c.DialContext(dialer.DialContext),
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return NewHTTPTransport(v1, h2cutil.DefaultTransport)
+}
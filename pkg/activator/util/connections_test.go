@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func pipeDial(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, _ := net.Pipe()
+	return c, nil
+}
+
+func TestConnectionCounter(t *testing.T) {
+	c := NewConnectionCounter()
+	dial := c.DialContext(pipeDial)
+	ctx := WithRevision(context.Background(), "ns", "rev")
+
+	conn1, _ := dial(ctx, "tcp", "rev.ns:80")
+	conn2, _ := dial(ctx, "tcp", "rev.ns:80")
+	// Connections dialed for no revision are not counted.
+	dial(context.Background(), "tcp", "other:80")
+
+	rev := RevisionKey{Namespace: "ns", Revision: "rev"}
+	want := []ConnectionPoolStat{{RevisionKey: rev, Open: 2}}
+	if diff := cmp.Diff(want, c.Report()); diff != "" {
+		t.Errorf("Report (-want, +got) = %v", diff)
+	}
+
+	conn1.Close()
+	conn1.Close()
+	conn2.Close()
+	want = []ConnectionPoolStat{{RevisionKey: rev, Open: 0}}
+	if diff := cmp.Diff(want, c.Report()); diff != "" {
+		t.Errorf("Report (-want, +got) = %v", diff)
+	}
+	// Revisions without connections are forgotten once reported.
+	if got := c.Report(); len(got) != 0 {
+		t.Errorf("Report = %v, want none", got)
+	}
+}
+
+func TestConnectionCounterGrowing(t *testing.T) {
+	c := NewConnectionCounter()
+	dial := c.DialContext(pipeDial)
+	ctx := WithRevision(context.Background(), "ns", "rev")
+
+	for i := 1; i <= 2*ConnectionGrowthPeriods; i++ {
+		dial(ctx, "tcp", "rev.ns:80")
+		stats := c.Report()
+		if got, want := stats[0].Growing, i%ConnectionGrowthPeriods == 0; got != want {
+			t.Errorf("Growing after %d growing periods = %v, want %v", i, got, want)
+		}
+	}
+	// A period without growth resets the streak.
+	c.Report()
+	for i := 1; i < ConnectionGrowthPeriods; i++ {
+		dial(ctx, "tcp", "rev.ns:80")
+		if c.Report()[0].Growing {
+			t.Errorf("Growing after %d growing periods, want not growing", i)
+		}
+	}
+}
+
+func TestCountingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := NewConnectionCounter()
+	client := &http.Client{Transport: NewCountingTransport(c)}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(req.WithContext(WithRevision(context.Background(), "ns", "rev")))
+	if err != nil {
+		t.Fatalf("Do() = %v", err)
+	}
+	resp.Body.Close()
+
+	// The idle connection stays open in the pool.
+	stats := c.Report()
+	if len(stats) != 1 || stats[0].Revision != "rev" || stats[0].Open != 1 {
+		t.Errorf("Report = %v, want 1 connection to rev", stats)
+	}
+}
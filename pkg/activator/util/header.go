@@ -27,6 +27,8 @@ var headersToRemove = []string{
 	activator.RequestCountHTTPHeader,
 	activator.RevisionHeaderName,
 	activator.RevisionHeaderNamespace,
+	activator.RouteHeaderName,
+	activator.RouteTagHeaderName,
 }
 
 // SetupHeaderPruning will cause the http.ReverseProxy
@@ -38,6 +38,16 @@ func TestHeaderPruning(t *testing.T) {
 			return
 		}
 
+		if r.Header.Get(activator.RouteHeaderName) != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if r.Header.Get(activator.RouteTagHeaderName) != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
 		w.WriteHeader(http.StatusOK)
 	}
 
@@ -55,6 +65,12 @@ func TestHeaderPruning(t *testing.T) {
 	}, {
 		name:   "revision namespace header",
 		header: activator.RevisionHeaderNamespace,
+	}, {
+		name:   "route name header",
+		header: activator.RouteHeaderName,
+	}, {
+		name:   "route tag header",
+		header: activator.RouteTagHeaderName,
 	}}
 
 	for _, test := range tests {
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// MemoryPressureRatio is the fraction of its memory limit the activator can
+// use before it is under memory pressure.
+const MemoryPressureRatio = 0.8
+
+// ReadRSS returns the resident set size in bytes read from the VmRSS field of
+// a /proc/<pid>/status file.
+func ReadRSS(statusFile string) (int64, error) {
+	f, err := os.Open(statusFile)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return parseRSS(f)
+}
+
+func parseRSS(r io.Reader) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// The field looks like "VmRSS:     1234 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != "VmRSS:" {
+			continue
+		}
+		if fields[2] != "kB" {
+			return 0, fmt.Errorf("unexpected VmRSS unit %q", fields[2])
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb * 1024, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no VmRSS field found")
+}
+
+// MemoryPressure tracks whether the memory used by the activator is above
+// MemoryPressureRatio of its limit.
+type MemoryPressure struct {
+	threshold int64
+	under     int32
+}
+
+// NewMemoryPressure creates a MemoryPressure for the given memory limit in
+// bytes. A limit of 0 means there is no limit and never any pressure.
+func NewMemoryPressure(limit int64) *MemoryPressure {
+	return &MemoryPressure{threshold: int64(float64(limit) * MemoryPressureRatio)}
+}
+
+// Update records the memory used by the activator, returning whether it has
+// just come under pressure.
+func (m *MemoryPressure) Update(rss int64) bool {
+	if m.threshold > 0 && rss > m.threshold {
+		return atomic.SwapInt32(&m.under, 1) == 0
+	}
+	atomic.StoreInt32(&m.under, 0)
+	return false
+}
+
+// UnderPressure returns whether the activator is under memory pressure.
+func (m *MemoryPressure) UnderPressure() bool {
+	return atomic.LoadInt32(&m.under) == 1
+}
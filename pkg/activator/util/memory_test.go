@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		want    int64
+		wantErr bool
+	}{{
+		name:   "rss",
+		status: "Name:\tactivator\nVmPeak:\t  20000 kB\nVmRSS:\t   1234 kB\nThreads:\t8\n",
+		want:   1234 * 1024,
+	}, {
+		name:    "no rss",
+		status:  "Name:\tactivator\nThreads:\t8\n",
+		wantErr: true,
+	}, {
+		name:    "unexpected unit",
+		status:  "VmRSS:\t1234 MB\n",
+		wantErr: true,
+	}, {
+		name:    "malformed",
+		status:  "VmRSS:\tlots kB\n",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseRSS(strings.NewReader(test.status))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseRSS() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("parseRSS() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMemoryPressure(t *testing.T) {
+	m := NewMemoryPressure(1000)
+	for _, step := range []struct {
+		rss         int64
+		wantEntered bool
+		wantUnder   bool
+	}{
+		{rss: 700},
+		{rss: 900, wantEntered: true, wantUnder: true},
+		// Already under pressure.
+		{rss: 950, wantUnder: true},
+		{rss: 800},
+		{rss: 801, wantEntered: true, wantUnder: true},
+	} {
+		if got := m.Update(step.rss); got != step.wantEntered {
+			t.Errorf("Update(%d) = %v, want %v", step.rss, got, step.wantEntered)
+		}
+		if got := m.UnderPressure(); got != step.wantUnder {
+			t.Errorf("UnderPressure() after Update(%d) = %v, want %v", step.rss, got, step.wantUnder)
+		}
+	}
+
+	unlimited := NewMemoryPressure(0)
+	if unlimited.Update(1<<40) || unlimited.UnderPressure() {
+		t.Error("Unlimited memory came under pressure")
+	}
+}
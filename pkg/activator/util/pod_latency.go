@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// PodLatencySkew is the coefficient of variation of the mean latencies of the
+// pods of a revision. A slow pod raises it, and with it the tail latency of
+// the whole revision.
+type PodLatencySkew struct {
+	RevisionKey
+	CoefficientOfVariation float64
+}
+
+type podLatency struct {
+	total time.Duration
+	count int
+}
+
+// PodLatencies accumulates the latencies of the requests served by each pod
+// of the revisions.
+type PodLatencies struct {
+	mux       sync.Mutex
+	latencies map[RevisionKey]map[string]*podLatency
+}
+
+// NewPodLatencies creates a PodLatencies.
+func NewPodLatencies() *PodLatencies {
+	return &PodLatencies{latencies: make(map[RevisionKey]map[string]*podLatency)}
+}
+
+// Record records the latency of a request served by the given pod of the
+// revision.
+func (p *PodLatencies) Record(rev RevisionKey, pod string, d time.Duration) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	pods, ok := p.latencies[rev]
+	if !ok {
+		pods = make(map[string]*podLatency)
+		p.latencies[rev] = pods
+	}
+	l, ok := pods[pod]
+	if !ok {
+		l = &podLatency{}
+		pods[pod] = l
+	}
+	l.total += d
+	l.count++
+}
+
+// Report returns the skew of the latencies recorded since the previous call
+// for each revision with requests served by at least two pods, and resets
+// them.
+func (p *PodLatencies) Report() []PodLatencySkew {
+	p.mux.Lock()
+	latencies := p.latencies
+	p.latencies = make(map[RevisionKey]map[string]*podLatency)
+	p.mux.Unlock()
+
+	skews := make([]PodLatencySkew, 0, len(latencies))
+	for rev, pods := range latencies {
+		if len(pods) < 2 {
+			continue
+		}
+		means := make([]float64, 0, len(pods))
+		for _, l := range pods {
+			means = append(means, float64(l.total)/float64(l.count))
+		}
+		skews = append(skews, PodLatencySkew{
+			RevisionKey:            rev,
+			CoefficientOfVariation: coefficientOfVariation(means),
+		})
+	}
+	return skews
+}
+
+// coefficientOfVariation returns the population standard deviation of the
+// values relative to their mean.
+func coefficientOfVariation(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if mean == 0 {
+		return 0
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(squares/float64(len(values))) / mean
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Knative Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPodLatencies(t *testing.T) {
+	p := NewPodLatencies()
+	skewed := RevisionKey{Namespace: "ns", Revision: "skewed"}
+	even := RevisionKey{Namespace: "ns", Revision: "even"}
+	single := RevisionKey{Namespace: "ns", Revision: "single"}
+
+	// Mean latencies of 10ms, 10ms and 40ms.
+	p.Record(skewed, "pod-a", 5*time.Millisecond)
+	p.Record(skewed, "pod-a", 15*time.Millisecond)
+	p.Record(skewed, "pod-b", 10*time.Millisecond)
+	p.Record(skewed, "pod-c", 40*time.Millisecond)
+	p.Record(even, "pod-a", 10*time.Millisecond)
+	p.Record(even, "pod-b", 10*time.Millisecond)
+	// A single pod cannot be skewed.
+	p.Record(single, "pod-a", 10*time.Millisecond)
+
+	got := map[RevisionKey]float64{}
+	for _, s := range p.Report() {
+		got[s.RevisionKey] = s.CoefficientOfVariation
+	}
+	if len(got) != 2 {
+		t.Errorf("Report() = %v, want the skewed and the even revision", got)
+	}
+	// The standard deviation of 10, 10 and 40 is sqrt(200), their mean 20.
+	if want := math.Sqrt(200) / 20; math.Abs(got[skewed]-want) > 1e-9 {
+		t.Errorf("Coefficient of variation of %v = %v, want %v", skewed, got[skewed], want)
+	}
+	if got[even] != 0 {
+		t.Errorf("Coefficient of variation of %v = %v, want 0", even, got[even])
+	}
+
+	if skews := p.Report(); len(skews) != 0 {
+		t.Errorf("Report() = %v after reset, want none", skews)
+	}
+}
@@ -14,12 +14,15 @@ limitations under the License.
 package util
 
 import (
+	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/knative/serving/pkg/activator"
+	pkghttp "github.com/knative/serving/pkg/http"
 
 	h2cutil "github.com/knative/serving/pkg/http/h2c"
 	"go.uber.org/zap"
@@ -46,6 +49,29 @@ func NewHTTPTransport(v1 http.RoundTripper, v2 http.RoundTripper) http.RoundTrip
 // AutoTransport uses h2c for HTTP2 requests and falls back to `http.DefaultTransport` for all others
 var AutoTransport = NewHTTPTransport(http.DefaultTransport, h2cutil.DefaultTransport)
 
+// AttemptObserver is called with the revision, the number and the latency of
+// each attempt of a request.
+type AttemptObserver func(rev RevisionKey, attempt int, latency time.Duration)
+
+// NewAttemptTimingRoundTripper times the attempts a retrying round tripper
+// wrapping rt makes, until the response headers are received. The attempts
+// of requests attributed to no revision, see WithRevision, are not observed.
+func NewAttemptTimingRoundTripper(rt http.RoundTripper, observe AttemptObserver) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		if rev, ok := RevisionFrom(r.Context()); ok {
+			// The retrying round tripper adds the number of each attempt.
+			attempt, convErr := strconv.Atoi(pkghttp.LastHeaderValue(r.Header, activator.RequestCountHTTPHeader))
+			if convErr != nil {
+				attempt = 1
+			}
+			observe(rev, attempt, time.Since(start))
+		}
+		return resp, err
+	})
+}
+
 type RetryCond func(*http.Response) bool
 
 // RetryStatus will filter responses matching `status`
@@ -55,20 +81,43 @@ func RetryStatus(status int) RetryCond {
 	}
 }
 
+const (
+	// BackoffReasonConnectError is the reason of a backoff after a request
+	// failed to reach the revision.
+	BackoffReasonConnectError = "connect_error"
+	// BackoffReasonTimeout is the reason of a backoff after a request timed
+	// out. Backoffs after a retry condition matched have the status code of
+	// the response as reason.
+	BackoffReasonTimeout = "timeout"
+)
+
+// BackoffObserver is called once for each request that backed off before
+// being retried, with the reason of each of its backoffs in order and the
+// total time it spent backing off.
+type BackoffObserver func(reasons []string, total time.Duration)
+
 type retryRoundTripper struct {
 	logger          *zap.SugaredLogger
 	transport       http.RoundTripper
 	backoffSettings wait.Backoff
 	retryConditions []RetryCond
+	observe         BackoffObserver
 }
 
 // RetryRoundTripper retries a request on error or retry condition, using the given `retry` strategy
 func NewRetryRoundTripper(rt http.RoundTripper, l *zap.SugaredLogger, b wait.Backoff, conditions ...RetryCond) http.RoundTripper {
+	return NewObservedRetryRoundTripper(rt, l, b, nil, conditions...)
+}
+
+// NewObservedRetryRoundTripper is a NewRetryRoundTripper that reports the
+// backoffs of the requests to `observe`, if not nil.
+func NewObservedRetryRoundTripper(rt http.RoundTripper, l *zap.SugaredLogger, b wait.Backoff, observe BackoffObserver, conditions ...RetryCond) http.RoundTripper {
 	return &retryRoundTripper{
 		logger:          l,
 		transport:       rt,
 		backoffSettings: b,
 		retryConditions: conditions,
+		observe:         observe,
 	}
 }
 
@@ -82,27 +131,46 @@ func (rrt *retryRoundTripper) RoundTrip(r *http.Request) (resp *http.Response, e
 	}
 
 	attempts := 0
+	// The reason of each failed attempt is only a backoff once the attempt
+	// after it starts, as no backoff follows the last attempt.
+	var (
+		reasons       []string
+		pendingReason string
+		failedAt      time.Time
+		backingOff    time.Duration
+	)
 	wait.ExponentialBackoff(rrt.backoffSettings, func() (bool, error) {
 		rrt.logger.Debugf("Retrying")
 
+		if pendingReason != "" {
+			reasons = append(reasons, pendingReason)
+			backingOff += time.Since(failedAt)
+		}
+
 		attempts++
 		r.Header.Add(activator.RequestCountHTTPHeader, strconv.Itoa(attempts))
 		resp, err = rrt.transport.RoundTrip(r)
 
 		if err != nil {
 			rrt.logger.Errorf("Error making a request: %s", err)
+			pendingReason, failedAt = backoffReason(err), time.Now()
 			return false, nil
 		}
 
 		for _, retryCond := range rrt.retryConditions {
 			if retryCond(resp) {
 				resp.Body.Close()
+				pendingReason, failedAt = strconv.Itoa(resp.StatusCode), time.Now()
 				return false, nil
 			}
 		}
 		return true, nil
 	})
 
+	if rrt.observe != nil && len(reasons) > 0 {
+		rrt.observe(reasons, backingOff)
+	}
+
 	if err == nil {
 		rrt.logger.Infof("Finished after %d attempt(s). Response code: %d", attempts, resp.StatusCode)
 
@@ -117,3 +185,11 @@ func (rrt *retryRoundTripper) RoundTrip(r *http.Request) (resp *http.Response, e
 
 	return
 }
+
+// backoffReason classifies the error of a failed attempt.
+func backoffReason(err error) string {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return BackoffReasonTimeout
+	}
+	return BackoffReasonConnectError
+}
@@ -13,12 +13,15 @@ limitations under the License.
 package util
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/knative/pkg/logging/testing"
 	"github.com/knative/serving/pkg/activator"
@@ -235,3 +238,103 @@ func TestRetryRoundTripperNilBody(t *testing.T) {
 
 	rt.RoundTrip(req)
 }
+
+func TestAttemptTimingRoundTripper(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}
+	calls := 0
+	transport := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		status := statuses[calls%len(statuses)]
+		calls++
+		return &http.Response{StatusCode: status, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	var attempts []int
+	observe := func(rev RevisionKey, attempt int, latency time.Duration) {
+		if rev.Namespace != "ns" || rev.Revision != "rev" {
+			t.Errorf("Observed revision %v, want ns/rev", rev)
+		}
+		attempts = append(attempts, attempt)
+	}
+	rt := NewRetryRoundTripper(
+		NewAttemptTimingRoundTripper(transport, observe),
+		TestLogger(t),
+		wait.Backoff{Steps: 3},
+		RetryStatus(http.StatusServiceUnavailable),
+	)
+
+	req, _ := http.NewRequest("GET", "http://knative.dev/test/", nil)
+	rt.RoundTrip(req.WithContext(WithRevision(context.Background(), "ns", "rev")))
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(attempts, want) {
+		t.Errorf("Observed attempts %v, want %v", attempts, want)
+	}
+
+	// Requests attributed to no revision are not observed.
+	attempts = nil
+	req, _ = http.NewRequest("GET", "http://knative.dev/test/", nil)
+	rt.RoundTrip(req)
+	if len(attempts) != 0 {
+		t.Errorf("Observed attempts %v, want none", attempts)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryRoundTripperBackoffs(t *testing.T) {
+	results := []struct {
+		status int
+		err    error
+	}{
+		{err: timeoutError{}},
+		{err: errors.New("connection refused")},
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusOK},
+	}
+	calls := 0
+	transport := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		res := results[calls%len(results)]
+		calls++
+		if res.err != nil {
+			return nil, res.err
+		}
+		return &http.Response{StatusCode: res.status, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	var (
+		reasons []string
+		total   time.Duration
+	)
+	observe := func(r []string, d time.Duration) {
+		reasons, total = r, d
+	}
+	rt := NewObservedRetryRoundTripper(
+		transport,
+		TestLogger(t),
+		wait.Backoff{Duration: 10 * time.Millisecond, Factor: 1, Steps: 4},
+		observe,
+		RetryStatus(http.StatusServiceUnavailable),
+	)
+
+	req, _ := http.NewRequest("GET", "http://knative.dev/test/", nil)
+	rt.RoundTrip(req)
+	if want := []string{BackoffReasonTimeout, BackoffReasonConnectError, "503"}; !reflect.DeepEqual(reasons, want) {
+		t.Errorf("Observed backoff reasons %v, want %v", reasons, want)
+	}
+	if total < 30*time.Millisecond {
+		t.Errorf("Observed backoff duration %v, want at least 30ms", total)
+	}
+
+	// No backoff follows the last attempt.
+	reasons = nil
+	calls = 2
+	rt = NewObservedRetryRoundTripper(transport, TestLogger(t), wait.Backoff{Steps: 1}, observe,
+		RetryStatus(http.StatusServiceUnavailable))
+	req, _ = http.NewRequest("GET", "http://knative.dev/test/", nil)
+	rt.RoundTrip(req)
+	if reasons != nil {
+		t.Errorf("Observed backoff reasons %v, want none", reasons)
+	}
+}
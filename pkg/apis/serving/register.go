@@ -60,4 +60,25 @@ const (
 	// BuildHashLabelKey is the label key attached to a Build indicating the
 	// hash of the spec from which they were created.
 	BuildHashLabelKey = GroupName + "/buildHash"
+
+	// ConfigSchemaVersionAnnotationKey is the annotation key attached to the
+	// ConfigMaps of Knative Serving indicating the version of their schema.
+	ConfigSchemaVersionAnnotationKey = GroupName + "/configSchemaVersion"
+
+	// MaxRequestBodySizeAnnotationKey is the annotation key attached to a
+	// Revision to limit the size in bytes of the request bodies it accepts.
+	MaxRequestBodySizeAnnotationKey = GroupName + "/max-request-body-size-bytes"
+
+	// UpgradeAllowlistAnnotationKey is the annotation key attached to a
+	// Revision to list the protocols, comma separated, its requests may
+	// upgrade to. All upgrades are allowed when it is absent.
+	UpgradeAllowlistAnnotationKey = GroupName + "/upgrade-allowlist"
+
+	// RateLimitAnnotationKey is the annotation key attached to a Revision to
+	// limit the number of requests per second each of its pods accepts.
+	RateLimitAnnotationKey = GroupName + "/rate-limit-requests-per-second"
+
+	// ArchivedAnnotationsAnnotationKey is the annotation key holding the JSON
+	// encoded annotations archived from a Revision.
+	ArchivedAnnotationsAnnotationKey = GroupName + "/archived-annotations"
 )
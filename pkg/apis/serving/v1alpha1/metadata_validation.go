@@ -23,6 +23,7 @@ import (
 
 	"github.com/knative/pkg/apis"
 	"github.com/knative/serving/pkg/apis/autoscaling"
+	"github.com/knative/serving/pkg/apis/serving"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -47,6 +48,14 @@ func ValidateObjectMetadata(meta metav1.Object) *apis.FieldError {
 		return err.ViaField("annotations")
 	}
 
+	if _, err := getIntGT0(meta.GetAnnotations(), serving.MaxRequestBodySizeAnnotationKey); err != nil {
+		return err.ViaField("annotations")
+	}
+
+	if _, err := getIntGT0(meta.GetAnnotations(), serving.RateLimitAnnotationKey); err != nil {
+		return err.ViaField("annotations")
+	}
+
 	return nil
 }
 
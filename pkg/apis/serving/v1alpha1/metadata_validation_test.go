@@ -20,8 +20,11 @@ import (
 	"fmt"
 	"github.com/knative/pkg/apis"
 	"github.com/knative/serving/pkg/apis/autoscaling"
+	"github.com/knative/serving/pkg/apis/serving"
 	"reflect"
 	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestValidateScaleBoundAnnotations(t *testing.T) {
@@ -95,3 +98,72 @@ func TestValidateScaleBoundAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMaxRequestBodySizeAnnotation(t *testing.T) {
+	cases := []struct {
+		name      string
+		value     string
+		expectErr *apis.FieldError
+	}{{
+		name:  "1 MiB",
+		value: "1048576",
+	}, {
+		name:  "0",
+		value: "0",
+		expectErr: &apis.FieldError{
+			Message: fmt.Sprintf("Invalid %s annotation value: must be integer greater than 0", serving.MaxRequestBodySizeAnnotationKey),
+			Paths:   []string{"annotations." + serving.MaxRequestBodySizeAnnotationKey},
+		},
+	}, {
+		name:  "not a number",
+		value: "1Mi",
+		expectErr: &apis.FieldError{
+			Message: fmt.Sprintf("Invalid %s annotation value: must be integer greater than 0", serving.MaxRequestBodySizeAnnotationKey),
+			Paths:   []string{"annotations." + serving.MaxRequestBodySizeAnnotationKey},
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			meta := &metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{serving.MaxRequestBodySizeAnnotationKey: c.value},
+			}
+			err := ValidateObjectMetadata(meta)
+			if !reflect.DeepEqual(c.expectErr, err) {
+				t.Errorf("Expected: '%+v', Got: '%+v'", c.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRateLimitAnnotation(t *testing.T) {
+	cases := []struct {
+		name      string
+		value     string
+		expectErr *apis.FieldError
+	}{{
+		name:  "100 requests per second",
+		value: "100",
+	}, {
+		name:  "negative",
+		value: "-1",
+		expectErr: &apis.FieldError{
+			Message: fmt.Sprintf("Invalid %s annotation value: must be integer greater than 0", serving.RateLimitAnnotationKey),
+			Paths:   []string{"annotations." + serving.RateLimitAnnotationKey},
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			meta := &metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{serving.RateLimitAnnotationKey: c.value},
+			}
+			err := ValidateObjectMetadata(meta)
+			if !reflect.DeepEqual(c.expectErr, err) {
+				t.Errorf("Expected: '%+v', Got: '%+v'", c.expectErr, err)
+			}
+		})
+	}
+}
@@ -32,6 +32,16 @@ const (
 	// ActivatorPodName defines the pod name of the activator
 	// as defined in the metrics it sends.
 	ActivatorPodName string = "activator"
+
+	// MaxHealthyBurstFactor is the change of the desired pod count in one
+	// cycle, relative to the previous desired pod count, above which the
+	// traffic model is likely poorly configured.
+	MaxHealthyBurstFactor = 10
+
+	// MaxMetricsLag is the age of the most recent stat above which the
+	// autoscaler holds the current pod count rather than scaling down on
+	// stale data.
+	MaxMetricsLag = 30 * time.Second
 )
 
 // Stat defines a single measurement at a point in time
@@ -51,6 +61,21 @@ type Stat struct {
 
 	// Lameduck indicates this Pod has received a shutdown signal.
 	LameDuck bool
+
+	// TimeoutBudgetExceeded indicates the requests handled by this pod have
+	// been using most of the revision timeout for several reporting periods.
+	TimeoutBudgetExceeded bool
+
+	// FullVolumes names the volumes of this pod that are close to full.
+	FullVolumes []string
+
+	// Number of requests completed since last Stat that failed with a
+	// server error.
+	ErrorCount int32
+
+	// Number of requests completed since last Stat that missed the latency
+	// SLO.
+	SlowRequestCount int32
 }
 
 // StatMessage wraps a Stat with identifying information so it can be routed
@@ -193,16 +218,42 @@ type Autoscaler struct {
 	panicking            bool
 	panicTime            *time.Time
 	maxPanicPods         float64
+	scalingFactor        float64
+	timeoutExceeded      bool
+	fullVolumes          []PodVolume
+	health               HealthSignals
+	panicHistory         []panicSample
+	lastDesiredPodCount  int32
+	burst                *BurstDetector
 	reporter             StatsReporter
+	reportBurst          func(magnitude float64)
+
+	// statArrival is when the newest stat was recorded, poked is when an
+	// event requested a scale decision ahead of the next tick, and decided is
+	// when the previous scale decision was made. They measure the decision
+	// delay.
+	statArrival time.Time
+	poked       time.Time
+	decided     time.Time
+}
+
+// panicSample records whether a scaling decision was made in panic mode.
+type panicSample struct {
+	time      time.Time
+	panicking bool
 }
 
-// New creates a new instance of autoscaler
-func New(dynamicConfig *DynamicConfig, containerConcurrency v1alpha1.RevisionContainerConcurrencyType, reporter StatsReporter) *Autoscaler {
+// New creates a new instance of autoscaler. reportBurst, if not nil, is called
+// once for each burst of the request rate, with its magnitude.
+func New(dynamicConfig *DynamicConfig, containerConcurrency v1alpha1.RevisionContainerConcurrencyType, reporter StatsReporter, reportBurst func(magnitude float64)) *Autoscaler {
 	return &Autoscaler{
 		DynamicConfig:        dynamicConfig,
 		containerConcurrency: containerConcurrency,
 		stats:                make(map[statKey]Stat),
+		health:               HealthSignals{LatencySLOAdherence: 1},
+		burst:                NewBurstDetector(),
 		reporter:             reporter,
+		reportBurst:          reportBurst,
 	}
 }
 
@@ -221,6 +272,32 @@ func (a *Autoscaler) Record(ctx context.Context, stat Stat) {
 		time:    *stat.Time,
 	}
 	a.stats[key] = stat
+	a.burst.Record(*stat.Time, stat.RequestCount)
+	a.statArrival = time.Now()
+}
+
+// Poke records an event which happened at the given time, e.g. a change of
+// the ready pods, requesting a scale decision ahead of the next tick.
+func (a *Autoscaler) Poke(triggered time.Time) {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+
+	if a.poked.IsZero() || triggered.Before(a.poked) {
+		a.poked = triggered
+	}
+}
+
+// decisionTrigger returns the time of the event the next scale decision
+// handles: the earliest poke since the previous decision, or else the
+// arrival of the newest stat if it arrived since then.
+func (a *Autoscaler) decisionTrigger() (time.Time, bool) {
+	if !a.poked.IsZero() {
+		return a.poked, true
+	}
+	if a.statArrival.After(a.decided) {
+		return a.statArrival, true
+	}
+	return time.Time{}, false
 }
 
 // Scale calculates the desired scale based on current statistics given the current time.
@@ -240,6 +317,12 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (int32, bool) {
 	// Last stat per Pod
 	lastStat := make(map[string]Stat)
 
+	// Request outcomes over the stable window
+	var requestCount, errorCount, slowRequestCount int32
+
+	// Time of the most recent stat
+	var newest time.Time
+
 	// accumulate stats into their respective buckets
 	for key, stat := range a.stats {
 		instant := key.time
@@ -248,6 +331,12 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (int32, bool) {
 		}
 		if instant.Add(config.StableWindow).After(now) {
 			stableData.aggregate(stat)
+			requestCount += stat.RequestCount
+			errorCount += stat.ErrorCount
+			slowRequestCount += stat.SlowRequestCount
+			if instant.After(newest) {
+				newest = instant
+			}
 
 			// If there's no last stat for this pod, set it
 			if _, ok := lastStat[stat.PodName]; !ok {
@@ -269,12 +358,23 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (int32, bool) {
 		return 0, false
 	}
 
+	// The stats are pushed to the autoscaler, a slow sender leaves it with
+	// stale data.
+	lag := now.Sub(newest)
+	a.reporter.Report(MetricsLagMsM, float64(lag/time.Millisecond))
+
 	// Log system totals
 	totalCurrentQPS := int32(0)
 	totalCurrentConcurrency := float64(0)
+	a.timeoutExceeded = false
+	a.fullVolumes = nil
 	for _, stat := range lastStat {
 		totalCurrentQPS = totalCurrentQPS + stat.RequestCount
 		totalCurrentConcurrency = totalCurrentConcurrency + stat.AverageConcurrentRequests
+		a.timeoutExceeded = a.timeoutExceeded || stat.TimeoutBudgetExceeded
+		for _, volume := range stat.FullVolumes {
+			a.fullVolumes = append(a.fullVolumes, PodVolume{Pod: stat.PodName, Volume: volume})
+		}
 	}
 	logger.Debugf("Current QPS: %v  Current concurrent clients: %v", totalCurrentQPS, totalCurrentConcurrency)
 
@@ -298,6 +398,19 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (int32, bool) {
 	logger.Debugf("PANIC: Observed average %0.3f concurrency over %v seconds over %v samples over %v pods.",
 		observedPanicConcurrencyPerPod, config.PanicWindow, panicData.probeCount, panicData.observedPods(now))
 
+	// Record the scaling factor before rate limiting so that severe
+	// under-provisioning remains visible.
+	a.scalingFactor = observedStableConcurrencyPerPod / config.TargetConcurrency(a.containerConcurrency)
+	a.reporter.Report(ConcurrencyScalingFactorM, a.scalingFactor)
+
+	// The pods actually needed are the pods required to serve the observed
+	// load at the target concurrency, without rate limiting. Compare them to
+	// what the previous cycle asked for.
+	neededPodCount := math.Ceil(a.scalingFactor * stableData.observedPods(now))
+	if a.lastDesiredPodCount > 0 && neededPodCount > 0 {
+		a.reporter.Report(PredictionErrorPercentM, predictionErrorPercent(float64(a.lastDesiredPodCount), neededPodCount))
+	}
+
 	// Stop panicking after the surge has made its way into the stable metric.
 	if a.panicking && a.panicTime.Add(config.StableWindow).Before(now) {
 		logger.Info("Un-panicking.")
@@ -330,10 +443,122 @@ func (a *Autoscaler) Scale(ctx context.Context, now time.Time) (int32, bool) {
 		desiredPodCount = int32(math.Ceil(desiredStablePodCount))
 	}
 
+	// Stale stats understate the current load, so do not scale down on them.
+	if lag > MaxMetricsLag {
+		if currentPodCount := int32(math.Ceil(stableData.observedPods(now))); desiredPodCount < currentPodCount {
+			logger.Warnf("The most recent stat is %v old, holding %d pods instead of scaling down to %d.",
+				lag, currentPodCount, desiredPodCount)
+			desiredPodCount = currentPodCount
+		}
+	}
+
 	a.reporter.Report(DesiredPodCountM, float64(desiredPodCount))
+	a.reporter.Report(KPADesiredPodsM, float64(desiredPodCount))
+	decided := time.Now()
+	if triggered, ok := a.decisionTrigger(); ok {
+		a.reporter.Report(DecisionDelayMsM, float64(decided.Sub(triggered)/time.Millisecond))
+	}
+	a.poked = time.Time{}
+	a.decided = decided
+	if a.lastDesiredPodCount > 0 && desiredPodCount != a.lastDesiredPodCount {
+		burst := burstFactor(a.lastDesiredPodCount, desiredPodCount)
+		a.reporter.Report(PodBurstFactorM, burst)
+		if burst > MaxHealthyBurstFactor {
+			logger.Warnf("Desired pods jumped from %d to %d in one cycle; the traffic model may be poorly configured.",
+				a.lastDesiredPodCount, desiredPodCount)
+		}
+	}
+
+	a.updateHealth(now, config.StableWindow, requestCount, errorCount, slowRequestCount)
+	a.updateRequestRateBurst(ctx, now)
+	a.lastDesiredPodCount = desiredPodCount
 	return desiredPodCount, true
 }
 
+// updateRequestRateBurst detects bursts of the request rate, reporting each
+// of them once. Must be called with statsMutex held.
+func (a *Autoscaler) updateRequestRateBurst(ctx context.Context, now time.Time) {
+	magnitude, started := a.burst.Detect(now)
+	if started {
+		logging.FromContext(ctx).Infof("Request rate of the last %v is %.1fx the average of the previous %v.",
+			RequestRateBurstWindow, magnitude, RequestRateBaselineWindow)
+		a.reporter.Report(RequestRateBurstCountM, 1)
+		a.reporter.Report(RequestRateBurstMagnitudeM, magnitude)
+		if a.reportBurst != nil {
+			a.reportBurst(magnitude)
+		}
+	}
+}
+
+// burstFactor returns the change from the previous to the new desired pod
+// count, relative to the previous one.
+func burstFactor(previous, desired int32) float64 {
+	return math.Abs(float64(desired-previous)) / float64(previous)
+}
+
+// predictionErrorPercent returns how far the predicted pod count was from the
+// pod count actually needed, as a percentage of the latter.
+func predictionErrorPercent(predicted, needed float64) float64 {
+	return math.Abs(predicted-needed) / needed * 100
+}
+
+// updateHealth refreshes the health signals from the request outcomes and
+// panic decisions over the stable window. Must be called with statsMutex held.
+func (a *Autoscaler) updateHealth(now time.Time, window time.Duration, requestCount, errorCount, slowRequestCount int32) {
+	a.panicHistory = append(a.panicHistory, panicSample{time: now, panicking: a.panicking})
+	for len(a.panicHistory) > 0 && !a.panicHistory[0].time.Add(window).After(now) {
+		a.panicHistory = a.panicHistory[1:]
+	}
+	panicCount := 0
+	for _, s := range a.panicHistory {
+		if s.panicking {
+			panicCount++
+		}
+	}
+
+	a.health = HealthSignals{
+		LatencySLOAdherence: 1,
+		PanicFraction:       float64(panicCount) / float64(len(a.panicHistory)),
+	}
+	if requestCount > 0 {
+		a.health.ErrorRate = float64(errorCount) / float64(requestCount)
+		a.health.LatencySLOAdherence = 1 - float64(slowRequestCount)/float64(requestCount)
+	}
+}
+
+// ScalingFactor returns the ratio of observed stable concurrency to target
+// concurrency computed by the most recent call to Scale.
+func (a *Autoscaler) ScalingFactor() float64 {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.scalingFactor
+}
+
+// Health returns the health signals computed by the most recent call to
+// Scale. PodAvailability is left for the caller to fill in.
+func (a *Autoscaler) Health() HealthSignals {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.health
+}
+
+// TimeoutBudgetExceeded returns whether any pod reported that its requests
+// have been using most of the revision timeout, as of the most recent call
+// to Scale.
+func (a *Autoscaler) TimeoutBudgetExceeded() bool {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.timeoutExceeded
+}
+
+// FullVolumes returns the volumes the pods reported as close to full, as of
+// the most recent call to Scale.
+func (a *Autoscaler) FullVolumes() []PodVolume {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.fullVolumes
+}
+
 func (a *Autoscaler) rateLimited(desiredRate float64) float64 {
 	if desiredRate > a.Current().MaxScaleUpRate {
 		return a.Current().MaxScaleUpRate
@@ -18,6 +18,7 @@ package autoscaler
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -468,6 +469,275 @@ func TestAutoscaler_RateLimit_ScaleUp(t *testing.T) {
 	a.expectScale(t, now, 100, true)
 }
 
+func TestAutoscaler_ScalingFactor_IgnoresRateLimit(t *testing.T) {
+	a := newTestAutoscaler(10.0)
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 1000,
+			endConcurrency:   1000,
+			durationSeconds:  1,
+			podCount:         1,
+		})
+
+	// Only scale x10 but report the full x100 shortfall.
+	a.expectScale(t, now, 10, true)
+	if got, want := a.ScalingFactor(), 100.0; got != want {
+		t.Errorf("ScalingFactor() = %v, want %v", got, want)
+	}
+}
+
+func TestAutoscaler_TimeoutBudgetExceeded(t *testing.T) {
+	a := newTestAutoscaler(10.0)
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 10,
+			endConcurrency:   10,
+			durationSeconds:  1,
+			podCount:         2,
+		})
+	a.expectScale(t, now, 2, true)
+	if a.TimeoutBudgetExceeded() {
+		t.Error("TimeoutBudgetExceeded() = true, want false")
+	}
+
+	now = a.recordMetric(t, Stat{
+		Time:                      &now,
+		PodName:                   "pod-1",
+		AverageConcurrentRequests: 10,
+		RequestCount:              1,
+		TimeoutBudgetExceeded:     true,
+	})
+	a.expectScale(t, now, 2, true)
+	if !a.TimeoutBudgetExceeded() {
+		t.Error("TimeoutBudgetExceeded() = false, want true")
+	}
+}
+
+func TestAutoscaler_FullVolumes(t *testing.T) {
+	a := newTestAutoscaler(10.0)
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 10,
+			endConcurrency:   10,
+			durationSeconds:  1,
+			podCount:         2,
+		})
+	a.expectScale(t, now, 2, true)
+	if got := a.FullVolumes(); len(got) != 0 {
+		t.Errorf("FullVolumes() = %v, want none", got)
+	}
+
+	now = a.recordMetric(t, Stat{
+		Time:                      &now,
+		PodName:                   "pod-1",
+		AverageConcurrentRequests: 10,
+		RequestCount:              1,
+		FullVolumes:               []string{"/data"},
+	})
+	a.expectScale(t, now, 2, true)
+	want := []PodVolume{{Pod: "pod-1", Volume: "/data"}}
+	if got := a.FullVolumes(); !reflect.DeepEqual(want, got) {
+		t.Errorf("FullVolumes() = %v, want %v", got, want)
+	}
+}
+
+func TestAutoscaler_RequestRateBurst(t *testing.T) {
+	a := newTestAutoscaler(10.0)
+	var bursts []float64
+	a.reportBurst = func(magnitude float64) {
+		bursts = append(bursts, magnitude)
+	}
+
+	// 10 requests per second for six minutes, then 60 for a minute.
+	start := time.Unix(1000, 0)
+	for i := 0; i < 420; i++ {
+		count := int32(10)
+		if i >= 360 {
+			count = 60
+		}
+		a.burst.Record(start.Add(time.Duration(i)*time.Second), count)
+	}
+	now := start.Add(419 * time.Second)
+	a.updateRequestRateBurst(TestContextWithLogger(t), now)
+	a.updateRequestRateBurst(TestContextWithLogger(t), now)
+	if want := []float64{6}; !reflect.DeepEqual(bursts, want) {
+		t.Errorf("Reported bursts = %v, want %v", bursts, want)
+	}
+}
+
+func TestAutoscaler_Health(t *testing.T) {
+	a := newTestAutoscaler(10.0)
+	if got, want := a.Health(), (HealthSignals{LatencySLOAdherence: 1}); got != want {
+		t.Errorf("Health() before scaling = %+v, want %+v", got, want)
+	}
+
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		now = a.recordMetric(t, Stat{
+			Time:                      &now,
+			PodName:                   "pod-1",
+			AverageConcurrentRequests: 10,
+			RequestCount:              10,
+			ErrorCount:                2,
+			SlowRequestCount:          5,
+		}).Add(time.Second)
+	}
+	a.expectScale(t, now, 1, true)
+	if got, want := a.Health(), (HealthSignals{ErrorRate: 0.2, LatencySLOAdherence: 0.5}); got != want {
+		t.Errorf("Health() = %+v, want %+v", got, want)
+	}
+
+	// Panic on the next decision: half of the decisions in the window panicked.
+	now = a.recordMetric(t, Stat{
+		Time:                      &now,
+		PodName:                   "pod-1",
+		AverageConcurrentRequests: 1000,
+		RequestCount:              20,
+	}).Add(time.Second)
+	a.Scale(TestContextWithLogger(t), now)
+	if got, want := a.Health(), (HealthSignals{ErrorRate: 0.1, LatencySLOAdherence: 0.75, PanicFraction: 0.5}); got != want {
+		t.Errorf("Health() after panicking = %+v, want %+v", got, want)
+	}
+}
+
+func TestAutoscaler_PredictionError(t *testing.T) {
+	a := newTestAutoscaler(10.0)
+	reporter := &recordingReporter{}
+	a.reporter = reporter
+
+	// Steady load: the first decision has nothing to compare against.
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 10,
+			endConcurrency:   10,
+			durationSeconds:  1,
+			podCount:         1,
+		})
+	a.expectScale(t, now, 1, true)
+	if got := reporter.values[PredictionErrorPercentM]; len(got) != 0 {
+		t.Errorf("Reported prediction errors = %v, want none", got)
+	}
+
+	// The load triples: averaged over the stable window 2 pods are needed
+	// where 1 was predicted.
+	now = a.recordLinearSeries(
+		t,
+		now,
+		linearSeries{
+			startConcurrency: 30,
+			endConcurrency:   30,
+			durationSeconds:  1,
+			podCount:         1,
+		})
+	a.expectScale(t, now, 2, true)
+
+	// The load holds: 2 pods are still needed as predicted.
+	a.expectScale(t, now, 2, true)
+
+	if got, want := reporter.values[PredictionErrorPercentM], []float64{50, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reported prediction errors = %v, want %v", got, want)
+	}
+}
+
+func TestAutoscaler_PodBurstFactor(t *testing.T) {
+	a := newTestAutoscaler(1)
+	a.DynamicConfig.config.(*Config).MaxScaleUpRate = 100
+	reporter := &recordingReporter{}
+	a.reporter = reporter
+
+	// The previous cycle wanted a single pod.
+	a.lastDesiredPodCount = 1
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 100,
+			endConcurrency:   100,
+			durationSeconds:  1,
+			podCount:         1,
+		})
+	a.expectScale(t, now, 100, true)
+	// Scale events without a change of the desired pods are not bursts.
+	a.expectScale(t, now, 100, true)
+
+	if got, want := reporter.values[PodBurstFactorM], []float64{99}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reported pod burst factors = %v, want %v", got, want)
+	}
+}
+
+func TestAutoscaler_DecisionDelay(t *testing.T) {
+	a := newTestAutoscaler(10)
+	reporter := &recordingReporter{}
+	a.reporter = reporter
+
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 10,
+			endConcurrency:   10,
+			durationSeconds:  1,
+			podCount:         1,
+		})
+	// The decision handles the stats just recorded.
+	a.expectScale(t, now, 1, true)
+	if got := reporter.values[DecisionDelayMsM]; len(got) != 1 {
+		t.Fatalf("Reported decision delays = %v, want a single delay", got)
+	}
+
+	// Without a new stat, a tick does not handle any event.
+	a.expectScale(t, now, 1, true)
+	if got := reporter.values[DecisionDelayMsM]; len(got) != 1 {
+		t.Fatalf("Reported decision delays = %v, want a single delay", got)
+	}
+
+	// The delay of a poke is measured from the event requesting it, the
+	// earliest one when several are pending.
+	a.Poke(time.Now().Add(-300 * time.Millisecond))
+	a.Poke(time.Now().Add(-500 * time.Millisecond))
+	a.expectScale(t, now, 1, true)
+	got := reporter.values[DecisionDelayMsM]
+	if len(got) != 2 {
+		t.Fatalf("Reported decision delays = %v, want two delays", got)
+	}
+	if delay := got[1]; delay < 500 || delay > 5000 {
+		t.Errorf("Reported decision delay = %vms, want about 500ms", delay)
+	}
+}
+
+func TestAutoscaler_StaleMetrics(t *testing.T) {
+	a := newTestAutoscaler(10)
+	reporter := &recordingReporter{}
+	a.reporter = reporter
+
+	now := a.recordLinearSeries(
+		t,
+		time.Now(),
+		linearSeries{
+			startConcurrency: 1,
+			endConcurrency:   1,
+			durationSeconds:  1,
+			podCount:         5,
+		})
+	a.expectScale(t, now, 1, true)
+
+	// The same stats hold the 5 pods once they are too old.
+	a.expectScale(t, now.Add(MaxMetricsLag+time.Second), 5, true)
+
+	lags := reporter.values[MetricsLagMsM]
+	if len(lags) != 2 || lags[1] <= float64(MaxMetricsLag/time.Millisecond) {
+		t.Errorf("Reported metrics lags = %v, want a second one above %v", lags, MaxMetricsLag)
+	}
+}
+
 type linearSeries struct {
 	startConcurrency int
 	endConcurrency   int
@@ -483,6 +753,18 @@ func (r *mockReporter) Report(m Measurement, v float64) error {
 	return nil
 }
 
+type recordingReporter struct {
+	values map[Measurement][]float64
+}
+
+func (r *recordingReporter) Report(m Measurement, v float64) error {
+	if r.values == nil {
+		r.values = make(map[Measurement][]float64)
+	}
+	r.values[m] = append(r.values[m], v)
+	return nil
+}
+
 func newTestAutoscaler(containerConcurrency int) *Autoscaler {
 	stableWindow := 60 * time.Second
 	panicWindow := 6 * time.Second
@@ -500,7 +782,7 @@ func newTestAutoscaler(containerConcurrency int) *Autoscaler {
 		config: config,
 		logger: zap.NewNop().Sugar(),
 	}
-	return New(dynConfig, v1alpha1.RevisionContainerConcurrencyType(containerConcurrency), &mockReporter{})
+	return New(dynConfig, v1alpha1.RevisionContainerConcurrencyType(containerConcurrency), &mockReporter{}, nil)
 }
 
 // Record a data point every second, for every pod, for duration of the
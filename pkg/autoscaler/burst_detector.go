@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"time"
+)
+
+const (
+	// RequestRateBurstFactor is how many times the average request rate of
+	// the previous RequestRateBaselineWindow the request rate of the last
+	// RequestRateBurstWindow must exceed to be considered a burst, e.g. on
+	// a viral traffic event or a DDoS.
+	RequestRateBurstFactor = 5
+
+	// RequestRateBurstWindow is the window of the current request rate.
+	RequestRateBurstWindow = time.Minute
+
+	// RequestRateBaselineWindow is the window the current request rate is
+	// compared to, right before RequestRateBurstWindow.
+	RequestRateBaselineWindow = 5 * time.Minute
+)
+
+// BurstDetector detects sudden increases of the request rate of a revision.
+// It is not safe for concurrent use.
+type BurstDetector struct {
+	// requests holds the number of requests per second, keyed by the Unix
+	// time of the second.
+	requests map[int64]int32
+	// start is when the first requests were recorded.
+	start    time.Time
+	bursting bool
+}
+
+// NewBurstDetector creates a BurstDetector.
+func NewBurstDetector() *BurstDetector {
+	return &BurstDetector{requests: make(map[int64]int32)}
+}
+
+// Record records requests received at the given time.
+func (d *BurstDetector) Record(t time.Time, requests int32) {
+	if d.start.IsZero() || t.Before(d.start) {
+		d.start = t
+	}
+	d.requests[t.Unix()] += requests
+}
+
+// Detect returns the ratio of the current request rate to the baseline
+// request rate if it exceeds RequestRateBurstFactor, and whether the burst
+// just started. The magnitude is 0 without a burst, or before a full
+// baseline window of requests has been recorded.
+func (d *BurstDetector) Detect(now time.Time) (magnitude float64, started bool) {
+	burstStart := now.Add(-RequestRateBurstWindow).Unix()
+	baselineStart := now.Add(-RequestRateBurstWindow - RequestRateBaselineWindow).Unix()
+
+	var current, baseline int64
+	for second, requests := range d.requests {
+		switch {
+		case second <= baselineStart:
+			delete(d.requests, second)
+		case second <= burstStart:
+			baseline += int64(requests)
+		case second <= now.Unix():
+			current += int64(requests)
+		}
+	}
+
+	// Without requests over the baseline window, any traffic would be a
+	// burst, e.g. when scaling from zero.
+	if baseline > 0 && !d.start.After(now.Add(-RequestRateBurstWindow-RequestRateBaselineWindow)) {
+		currentRate := float64(current) / RequestRateBurstWindow.Seconds()
+		baselineRate := float64(baseline) / RequestRateBaselineWindow.Seconds()
+		magnitude = currentRate / baselineRate
+	}
+	if magnitude <= RequestRateBurstFactor {
+		d.bursting = false
+		return 0, false
+	}
+	started = !d.bursting
+	d.bursting = true
+	return magnitude, started
+}
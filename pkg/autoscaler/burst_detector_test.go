@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBurstDetector(t *testing.T) {
+	d := NewBurstDetector()
+	start := time.Unix(1000, 0)
+	// 10 requests per second for six minutes.
+	for i := 0; i < 360; i++ {
+		d.Record(start.Add(time.Duration(i)*time.Second), 10)
+	}
+	now := start.Add(359 * time.Second)
+	if magnitude, started := d.Detect(now); magnitude != 0 || started {
+		t.Errorf("Detect() on steady traffic = %v, %v, want 0, false", magnitude, started)
+	}
+
+	// 60 requests per second during the next minute.
+	for i := 360; i < 420; i++ {
+		d.Record(start.Add(time.Duration(i)*time.Second), 60)
+	}
+	now = start.Add(419 * time.Second)
+	magnitude, started := d.Detect(now)
+	if magnitude != 6 || !started {
+		t.Errorf("Detect() on the burst = %v, %v, want 6, true", magnitude, started)
+	}
+	// The burst is only started once.
+	if magnitude, started := d.Detect(now); magnitude != 6 || started {
+		t.Errorf("Detect() again = %v, %v, want 6, false", magnitude, started)
+	}
+
+	// Once the rate goes back down, the burst ends.
+	for i := 420; i < 480; i++ {
+		d.Record(start.Add(time.Duration(i)*time.Second), 10)
+	}
+	if magnitude, started := d.Detect(start.Add(479 * time.Second)); magnitude != 0 || started {
+		t.Errorf("Detect() after the burst = %v, %v, want 0, false", magnitude, started)
+	}
+	// Requests older than the baseline window are dropped.
+	if got, want := len(d.requests), 360; got != want {
+		t.Errorf("Seconds held = %d, want %d", got, want)
+	}
+}
+
+func TestBurstDetectorWithoutBaseline(t *testing.T) {
+	d := NewBurstDetector()
+	now := time.Unix(1000, 0)
+
+	// Traffic from zero, or before a full baseline window, is no burst.
+	d.Record(now, 1000)
+	if magnitude, _ := d.Detect(now); magnitude != 0 {
+		t.Errorf("Detect() without a baseline = %v, want 0", magnitude)
+	}
+	d.Record(now.Add(-2*time.Minute), 1)
+	if magnitude, _ := d.Detect(now); magnitude != 0 {
+		t.Errorf("Detect() with a partial baseline = %v, want 0", magnitude)
+	}
+}
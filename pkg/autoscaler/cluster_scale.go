@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ClusterScaleTracker follows pods that cannot be scheduled for lack of node
+// capacity. Such pods are what triggers the cluster autoscaler to provision
+// new nodes, and the time until they get scheduled is the time it took.
+type ClusterScaleTracker struct {
+	mux sync.Mutex
+	// unschedulable holds the time each pending pod was found unschedulable.
+	unschedulable map[string]time.Time
+}
+
+// NewClusterScaleTracker creates a ClusterScaleTracker.
+func NewClusterScaleTracker() *ClusterScaleTracker {
+	return &ClusterScaleTracker{unschedulable: make(map[string]time.Time)}
+}
+
+// Observe records the scheduling state of the pod identified by key. It
+// returns triggered when the pod is first found unschedulable, and the time
+// it waited for a node along with scheduled once such a pod got scheduled.
+func (t *ClusterScaleTracker) Observe(key string, pod *corev1.Pod) (triggered bool, latency time.Duration, scheduled bool) {
+	cond := podScheduledCondition(pod)
+	if cond == nil {
+		return false, 0, false
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	since, tracked := t.unschedulable[key]
+	switch {
+	case cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable:
+		if !tracked {
+			t.unschedulable[key] = cond.LastTransitionTime.Time
+			return true, 0, false
+		}
+	case cond.Status == corev1.ConditionTrue && tracked:
+		delete(t.unschedulable, key)
+		return false, cond.LastTransitionTime.Sub(since), true
+	}
+	return false, 0, false
+}
+
+// Forget drops the state kept for the pod identified by key.
+func (t *ClusterScaleTracker) Forget(key string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.unschedulable, key)
+}
+
+func podScheduledCondition(pod *corev1.Pod) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodScheduled {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterScaleTracker(t *testing.T) {
+	start := time.Now()
+	pod := func(status corev1.ConditionStatus, reason string, at time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             status,
+					Reason:             reason,
+					LastTransitionTime: metav1.NewTime(start.Add(at)),
+				}},
+			},
+		}
+	}
+
+	tracker := NewClusterScaleTracker()
+
+	// A pod scheduled right away never interacts with the cluster autoscaler.
+	if triggered, _, scheduled := tracker.Observe("ns/fast", pod(corev1.ConditionTrue, "", 0)); triggered || scheduled {
+		t.Errorf("Observe() of a scheduled pod = %v, %v, want false, false", triggered, scheduled)
+	}
+	// Pods without a scheduling condition are ignored.
+	if triggered, _, scheduled := tracker.Observe("ns/new", &corev1.Pod{}); triggered || scheduled {
+		t.Errorf("Observe() of a new pod = %v, %v, want false, false", triggered, scheduled)
+	}
+
+	if triggered, _, _ := tracker.Observe("ns/slow", pod(corev1.ConditionFalse, corev1.PodReasonUnschedulable, 0)); !triggered {
+		t.Error("Observe() of an unschedulable pod = false, want true")
+	}
+	// Further updates to the pending pod only trigger once.
+	if triggered, _, _ := tracker.Observe("ns/slow", pod(corev1.ConditionFalse, corev1.PodReasonUnschedulable, 0)); triggered {
+		t.Error("Observe() of an unschedulable pod again = true, want false")
+	}
+	triggered, latency, scheduled := tracker.Observe("ns/slow", pod(corev1.ConditionTrue, "", 90*time.Second))
+	if triggered || !scheduled || latency != 90*time.Second {
+		t.Errorf("Observe() = %v, %v, %v, want false, %v, true", triggered, latency, scheduled, 90*time.Second)
+	}
+
+	tracker.Observe("ns/deleted", pod(corev1.ConditionFalse, corev1.PodReasonUnschedulable, 0))
+	tracker.Forget("ns/deleted")
+	if _, _, scheduled := tracker.Observe("ns/deleted", pod(corev1.ConditionTrue, "", time.Minute)); scheduled {
+		t.Error("Observe() of a forgotten pod = true, want false")
+	}
+}
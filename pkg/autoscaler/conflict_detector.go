@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"math"
+	"sync"
+)
+
+// DefaultConflictThreshold is the relative difference between the pod
+// counts desired by the KPA and by an HPA above which the two are
+// considered to be in conflict.
+const DefaultConflictThreshold = 0.2
+
+// ConflictDetector detects when the KPA and an HPA targeting the same
+// deployment disagree on how many pods should be running. Such conflicts
+// otherwise only show up as pod count instability.
+type ConflictDetector struct {
+	// Threshold is the maximum tolerated relative difference between the
+	// two desired pod counts, e.g. 0.2 for 20%.
+	Threshold float64
+
+	mux sync.Mutex
+	// conflicting tracks the HPAs conflicting with each KPA.
+	conflicting map[string]map[string]bool
+}
+
+// NewConflictDetector creates a ConflictDetector using the DefaultConflictThreshold.
+func NewConflictDetector() *ConflictDetector {
+	return &ConflictDetector{Threshold: DefaultConflictThreshold}
+}
+
+// IsConflicting returns true if the pod count desired by the KPA differs
+// from the one desired by the HPA by more than the threshold, relative to
+// the HPA's desired pod count.
+func (d *ConflictDetector) IsConflicting(kpaDesired, hpaDesired int32) bool {
+	if hpaDesired == 0 {
+		return kpaDesired != 0
+	}
+	diff := math.Abs(float64(kpaDesired - hpaDesired))
+	return diff/float64(hpaDesired) > d.Threshold
+}
+
+// StartedConflicting records whether the KPA identified by key and the named
+// HPA disagree on the pod count, and returns true only when they did not at
+// the previous call.
+func (d *ConflictDetector) StartedConflicting(key, hpa string, kpaDesired, hpaDesired int32) bool {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.conflicting == nil {
+		d.conflicting = make(map[string]map[string]bool)
+	}
+	if d.conflicting[key] == nil {
+		d.conflicting[key] = make(map[string]bool)
+	}
+	conflicting := d.IsConflicting(kpaDesired, hpaDesired)
+	started := conflicting && !d.conflicting[key][hpa]
+	d.conflicting[key][hpa] = conflicting
+	return started
+}
+
+// Forget drops the state kept for the KPA identified by key.
+func (d *ConflictDetector) Forget(key string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	delete(d.conflicting, key)
+}
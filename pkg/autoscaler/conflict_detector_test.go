@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import "testing"
+
+func TestConflictDetector(t *testing.T) {
+	tests := []struct {
+		name       string
+		kpaDesired int32
+		hpaDesired int32
+		want       bool
+	}{{
+		name: "both zero",
+	}, {
+		name:       "hpa zero",
+		kpaDesired: 1,
+		want:       true,
+	}, {
+		name:       "equal",
+		kpaDesired: 10,
+		hpaDesired: 10,
+	}, {
+		name:       "within threshold",
+		kpaDesired: 12,
+		hpaDesired: 10,
+	}, {
+		name:       "above threshold",
+		kpaDesired: 13,
+		hpaDesired: 10,
+		want:       true,
+	}, {
+		name:       "below threshold",
+		kpaDesired: 7,
+		hpaDesired: 10,
+		want:       true,
+	}}
+
+	d := NewConflictDetector()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := d.IsConflicting(test.kpaDesired, test.hpaDesired); got != test.want {
+				t.Errorf("IsConflicting(%d, %d) = %v, want %v", test.kpaDesired, test.hpaDesired, got, test.want)
+			}
+		})
+	}
+}
+
+func TestConflictDetectorStartedConflicting(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		hpa        string
+		kpaDesired int32
+		want       bool
+	}{{
+		name:       "agreeing",
+		key:        "ns/kpa",
+		hpa:        "hpa",
+		kpaDesired: 10,
+	}, {
+		name:       "starts conflicting",
+		key:        "ns/kpa",
+		hpa:        "hpa",
+		kpaDesired: 20,
+		want:       true,
+	}, {
+		name:       "keeps conflicting",
+		key:        "ns/kpa",
+		hpa:        "hpa",
+		kpaDesired: 20,
+	}, {
+		name:       "another HPA starts conflicting",
+		key:        "ns/kpa",
+		hpa:        "other-hpa",
+		kpaDesired: 20,
+		want:       true,
+	}, {
+		name:       "agreeing again",
+		key:        "ns/kpa",
+		hpa:        "hpa",
+		kpaDesired: 10,
+	}, {
+		name:       "conflicts again",
+		key:        "ns/kpa",
+		hpa:        "hpa",
+		kpaDesired: 20,
+		want:       true,
+	}}
+
+	d := NewConflictDetector()
+	for _, test := range tests {
+		if got := d.StartedConflicting(test.key, test.hpa, test.kpaDesired, 10); got != test.want {
+			t.Errorf("%s: StartedConflicting() = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	d.Forget("ns/kpa")
+	if !d.StartedConflicting("ns/kpa", "hpa", 20, 10) {
+		t.Error("StartedConflicting() after Forget() = false, want true")
+	}
+}
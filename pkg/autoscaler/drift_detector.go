@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"math"
+	"sync"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// DefaultDriftThreshold is the relative difference between the CPU
+// utilization implied by the KPA target and the target of an HPA above
+// which the two targets are considered to have drifted apart.
+const DefaultDriftThreshold = 0.2
+
+// DriftDetector detects when the targets of the KPA and of an HPA targeting
+// the same deployment have drifted apart, so that the two autoscalers aim
+// for different pod counts under the same load.
+type DriftDetector struct {
+	// Threshold is the maximum tolerated relative difference between the
+	// two targets, e.g. 0.2 for 20%.
+	Threshold float64
+
+	mux sync.Mutex
+	// drifting tracks the HPAs drifting from each KPA.
+	drifting map[string]map[string]bool
+}
+
+// NewDriftDetector creates a DriftDetector using the DefaultDriftThreshold.
+func NewDriftDetector() *DriftDetector {
+	return &DriftDetector{Threshold: DefaultDriftThreshold}
+}
+
+// ImpliedCPUUtilization returns the CPU utilization percentage implied by
+// the KPA targeting targetConcurrency requests per pod, assuming a pod
+// serving containerConcurrency requests uses all of its CPU request. It
+// returns false when the container concurrency is unlimited.
+func ImpliedCPUUtilization(targetConcurrency float64, containerConcurrency v1alpha1.RevisionContainerConcurrencyType) (float64, bool) {
+	if containerConcurrency == 0 {
+		return 0, false
+	}
+	return 100 * targetConcurrency / float64(containerConcurrency), true
+}
+
+// Drift returns the difference between the implied CPU utilization and the
+// HPA's target CPU utilization, relative to the latter.
+func (d *DriftDetector) Drift(implied float64, hpaTarget int32) float64 {
+	if hpaTarget == 0 {
+		return 0
+	}
+	return math.Abs(implied-float64(hpaTarget)) / float64(hpaTarget)
+}
+
+// IsDrifting returns true if the implied CPU utilization differs from the
+// HPA's target CPU utilization by more than the threshold.
+func (d *DriftDetector) IsDrifting(implied float64, hpaTarget int32) bool {
+	return d.Drift(implied, hpaTarget) > d.Threshold
+}
+
+// StartedDrifting records whether the targets of the KPA identified by key
+// and of the named HPA are drifting apart, and returns true only when they
+// were not at the previous call.
+func (d *DriftDetector) StartedDrifting(key, hpa string, implied float64, hpaTarget int32) bool {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.drifting == nil {
+		d.drifting = make(map[string]map[string]bool)
+	}
+	if d.drifting[key] == nil {
+		d.drifting[key] = make(map[string]bool)
+	}
+	drifting := d.IsDrifting(implied, hpaTarget)
+	started := drifting && !d.drifting[key][hpa]
+	d.drifting[key][hpa] = drifting
+	return started
+}
+
+// Forget drops the state kept for the KPA identified by key.
+func (d *DriftDetector) Forget(key string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	delete(d.drifting, key)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestImpliedCPUUtilization(t *testing.T) {
+	if got, ok := ImpliedCPUUtilization(7, 10); !ok || got != 70 {
+		t.Errorf("ImpliedCPUUtilization(7, 10) = %v, %v, want 70, true", got, ok)
+	}
+	if _, ok := ImpliedCPUUtilization(100, v1alpha1.RevisionContainerConcurrencyType(0)); ok {
+		t.Error("ImpliedCPUUtilization(100, 0) = true, want false")
+	}
+}
+
+func TestDriftDetector(t *testing.T) {
+	tests := []struct {
+		name      string
+		implied   float64
+		hpaTarget int32
+		want      bool
+	}{{
+		name:      "equal",
+		implied:   70,
+		hpaTarget: 70,
+	}, {
+		name:      "within threshold",
+		implied:   80,
+		hpaTarget: 70,
+	}, {
+		name:      "above threshold",
+		implied:   90,
+		hpaTarget: 70,
+		want:      true,
+	}, {
+		name:      "below threshold",
+		implied:   50,
+		hpaTarget: 70,
+		want:      true,
+	}, {
+		name:    "no hpa target",
+		implied: 70,
+	}}
+
+	d := NewDriftDetector()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := d.IsDrifting(test.implied, test.hpaTarget); got != test.want {
+				t.Errorf("IsDrifting(%v, %d) = %v, want %v", test.implied, test.hpaTarget, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDriftDetectorStartedDrifting(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		hpa     string
+		implied float64
+		want    bool
+	}{{
+		name:    "aligned",
+		key:     "ns/kpa",
+		hpa:     "hpa",
+		implied: 70,
+	}, {
+		name:    "starts drifting",
+		key:     "ns/kpa",
+		hpa:     "hpa",
+		implied: 90,
+		want:    true,
+	}, {
+		name:    "keeps drifting",
+		key:     "ns/kpa",
+		hpa:     "hpa",
+		implied: 90,
+	}, {
+		name:    "another HPA starts drifting",
+		key:     "ns/kpa",
+		hpa:     "other-hpa",
+		implied: 90,
+		want:    true,
+	}, {
+		name:    "realigned",
+		key:     "ns/kpa",
+		hpa:     "hpa",
+		implied: 70,
+	}, {
+		name:    "drifts again",
+		key:     "ns/kpa",
+		hpa:     "hpa",
+		implied: 90,
+		want:    true,
+	}}
+
+	d := NewDriftDetector()
+	for _, test := range tests {
+		if got := d.StartedDrifting(test.key, test.hpa, test.implied, 70); got != test.want {
+			t.Errorf("%s: StartedDrifting() = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	d.Forget("ns/kpa")
+	if !d.StartedDrifting("ns/kpa", "hpa", 90, 70) {
+		t.Error("StartedDrifting() after Forget() = false, want true")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+// The revision health score is a weighted average of four signals, each
+// normalized to [0, 1] where 1 is healthy:
+//
+//   - availability (40%): one minus the error rate. Failed requests are the
+//     most direct symptom users see, so they dominate the score.
+//   - latency SLO adherence (25%): the fraction of requests meeting the
+//     latency SLO. Slow requests hurt users, but less than failed ones.
+//   - pod availability (25%): ready pods over desired pods. A shortfall
+//     predicts queueing and latency before users notice.
+//   - autoscaler stability (10%): one minus the fraction of recent decisions
+//     made in panic mode. Panicking is expected on bursts, so it only
+//     contributes a little.
+const (
+	availabilityWeight    = 0.4
+	latencyWeight         = 0.25
+	podAvailabilityWeight = 0.25
+	stabilityWeight       = 0.1
+
+	// CriticalHealthScore is the score below which a revision is considered
+	// critically unhealthy.
+	CriticalHealthScore = 0.5
+)
+
+// HealthSignals are the inputs of the revision health score.
+type HealthSignals struct {
+	// ErrorRate is the fraction of requests that failed with a server error.
+	ErrorRate float64
+	// LatencySLOAdherence is the fraction of requests that met the latency SLO.
+	LatencySLOAdherence float64
+	// PodAvailability is the number of ready pods over the number of desired pods.
+	PodAvailability float64
+	// PanicFraction is the fraction of recent scaling decisions made in panic mode.
+	PanicFraction float64
+}
+
+// Score returns the health score of the signals, between 0 and 1.
+func (h HealthSignals) Score() float64 {
+	return availabilityWeight*clamp01(1-h.ErrorRate) +
+		latencyWeight*clamp01(h.LatencySLOAdherence) +
+		podAvailabilityWeight*clamp01(h.PodAvailability) +
+		stabilityWeight*clamp01(1-h.PanicFraction)
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHealthScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals HealthSignals
+		want    float64
+	}{{
+		name:    "healthy",
+		signals: HealthSignals{LatencySLOAdherence: 1, PodAvailability: 1},
+		want:    1,
+	}, {
+		name:    "all requests failing",
+		signals: HealthSignals{ErrorRate: 1, LatencySLOAdherence: 1, PodAvailability: 1},
+		want:    0.6,
+	}, {
+		name:    "slow requests",
+		signals: HealthSignals{LatencySLOAdherence: 0.5, PodAvailability: 1},
+		want:    0.875,
+	}, {
+		name:    "missing pods",
+		signals: HealthSignals{LatencySLOAdherence: 1, PodAvailability: 0.5},
+		want:    0.875,
+	}, {
+		name:    "always panicking",
+		signals: HealthSignals{LatencySLOAdherence: 1, PodAvailability: 1, PanicFraction: 1},
+		want:    0.9,
+	}, {
+		name:    "everything wrong",
+		signals: HealthSignals{ErrorRate: 1, PanicFraction: 1},
+		want:    0,
+	}, {
+		name:    "out of range signals are clamped",
+		signals: HealthSignals{ErrorRate: -1, LatencySLOAdherence: 2, PodAvailability: 3},
+		want:    1,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.signals.Score(); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	kpa "github.com/knative/serving/pkg/apis/autoscaling/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 )
 
 const (
@@ -39,6 +40,19 @@ const (
 
 type Metric struct {
 	DesiredScale int32
+	// ScalingFactor is the most recent ratio of observed to target concurrency.
+	ScalingFactor float64
+	// TimeoutBudgetExceeded is true when requests to the target have been
+	// using most of the revision timeout.
+	TimeoutBudgetExceeded bool
+	// FullVolumes are the volumes of the target's pods that are close to
+	// full.
+	FullVolumes []PodVolume
+	// Health holds the signals of the revision health score known to the
+	// autoscaler.
+	Health HealthSignals
+	// TargetConcurrency is the concurrency per pod the autoscaler targets.
+	TargetConcurrency float64
 }
 
 // UniScaler records statistics for a particular KPA and proposes the scale for the KPA's target based on those statistics.
@@ -49,6 +63,21 @@ type UniScaler interface {
 	// Scale either proposes a number of replicas or skips proposing. The proposal is requested at the given time.
 	// The returned boolean is true if and only if a proposal was returned.
 	Scale(context.Context, time.Time) (int32, bool)
+
+	// Poke records an event which happened at the given time and requests the next proposal.
+	Poke(time.Time)
+
+	// ScalingFactor returns the ratio of observed to target concurrency computed by the most recent proposal.
+	ScalingFactor() float64
+
+	// TimeoutBudgetExceeded returns whether any pod reported requests using most of the revision timeout.
+	TimeoutBudgetExceeded() bool
+
+	// FullVolumes returns the volumes the pods reported as close to full in the most recent proposal.
+	FullVolumes() []PodVolume
+
+	// Health returns the health signals computed by the most recent proposal.
+	Health() HealthSignals
 }
 
 // UniScalerFactory creates a UniScaler for a given KPA using the given dynamic configuration.
@@ -59,9 +88,16 @@ type scalerRunner struct {
 	scaler UniScaler
 	stopCh chan struct{}
 
+	containerConcurrency v1alpha1.RevisionContainerConcurrencyType
+
 	// lsm guards access to latestScale
 	lsm         sync.RWMutex
 	latestScale int32
+
+	// pokeCh requests a scale decision ahead of the next tick, with the time
+	// of the event requesting it. It holds a single request, pokes arriving
+	// while one is pending are dropped.
+	pokeCh chan time.Time
 }
 
 func (sr *scalerRunner) getLatestScale() int32 {
@@ -122,7 +158,12 @@ func (m *MultiScaler) Get(ctx context.Context, key string) (*Metric, error) {
 		return nil, errors.NewNotFound(kpa.Resource("Metrics"), key)
 	}
 	return &Metric{
-		DesiredScale: scaler.getLatestScale(),
+		DesiredScale:          scaler.getLatestScale(),
+		ScalingFactor:         scaler.scaler.ScalingFactor(),
+		TimeoutBudgetExceeded: scaler.scaler.TimeoutBudgetExceeded(),
+		FullVolumes:           scaler.scaler.FullVolumes(),
+		Health:                scaler.scaler.Health(),
+		TargetConcurrency:     m.dynConfig.Current().TargetConcurrency(scaler.containerConcurrency),
 	}, nil
 }
 
@@ -141,6 +182,7 @@ func (m *MultiScaler) Create(ctx context.Context, kpa *kpa.PodAutoscaler) (*Metr
 	}
 	return &Metric{
 		DesiredScale: scaler.getLatestScale(),
+		Health:       scaler.scaler.Health(),
 	}, nil
 }
 
@@ -168,7 +210,13 @@ func (m *MultiScaler) createScaler(ctx context.Context, kpa *kpa.PodAutoscaler)
 	}
 
 	stopCh := make(chan struct{})
-	runner := &scalerRunner{scaler: scaler, latestScale: -1, stopCh: stopCh}
+	runner := &scalerRunner{
+		scaler:               scaler,
+		latestScale:          -1,
+		stopCh:               stopCh,
+		containerConcurrency: kpa.Spec.ContainerConcurrency,
+		pokeCh:               make(chan time.Time, 1),
+	}
 
 	ticker := time.NewTicker(m.dynConfig.Current().TickInterval)
 
@@ -183,8 +231,11 @@ func (m *MultiScaler) createScaler(ctx context.Context, kpa *kpa.PodAutoscaler)
 			case <-stopCh:
 				ticker.Stop()
 				return
-			case <-ticker.C:
-				m.tickScaler(ctx, scaler, scaleChan)
+			case requested := <-ticker.C:
+				m.tickScaler(ctx, scaler, scaleChan, requested)
+			case triggered := <-runner.pokeCh:
+				scaler.Poke(triggered)
+				m.tickScaler(ctx, scaler, scaleChan, time.Now())
 			}
 		}
 	}()
@@ -208,9 +259,9 @@ func (m *MultiScaler) createScaler(ctx context.Context, kpa *kpa.PodAutoscaler)
 	return runner, nil
 }
 
-func (m *MultiScaler) tickScaler(ctx context.Context, scaler UniScaler, scaleChan chan<- int32) {
+func (m *MultiScaler) tickScaler(ctx context.Context, scaler UniScaler, scaleChan chan<- int32, requested time.Time) {
 	logger := logging.FromContext(ctx)
-	desiredScale, scaled := scaler.Scale(ctx, time.Now())
+	desiredScale, scaled := scaler.Scale(ctx, requested)
 
 	if scaled {
 		// Cannot scale negative.
@@ -229,6 +280,21 @@ func (m *MultiScaler) tickScaler(ctx context.Context, scaler UniScaler, scaleCha
 	}
 }
 
+// Poke requests a scale decision for the given KPA ahead of its next tick,
+// e.g. because its pods became ready or unready at the triggered time. kpaKey
+// should have the form namespace/name.
+func (m *MultiScaler) Poke(key string, triggered time.Time) {
+	m.scalersMutex.RLock()
+	defer m.scalersMutex.RUnlock()
+
+	if scaler, exists := m.scalers[key]; exists {
+		select {
+		case scaler.pokeCh <- triggered:
+		default:
+		}
+	}
+}
+
 // RecordStat records some statistics for the given KPA. kpaKey should have the
 // form namespace/name.
 func (m *MultiScaler) RecordStat(key string, stat Stat) {
@@ -19,6 +19,7 @@ package autoscaler_test
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -46,7 +47,8 @@ func TestMultiScalerScaling(t *testing.T) {
 	ctx := context.TODO()
 	servingClient := fakeKna.NewSimpleClientset()
 	ms, stopCh, uniScaler := createMultiScaler(t, &autoscaler.Config{
-		TickInterval: time.Millisecond * 1,
+		TickInterval:                      time.Millisecond * 1,
+		ContainerConcurrencyTargetDefault: 100,
 	})
 	defer close(stopCh)
 
@@ -79,6 +81,9 @@ func TestMultiScalerScaling(t *testing.T) {
 		if got, want := m.DesiredScale, int32(1); got != want {
 			t.Errorf("Get() = %v, wanted %v", got, want)
 		}
+		if got, want := m.TargetConcurrency, float64(100); got != want {
+			t.Errorf("Get().TargetConcurrency = %v, wanted %v", got, want)
+		}
 	})
 
 	_, err = ms.Create(ctx, kpa)
@@ -117,6 +122,52 @@ func TestMultiScalerScaling(t *testing.T) {
 	}
 }
 
+func TestMultiScalerPoke(t *testing.T) {
+	ctx := context.TODO()
+	servingClient := fakeKna.NewSimpleClientset()
+	ms, stopCh, uniScaler := createMultiScaler(t, &autoscaler.Config{
+		// Long enough for the test to be over before the first tick.
+		TickInterval:                      time.Hour,
+		ContainerConcurrencyTargetDefault: 100,
+	})
+	defer close(stopCh)
+
+	revision := newRevision(t, servingClient)
+	kpa := newKPA(t, servingClient, revision)
+	kpaKey := fmt.Sprintf("%s/%s", kpa.Namespace, kpa.Name)
+
+	uniScaler.setScaleResult(1, true)
+
+	done := make(chan struct{}, 1)
+	ms.Watch(func(key string) {
+		done <- struct{}{}
+	})
+
+	// Pokes of unknown KPAs are ignored.
+	ms.Poke(kpaKey, time.Now())
+
+	if _, err := ms.Create(ctx, kpa); err != nil {
+		t.Errorf("Create() = %v", err)
+	}
+	select {
+	case <-done:
+		t.Fatal("Got unexpected scale decision before the poke")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	ms.Poke(kpaKey, time.Now())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the scale decision of the poke")
+	}
+	if m, err := ms.Get(ctx, kpaKey); err != nil {
+		t.Errorf("Get() = %v", err)
+	} else if m.DesiredScale != 1 {
+		t.Errorf("Get().DesiredScale = %d, want 1", m.DesiredScale)
+	}
+}
+
 func TestMultiScalerScaleToZero(t *testing.T) {
 	ctx := context.TODO()
 	servingClient := fakeKna.NewSimpleClientset()
@@ -369,6 +420,25 @@ func (u *fakeUniScaler) Scale(context.Context, time.Time) (int32, bool) {
 	return u.replicas, u.scaled
 }
 
+func (u *fakeUniScaler) Poke(time.Time) {
+}
+
+func (u *fakeUniScaler) ScalingFactor() float64 {
+	return 1
+}
+
+func (u *fakeUniScaler) TimeoutBudgetExceeded() bool {
+	return false
+}
+
+func (u *fakeUniScaler) FullVolumes() []autoscaler.PodVolume {
+	return nil
+}
+
+func (u *fakeUniScaler) Health() autoscaler.HealthSignals {
+	return autoscaler.HealthSignals{LatencySLOAdherence: 1}
+}
+
 func (u *fakeUniScaler) setScaleResult(replicas int32, scaled bool) {
 	u.mutex.Lock()
 	defer u.mutex.Unlock()
@@ -387,7 +457,7 @@ func (u *fakeUniScaler) Record(ctx context.Context, stat autoscaler.Stat) {
 func (u *fakeUniScaler) checkLastStat(t *testing.T, stat autoscaler.Stat) {
 	t.Helper()
 
-	if u.lastStat != stat {
+	if !reflect.DeepEqual(u.lastStat, stat) {
 		t.Fatalf("Last statistic recorded was %#v instead of expected statistic %#v", u.lastStat, stat)
 	}
 }
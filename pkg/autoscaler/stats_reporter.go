@@ -21,6 +21,7 @@ import (
 	"errors"
 
 	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -48,6 +49,57 @@ const (
 	TargetConcurrencyM
 	// PanicM is used as a flag to indicate if autoscaler is in panic mode or not
 	PanicM
+	// KPADesiredPodsM is used for the pod count that the KPA wants
+	KPADesiredPodsM
+	// HPADesiredPodsM is used for the pod count that an HPA targeting the same
+	// deployment wants
+	HPADesiredPodsM
+	// ConcurrencyScalingFactorM is the ratio of observed stable concurrency to
+	// target concurrency
+	ConcurrencyScalingFactorM
+	// RevisionHealthScoreM is the composite health score of the revision
+	RevisionHealthScoreM
+	// PredictionErrorPercentM is the error of the previous desired pod count
+	// relative to the pods actually needed
+	PredictionErrorPercentM
+	// WarmPoolSizeM is the number of ready pods in excess of the pods the
+	// load requires, for revisions with a minimum scale
+	WarmPoolSizeM
+	// WarmPoolHitCountM is the number of required pods served by a
+	// pre-warmed pod
+	WarmPoolHitCountM
+	// WarmPoolMissCountM is the number of required pods that had to be
+	// scheduled because the warm pool was exhausted
+	WarmPoolMissCountM
+	// ClusterScaleTriggeredM is the number of pods that could not be
+	// scheduled for lack of node capacity
+	ClusterScaleTriggeredM
+	// ClusterScaleLatencyMsM is the time unschedulable pods waited for a
+	// node to be provisioned
+	ClusterScaleLatencyMsM
+	// HPATargetCPUUtilizationM is the target CPU utilization of an HPA
+	// targeting the same deployment
+	HPATargetCPUUtilizationM
+	// KPATargetConcurrencyM is the concurrency per pod the KPA targets
+	KPATargetConcurrencyM
+	// TargetUtilizationDriftM is the relative difference between the CPU
+	// utilization implied by the KPA target and the HPA target
+	TargetUtilizationDriftM
+	// PodBurstFactorM is the change of the desired pod count relative to
+	// the previous desired pod count, per scale event
+	PodBurstFactorM
+	// RequestRateBurstCountM is the number of bursts of the request rate
+	RequestRateBurstCountM
+	// RequestRateBurstMagnitudeM is the ratio of the request rate to the
+	// baseline request rate, per burst
+	RequestRateBurstMagnitudeM
+	// DecisionDelayMsM is the time from the event requesting a scale
+	// decision, i.e. the arrival of a stat or a change of the ready pods, to
+	// the desired pod count being computed
+	DecisionDelayMsM
+	// MetricsLagMsM is the age of the most recent stat received by the
+	// autoscaler, per scale decision
+	MetricsLagMsM
 )
 
 var (
@@ -84,6 +136,78 @@ var (
 			"panic_mode",
 			"1 if autoscaler is in panic mode, 0 otherwise",
 			stats.UnitNone),
+		KPADesiredPodsM: stats.Float64(
+			"kpa_desired_pods",
+			"Number of pods the KPA wants to allocate",
+			stats.UnitNone),
+		HPADesiredPodsM: stats.Float64(
+			"hpa_desired_pods",
+			"Number of pods an HPA targeting the same deployment wants to allocate",
+			stats.UnitNone),
+		ConcurrencyScalingFactorM: stats.Float64(
+			"concurrency_scaling_factor",
+			"Ratio of observed stable concurrency to target concurrency",
+			stats.UnitNone),
+		RevisionHealthScoreM: stats.Float64(
+			"revision_health_score",
+			"Composite health score of the revision between 0 and 1",
+			stats.UnitNone),
+		PredictionErrorPercentM: stats.Float64(
+			"prediction_error_percent",
+			"Error of the desired pod count relative to the pods actually needed in the next cycle",
+			stats.UnitNone),
+		WarmPoolSizeM: stats.Float64(
+			"warm_pool_size",
+			"Number of ready pods in excess of the pods the load requires",
+			stats.UnitNone),
+		WarmPoolHitCountM: stats.Float64(
+			"warm_pool_hit_total",
+			"Number of required pods served by a pre-warmed pod",
+			stats.UnitNone),
+		WarmPoolMissCountM: stats.Float64(
+			"warm_pool_miss_total",
+			"Number of required pods that had to be scheduled because the warm pool was exhausted",
+			stats.UnitNone),
+		ClusterScaleTriggeredM: stats.Float64(
+			"cluster_scale_triggered_total",
+			"Number of pods that could not be scheduled for lack of node capacity",
+			stats.UnitNone),
+		ClusterScaleLatencyMsM: stats.Float64(
+			"cluster_scale_latency_ms",
+			"Time unschedulable pods waited for a node to be provisioned in milliseconds",
+			stats.UnitMilliseconds),
+		HPATargetCPUUtilizationM: stats.Float64(
+			"hpa_target_cpu_utilization",
+			"Target CPU utilization percentage of an HPA targeting the same deployment",
+			stats.UnitNone),
+		KPATargetConcurrencyM: stats.Float64(
+			"kpa_target_concurrency",
+			"Number of concurrent requests per pod the KPA targets",
+			stats.UnitNone),
+		TargetUtilizationDriftM: stats.Float64(
+			"hpa_target_utilization_drift",
+			"Relative difference between the CPU utilization implied by the KPA target and the HPA target",
+			stats.UnitNone),
+		PodBurstFactorM: stats.Float64(
+			"pod_burst_factor",
+			"Change of the desired pod count relative to the previous desired pod count",
+			stats.UnitNone),
+		RequestRateBurstCountM: stats.Float64(
+			"request_rate_burst_total",
+			"Number of bursts of the request rate",
+			stats.UnitNone),
+		RequestRateBurstMagnitudeM: stats.Float64(
+			"request_rate_burst_magnitude",
+			"Ratio of the request rate of the last minute to the average of the previous five minutes, per burst",
+			stats.UnitNone),
+		DecisionDelayMsM: stats.Float64(
+			"decision_delay_ms",
+			"Time from the event requesting a scale decision to the desired pod count being computed in milliseconds",
+			stats.UnitMilliseconds),
+		MetricsLagMsM: stats.Float64(
+			"autoscaler_metrics_lag_ms",
+			"Age of the most recent stat received by the autoscaler in milliseconds",
+			stats.UnitMilliseconds),
 	}
 	namespaceTagKey tag.Key
 	configTagKey    tag.Key
@@ -118,7 +242,7 @@ func init() {
 	// Create views to see our measurements. This can return an error if
 	// a previously-registered view has the same name with a different value.
 	// View name defaults to the measure name if unspecified.
-	err = view.Register(
+	err = metrics.RegisterViews(
 		&view.View{
 			Description: "Number of pods autoscaler wants to allocate",
 			Measure:     measurements[DesiredPodCountM],
@@ -167,6 +291,114 @@ func init() {
 			Aggregation: view.LastValue(),
 			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
 		},
+		&view.View{
+			Description: "Number of pods the KPA wants to allocate",
+			Measure:     measurements[KPADesiredPodsM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of pods an HPA targeting the same deployment wants to allocate",
+			Measure:     measurements[HPADesiredPodsM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Ratio of observed stable concurrency to target concurrency",
+			Measure:     measurements[ConcurrencyScalingFactorM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Composite health score of the revision between 0 and 1",
+			Measure:     measurements[RevisionHealthScoreM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Error of the desired pod count relative to the pods actually needed in the next cycle",
+			Measure:     measurements[PredictionErrorPercentM],
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 200, 500),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of ready pods in excess of the pods the load requires",
+			Measure:     measurements[WarmPoolSizeM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of required pods served by a pre-warmed pod",
+			Measure:     measurements[WarmPoolHitCountM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of required pods that had to be scheduled because the warm pool was exhausted",
+			Measure:     measurements[WarmPoolMissCountM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of pods that could not be scheduled for lack of node capacity",
+			Measure:     measurements[ClusterScaleTriggeredM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Time unschedulable pods waited for a node to be provisioned in milliseconds",
+			Measure:     measurements[ClusterScaleLatencyMsM],
+			Aggregation: view.Distribution(1000, 5000, 10000, 30000, 60000, 120000, 300000, 600000),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Target CPU utilization percentage of an HPA targeting the same deployment",
+			Measure:     measurements[HPATargetCPUUtilizationM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of concurrent requests per pod the KPA targets",
+			Measure:     measurements[KPATargetConcurrencyM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Relative difference between the CPU utilization implied by the KPA target and the HPA target",
+			Measure:     measurements[TargetUtilizationDriftM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Change of the desired pod count relative to the previous desired pod count",
+			Measure:     measurements[PodBurstFactorM],
+			Aggregation: view.Distribution(0.1, 0.25, 0.5, 1, 2, 5, 10, 25, 50, 100),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of bursts of the request rate",
+			Measure:     measurements[RequestRateBurstCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Ratio of the request rate of the last minute to the average of the previous five minutes, per burst",
+			Measure:     measurements[RequestRateBurstMagnitudeM],
+			Aggregation: view.Distribution(5, 10, 20, 50, 100, 1000),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Time from the event requesting a scale decision to the desired pod count being computed in milliseconds",
+			Measure:     measurements[DecisionDelayMsM],
+			Aggregation: view.Distribution(1, 5, 10, 50, 100, 500, 1000, 2000, 5000, 10000),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Age of the most recent stat received by the autoscaler in milliseconds",
+			Measure:     measurements[MetricsLagMsM],
+			Aggregation: view.Distribution(100, 500, 1000, 2000, 5000, 10000, 30000, 60000),
+			TagKeys:     []tag.Key{namespaceTagKey, serviceTagKey, configTagKey, revisionTagKey},
+		},
 	)
 	if err != nil {
 		panic(err)
@@ -217,3 +449,11 @@ func (r *Reporter) Report(m Measurement, v float64) error {
 	stats.Record(r.ctx, measurements[m].M(v))
 	return nil
 }
+
+// DeleteRevisionStats deletes the gauges reported for a revision, so that
+// they are no longer exported once its KPA is deleted.
+func DeleteRevisionStats(namespace, revision string) error {
+	return metrics.DeleteLastValueRows(
+		tag.Tag{Key: namespaceTagKey, Value: namespace},
+		tag.Tag{Key: revisionTagKey, Value: revision})
+}
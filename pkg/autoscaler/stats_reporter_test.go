@@ -98,6 +98,30 @@ func TestReporter_Report(t *testing.T) {
 	checkData(t, "panic_mode", wantTags, 0)
 }
 
+func TestDeleteRevisionStats(t *testing.T) {
+	r, err := NewStatsReporter("testns", "testsvc", "testconfig", "deletedrev")
+	if err != nil {
+		t.Fatalf("NewStatsReporter() = %v", err)
+	}
+	expectSuccess(t, func() error { return r.Report(RevisionHealthScoreM, 0.3) })
+
+	if err := DeleteRevisionStats("testns", "deletedrev"); err != nil {
+		t.Fatalf("DeleteRevisionStats() = %v", err)
+	}
+
+	rows, err := view.RetrieveData("revision_health_score")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key.Name() == metricskey.LabelRevisionName && tag.Value == "deletedrev" {
+				t.Errorf("Got row %v of the deleted revision", row)
+			}
+		}
+	}
+}
+
 func expectSuccess(t *testing.T, f func() error) {
 	if err := f(); err != nil {
 		t.Errorf("Reporter.Report() expected success but got error %v", err)
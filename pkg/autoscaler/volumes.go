@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"sync"
+)
+
+// PodVolume identifies a volume of a pod.
+type PodVolume struct {
+	Pod    string
+	Volume string
+}
+
+// FullVolumeTracker tracks the volumes the pods of each revision report as
+// close to full, so that each volume is only reported once per crossing.
+type FullVolumeTracker struct {
+	mux  sync.Mutex
+	full map[string]map[PodVolume]bool
+}
+
+// NewFullVolumeTracker creates a FullVolumeTracker.
+func NewFullVolumeTracker() *FullVolumeTracker {
+	return &FullVolumeTracker{full: make(map[string]map[PodVolume]bool)}
+}
+
+// Observe records the volumes close to full of the revision identified by
+// key, and returns those that were not close to full at the previous
+// observation.
+func (t *FullVolumeTracker) Observe(key string, volumes []PodVolume) []PodVolume {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	prev := t.full[key]
+	full := make(map[PodVolume]bool, len(volumes))
+	var crossed []PodVolume
+	for _, v := range volumes {
+		if !prev[v] && !full[v] {
+			crossed = append(crossed, v)
+		}
+		full[v] = true
+	}
+	t.full[key] = full
+	return crossed
+}
+
+// Forget drops the state kept for the revision identified by key.
+func (t *FullVolumeTracker) Forget(key string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.full, key)
+}
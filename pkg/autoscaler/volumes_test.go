@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFullVolumeTracker(t *testing.T) {
+	tracker := NewFullVolumeTracker()
+	data := PodVolume{Pod: "pod-1", Volume: "/data"}
+	cache := PodVolume{Pod: "pod-2", Volume: "/cache"}
+
+	tests := []struct {
+		name    string
+		volumes []PodVolume
+		want    []PodVolume
+	}{{
+		name: "nothing full",
+	}, {
+		name:    "volume crosses",
+		volumes: []PodVolume{data},
+		want:    []PodVolume{data},
+	}, {
+		name:    "volume stays full",
+		volumes: []PodVolume{data},
+	}, {
+		name:    "another volume crosses",
+		volumes: []PodVolume{data, cache},
+		want:    []PodVolume{cache},
+	}, {
+		name:    "volume freed",
+		volumes: []PodVolume{cache},
+	}, {
+		name:    "volume crosses again",
+		volumes: []PodVolume{data, cache},
+		want:    []PodVolume{data},
+	}}
+	for _, test := range tests {
+		if got := tracker.Observe("ns/rev", test.volumes); !cmp.Equal(test.want, got) {
+			t.Errorf("%s: Observe() = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if got := tracker.Observe("ns/other", []PodVolume{data}); !cmp.Equal([]PodVolume{data}, got) {
+		t.Errorf("Observe() of another revision = %v, want %v", got, []PodVolume{data})
+	}
+
+	tracker.Forget("ns/rev")
+	if got := tracker.Observe("ns/rev", []PodVolume{data, cache}); !cmp.Equal([]PodVolume{data, cache}, got) {
+		t.Errorf("Observe() after Forget() = %v, want %v", got, []PodVolume{data, cache})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"sync"
+)
+
+// WarmPoolTracker measures the warm pool of revisions kept above zero by a
+// minimum scale: the ready pods in excess of the pods the load requires.
+// When the load grows, the pods it requires are hits when they come from the
+// warm pool and misses when new pods have to be scheduled.
+type WarmPoolTracker struct {
+	mux  sync.Mutex
+	last map[string]warmPoolObservation
+}
+
+type warmPoolObservation struct {
+	demand int32
+	ready  int32
+}
+
+// NewWarmPoolTracker creates a WarmPoolTracker.
+func NewWarmPoolTracker() *WarmPoolTracker {
+	return &WarmPoolTracker{last: make(map[string]warmPoolObservation)}
+}
+
+// Observe records the pods required by the load and the ready pods of the
+// revision identified by key. It returns the current warm pool size, and the
+// hits and misses of the additional pods required since the previous
+// observation, which were served from the warm pool as it was then.
+func (t *WarmPoolTracker) Observe(key string, demand, ready int32) (size, hits, misses int32) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if prev, ok := t.last[key]; ok && demand > prev.demand {
+		increase := demand - prev.demand
+		hits = min32(increase, max32(prev.ready-prev.demand, 0))
+		misses = increase - hits
+	}
+	t.last[key] = warmPoolObservation{demand: demand, ready: ready}
+	return max32(ready-demand, 0), hits, misses
+}
+
+// Forget drops the state kept for the revision identified by key.
+func (t *WarmPoolTracker) Forget(key string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	delete(t.last, key)
+}
+
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+)
+
+func TestWarmPoolTracker(t *testing.T) {
+	tracker := NewWarmPoolTracker()
+
+	tests := []struct {
+		name       string
+		demand     int32
+		ready      int32
+		wantSize   int32
+		wantHits   int32
+		wantMisses int32
+	}{{
+		name:     "idle with a warm pool of 3",
+		demand:   0,
+		ready:    3,
+		wantSize: 3,
+	}, {
+		name:     "load absorbed by the warm pool",
+		demand:   2,
+		ready:    3,
+		wantSize: 1,
+		wantHits: 2,
+	}, {
+		name:       "load exceeds the warm pool",
+		demand:     5,
+		ready:      3,
+		wantHits:   1,
+		wantMisses: 2,
+	}, {
+		name:   "new pods become ready",
+		demand: 5,
+		ready:  5,
+	}, {
+		name:     "load drops",
+		demand:   1,
+		ready:    5,
+		wantSize: 4,
+	}}
+
+	for _, test := range tests {
+		size, hits, misses := tracker.Observe("ns/rev", test.demand, test.ready)
+		if size != test.wantSize || hits != test.wantHits || misses != test.wantMisses {
+			t.Errorf("%s: Observe() = (%d, %d, %d), want (%d, %d, %d)", test.name,
+				size, hits, misses, test.wantSize, test.wantHits, test.wantMisses)
+		}
+	}
+
+	// A forgotten revision starts over without hits or misses.
+	tracker.Forget("ns/rev")
+	if _, hits, misses := tracker.Observe("ns/rev", 10, 0); hits != 0 || misses != 0 {
+		t.Errorf("Observe() after Forget() = %d hits, %d misses, want none", hits, misses)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+const (
+	azureMonitorResource = "https://monitoring.azure.com/"
+
+	// Azure Monitor custom metrics accept at most this many dimensions.
+	azureMaxDimensions = 20
+
+	// Access tokens are valid for 3600 seconds. Refresh them a little early so
+	// that in-flight exports never use an expired token.
+	azureDefaultTokenLifetime = 3600 * time.Second
+	azureTokenRefreshMargin   = 5 * time.Minute
+
+	azureRequestTimeout = 30 * time.Second
+
+	// The buffered views are sent this often.
+	azureFlushInterval = 10 * time.Second
+)
+
+// azureMetricData is the request body of the Azure Monitor custom metrics API.
+type azureMetricData struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		BaseData azureBaseData `json:"baseData"`
+	} `json:"data"`
+}
+
+type azureBaseData struct {
+	Metric    string              `json:"metric"`
+	Namespace string              `json:"namespace"`
+	DimNames  []string            `json:"dimNames,omitempty"`
+	Series    []azureMetricSeries `json:"series"`
+}
+
+type azureMetricSeries struct {
+	DimValues []string `json:"dimValues,omitempty"`
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	Sum       float64  `json:"sum"`
+	Count     int64    `json:"count"`
+}
+
+// azureMonitorExporter exports view data to the Azure Monitor custom metrics
+// REST API, authenticating as a service principal. The views are buffered and
+// sent in the background, Close sends what is left.
+type azureMonitorExporter struct {
+	config *MetricsConfig
+	logger *zap.SugaredLogger
+	client *http.Client
+
+	tokenURL   string
+	metricsURL string
+	now        func() time.Time
+	// onError reports the failed sends to the circuit breaker.
+	onError func(error)
+
+	mux sync.Mutex
+	// metrics holds the latest data of each view since the last send, the
+	// views are cumulative so the latest data supersedes the earlier ones.
+	metrics map[string]*azureMetricData
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+
+	tokenMux    sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newAzureMonitorExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	breaker := newCircuitBreaker(logger)
+	e := &azureMonitorExporter{
+		config:     config,
+		logger:     logger,
+		client:     &http.Client{Timeout: azureRequestTimeout},
+		tokenURL:   fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/token", url.PathEscape(config.AzureTenantID)),
+		metricsURL: fmt.Sprintf("https://%s.monitoring.azure.com%s/metrics", config.AzureRegion, config.AzureResourceID),
+		now:        time.Now,
+		onError:    breaker.onError,
+		stopCh:     make(chan struct{}),
+	}
+	go flushEvery(e, azureFlushInterval, e.stopCh)
+	logger.Infof("Created Azure Monitor exporter with config %v", config)
+	return &circuitBreakerExporter{Exporter: e, breaker: breaker}, nil
+}
+
+// ExportView implements view.Exporter.
+func (e *azureMonitorExporter) ExportView(vd *view.Data) {
+	if len(vd.Rows) == 0 {
+		return
+	}
+	md := e.toMetricData(vd)
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if e.metrics == nil {
+		e.metrics = make(map[string]*azureMetricData)
+	}
+	e.metrics[vd.View.Name] = md
+}
+
+// Flush sends the buffered views to Azure Monitor. The API takes a single
+// metric per request, the sends stop at the first failure.
+func (e *azureMonitorExporter) Flush() {
+	e.mux.Lock()
+	metrics := e.metrics
+	e.metrics = nil
+	e.mux.Unlock()
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if err := e.send(metrics[name]); err != nil {
+			e.onError(fmt.Errorf("failed to export %d views to Azure Monitor: %v", len(names)-i, err))
+			return
+		}
+	}
+}
+
+// Close stops sending in the background and flushes the buffered views.
+func (e *azureMonitorExporter) Close() {
+	e.closeOnce.Do(func() {
+		close(e.stopCh)
+		e.Flush()
+	})
+}
+
+// toMetricData maps the rows of a view to a single Azure Monitor metric with
+// one series per row. Tags beyond the first azureMaxDimensions tag keys of
+// the view are dropped.
+func (e *azureMonitorExporter) toMetricData(vd *view.Data) *azureMetricData {
+	keys := vd.View.TagKeys
+	if len(keys) > azureMaxDimensions {
+		e.logger.Warnf("View %q has %d tag keys, only the first %d are exported to Azure Monitor",
+			vd.View.Name, len(keys), azureMaxDimensions)
+		keys = keys[:azureMaxDimensions]
+	}
+
+	md := &azureMetricData{Time: vd.End.UTC()}
+	md.Data.BaseData = azureBaseData{
+		Metric:    vd.View.Name,
+		Namespace: e.config.Domain + "/" + e.config.Component,
+	}
+	for _, k := range keys {
+		md.Data.BaseData.DimNames = append(md.Data.BaseData.DimNames, k.Name())
+	}
+
+	for _, row := range vd.Rows {
+		series := azureMetricSeries{}
+		if len(keys) > 0 {
+			series.DimValues = make([]string, len(keys))
+			for _, t := range row.Tags {
+				for i, k := range keys {
+					if t.Key == k {
+						series.DimValues[i] = t.Value
+					}
+				}
+			}
+		}
+		switch data := row.Data.(type) {
+		case *view.CountData:
+			series.setValue(float64(data.Value))
+		case *view.SumData:
+			series.setValue(data.Value)
+		case *view.LastValueData:
+			series.setValue(data.Value)
+		case *view.DistributionData:
+			series.Min = data.Min
+			series.Max = data.Max
+			series.Sum = data.Mean * float64(data.Count)
+			series.Count = data.Count
+		default:
+			continue
+		}
+		md.Data.BaseData.Series = append(md.Data.BaseData.Series, series)
+	}
+	return md
+}
+
+// setValue records a single sample, which is how Azure Monitor represents
+// counters and gauges.
+func (s *azureMetricSeries) setValue(v float64) {
+	s.Min, s.Max, s.Sum, s.Count = v, v, v, 1
+}
+
+func (e *azureMonitorExporter) send(md *azureMetricData) error {
+	token, err := e.accessToken()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.metricsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d from Azure Monitor: %s", resp.StatusCode, b)
+	}
+	return nil
+}
+
+// accessToken returns a cached access token for Azure Monitor, requesting a
+// new one when the cached token is about to expire.
+func (e *azureMonitorExporter) accessToken() (string, error) {
+	e.tokenMux.Lock()
+	defer e.tokenMux.Unlock()
+
+	now := e.now()
+	if e.token != "" && now.Add(azureTokenRefreshMargin).Before(e.tokenExpiry) {
+		return e.token, nil
+	}
+
+	resp, err := e.client.PostForm(e.tokenURL, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {e.config.AzureClientID},
+		"client_secret": {e.config.AzureClientSecret},
+		"resource":      {azureMonitorResource},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("unexpected status %d requesting Azure access token: %s", resp.StatusCode, b)
+	}
+
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+	if tr.AccessToken == "" {
+		return "", fmt.Errorf("no access token returned by %s", e.tokenURL)
+	}
+
+	lifetime := azureDefaultTokenLifetime
+	if secs, err := strconv.Atoi(tr.ExpiresIn); err == nil && secs > 0 {
+		lifetime = time.Duration(secs) * time.Second
+	}
+	e.token = tr.AccessToken
+	e.tokenExpiry = now.Add(lifetime)
+	return e.token, nil
+}
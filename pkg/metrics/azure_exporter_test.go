@@ -0,0 +1,315 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+type fakeAzure struct {
+	tokenRequests int
+	auth          []string
+	metrics       []azureMetricData
+	failMetrics   bool
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/token":
+		f.tokenRequests++
+		if got := r.PostFormValue("client_secret"); got != "secret" {
+			http.Error(w, "bad secret "+got, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": "3600"}`, f.tokenRequests)
+	case "/metrics":
+		if f.failMetrics {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var md azureMetricData
+		if err := json.NewDecoder(r.Body).Decode(&md); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.auth = append(f.auth, r.Header.Get("Authorization"))
+		f.metrics = append(f.metrics, md)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestAzureExporter(t *testing.T, srv *httptest.Server, now *time.Time) *azureMonitorExporter {
+	logger := TestLogger(t)
+	return &azureMonitorExporter{
+		config: &MetricsConfig{
+			Domain:            metricsDomain,
+			Component:         "testcomponent",
+			AzureClientID:     "client",
+			AzureClientSecret: "secret",
+		},
+		logger:     logger,
+		client:     srv.Client(),
+		tokenURL:   srv.URL + "/token",
+		metricsURL: srv.URL + "/metrics",
+		now:        func() time.Time { return *now },
+		onError:    newCircuitBreaker(logger).onError,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+func TestNewAzureMonitorExporter(t *testing.T) {
+	// The token is requested on the first send, the constructor does not
+	// reach the (unresolvable) tenant.
+	e, err := newAzureMonitorExporter(&MetricsConfig{
+		Domain:          metricsDomain,
+		Component:       "testcomponent",
+		AzureTenantID:   "tenant",
+		AzureRegion:     "invalid.test",
+		AzureResourceID: "/subscriptions/sub",
+	}, TestLogger(t))
+	if err != nil {
+		t.Fatalf("newAzureMonitorExporter() = %v", err)
+	}
+	defer e.(closer).Close()
+
+	ce, ok := e.(*circuitBreakerExporter)
+	if !ok {
+		t.Fatalf("newAzureMonitorExporter() = %T, want a *circuitBreakerExporter", e)
+	}
+	if ae := ce.Exporter.(*azureMonitorExporter); ae.token != "" {
+		t.Errorf("token = %q before any export, want none", ae.token)
+	}
+}
+
+func TestAzureMonitorExporterTokenRefresh(t *testing.T) {
+	fake := &fakeAzure{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	now := time.Now()
+	e := newTestAzureExporter(t, srv, &now)
+
+	for _, step := range []struct {
+		advance   time.Duration
+		wantToken string
+	}{
+		{0, "token-1"},
+		{30 * time.Minute, "token-1"},
+		// Within the refresh margin of the one hour lifetime.
+		{26 * time.Minute, "token-2"},
+		{time.Minute, "token-2"},
+	} {
+		now = now.Add(step.advance)
+		got, err := e.accessToken()
+		if err != nil {
+			t.Fatalf("accessToken() = %v", err)
+		}
+		if got != step.wantToken {
+			t.Errorf("accessToken() = %q, want %q", got, step.wantToken)
+		}
+	}
+}
+
+func TestAzureMonitorExporterBadCredentials(t *testing.T) {
+	fake := &fakeAzure{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	now := time.Now()
+	e := newTestAzureExporter(t, srv, &now)
+	e.config.AzureClientSecret = "wrong"
+	if _, err := e.accessToken(); err == nil {
+		t.Error("accessToken() = nil, wanted an error")
+	}
+}
+
+func TestAzureMonitorExporterExportView(t *testing.T) {
+	fake := &fakeAzure{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	now := time.Now()
+	e := newTestAzureExporter(t, srv, &now)
+
+	nsKey, _ := tag.NewKey("namespace_name")
+	measure := stats.Float64("azure_test", "test measure", stats.UnitNone)
+	end := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+	e.ExportView(&view.Data{
+		View: &view.View{
+			Name:        "azure_test",
+			Measure:     measure,
+			Aggregation: view.Distribution(1, 10),
+			TagKeys:     []tag.Key{nsKey},
+		},
+		End: end,
+		Rows: []*view.Row{{
+			Tags: []tag.Tag{{Key: nsKey, Value: "default"}},
+			Data: &view.DistributionData{Count: 4, Min: 1, Max: 7, Mean: 2.5},
+		}, {
+			Tags: []tag.Tag{{Key: nsKey, Value: "other"}},
+			Data: &view.DistributionData{Count: 1, Min: 3, Max: 3, Mean: 3},
+		}},
+	})
+
+	// The views are buffered until the exporter is flushed.
+	if len(fake.metrics) != 0 || fake.tokenRequests != 0 {
+		t.Fatalf("Got %d metric and %d token requests before flushing, want none", len(fake.metrics), fake.tokenRequests)
+	}
+	e.Close()
+
+	if len(fake.metrics) != 1 {
+		t.Fatalf("Got %d metric requests, want 1", len(fake.metrics))
+	}
+	if got, want := fake.auth[0], "Bearer token-1"; got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	want := azureMetricData{Time: end}
+	want.Data.BaseData = azureBaseData{
+		Metric:    "azure_test",
+		Namespace: "knative.dev/serving/testcomponent",
+		DimNames:  []string{"namespace_name"},
+		Series: []azureMetricSeries{{
+			DimValues: []string{"default"},
+			Min:       1,
+			Max:       7,
+			Sum:       10,
+			Count:     4,
+		}, {
+			DimValues: []string{"other"},
+			Min:       3,
+			Max:       3,
+			Sum:       3,
+			Count:     1,
+		}},
+	}
+	if diff := cmp.Diff(want, fake.metrics[0]); diff != "" {
+		t.Errorf("Unexpected metric data (-want +got): %v", diff)
+	}
+
+	// Closing again sends nothing.
+	e.Close()
+	if len(fake.metrics) != 1 {
+		t.Errorf("Got %d metric requests after closing twice, want 1", len(fake.metrics))
+	}
+}
+
+func TestAzureMonitorExporterFlush(t *testing.T) {
+	fake := &fakeAzure{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	now := time.Now()
+	e := newTestAzureExporter(t, srv, &now)
+	var errs []error
+	e.onError = func(err error) { errs = append(errs, err) }
+
+	export := func(name string, v float64) {
+		e.ExportView(&view.Data{
+			View: &view.View{
+				Name:        name,
+				Measure:     stats.Float64(name, "test measure", stats.UnitNone),
+				Aggregation: view.LastValue(),
+			},
+			Rows: []*view.Row{{Data: &view.LastValueData{Value: v}}},
+		})
+	}
+
+	// Only the latest data of a view is sent.
+	export("azure_b", 1)
+	export("azure_a", 2)
+	export("azure_b", 3)
+	e.Flush()
+	var got []string
+	for _, md := range fake.metrics {
+		got = append(got, fmt.Sprintf("%s=%v", md.Data.BaseData.Metric, md.Data.BaseData.Series[0].Sum))
+	}
+	if want := []string{"azure_a=2", "azure_b=3"}; !cmp.Equal(want, got) {
+		t.Errorf("Sent metrics = %v, want %v", got, want)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Got errors %v, want none", errs)
+	}
+
+	// A failed send is reported once and drops the buffered views.
+	fake.failMetrics = true
+	export("azure_a", 4)
+	export("azure_b", 5)
+	e.Flush()
+	if len(errs) != 1 {
+		t.Fatalf("Got %d errors, want 1", len(errs))
+	}
+	fake.failMetrics = false
+	e.Flush()
+	if got := len(fake.metrics); got != 2 {
+		t.Errorf("Got %d metric requests, want 2", got)
+	}
+}
+
+func TestAzureMonitorExporterDimensionLimit(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	now := time.Now()
+	e := newTestAzureExporter(t, srv, &now)
+
+	var keys []tag.Key
+	var tags []tag.Tag
+	for i := 0; i < 25; i++ {
+		k, _ := tag.NewKey(fmt.Sprintf("key_%02d", i))
+		keys = append(keys, k)
+		tags = append(tags, tag.Tag{Key: k, Value: fmt.Sprintf("value_%02d", i)})
+	}
+
+	md := e.toMetricData(&view.Data{
+		View: &view.View{
+			Name:        "many_tags",
+			Measure:     stats.Float64("many_tags", "test measure", stats.UnitNone),
+			Aggregation: view.LastValue(),
+			TagKeys:     keys,
+		},
+		Rows: []*view.Row{{
+			Tags: tags,
+			Data: &view.LastValueData{Value: 42},
+		}},
+	})
+
+	if got := len(md.Data.BaseData.DimNames); got != azureMaxDimensions {
+		t.Errorf("len(DimNames) = %d, want %d", got, azureMaxDimensions)
+	}
+	series := md.Data.BaseData.Series[0]
+	if got := len(series.DimValues); got != azureMaxDimensions {
+		t.Errorf("len(DimValues) = %d, want %d", got, azureMaxDimensions)
+	}
+	if got, want := series.DimValues[19], "value_19"; got != want {
+		t.Errorf("DimValues[19] = %q, want %q", got, want)
+	}
+	if series.Min != 42 || series.Max != 42 || series.Sum != 42 || series.Count != 1 {
+		t.Errorf("Unexpected series values %+v", series)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+const (
+	// circuitErrorThreshold export errors within circuitErrorWindow open the
+	// circuit. The Stackdriver exporter only reports failed exports, so the
+	// errors are counted within a window rather than as consecutive exports.
+	circuitErrorThreshold = 5
+	circuitErrorWindow    = 30 * time.Second
+	// circuitOpenDuration is how long the exports are dropped before one is
+	// tried again.
+	circuitOpenDuration = 60 * time.Second
+	// circuitWarnInterval is how often the dropped exports are logged.
+	circuitWarnInterval = time.Minute
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	// circuitHalfOpen lets a single export through, the circuit closes if it
+	// does not fail within circuitErrorWindow.
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops the exports to a backend that keeps failing, so that
+// they do not pile up while the backend is unavailable.
+type circuitBreaker struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	logger *zap.SugaredLogger
+
+	state       circuitState
+	errors      []time.Time
+	changed     time.Time
+	lastWarning time.Time
+	dropped     int
+}
+
+func newCircuitBreaker(logger *zap.SugaredLogger) *circuitBreaker {
+	return &circuitBreaker{
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// allow returns whether an export may go through, moving an open circuit to
+// half-open once circuitOpenDuration has passed.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case circuitOpen:
+		if now.Sub(b.changed) >= circuitOpenDuration {
+			b.setState(circuitHalfOpen, now)
+			return true
+		}
+	case circuitHalfOpen:
+		if now.Sub(b.changed) >= circuitErrorWindow {
+			b.setState(circuitClosed, now)
+			return true
+		}
+	default:
+		return true
+	}
+
+	b.dropped++
+	if now.Sub(b.lastWarning) >= circuitWarnInterval {
+		b.logger.Warnf("The metrics export circuit is %v, dropped %d exports", b.state, b.dropped)
+		b.lastWarning, b.dropped = now, 0
+	}
+	return false
+}
+
+// onError records a failed export.
+func (b *circuitBreaker) onError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.logger.Errorw("Failed to export metrics.", zap.Error(err))
+	now := b.now()
+	switch b.state {
+	case circuitHalfOpen:
+		b.setState(circuitOpen, now)
+	case circuitClosed:
+		recent := b.errors[:0]
+		for _, t := range b.errors {
+			if now.Sub(t) < circuitErrorWindow {
+				recent = append(recent, t)
+			}
+		}
+		b.errors = append(recent, now)
+		if len(b.errors) >= circuitErrorThreshold {
+			b.setState(circuitOpen, now)
+		}
+	}
+}
+
+// setState changes the state of the circuit. b.mu must be held.
+func (b *circuitBreaker) setState(s circuitState, now time.Time) {
+	b.logger.Infof("The metrics export circuit changed from %v to %v", b.state, s)
+	b.state, b.changed, b.errors = s, now, nil
+}
+
+func (b *circuitBreaker) getState() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// circuitBreakerExporter drops the exports while its circuit is not closed.
+type circuitBreakerExporter struct {
+	view.Exporter
+	breaker *circuitBreaker
+}
+
+// ExportView implements view.Exporter.
+func (e *circuitBreakerExporter) ExportView(vd *view.Data) {
+	if e.breaker.allow() {
+		e.Exporter.ExportView(vd)
+	}
+}
+
+// Flush flushes the wrapped exporter if it buffers view data.
+func (e *circuitBreakerExporter) Flush() {
+	if f, ok := e.Exporter.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close closes the wrapped exporter if it buffers view data.
+func (e *circuitBreakerExporter) Close() {
+	if c, ok := e.Exporter.(closer); ok {
+		c.Close()
+	}
+}
+
+// getCircuitBreaker returns the circuit breaker of the current exporter, or
+// nil if it has none.
+func getCircuitBreaker() *circuitBreaker {
+	for _, ce := range backendExporters(getCurMetricsExporter()) {
+		if e, ok := ce.(*circuitBreakerExporter); ok {
+			return e.breaker
+		}
+	}
+	return nil
+}
+
+// IsMetricsCircuitOpen returns whether the current metrics exporter drops the
+// metrics because the backend keeps failing. Readiness probes can use it.
+func IsMetricsCircuitOpen() bool {
+	b := getCircuitBreaker()
+	return b != nil && b.getState() != circuitClosed
+}
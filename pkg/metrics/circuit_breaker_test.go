@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats/view"
+)
+
+func TestCircuitBreakerExporter(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(TestLogger(t))
+	b.now = func() time.Time { return now }
+	fake := &fakeExporter{}
+	e := &circuitBreakerExporter{Exporter: fake, breaker: b}
+	vd := &view.Data{View: &view.View{Name: "circuit_breaker_test"}}
+	exported := func() int {
+		n := len(fake.exported)
+		fake.exported = nil
+		return n
+	}
+	exportErr := errors.New("monitoring API unavailable")
+
+	// Errors spread over more than the window do not open the circuit.
+	for i := 0; i < circuitErrorThreshold; i++ {
+		b.onError(exportErr)
+		now = now.Add(circuitErrorWindow / 2)
+	}
+	if got := b.getState(); got != circuitClosed {
+		t.Fatalf("State = %v, want %v", got, circuitClosed)
+	}
+
+	for i := 0; i < circuitErrorThreshold; i++ {
+		b.onError(exportErr)
+	}
+	if got := b.getState(); got != circuitOpen {
+		t.Fatalf("State = %v, want %v", got, circuitOpen)
+	}
+	e.ExportView(vd)
+	if got := exported(); got != 0 {
+		t.Errorf("Exported %d views while open, want 0", got)
+	}
+
+	// A single export is tried once the circuit has been open long enough,
+	// and its failure opens the circuit again.
+	now = now.Add(circuitOpenDuration)
+	e.ExportView(vd)
+	e.ExportView(vd)
+	if got := exported(); got != 1 {
+		t.Errorf("Exported %d views while half-open, want 1", got)
+	}
+	b.onError(exportErr)
+	if got := b.getState(); got != circuitOpen {
+		t.Fatalf("State = %v, want %v", got, circuitOpen)
+	}
+
+	// The circuit closes once the trial export does not fail.
+	now = now.Add(circuitOpenDuration)
+	e.ExportView(vd)
+	now = now.Add(circuitErrorWindow)
+	e.ExportView(vd)
+	e.ExportView(vd)
+	if got := exported(); got != 3 {
+		t.Errorf("Exported %d views, want 3", got)
+	}
+	if got := b.getState(); got != circuitClosed {
+		t.Errorf("State = %v, want %v", got, circuitClosed)
+	}
+}
+
+func TestIsMetricsCircuitOpen(t *testing.T) {
+	defer func(ce view.Exporter) {
+		metricsMux.Lock()
+		curMetricsExporter = ce
+		metricsMux.Unlock()
+	}(getCurMetricsExporter())
+
+	b := newCircuitBreaker(TestLogger(t))
+	metricsMux.Lock()
+	curMetricsExporter = &pipelineLatencyExporter{
+		Exporter: &circuitBreakerExporter{Exporter: &fakeExporter{}, breaker: b},
+		now:      time.Now,
+	}
+	metricsMux.Unlock()
+	if IsMetricsCircuitOpen() {
+		t.Error("IsMetricsCircuitOpen() = true, want false")
+	}
+	for i := 0; i < circuitErrorThreshold; i++ {
+		b.onError(errors.New("monitoring API unavailable"))
+	}
+	if !IsMetricsCircuitOpen() {
+		t.Error("IsMetricsCircuitOpen() = false, want true")
+	}
+
+	metricsMux.Lock()
+	curMetricsExporter = &fakeExporter{}
+	metricsMux.Unlock()
+	if IsMetricsCircuitOpen() {
+		t.Error("IsMetricsCircuitOpen() = true without a circuit breaker, want false")
+	}
+}
@@ -17,7 +17,14 @@ limitations under the License.
 package metrics
 
 import (
-	"github.com/knative/pkg/metrics"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -25,10 +32,529 @@ import (
 const (
 	ObservabilityConfigName = "config-observability"
 	metricsDomain           = "knative.dev/serving"
+
+	backendDestinationKey   = "metrics.backend-destination"
+	secondaryBackendKey     = "metrics.secondary-backend-destination"
+	stackdriverProjectIDKey = "metrics.stackdriver-project-id"
+	stackdriverCredsKey     = "metrics.stackdriver-credentials-file"
+	stackdriverLabelsKey    = "metrics.stackdriver-custom-labels"
+	prefixOverrideKey       = "metrics.prefix-override"
+	prometheusPortKey       = "metrics.prometheus-port"
+	pushgatewayURLKey       = "metrics.prometheus-pushgateway-url"
+	reportingPeriodKey      = "metrics.reporting-period-seconds"
+	azureSubscriptionIDKey  = "metrics.azure-subscription-id"
+	azureResourceIDKey      = "metrics.azure-resource-id"
+	azureRegionKey          = "metrics.azure-region"
+	azureClientIDKey        = "metrics.azure-client-id"
+	azureClientSecretKey    = "metrics.azure-client-secret"
+	azureTenantIDKey        = "metrics.azure-tenant-id"
+	datadogAPIKeyKey        = "metrics.datadog-api-key"
+	datadogSiteKey          = "metrics.datadog-site"
+	datadogNamespaceKey     = "metrics.datadog-namespace"
+	otlpEndpointKey         = "metrics.otlp-endpoint"
+	otlpInsecureKey         = "metrics.otlp-insecure"
+	allowedTagKeysKey       = "metrics.allowed-tag-keys"
+	jaegerQueryURLKey       = "metrics.jaeger-query-url"
+
+	defaultPrometheusPort = 9090
+
+	defaultDatadogSite      = "datadoghq.com"
+	defaultDatadogNamespace = "knative.serving"
+
+	defaultOTLPEndpoint = "localhost:4318"
+
+	defaultReportingPeriodSeconds = 60
+	maxReportingPeriodSeconds     = 3600
 )
 
+// validMetricPrefix matches the metric prefixes Stackdriver accepts in metric
+// types, e.g. "knative.dev/serving/activator".
+var validMetricPrefix = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)*$`)
+
+// validLabelKey matches the metric label keys Stackdriver accepts.
+var validLabelKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// MetricsBackend specifies the backend to use for metrics
+type MetricsBackend string
+
+const (
+	// The metrics backend is stackdriver
+	Stackdriver MetricsBackend = "stackdriver"
+	// The metrics backend is prometheus
+	Prometheus MetricsBackend = "prometheus"
+	// The metrics are pushed to a Prometheus Pushgateway
+	PrometheusPushgateway MetricsBackend = "prometheus-pushgateway"
+	// The metrics backend is Azure Monitor
+	AzureMonitor MetricsBackend = "azuremonitor"
+	// The metrics backend is Datadog
+	Datadog MetricsBackend = "datadog"
+	// The metrics backend is an OpenTelemetry collector
+	OTLP MetricsBackend = "otlp"
+	// The metrics are kept in memory, see GetNoneExporterData
+	None MetricsBackend = "none"
+)
+
+// MetricsConfig is the metrics configuration of a component, parsed from the
+// config-observability config map.
+type MetricsConfig struct {
+	// The metrics domain. e.g. "serving.knative.dev" or "build.knative.dev".
+	Domain string
+	// The component that emits the metrics. e.g. "activator", "autoscaler".
+	Component string
+	// The metrics backend destination.
+	BackendDestination MetricsBackend
+	// If set, the metrics are also sent to this backend, e.g. while migrating
+	// from one backend to another. Its failures do not affect the primary
+	// backend.
+	SecondaryBackend MetricsBackend
+	// How often the views are exported, in seconds.
+	ReportingPeriodSeconds int
+	// The stackdriver project ID where the stats data are uploaded to. This is
+	// not the GCP project ID.
+	StackdriverProjectID string
+	// If set, the path of the JSON key file the Stackdriver exporter
+	// authenticates with instead of the application default credentials.
+	StackdriverCredentialsFile string
+	// Static labels added to every metric exported to Stackdriver, e.g. the
+	// environment or the cluster tier.
+	StackdriverCustomLabels map[string]string
+	// If set, replaces the Stackdriver metric prefix, which defaults to
+	// Domain/Component.
+	MetricsPrefixOverride string
+	// The port the Prometheus exporter serves the metrics on.
+	PrometheusPort int
+	// The URL of the Prometheus Pushgateway the metrics are pushed to, e.g.
+	// "http://pushgateway.monitoring:9091".
+	PrometheusPushgatewayURL string
+	// If set, the Prometheus federation URLs of the clusters, by name, whose
+	// metrics are combined on FederationPath of the Prometheus port, see
+	// MultiClusterMetricsFederator.
+	FederationClusters map[string]string
+	// If set, the Stackdriver and Prometheus exporters drop the tags whose
+	// key is not listed.
+	AllowedTagKeys []string
+
+	// The Azure subscription that owns AzureResourceID.
+	AzureSubscriptionID string
+	// The Azure resource the custom metrics are attached to, e.g. the AKS
+	// cluster running Knative.
+	AzureResourceID string
+	// The Azure region of AzureResourceID, e.g. "westus2".
+	AzureRegion string
+	// The service principal used to authenticate with Azure Monitor.
+	AzureClientID     string
+	AzureClientSecret string
+	AzureTenantID     string
+
+	// The API key used to authenticate with Datadog.
+	DatadogAPIKey string
+	// The Datadog site the metrics are sent to, e.g. "datadoghq.eu".
+	DatadogSite string
+	// The prefix of the Datadog metric names, e.g. "knative.serving".
+	DatadogNamespace string
+
+	// The host:port of the OTLP/HTTP receiver of the OpenTelemetry collector.
+	OTLPEndpoint string
+	// Whether to send the metrics to the collector over plain HTTP.
+	OTLPInsecure bool
+
+	// If set, the URL of the Jaeger query service request metrics are
+	// derived from, see JaegerDerivedMetricsExporter.
+	JaegerQueryURL string
+}
+
+// metricsConfig is the former name of MetricsConfig.
+//
+// Deprecated: use MetricsConfig, this alias will be removed.
+type metricsConfig = MetricsConfig
+
+// String implements fmt.Stringer, omitting the Azure client secret, the
+// Datadog API key and the Pushgateway credentials so that the config can be
+// logged.
+func (mc *MetricsConfig) String() string {
+	redacted := *mc
+	if redacted.AzureClientSecret != "" {
+		redacted.AzureClientSecret = "<redacted>"
+	}
+	if redacted.DatadogAPIKey != "" {
+		redacted.DatadogAPIKey = "<redacted>"
+	}
+	redacted.PrometheusPushgatewayURL = redactURLUserinfo(redacted.PrometheusPushgatewayURL)
+	redacted.FederationClusters = redactFederationClusters(redacted.FederationClusters)
+	type plain MetricsConfig
+	return fmt.Sprintf("%+v", plain(redacted))
+}
+
+// redactFederationClusters redacts the basic auth credentials of the
+// federation URLs of the clusters.
+func redactFederationClusters(clusters map[string]string) map[string]string {
+	if clusters == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(clusters))
+	for name, u := range clusters {
+		redacted[name] = redactURLUserinfo(u)
+	}
+	return redacted
+}
+
+// redactURLUserinfo redacts the basic auth credentials of the given URL.
+func redactURLUserinfo(v string) string {
+	u, err := url.Parse(v)
+	if err != nil || u.User == nil {
+		return v
+	}
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://<redacted>@", 1)
+}
+
+// uses returns whether the metrics are sent to the given backend, as the
+// primary or the secondary one.
+func (mc *MetricsConfig) uses(backend MetricsBackend) bool {
+	return mc.BackendDestination == backend || mc.SecondaryBackend == backend
+}
+
+func getMetricsConfig(m map[string]string, domain string, component string, logger *zap.SugaredLogger) (*MetricsConfig, error) {
+	var mc MetricsConfig
+	// Without a valid backend the metrics are kept in memory, so that tests
+	// and dry runs work without any config.
+	backend := m[backendDestinationKey]
+	lb := MetricsBackend(strings.ToLower(backend))
+	switch lb {
+	case Stackdriver, Prometheus, PrometheusPushgateway, AzureMonitor, Datadog, OTLP, None:
+		mc.BackendDestination = lb
+	case "":
+		mc.BackendDestination = None
+	default:
+		logger.Warnf("Unsupported metrics backend value %q, keeping the metrics in memory", backend)
+		mc.BackendDestination = None
+	}
+
+	if v, ok := m[secondaryBackendKey]; ok && v != "" {
+		sb := MetricsBackend(strings.ToLower(v))
+		switch sb {
+		case Stackdriver, Prometheus, PrometheusPushgateway, AzureMonitor, Datadog, OTLP:
+		default:
+			return nil, fmt.Errorf("Invalid %s value %q, must be a supported metrics backend other than %s", secondaryBackendKey, v, None)
+		}
+		if sb == mc.BackendDestination {
+			return nil, fmt.Errorf("Invalid %s value %q, must differ from %s", secondaryBackendKey, v, backendDestinationKey)
+		}
+		mc.SecondaryBackend = sb
+	}
+
+	mc.ReportingPeriodSeconds = defaultReportingPeriodSeconds
+	if v, ok := m[reportingPeriodKey]; ok {
+		period, err := strconv.Atoi(v)
+		if err != nil || period < 1 || period > maxReportingPeriodSeconds {
+			return nil, fmt.Errorf("Invalid %s value %q, must be between 1 and %d", reportingPeriodKey, v, maxReportingPeriodSeconds)
+		}
+		mc.ReportingPeriodSeconds = period
+	}
+
+	// If stackdriverProjectIDKey is not provided for stackdriver backend destination, OpenCensus will try to
+	// use the application default credentials. If that is not available, Opencensus would fail to create the
+	// metrics exporter.
+	if mc.uses(Stackdriver) {
+		mc.StackdriverProjectID = m[stackdriverProjectIDKey]
+		mc.StackdriverCredentialsFile = m[stackdriverCredsKey]
+		if v := m[stackdriverLabelsKey]; v != "" {
+			labels, err := parseStackdriverLabels(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s value %q: %v", stackdriverLabelsKey, v, err)
+			}
+			mc.StackdriverCustomLabels = labels
+		}
+		for _, key := range []string{prefixOverrideKey, componentPrefixOverrideKey(component)} {
+			v, ok := m[key]
+			if !ok {
+				continue
+			}
+			if !validMetricPrefix.MatchString(v) {
+				return nil, fmt.Errorf("Invalid %s value %q, must be a valid Stackdriver metric type prefix", key, v)
+			}
+			mc.MetricsPrefixOverride = v
+		}
+	}
+
+	// Tags of unbounded cardinality get whole batches rejected by
+	// Stackdriver and blow up the Prometheus series.
+	if v := m[allowedTagKeysKey]; v != "" && (mc.uses(Stackdriver) || mc.uses(Prometheus)) {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				mc.AllowedTagKeys = append(mc.AllowedTagKeys, k)
+			}
+		}
+	}
+
+	// Components running in the same pod, or on the host network of the same
+	// node, need different ports, so the port can be set per component.
+	if mc.uses(Prometheus) {
+		mc.PrometheusPort = defaultPrometheusPort
+		for _, key := range []string{prometheusPortKey, componentPrometheusPortKey(component)} {
+			v, ok := m[key]
+			if !ok {
+				continue
+			}
+			port, err := strconv.Atoi(v)
+			if err != nil || port < 1 || port > 65535 {
+				return nil, fmt.Errorf("Invalid %s value %q, must be a port number", key, v)
+			}
+			mc.PrometheusPort = port
+		}
+
+		// Only the component that federates the clusters sets them, the
+		// other ones would scrape them for nothing.
+		key := componentFederationClustersKey(component)
+		if v := m[key]; v != "" {
+			clusters, err := parseFederationClusters(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s value %q: %v", key, v, err)
+			}
+			mc.FederationClusters = clusters
+		}
+	}
+
+	if mc.uses(PrometheusPushgateway) {
+		v := m[pushgatewayURLKey]
+		if v == "" {
+			return nil, fmt.Errorf("%s is required for the %s backend", pushgatewayURLKey, PrometheusPushgateway)
+		}
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("Invalid %s value %q, must be an http or https URL", pushgatewayURLKey, v)
+		}
+		mc.PrometheusPushgatewayURL = v
+	}
+
+	if mc.uses(AzureMonitor) {
+		for key, field := range map[string]*string{
+			azureSubscriptionIDKey: &mc.AzureSubscriptionID,
+			azureResourceIDKey:     &mc.AzureResourceID,
+			azureRegionKey:         &mc.AzureRegion,
+			azureClientIDKey:       &mc.AzureClientID,
+			azureClientSecretKey:   &mc.AzureClientSecret,
+			azureTenantIDKey:       &mc.AzureTenantID,
+		} {
+			v, ok := m[key]
+			if !ok || v == "" {
+				return nil, fmt.Errorf("%s is required for the %s backend", key, AzureMonitor)
+			}
+			*field = v
+		}
+		if !strings.HasPrefix(strings.ToLower(mc.AzureResourceID), "/subscriptions/"+strings.ToLower(mc.AzureSubscriptionID)+"/") {
+			return nil, fmt.Errorf("%s %q does not belong to subscription %q", azureResourceIDKey, mc.AzureResourceID, mc.AzureSubscriptionID)
+		}
+	}
+
+	if mc.uses(Datadog) {
+		mc.DatadogAPIKey = m[datadogAPIKeyKey]
+		if mc.DatadogAPIKey == "" {
+			return nil, fmt.Errorf("%s is required for the %s backend", datadogAPIKeyKey, Datadog)
+		}
+		mc.DatadogSite = defaultDatadogSite
+		if v := m[datadogSiteKey]; v != "" {
+			mc.DatadogSite = v
+		}
+		mc.DatadogNamespace = defaultDatadogNamespace
+		if v := m[datadogNamespaceKey]; v != "" {
+			mc.DatadogNamespace = v
+		}
+	}
+
+	if mc.uses(OTLP) {
+		mc.OTLPEndpoint = defaultOTLPEndpoint
+		if v := m[otlpEndpointKey]; v != "" {
+			mc.OTLPEndpoint = v
+		}
+		if v, ok := m[otlpInsecureKey]; ok {
+			insecure, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s value %q, must be a boolean", otlpInsecureKey, v)
+			}
+			mc.OTLPInsecure = insecure
+		}
+	}
+
+	if v := m[jaegerQueryURLKey]; v != "" {
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("Invalid %s value %q, must be an http or https URL", jaegerQueryURLKey, v)
+		}
+		mc.JaegerQueryURL = v
+	}
+
+	if domain == "" {
+		return nil, errors.New("Metrics domain cannot be empty")
+	}
+	mc.Domain = domain
+
+	if component == "" {
+		return nil, errors.New("Metrics component name cannot be empty")
+	}
+	mc.Component = component
+	return &mc, nil
+}
+
+// parseStackdriverLabels parses labels in YAML, e.g.
+// "{environment: prod, tier: gold}".
+func parseStackdriverLabels(v string) (map[string]string, error) {
+	var labels map[string]string
+	if err := yaml.Unmarshal([]byte(v), &labels); err != nil {
+		return nil, err
+	}
+	for k := range labels {
+		if !validLabelKey.MatchString(k) {
+			return nil, fmt.Errorf("label key %q must be lowercase letters, digits and underscores, starting with a letter", k)
+		}
+	}
+	return labels, nil
+}
+
+// parseFederationClusters parses the federation URLs of the clusters in
+// YAML, e.g. "{east: http://prometheus.east:9090/federate?match[]=...}".
+func parseFederationClusters(v string) (map[string]string, error) {
+	var clusters map[string]string
+	if err := yaml.Unmarshal([]byte(v), &clusters); err != nil {
+		return nil, err
+	}
+	for name, cu := range clusters {
+		if u, err := url.Parse(cu); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("the URL %q of cluster %q must be an http or https URL", cu, name)
+		}
+	}
+	return clusters, nil
+}
+
+// componentFederationClustersKey returns the key of the federated clusters of
+// the given component.
+func componentFederationClustersKey(component string) string {
+	return "metrics." + component + ".federation-clusters"
+}
+
+// componentPrometheusPortKey returns the key of the Prometheus port of the
+// given component, which overrides prometheusPortKey.
+func componentPrometheusPortKey(component string) string {
+	return "metrics." + component + ".prometheus-port"
+}
+
+// ConfigChangeError is returned by UpdateExporter when the metrics config in
+// the config map is invalid, as opposed to a failure to create the exporter.
+type ConfigChangeError struct {
+	Err error
+}
+
+func (e *ConfigChangeError) Error() string {
+	return fmt.Sprintf("invalid metrics config: %v", e.Err)
+}
+
+// UpdateExporter updates the exporter of the component from the given config
+// map. The exporter is only recreated when the backend or its settings change;
+// a change of the reporting period takes effect without recreating it. A
+// *ConfigChangeError is returned if the config map is invalid, in which case
+// the current exporter is kept.
+func UpdateExporter(component string, configMap *corev1.ConfigMap, logger *zap.SugaredLogger) error {
+	newConfig, err := getMetricsConfig(configMap.Data, metricsDomain, component, logger)
+	if err != nil {
+		return &ConfigChangeError{Err: err}
+	}
+	if !isMetricsConfigChanged(newConfig) {
+		updateReportingPeriod(newConfig.ReportingPeriodSeconds)
+		return nil
+	}
+	return newMetricsExporter(newConfig, logger)
+}
+
+// componentPrefixOverrideKey returns the key of the metric prefix override of
+// the given component, which overrides prefixOverrideKey.
+func componentPrefixOverrideKey(component string) string {
+	return "metrics." + component + ".prefix-override"
+}
+
 // UpdateExporterFromConfigMap returns a helper func that can be used to update the exporter
-// when a config map is updated
+// when a config map is updated. See UpdateExporter.
 func UpdateExporterFromConfigMap(component string, logger *zap.SugaredLogger) func(configMap *corev1.ConfigMap) {
-	return metrics.UpdateExporterFromConfigMap(metricsDomain, component, logger)
+	return func(configMap *corev1.ConfigMap) {
+		err := UpdateExporter(component, configMap, logger)
+		if err == nil {
+			return
+		}
+		if _, ok := err.(*ConfigChangeError); !ok {
+			logger.Errorf("Failed to update the metrics exporter. error: %v", err)
+			return
+		}
+		if getCurMetricsExporter() == nil {
+			// Fail the process if there doesn't exist an exporter.
+			logger.Fatal("Failed to get a valid metrics config", zap.Error(err))
+		}
+		logger.Error("Failed to get a valid metrics config; Skip updating the metrics exporter", zap.Error(err))
+	}
+}
+
+// isMetricsConfigChanged compares the non-nil newConfig against curMetricsConfig. When backend changes,
+// or the backend specific settings change, we need to update the metrics exporter. The reporting
+// period is not backend specific and is updated separately.
+func isMetricsConfigChanged(newConfig *MetricsConfig) bool {
+	cc := getCurMetricsConfig()
+	if cc == nil || newConfig.BackendDestination != cc.BackendDestination || newConfig.SecondaryBackend != cc.SecondaryBackend ||
+		newConfig.JaegerQueryURL != cc.JaegerQueryURL {
+		return true
+	}
+	return isBackendConfigChanged(newConfig.BackendDestination, newConfig, cc) ||
+		isBackendConfigChanged(newConfig.SecondaryBackend, newConfig, cc)
+}
+
+// isBackendConfigChanged compares the settings of the given backend in
+// newConfig and cc.
+func isBackendConfigChanged(backend MetricsBackend, newConfig, cc *MetricsConfig) bool {
+	switch backend {
+	case Stackdriver:
+		return newConfig.StackdriverProjectID != cc.StackdriverProjectID ||
+			newConfig.StackdriverCredentialsFile != cc.StackdriverCredentialsFile ||
+			newConfig.MetricsPrefixOverride != cc.MetricsPrefixOverride ||
+			!equalLabels(newConfig.StackdriverCustomLabels, cc.StackdriverCustomLabels) ||
+			!equalTagKeys(newConfig.AllowedTagKeys, cc.AllowedTagKeys)
+	case Prometheus:
+		return newConfig.PrometheusPort != cc.PrometheusPort ||
+			!equalTagKeys(newConfig.AllowedTagKeys, cc.AllowedTagKeys) ||
+			!equalLabels(newConfig.FederationClusters, cc.FederationClusters)
+	case PrometheusPushgateway:
+		return newConfig.PrometheusPushgatewayURL != cc.PrometheusPushgatewayURL
+	case AzureMonitor:
+		return newConfig.AzureSubscriptionID != cc.AzureSubscriptionID ||
+			newConfig.AzureResourceID != cc.AzureResourceID ||
+			newConfig.AzureRegion != cc.AzureRegion ||
+			newConfig.AzureClientID != cc.AzureClientID ||
+			newConfig.AzureClientSecret != cc.AzureClientSecret ||
+			newConfig.AzureTenantID != cc.AzureTenantID
+	case Datadog:
+		return newConfig.DatadogAPIKey != cc.DatadogAPIKey ||
+			newConfig.DatadogSite != cc.DatadogSite ||
+			newConfig.DatadogNamespace != cc.DatadogNamespace
+	case OTLP:
+		return newConfig.OTLPEndpoint != cc.OTLPEndpoint || newConfig.OTLPInsecure != cc.OTLPInsecure
+	}
+	return false
+}
+
+func equalTagKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// MetricsConfigPath is the HTTP path on which components expose their current
+// metrics config.
+const MetricsConfigPath = "/debug/metrics-config"
+
+const redacted = "<redacted>"
+
+// metricsConfigJSON is the JSON representation of a MetricsConfig. Secrets
+// are redacted and IDs masked.
+type metricsConfigJSON struct {
+	Domain                 string         `json:"domain"`
+	Component              string         `json:"component"`
+	Backend                MetricsBackend `json:"backend"`
+	SecondaryBackend       MetricsBackend `json:"secondaryBackend,omitempty"`
+	ReportingPeriodSeconds int            `json:"reportingPeriodSeconds"`
+
+	StackdriverProjectID       string            `json:"stackdriverProjectID,omitempty"`
+	StackdriverCredentialsFile string            `json:"stackdriverCredentialsFile,omitempty"`
+	StackdriverCustomLabels    map[string]string `json:"stackdriverCustomLabels,omitempty"`
+	MetricsPrefixOverride      string            `json:"metricsPrefixOverride,omitempty"`
+
+	PrometheusPort           int               `json:"prometheusPort,omitempty"`
+	PrometheusPushgatewayURL string            `json:"prometheusPushgatewayURL,omitempty"`
+	FederationClusters       map[string]string `json:"federationClusters,omitempty"`
+
+	AllowedTagKeys []string `json:"allowedTagKeys,omitempty"`
+
+	AzureSubscriptionID string `json:"azureSubscriptionID,omitempty"`
+	AzureResourceID     string `json:"azureResourceID,omitempty"`
+	AzureRegion         string `json:"azureRegion,omitempty"`
+	AzureClientID       string `json:"azureClientID,omitempty"`
+	AzureClientSecret   string `json:"azureClientSecret,omitempty"`
+	AzureTenantID       string `json:"azureTenantID,omitempty"`
+
+	DatadogAPIKey    string `json:"datadogAPIKey,omitempty"`
+	DatadogSite      string `json:"datadogSite,omitempty"`
+	DatadogNamespace string `json:"datadogNamespace,omitempty"`
+
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty"`
+	OTLPInsecure bool   `json:"otlpInsecure,omitempty"`
+
+	JaegerQueryURL string `json:"jaegerQueryURL,omitempty"`
+
+	// CircuitBreaker is the state of the export circuit of the backends
+	// that have one: closed, open or half-open.
+	CircuitBreaker string `json:"circuitBreaker,omitempty"`
+}
+
+func newMetricsConfigJSON(mc MetricsConfig) metricsConfigJSON {
+	j := metricsConfigJSON{
+		Domain:                     mc.Domain,
+		Component:                  mc.Component,
+		Backend:                    mc.BackendDestination,
+		SecondaryBackend:           mc.SecondaryBackend,
+		ReportingPeriodSeconds:     mc.ReportingPeriodSeconds,
+		StackdriverProjectID:       maskID(mc.StackdriverProjectID),
+		StackdriverCredentialsFile: mc.StackdriverCredentialsFile,
+		StackdriverCustomLabels:    mc.StackdriverCustomLabels,
+		MetricsPrefixOverride:      mc.MetricsPrefixOverride,
+		PrometheusPort:             mc.PrometheusPort,
+		PrometheusPushgatewayURL:   redactURLUserinfo(mc.PrometheusPushgatewayURL),
+		FederationClusters:         redactFederationClusters(mc.FederationClusters),
+		AllowedTagKeys:             mc.AllowedTagKeys,
+		AzureSubscriptionID:        maskID(mc.AzureSubscriptionID),
+		AzureRegion:                mc.AzureRegion,
+		AzureClientID:              maskID(mc.AzureClientID),
+		AzureTenantID:              maskID(mc.AzureTenantID),
+		DatadogSite:                mc.DatadogSite,
+		DatadogNamespace:           mc.DatadogNamespace,
+		OTLPEndpoint:               mc.OTLPEndpoint,
+		OTLPInsecure:               mc.OTLPInsecure,
+		JaegerQueryURL:             mc.JaegerQueryURL,
+	}
+	if mc.AzureClientSecret != "" {
+		j.AzureClientSecret = redacted
+	}
+	if mc.DatadogAPIKey != "" {
+		j.DatadogAPIKey = redacted
+	}
+	// The resource ID contains the subscription ID.
+	if mc.AzureResourceID != "" {
+		j.AzureResourceID = redacted
+	}
+	return j
+}
+
+// maskID shows the first 4 characters of an ID, which is enough to tell IDs
+// apart without giving them away.
+func maskID(id string) string {
+	if id == "" {
+		return ""
+	}
+	if len(id) <= 4 {
+		return "****"
+	}
+	return id[:4] + "****"
+}
+
+// AttachMetricsConfigHandler registers a handler on MetricsConfigPath that
+// serves the current metrics config as JSON, with its credentials redacted.
+func AttachMetricsConfigHandler(mux *http.ServeMux) {
+	mux.HandleFunc(MetricsConfigPath, serveMetricsConfig)
+}
+
+func serveMetricsConfig(w http.ResponseWriter, r *http.Request) {
+	// getCurMetricsConfig only holds metricsMux to read the pointer; the
+	// configs are replaced rather than modified.
+	cc := getCurMetricsConfig()
+	if cc == nil {
+		http.Error(w, "no metrics config", http.StatusServiceUnavailable)
+		return
+	}
+	j := newMetricsConfigJSON(*cc)
+	if b := getCircuitBreaker(); b != nil {
+		j.CircuitBreaker = b.getState().String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(j); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
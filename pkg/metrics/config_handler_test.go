@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetricsConfigHandler(t *testing.T) {
+	defer func(cc *MetricsConfig) {
+		metricsMux.Lock()
+		curMetricsConfig = cc
+		metricsMux.Unlock()
+	}(getCurMetricsConfig())
+
+	mux := http.NewServeMux()
+	AttachMetricsConfigHandler(mux)
+	get := func() *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, MetricsConfigPath, nil))
+		return resp
+	}
+
+	metricsMux.Lock()
+	curMetricsConfig = nil
+	metricsMux.Unlock()
+	if resp := get(); resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("%s without a config = %d, want %d", MetricsConfigPath, resp.Code, http.StatusServiceUnavailable)
+	}
+
+	metricsMux.Lock()
+	curMetricsConfig = &MetricsConfig{
+		Domain:                 metricsDomain,
+		Component:              "component",
+		BackendDestination:     Stackdriver,
+		ReportingPeriodSeconds: 60,
+		StackdriverProjectID:   "my-project",
+		DatadogAPIKey:          "key",
+	}
+	metricsMux.Unlock()
+	resp := get()
+	if resp.Code != http.StatusOK {
+		t.Fatalf("%s = %d, want %d", MetricsConfigPath, resp.Code, http.StatusOK)
+	}
+	if strings.Contains(resp.Body.String(), "my-project") || strings.Contains(resp.Body.String(), `"key"`) {
+		t.Errorf("%s = %s, leaks the credentials", MetricsConfigPath, resp.Body.String())
+	}
+	var got metricsConfigJSON
+	if err := json.Unmarshal(resp.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	want := metricsConfigJSON{
+		Domain:                 metricsDomain,
+		Component:              "component",
+		Backend:                Stackdriver,
+		ReportingPeriodSeconds: 60,
+		StackdriverProjectID:   "my-p****",
+		DatadogAPIKey:          "<redacted>",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected config (-want +got): %v", diff)
+	}
+}
+
+func TestMaskID(t *testing.T) {
+	for id, want := range map[string]string{
+		"":           "",
+		"abc":        "****",
+		"my-project": "my-p****",
+	} {
+		if got := maskID(id); got != want {
+			t.Errorf("maskID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
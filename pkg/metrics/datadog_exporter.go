@@ -0,0 +1,182 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+const (
+	// The series are buffered and sent at most this often, or once this many
+	// series are buffered.
+	datadogFlushInterval = 10 * time.Second
+	datadogMaxSeries     = 500
+
+	datadogRequestTimeout = 30 * time.Second
+)
+
+// datadogSeriesData is the request body of the Datadog series API.
+type datadogSeriesData struct {
+	Series []datadogSeries `json:"series"`
+}
+
+type datadogSeries struct {
+	Metric string `json:"metric"`
+	// Points are [unix seconds, value] pairs.
+	Points [][2]float64 `json:"points"`
+	Type   string       `json:"type"`
+	Tags   []string     `json:"tags,omitempty"`
+}
+
+// datadogExporter exports view data to the Datadog series API. The series
+// are buffered and sent in the background, Close sends what is left.
+type datadogExporter struct {
+	config    *MetricsConfig
+	logger    *zap.SugaredLogger
+	client    *http.Client
+	seriesURL string
+
+	mux    sync.Mutex
+	series []datadogSeries
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newDatadogExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	e := &datadogExporter{
+		config:    config,
+		logger:    logger,
+		client:    &http.Client{Timeout: datadogRequestTimeout},
+		seriesURL: fmt.Sprintf("https://api.%s/api/v1/series", config.DatadogSite),
+		stopCh:    make(chan struct{}),
+	}
+	go flushEvery(e, datadogFlushInterval, e.stopCh)
+	logger.Infof("Created Datadog exporter with config %v", config)
+	return e, nil
+}
+
+// ExportView implements view.Exporter.
+func (e *datadogExporter) ExportView(vd *view.Data) {
+	if len(vd.Rows) == 0 {
+		return
+	}
+	series := e.toSeries(vd)
+
+	e.mux.Lock()
+	e.series = append(e.series, series...)
+	full := len(e.series) >= datadogMaxSeries
+	e.mux.Unlock()
+
+	if full {
+		e.Flush()
+	}
+}
+
+// toSeries maps each row of a view to a gauge, Datadog expects counts to be
+// deltas while OpenCensus reports cumulative values. Distributions are mapped
+// to their count, average, minimum and maximum.
+func (e *datadogExporter) toSeries(vd *view.Data) []datadogSeries {
+	name := e.config.DatadogNamespace + "." + vd.View.Name
+	ts := float64(vd.End.Unix())
+
+	var series []datadogSeries
+	gauge := func(metric string, tags []string, v float64) {
+		series = append(series, datadogSeries{
+			Metric: metric,
+			Points: [][2]float64{{ts, v}},
+			Type:   "gauge",
+			Tags:   tags,
+		})
+	}
+	for _, row := range vd.Rows {
+		tags := []string{"component:" + e.config.Component}
+		for _, t := range row.Tags {
+			tags = append(tags, t.Key.Name()+":"+t.Value)
+		}
+		switch data := row.Data.(type) {
+		case *view.CountData:
+			gauge(name, tags, float64(data.Value))
+		case *view.SumData:
+			gauge(name, tags, data.Value)
+		case *view.LastValueData:
+			gauge(name, tags, data.Value)
+		case *view.DistributionData:
+			gauge(name+".count", tags, float64(data.Count))
+			gauge(name+".avg", tags, data.Mean)
+			gauge(name+".min", tags, data.Min)
+			gauge(name+".max", tags, data.Max)
+		}
+	}
+	return series
+}
+
+// Flush sends the buffered series to Datadog.
+func (e *datadogExporter) Flush() {
+	e.mux.Lock()
+	series := e.series
+	e.series = nil
+	e.mux.Unlock()
+
+	if len(series) == 0 {
+		return
+	}
+	if err := e.send(&datadogSeriesData{Series: series}); err != nil {
+		e.logger.Errorw("Failed to export series to Datadog", zap.Int("series", len(series)), zap.Error(err))
+	}
+}
+
+// Close stops sending in the background and flushes the buffered series.
+func (e *datadogExporter) Close() {
+	e.closeOnce.Do(func() {
+		close(e.stopCh)
+		e.Flush()
+	})
+}
+
+func (e *datadogExporter) send(sd *datadogSeriesData) error {
+	body, err := json.Marshal(sd)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.seriesURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("DD-API-KEY", e.config.DatadogAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d from Datadog: %s", resp.StatusCode, b)
+	}
+	return nil
+}
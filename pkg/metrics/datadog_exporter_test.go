@@ -0,0 +1,178 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+type fakeDatadog struct {
+	mux     sync.Mutex
+	apiKeys []string
+	series  []datadogSeriesData
+}
+
+func (f *fakeDatadog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/series" {
+		http.NotFound(w, r)
+		return
+	}
+	var sd datadogSeriesData
+	if err := json.NewDecoder(r.Body).Decode(&sd); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.apiKeys = append(f.apiKeys, r.Header.Get("DD-API-KEY"))
+	f.series = append(f.series, sd)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func newTestDatadogExporter(t *testing.T, srv *httptest.Server) *datadogExporter {
+	return &datadogExporter{
+		config: &MetricsConfig{
+			Domain:           metricsDomain,
+			Component:        "testcomponent",
+			DatadogAPIKey:    "key",
+			DatadogNamespace: "knative.serving",
+		},
+		logger:    TestLogger(t),
+		client:    srv.Client(),
+		seriesURL: srv.URL + "/api/v1/series",
+		stopCh:    make(chan struct{}),
+	}
+}
+
+func TestDatadogExporterExportView(t *testing.T) {
+	fake := &fakeDatadog{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestDatadogExporter(t, srv)
+
+	nsKey, _ := tag.NewKey("namespace_name")
+	end := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+	e.ExportView(&view.Data{
+		View: &view.View{
+			Name:        "datadog_count",
+			Measure:     stats.Int64("datadog_count", "test measure", stats.UnitNone),
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{nsKey},
+		},
+		End: end,
+		Rows: []*view.Row{{
+			Tags: []tag.Tag{{Key: nsKey, Value: "default"}},
+			Data: &view.CountData{Value: 3},
+		}},
+	})
+	e.ExportView(&view.Data{
+		View: &view.View{
+			Name:        "datadog_latency",
+			Measure:     stats.Float64("datadog_latency", "test measure", stats.UnitNone),
+			Aggregation: view.Distribution(1, 10),
+		},
+		End: end,
+		Rows: []*view.Row{{
+			Data: &view.DistributionData{Count: 4, Min: 1, Max: 7, Mean: 2.5},
+		}},
+	})
+
+	// The series are buffered until the exporter is flushed.
+	if len(fake.series) != 0 {
+		t.Fatalf("Got %d series requests before flushing, want 0", len(fake.series))
+	}
+	e.Close()
+
+	if len(fake.series) != 1 {
+		t.Fatalf("Got %d series requests, want 1", len(fake.series))
+	}
+	if got, want := fake.apiKeys[0], "key"; got != want {
+		t.Errorf("DD-API-KEY = %q, want %q", got, want)
+	}
+	ts := float64(end.Unix())
+	tags := []string{"component:testcomponent"}
+	want := datadogSeriesData{Series: []datadogSeries{{
+		Metric: "knative.serving.datadog_count",
+		Points: [][2]float64{{ts, 3}},
+		Type:   "gauge",
+		Tags:   []string{"component:testcomponent", "namespace_name:default"},
+	}, {
+		Metric: "knative.serving.datadog_latency.count",
+		Points: [][2]float64{{ts, 4}},
+		Type:   "gauge",
+		Tags:   tags,
+	}, {
+		Metric: "knative.serving.datadog_latency.avg",
+		Points: [][2]float64{{ts, 2.5}},
+		Type:   "gauge",
+		Tags:   tags,
+	}, {
+		Metric: "knative.serving.datadog_latency.min",
+		Points: [][2]float64{{ts, 1}},
+		Type:   "gauge",
+		Tags:   tags,
+	}, {
+		Metric: "knative.serving.datadog_latency.max",
+		Points: [][2]float64{{ts, 7}},
+		Type:   "gauge",
+		Tags:   tags,
+	}}}
+	if diff := cmp.Diff(want, fake.series[0]); diff != "" {
+		t.Errorf("Unexpected series data (-want +got): %v", diff)
+	}
+
+	// Closing again sends nothing.
+	e.Close()
+	if len(fake.series) != 1 {
+		t.Errorf("Got %d series requests after closing twice, want 1", len(fake.series))
+	}
+}
+
+func TestResetCurMetricsExporterFlushes(t *testing.T) {
+	fake := &fakeDatadog{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestDatadogExporter(t, srv)
+	e.series = []datadogSeries{{Metric: "knative.serving.pending", Type: "gauge"}}
+	defer func(ce view.Exporter) {
+		metricsMux.Lock()
+		curMetricsExporter = ce
+		metricsMux.Unlock()
+	}(getCurMetricsExporter())
+
+	metricsMux.Lock()
+	curMetricsExporter = &pipelineLatencyExporter{Exporter: e, now: time.Now}
+	metricsMux.Unlock()
+
+	resetCurMetricsExporter()
+	if len(fake.series) != 1 {
+		t.Errorf("Got %d series requests, want 1", len(fake.series))
+	}
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"contrib.go.opencensus.io/exporter/stackdriver"
+	"go.opencensus.io/exporter/prometheus"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+var (
+	curMetricsExporter view.Exporter
+	curMetricsConfig   *MetricsConfig
+	curPromSrv         *http.Server
+	curPromLn          net.Listener
+	metricsMux         sync.Mutex
+
+	// exporterMux serializes newMetricsExporter, so that config map updates
+	// arriving at once replace the exporter one after the other.
+	exporterMux sync.Mutex
+)
+
+// newMetricsExporter gets a metrics exporter based on the config.
+func newMetricsExporter(config *MetricsConfig, logger *zap.SugaredLogger) error {
+	exporterMux.Lock()
+	defer exporterMux.Unlock()
+
+	// If there is a Prometheus Exporter server running, stop it.
+	resetCurPromSrv()
+	resetCurMetricsExporter()
+	e, err := newBackendExporter(config.BackendDestination, config, logger)
+	if err != nil {
+		return err
+	}
+	if config.SecondaryBackend != "" {
+		// The secondary backend must not take the primary one down with it.
+		if se, err := newBackendExporter(config.SecondaryBackend, config, logger); err != nil {
+			logger.Errorw("Failed to create the secondary metrics exporter, only exporting to the primary backend", zap.Error(err))
+		} else {
+			e = newMultiExporter(e, []view.Exporter{se}, logger)
+		}
+	}
+	if config.JaegerQueryURL != "" {
+		// Deriving metrics from traces is optional, the backend exports the
+		// other metrics regardless.
+		if je, err := newJaegerDerivedExporter(e, config, logger); err != nil {
+			logger.Errorw("Failed to derive metrics from Jaeger traces", zap.Error(err))
+		} else {
+			e = je
+		}
+	}
+	e = &pipelineLatencyExporter{Exporter: e, now: time.Now}
+	existingConfig := getCurMetricsConfig()
+	setCurMetricsExporterAndConfig(e, config)
+	logger.Infof("Successfully updated the metrics exporter; old config: %v; new config %v", existingConfig, config)
+	return nil
+}
+
+// newBackendExporter creates the exporter of the given backend.
+func newBackendExporter(backend MetricsBackend, config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	switch backend {
+	case Stackdriver:
+		return newStackdriverExporter(config, logger)
+	case Prometheus:
+		return newPrometheusExporter(config, logger)
+	case PrometheusPushgateway:
+		return newPrometheusPushgatewayExporter(config, logger)
+	case AzureMonitor:
+		return newAzureMonitorExporter(config, logger)
+	case Datadog:
+		return newDatadogExporter(config, logger)
+	case OTLP:
+		return newOTLPExporter(config, logger)
+	case None:
+		return newNoneExporter(config, logger)
+	}
+	return nil, fmt.Errorf("Unsupported metrics backend %v", backend)
+}
+
+func newStackdriverExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	prefix := config.Domain + "/" + config.Component
+	if config.MetricsPrefixOverride != "" {
+		prefix = config.MetricsPrefixOverride
+	}
+	opts := stackdriver.Options{
+		ProjectID:               config.StackdriverProjectID,
+		MetricPrefix:            prefix,
+		GetMonitoredResource:    getMonitoredResource(func() gcpLocation { return cachedGCPLocation(time.Now()) }, newLabelFilter(config.AllowedTagKeys)),
+		DefaultMonitoringLabels: &stackdriver.Labels{},
+	}
+	for k, v := range config.StackdriverCustomLabels {
+		opts.DefaultMonitoringLabels.Set(k, v, "")
+	}
+	if config.StackdriverCredentialsFile != "" {
+		creds, err := loadStackdriverCredentials(config.StackdriverCredentialsFile)
+		if err != nil {
+			logger.Error("Failed to load the Stackdriver credentials.", zap.Error(err))
+			return nil, err
+		}
+		if opts.ProjectID == "" {
+			opts.ProjectID = creds.ProjectID
+		}
+		opts.MonitoringClientOptions = []option.ClientOption{option.WithCredentials(creds)}
+		opts.TraceClientOptions = []option.ClientOption{option.WithCredentials(creds)}
+	}
+	breaker := newCircuitBreaker(logger)
+	opts.OnError = breaker.onError
+	e, err := stackdriver.NewExporter(opts)
+	if err != nil {
+		logger.Error("Failed to create the Stackdriver exporter.", zap.Error(err))
+		return nil, err
+	}
+	logger.Infof("Created Opencensus Stackdriver exporter with config %v", config)
+	return &circuitBreakerExporter{Exporter: e, breaker: breaker}, nil
+}
+
+// loadStackdriverCredentials reads the credentials in the JSON key file at
+// path, so that a missing or invalid file fails the exporter creation rather
+// than the first export.
+func loadStackdriverCredentials(path string) (*google.Credentials, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Stackdriver credentials file %q: %v", path, err)
+	}
+	creds, err := google.CredentialsFromJSON(context.Background(), data, monitoring.DefaultAuthScopes()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the Stackdriver credentials file %q: %v", path, err)
+	}
+	return creds, nil
+}
+
+func newPrometheusExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	e, err := prometheus.NewExporter(prometheus.Options{Namespace: config.Component})
+	if err != nil {
+		logger.Error("Failed to create the Prometheus exporter.", zap.Error(err))
+		return nil, err
+	}
+	logger.Infof("Created Opencensus Prometheus exporter with config: %v. Start the server for Prometheus exporter.", config)
+	// Start the server for Prometheus scraping. Listen before publishing the
+	// server so that the port being in use fails the exporter rather than the
+	// goroutine, and so that resetCurPromSrv always closes a bound listener.
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(config.PrometheusPort))
+	if err != nil {
+		logger.Error("Failed to listen for Prometheus scraping.", zap.Error(err))
+		return nil, err
+	}
+	var federator *MultiClusterMetricsFederator
+	if len(config.FederationClusters) > 0 {
+		federator = NewMultiClusterMetricsFederator(config.FederationClusters, logger)
+	}
+	srv := startNewPromSrv(e, ln, federator)
+	go func() {
+		if err := srv.Serve(ln); err != http.ErrServerClosed {
+			logger.Error("The Prometheus exporter server failed.", zap.Error(err))
+		}
+	}()
+	if len(config.AllowedTagKeys) > 0 {
+		return &labelFilterExporter{Exporter: e, filter: newLabelFilter(config.AllowedTagKeys)}, nil
+	}
+	return e, nil
+}
+
+func getCurPromSrv() *http.Server {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	return curPromSrv
+}
+
+func resetCurPromSrv() {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	closeCurPromSrv()
+}
+
+// closeCurPromSrv closes the current Prometheus server and its listener. The
+// server only closes the listener once it serves it, closing the listener
+// here releases the port even if the server has not started serving yet.
+// metricsMux must be held.
+func closeCurPromSrv() {
+	if curPromSrv != nil {
+		curPromSrv.Close()
+		curPromLn.Close()
+		curPromSrv, curPromLn = nil, nil
+	}
+}
+
+// startNewPromSrv replaces the current Prometheus server with one for ln,
+// which the caller serves with it. The federator, if any, is served on
+// FederationPath.
+func startNewPromSrv(e *prometheus.Exporter, ln net.Listener, federator *MultiClusterMetricsFederator) *http.Server {
+	sm := http.NewServeMux()
+	sm.Handle("/metrics", e)
+	sm.HandleFunc("/healthz", serveHealthz)
+	sm.HandleFunc("/readyz", serveReadyz)
+	AttachMetricsConfigHandler(sm)
+	handlePodMetrics(sm, e)
+	if federator != nil {
+		sm.Handle(FederationPath, federator)
+	}
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	closeCurPromSrv()
+	curPromSrv = &http.Server{
+		Addr:    ln.Addr().String(),
+		Handler: sm,
+	}
+	curPromLn = ln
+	return curPromSrv
+}
+
+// serveHealthz reports that the metrics server is up, so that health checks
+// on the metrics port do not get a 404.
+func serveHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+// serveReadyz reports whether a metrics exporter has been registered.
+func serveReadyz(w http.ResponseWriter, r *http.Request) {
+	if getCurMetricsExporter() == nil {
+		http.Error(w, "no metrics exporter registered", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+// backendExporters returns the exporters of the backends e sends the view
+// data to, the primary one first.
+func backendExporters(e view.Exporter) []view.Exporter {
+	if p, ok := e.(*pipelineLatencyExporter); ok {
+		e = p.Exporter
+	}
+	if j, ok := e.(*jaegerDerivedExporter); ok {
+		e = j.Exporter
+	}
+	if m, ok := e.(*multiExporter); ok {
+		return m.exporters
+	}
+	return []view.Exporter{e}
+}
+
+func getCurMetricsExporter() view.Exporter {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	return curMetricsExporter
+}
+
+// closer is implemented by the exporters that buffer view data and need to
+// send it before they are dropped.
+type closer interface {
+	Close()
+}
+
+// resetCurMetricsExporter unregisters the current exporter and closes it,
+// flushing any buffered view data.
+func resetCurMetricsExporter() {
+	ce := getCurMetricsExporter()
+	if ce == nil {
+		return
+	}
+	// UnregisterExporter is idempotent and it can be called multiple times for the same exporter
+	// without side effects.
+	view.UnregisterExporter(ce)
+	if c, ok := ce.(closer); ok {
+		c.Close()
+	}
+}
+
+// flusher is implemented by the exporters that buffer view data and can send
+// it on demand, e.g. the Stackdriver exporter.
+type flusher interface {
+	Flush()
+}
+
+// flushEvery flushes f every interval until stopCh is closed, for the
+// exporters that buffer view data and send it in the background.
+func flushEvery(f flusher, interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.Flush()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// flushTimeout bounds how long FlushExporter waits for the exporter to send
+// the buffered view data.
+const flushTimeout = 5 * time.Second
+
+// metricsStart approximates the start of the cumulative views, which are
+// registered when the components start.
+var metricsStart = time.Now()
+
+// FlushMetrics exports the current data of the views registered through
+// RegisterViews once more with the current exporter and sends the view data
+// it buffers, waiting until ctx is done. Components call it on shutdown so
+// that the data recorded since the last reporting period is not lost. It is a
+// no-op when Prometheus, which is pull-based, is the only backend.
+func FlushMetrics(ctx context.Context) error {
+	ce, cc := getCurMetricsExporter(), getCurMetricsConfig()
+	if ce == nil || cc == nil || (cc.BackendDestination == Prometheus && cc.SecondaryBackend == "") {
+		return nil
+	}
+
+	viewsMu.RLock()
+	views := make([]*view.View, 0, len(registeredViews))
+	for _, v := range registeredViews {
+		views = append(views, v)
+	}
+	viewsMu.RUnlock()
+
+	now := time.Now()
+	for _, v := range views {
+		rows, err := view.RetrieveData(viewName(v))
+		if err != nil || len(rows) == 0 {
+			continue
+		}
+		ce.ExportView(&view.Data{View: v, Start: metricsStart, End: now, Rows: rows})
+	}
+
+	f, ok := ce.(flusher)
+	if !ok {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Flush()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FlushExporter exports the current view data with FlushMetrics, waiting up
+// to flushTimeout, and flushes the view data buffered by the metrics exporter.
+// It unregisters the exporter and must only be called on shutdown.
+func FlushExporter() {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	FlushMetrics(ctx)
+	resetCurMetricsExporter()
+}
+
+func setCurMetricsExporterAndConfig(e view.Exporter, c *MetricsConfig) {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	view.RegisterExporter(e)
+	view.SetReportingPeriod(time.Duration(c.ReportingPeriodSeconds) * time.Second)
+	curMetricsExporter = e
+	curMetricsConfig = c
+}
+
+// updateReportingPeriod sets the reporting period of the current exporter.
+func updateReportingPeriod(seconds int) {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	if curMetricsConfig == nil || curMetricsConfig.ReportingPeriodSeconds == seconds {
+		return
+	}
+	view.SetReportingPeriod(time.Duration(seconds) * time.Second)
+	c := *curMetricsConfig
+	c.ReportingPeriodSeconds = seconds
+	curMetricsConfig = &c
+}
+
+func getCurMetricsConfig() *MetricsConfig {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	return curMetricsConfig
+}
+
+// GetCurrentMetricsConfig returns a copy of the current metrics config, or
+// the zero config if none was set yet.
+func GetCurrentMetricsConfig() MetricsConfig {
+	cc := getCurMetricsConfig()
+	if cc == nil {
+		return MetricsConfig{}
+	}
+	c := *cc
+	if cc.StackdriverCustomLabels != nil {
+		c.StackdriverCustomLabels = make(map[string]string, len(cc.StackdriverCustomLabels))
+		for k, v := range cc.StackdriverCustomLabels {
+			c.StackdriverCustomLabels[k] = v
+		}
+	}
+	if cc.AllowedTagKeys != nil {
+		c.AllowedTagKeys = append([]string(nil), cc.AllowedTagKeys...)
+	}
+	return c
+}
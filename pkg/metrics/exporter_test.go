@@ -0,0 +1,348 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/exporter/prometheus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+func TestNewPrometheusExporterPortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	defer ln.Close()
+
+	config := &MetricsConfig{
+		Domain:             metricsDomain,
+		Component:          "component",
+		BackendDestination: Prometheus,
+		PrometheusPort:     ln.Addr().(*net.TCPAddr).Port,
+	}
+	if _, err := newPrometheusExporter(config, TestLogger(t)); err == nil {
+		t.Error("newPrometheusExporter() = nil, want an error for the port in use")
+	}
+	resetCurPromSrv()
+}
+
+func TestLoadStackdriverCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stackdriver-creds")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile() = %v", err)
+		}
+		return path
+	}
+
+	creds, err := loadStackdriverCredentials(write("key.json", `{
+		"type": "service_account",
+		"project_id": "project",
+		"private_key_id": "id",
+		"client_email": "metrics@project.iam.gserviceaccount.com",
+		"client_id": "123"
+	}`))
+	if err != nil {
+		t.Fatalf("loadStackdriverCredentials() = %v", err)
+	}
+	if creds.ProjectID != "project" {
+		t.Errorf("ProjectID = %q, want %q", creds.ProjectID, "project")
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), write("invalid.json", "not json")} {
+		if _, err := loadStackdriverCredentials(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("loadStackdriverCredentials(%s) = %v, want an error naming the file", path, err)
+		}
+	}
+}
+
+func TestUpdateReportingPeriod(t *testing.T) {
+	defer func(c *MetricsConfig) {
+		metricsMux.Lock()
+		curMetricsConfig = c
+		metricsMux.Unlock()
+	}(getCurMetricsConfig())
+
+	old := &MetricsConfig{BackendDestination: Prometheus, ReportingPeriodSeconds: 60}
+	metricsMux.Lock()
+	curMetricsConfig = old
+	metricsMux.Unlock()
+
+	updateReportingPeriod(10)
+	if got := getCurMetricsConfig().ReportingPeriodSeconds; got != 10 {
+		t.Errorf("reportingPeriodSeconds = %d, want 10", got)
+	}
+	if old.ReportingPeriodSeconds != 60 {
+		t.Errorf("The previous config was modified: %v", old)
+	}
+}
+
+func TestGetCurrentMetricsConfig(t *testing.T) {
+	defer func(c *MetricsConfig) {
+		metricsMux.Lock()
+		curMetricsConfig = c
+		metricsMux.Unlock()
+	}(getCurMetricsConfig())
+
+	metricsMux.Lock()
+	curMetricsConfig = nil
+	metricsMux.Unlock()
+	if got := GetCurrentMetricsConfig(); !reflect.DeepEqual(got, MetricsConfig{}) {
+		t.Errorf("GetCurrentMetricsConfig() = %v, want the zero config", &got)
+	}
+
+	want := MetricsConfig{
+		Domain:                  metricsDomain,
+		Component:               "component",
+		BackendDestination:      Stackdriver,
+		StackdriverCustomLabels: map[string]string{"environment": "prod"},
+		AllowedTagKeys:          []string{"response_code"},
+	}
+	cur := want
+	cur.StackdriverCustomLabels = map[string]string{"environment": "prod"}
+	cur.AllowedTagKeys = []string{"response_code"}
+	metricsMux.Lock()
+	curMetricsConfig = &cur
+	metricsMux.Unlock()
+
+	got := GetCurrentMetricsConfig()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCurrentMetricsConfig() = %v, want %v", &got, &want)
+	}
+	// The copy does not share anything with the current config.
+	got.StackdriverCustomLabels["environment"] = "dev"
+	got.AllowedTagKeys[0] = "response_code_class"
+	if !reflect.DeepEqual(cur, want) {
+		t.Errorf("The current config was modified: %v", &cur)
+	}
+}
+
+func TestNewMetricsExporterConcurrently(t *testing.T) {
+	defer func(ce view.Exporter, cc *MetricsConfig) {
+		resetCurMetricsExporter()
+		resetCurPromSrv()
+		metricsMux.Lock()
+		curMetricsExporter, curMetricsConfig = ce, cc
+		metricsMux.Unlock()
+	}(getCurMetricsExporter(), getCurMetricsConfig())
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	config := &MetricsConfig{
+		Domain:                 metricsDomain,
+		Component:              "component",
+		BackendDestination:     Prometheus,
+		ReportingPeriodSeconds: 60,
+		PrometheusPort:         port,
+	}
+	logger := TestLogger(t)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- newMetricsExporter(config, logger)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("newMetricsExporter() = %v", err)
+		}
+	}
+
+	// The last server is alive and serves the metrics.
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/metrics", port))
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// Once it is closed, no other server holds on to the port.
+	resetCurPromSrv()
+	ln, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Fatalf("Listen() = %v, want the port to be released", err)
+	}
+	ln.Close()
+}
+
+func TestPromSrvHealthAndReadiness(t *testing.T) {
+	defer func(ce view.Exporter) {
+		metricsMux.Lock()
+		curMetricsExporter = ce
+		metricsMux.Unlock()
+	}(getCurMetricsExporter())
+	metricsMux.Lock()
+	curMetricsExporter = nil
+	metricsMux.Unlock()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	e, err := prometheus.NewExporter(prometheus.Options{Namespace: "component"})
+	if err != nil {
+		t.Fatalf("NewExporter() = %v", err)
+	}
+	srv := startNewPromSrv(e, ln, nil)
+	defer resetCurPromSrv()
+
+	get := func(path string) (int, string) {
+		resp := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		return resp.Code, resp.Body.String()
+	}
+
+	if code, body := get("/healthz"); code != http.StatusOK || body != "ok" {
+		t.Errorf("/healthz = %d %q, want %d %q", code, body, http.StatusOK, "ok")
+	}
+	if code, _ := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz before an exporter is registered = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	metricsMux.Lock()
+	curMetricsExporter = e
+	metricsMux.Unlock()
+	if code, body := get("/readyz"); code != http.StatusOK || body != "ok" {
+		t.Errorf("/readyz = %d %q, want %d %q", code, body, http.StatusOK, "ok")
+	}
+}
+
+func TestPromSrvFederation(t *testing.T) {
+	cluster := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `up{cluster="other"} 1`)
+	}))
+	defer cluster.Close()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	e, err := prometheus.NewExporter(prometheus.Options{Namespace: "component"})
+	if err != nil {
+		t.Fatalf("NewExporter() = %v", err)
+	}
+	federator := NewMultiClusterMetricsFederator(map[string]string{"east": cluster.URL}, TestLogger(t))
+	srv := startNewPromSrv(e, ln, federator)
+	defer resetCurPromSrv()
+
+	resp := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, FederationPath, nil))
+	if got, want := resp.Body.String(), "up{cluster=\"east\"} 1\n"; resp.Code != http.StatusOK || got != want {
+		t.Errorf("%s = %d %q, want %d %q", FederationPath, resp.Code, got, http.StatusOK, want)
+	}
+}
+
+type flushCountingExporter struct {
+	fakeExporter
+	flushes int
+	block   chan struct{}
+}
+
+func (f *flushCountingExporter) Flush() {
+	f.flushes++
+	if f.block != nil {
+		<-f.block
+	}
+}
+
+func TestFlushMetrics(t *testing.T) {
+	defer func(ce view.Exporter, cc *MetricsConfig) {
+		metricsMux.Lock()
+		curMetricsExporter, curMetricsConfig = ce, cc
+		metricsMux.Unlock()
+	}(getCurMetricsExporter(), getCurMetricsConfig())
+
+	m := stats.Int64("flush_metrics_test", "A test measure.", stats.UnitDimensionless)
+	v := &view.View{Measure: m, Aggregation: view.Count()}
+	if err := RegisterViews(v); err != nil {
+		t.Fatalf("RegisterViews() = %v", err)
+	}
+	defer UnregisterViews(v)
+	stats.Record(context.Background(), m.M(1))
+
+	setExporter := func(e view.Exporter, backend MetricsBackend) {
+		metricsMux.Lock()
+		defer metricsMux.Unlock()
+		curMetricsExporter = e
+		curMetricsConfig = &MetricsConfig{BackendDestination: backend}
+	}
+
+	e := &flushCountingExporter{}
+	setExporter(&pipelineLatencyExporter{Exporter: e, now: time.Now}, Stackdriver)
+	if err := FlushMetrics(context.Background()); err != nil {
+		t.Errorf("FlushMetrics() = %v", err)
+	}
+	if e.flushes != 1 {
+		t.Errorf("Flushes = %d, want 1", e.flushes)
+	}
+	if len(e.exported) != 1 || e.exported[0] != "flush_metrics_test" {
+		t.Errorf("Exported views = %v, want [flush_metrics_test]", e.exported)
+	}
+
+	// Prometheus is pull-based, there is nothing to flush.
+	e = &flushCountingExporter{}
+	setExporter(e, Prometheus)
+	if err := FlushMetrics(context.Background()); err != nil {
+		t.Errorf("FlushMetrics() = %v", err)
+	}
+	if e.flushes != 0 || len(e.exported) != 0 {
+		t.Errorf("Flushes = %d, exported views = %v, want none", e.flushes, e.exported)
+	}
+
+	// FlushMetrics gives up when the context is done.
+	e = &flushCountingExporter{block: make(chan struct{})}
+	defer close(e.block)
+	setExporter(e, Stackdriver)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := FlushMetrics(ctx); err != context.DeadlineExceeded {
+		t.Errorf("FlushMetrics() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+)
+
+// FederationPath is the HTTP path on which the Prometheus port of the
+// component federating the clusters serves their combined metrics.
+const FederationPath = "/federate"
+
+const (
+	// ClusterLabel is the label added to the federated samples of each cluster.
+	ClusterLabel = "cluster"
+	// StaleLabel is set to "true" on the samples of a cluster that could not
+	// be reached, which are served from its last successful scrape.
+	StaleLabel = "stale"
+
+	// DefaultFederationMaxStaleness is how long the samples of an unreachable
+	// cluster keep being served.
+	DefaultFederationMaxStaleness = 5 * time.Minute
+	// DefaultFederationScrapeInterval is how long the scrapes of the clusters
+	// are served before the clusters are scraped again.
+	DefaultFederationScrapeInterval = 15 * time.Second
+
+	federationScrapeTimeout = 10 * time.Second
+)
+
+var (
+	clusterUnreachableCountM = stats.Int64(
+		"federation/cluster_unreachable_total",
+		"Number of failed scrapes of a federated cluster",
+		stats.UnitDimensionless)
+
+	clusterTagKey tag.Key
+)
+
+func init() {
+	var err error
+	clusterTagKey, err = tag.NewKey(ClusterLabel)
+	if err != nil {
+		panic(err)
+	}
+	err = RegisterViews(
+		&view.View{
+			Description: "Number of failed scrapes of a federated cluster",
+			Measure:     clusterUnreachableCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{clusterTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// MultiClusterMetricsFederator serves the combined metrics of several
+// clusters. When it is scraped, it scrapes the Prometheus federation endpoint
+// of each cluster, at most once per ScrapeInterval, and adds a ClusterLabel
+// to the samples of each.
+//
+// The last successful scrape of a cluster that cannot be reached keeps being
+// served, marked with StaleLabel, for up to MaxStaleness.
+type MultiClusterMetricsFederator struct {
+	// clusters maps the names of the clusters to their federation URLs,
+	// including the match[] selectors, e.g.
+	// http://prometheus:9090/federate?match[]={job="knative"}.
+	clusters map[string]string
+	client   *http.Client
+	logger   *zap.SugaredLogger
+	now      func() time.Time
+
+	// MaxStaleness is how long the samples of an unreachable cluster keep
+	// being served.
+	MaxStaleness time.Duration
+	// ScrapeInterval is how long the scrapes of the clusters are served
+	// before the clusters are scraped again.
+	ScrapeInterval time.Duration
+
+	// scrapeMux serializes the scrapes of ServeHTTP, so that concurrent
+	// requests share a single scrape.
+	scrapeMux  sync.Mutex
+	lastScrape time.Time
+
+	mux     sync.Mutex
+	scrapes map[string]*clusterScrape
+}
+
+// clusterScrape is the last successful scrape of a cluster.
+type clusterScrape struct {
+	time     time.Time
+	families []*metricFamily
+	// stale is set when the latest attempt to scrape the cluster failed.
+	stale bool
+}
+
+// metricFamily holds the samples of a metric, in the Prometheus text format.
+type metricFamily struct {
+	name     string
+	comments []string
+	samples  []string
+}
+
+// NewMultiClusterMetricsFederator creates a federator of the clusters, given
+// as a map of cluster names to federation URLs.
+func NewMultiClusterMetricsFederator(clusters map[string]string, logger *zap.SugaredLogger) *MultiClusterMetricsFederator {
+	return &MultiClusterMetricsFederator{
+		clusters:       clusters,
+		client:         &http.Client{Timeout: federationScrapeTimeout},
+		logger:         logger,
+		now:            time.Now,
+		MaxStaleness:   DefaultFederationMaxStaleness,
+		ScrapeInterval: DefaultFederationScrapeInterval,
+		scrapes:        make(map[string]*clusterScrape),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (f *MultiClusterMetricsFederator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	f.scrapeIfDue()
+	f.WriteMetrics(w)
+}
+
+// scrapeIfDue scrapes the clusters unless they were scraped within the
+// ScrapeInterval.
+func (f *MultiClusterMetricsFederator) scrapeIfDue() {
+	f.scrapeMux.Lock()
+	defer f.scrapeMux.Unlock()
+	if !f.lastScrape.IsZero() && f.now().Sub(f.lastScrape) < f.ScrapeInterval {
+		return
+	}
+	f.Scrape()
+	f.lastScrape = f.now()
+}
+
+// Scrape scrapes all the clusters concurrently.
+func (f *MultiClusterMetricsFederator) Scrape() {
+	var wg sync.WaitGroup
+	for name, url := range f.clusters {
+		wg.Add(1)
+		go func(name, url string) {
+			defer wg.Done()
+			families, err := f.scrape(url)
+			if err != nil {
+				f.logger.Errorw("Failed to scrape federated cluster", zap.String(ClusterLabel, name), zap.Error(err))
+				f.reportUnreachable(name)
+				f.mux.Lock()
+				defer f.mux.Unlock()
+				if scrape, ok := f.scrapes[name]; ok {
+					scrape.stale = true
+				}
+				return
+			}
+			f.mux.Lock()
+			defer f.mux.Unlock()
+			f.scrapes[name] = &clusterScrape{time: f.now(), families: families}
+		}(name, url)
+	}
+	wg.Wait()
+}
+
+func (f *MultiClusterMetricsFederator) scrape(url string) ([]*metricFamily, error) {
+	resp, err := f.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return parseMetricFamilies(resp.Body)
+}
+
+func (f *MultiClusterMetricsFederator) reportUnreachable(cluster string) {
+	ctx, err := tag.New(context.Background(), tag.Insert(clusterTagKey, cluster))
+	if err != nil {
+		f.logger.Errorw("Failed to tag the unreachable cluster", zap.Error(err))
+		return
+	}
+	stats.Record(ctx, clusterUnreachableCountM.M(1))
+}
+
+// WriteMetrics writes the combined metrics of the last scrapes of the clusters.
+func (f *MultiClusterMetricsFederator) WriteMetrics(w io.Writer) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	// Sort the clusters so that the output is stable.
+	names := make([]string, 0, len(f.scrapes))
+	for name := range f.scrapes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Families must be contiguous in the output, so gather the samples of
+	// each family across the clusters.
+	var order []string
+	combined := make(map[string]*metricFamily)
+	now := f.now()
+	for _, name := range names {
+		scrape := f.scrapes[name]
+		labels := []label{{ClusterLabel, name}}
+		if scrape.stale {
+			if now.Sub(scrape.time) > f.MaxStaleness {
+				delete(f.scrapes, name)
+				continue
+			}
+			labels = append(labels, label{StaleLabel, "true"})
+		}
+		for _, family := range scrape.families {
+			c, ok := combined[family.name]
+			if !ok {
+				c = &metricFamily{name: family.name, comments: family.comments}
+				combined[family.name] = c
+				order = append(order, family.name)
+			}
+			for _, sample := range family.samples {
+				c.samples = append(c.samples, addLabels(sample, labels))
+			}
+		}
+	}
+
+	for _, name := range order {
+		for _, line := range combined[name].comments {
+			fmt.Fprintln(w, line)
+		}
+		for _, line := range combined[name].samples {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// parseMetricFamilies splits the Prometheus text exposition in r into metric
+// families. Samples belong to the family of the preceding TYPE line, which
+// covers the _bucket, _sum and _count samples of histograms and summaries.
+func parseMetricFamilies(r io.Reader) ([]*metricFamily, error) {
+	var families []*metricFamily
+	var current *metricFamily
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 || (fields[1] != "HELP" && fields[1] != "TYPE") {
+				continue
+			}
+			if current == nil || current.name != fields[2] {
+				current = &metricFamily{name: fields[2]}
+				families = append(families, current)
+			}
+			current.comments = append(current.comments, line)
+			continue
+		}
+		name := sampleName(line)
+		if current == nil || !inFamily(name, current.name) {
+			current = &metricFamily{name: name}
+			families = append(families, current)
+		}
+		current.samples = append(current.samples, line)
+	}
+	return families, scanner.Err()
+}
+
+func inFamily(sample, family string) bool {
+	switch sample {
+	case family, family + "_bucket", family + "_sum", family + "_count":
+		return true
+	}
+	return false
+}
+
+func sampleName(sample string) string {
+	if i := strings.IndexAny(sample, "{ "); i >= 0 {
+		return sample[:i]
+	}
+	return sample
+}
+
+// label is a label added to the federated samples.
+type label struct {
+	name, value string
+}
+
+// addLabels adds the labels to the sample, replacing the labels of the sample
+// with the same names, e.g. the cluster label a federated Prometheus adds.
+func addLabels(sample string, labels []label) string {
+	name := sampleName(sample)
+	rest := sample[len(name):]
+
+	pairs := make([]string, 0, len(labels))
+	added := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		pairs = append(pairs, l.name+"="+strconv.Quote(l.value))
+		added[l.name] = true
+	}
+	if strings.HasPrefix(rest, "{") {
+		existing, end := splitLabels(rest)
+		for _, pair := range existing {
+			if !added[strings.TrimSpace(pair[:strings.Index(pair, "=")])] {
+				pairs = append(pairs, pair)
+			}
+		}
+		rest = rest[end:]
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}" + rest
+}
+
+// splitLabels splits the label set at the start of s, e.g. {a="1",b="2"},
+// into its name="value" pairs and returns them with the index following the
+// closing brace. Commas and braces may appear in the quoted values.
+func splitLabels(s string) ([]string, int) {
+	var pairs []string
+	start, quoted := 1, false
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == ',' || c == '}'):
+			if pair := strings.TrimSpace(s[start:i]); strings.Contains(pair, "=") {
+				pairs = append(pairs, pair)
+			}
+			if c == '}' {
+				return pairs, i + 1
+			}
+			start = i + 1
+		}
+	}
+	return pairs, len(s)
+}
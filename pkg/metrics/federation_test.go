@@ -0,0 +1,211 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats/view"
+)
+
+const (
+	eastMetrics = `# HELP activator_request_count The number of requests
+# TYPE activator_request_count counter
+activator_request_count{revision_name="rev"} 10 1500000000000
+# HELP activator_latency The request latency
+# TYPE activator_latency histogram
+activator_latency_bucket{le="10"} 3
+activator_latency_bucket{le="+Inf"} 4
+activator_latency_sum 52
+activator_latency_count 4
+`
+	westMetrics = `# TYPE activator_request_count counter
+activator_request_count{revision_name="rev"} 7
+up{} 1
+`
+)
+
+func TestParseMetricFamilies(t *testing.T) {
+	families, err := parseMetricFamilies(strings.NewReader(eastMetrics + "\n" + "up 1\n"))
+	if err != nil {
+		t.Fatalf("parseMetricFamilies() = %v", err)
+	}
+	var got []string
+	for _, f := range families {
+		got = append(got, fmt.Sprintf("%s:%d:%d", f.name, len(f.comments), len(f.samples)))
+	}
+	want := []string{"activator_request_count:2:1", "activator_latency:2:4", "up:0:1"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseMetricFamilies (-want, +got) = %v", diff)
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{{
+		in:   `up 1`,
+		want: `up{cluster="east"} 1`,
+	}, {
+		in:   `up{} 1 1500000000000`,
+		want: `up{cluster="east"} 1 1500000000000`,
+	}, {
+		in:   `latency_bucket{le="+Inf"} 4`,
+		want: `latency_bucket{cluster="east",le="+Inf"} 4`,
+	}, {
+		// The cluster label of the federated Prometheus is replaced.
+		in:   `up{job="a",cluster="other", zone="b"} 1`,
+		want: `up{cluster="east",job="a",zone="b"} 1`,
+	}, {
+		in:   `up{path="{a,b}",msg="say \"hi,}\"",cluster="x"} 1`,
+		want: `up{cluster="east",path="{a,b}",msg="say \"hi,}\""} 1`,
+	}, {
+		in:   `up{job="a",} 1`,
+		want: `up{cluster="east",job="a"} 1`,
+	}}
+	for _, test := range tests {
+		if got := addLabels(test.in, []label{{ClusterLabel, "east"}}); got != test.want {
+			t.Errorf("addLabels(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestMultiClusterMetricsFederator(t *testing.T) {
+	westUp := true
+	east := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, eastMetrics)
+	}))
+	defer east.Close()
+	west := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !westUp {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, westMetrics)
+	}))
+	defer west.Close()
+
+	now := time.Now()
+	f := NewMultiClusterMetricsFederator(map[string]string{
+		"east": east.URL + "/federate",
+		"west": west.URL + "/federate",
+	}, TestLogger(t))
+	f.now = func() time.Time { return now }
+
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, httptest.NewRequest("GET", "/federate", nil))
+	want := `# HELP activator_request_count The number of requests
+# TYPE activator_request_count counter
+activator_request_count{cluster="east",revision_name="rev"} 10 1500000000000
+activator_request_count{cluster="west",revision_name="rev"} 7
+# HELP activator_latency The request latency
+# TYPE activator_latency histogram
+activator_latency_bucket{cluster="east",le="10"} 3
+activator_latency_bucket{cluster="east",le="+Inf"} 4
+activator_latency_sum{cluster="east"} 52
+activator_latency_count{cluster="east"} 4
+up{cluster="west"} 1
+`
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Errorf("Federated metrics (-want, +got) = %v", diff)
+	}
+
+	// An unreachable cluster is served from its last scrape, marked stale.
+	westUp = false
+	now = now.Add(time.Minute)
+	f.Scrape()
+	var buf bytes.Buffer
+	f.WriteMetrics(&buf)
+	if got, want := buf.String(), `up{cluster="west",stale="true"} 1`; !strings.Contains(got, want) {
+		t.Errorf("Federated metrics = %s, want to contain %s", got, want)
+	}
+	checkUnreachable(t, "west", 1)
+
+	// Until its samples are too old.
+	now = now.Add(DefaultFederationMaxStaleness)
+	f.Scrape()
+	buf.Reset()
+	f.WriteMetrics(&buf)
+	if got := buf.String(); strings.Contains(got, `cluster="west"`) {
+		t.Errorf("Federated metrics = %s, want no samples of west", got)
+	}
+	checkUnreachable(t, "west", 2)
+
+	// A cluster coming back is no longer stale.
+	westUp = true
+	f.Scrape()
+	buf.Reset()
+	f.WriteMetrics(&buf)
+	if got, want := buf.String(), `up{cluster="west"} 1`; !strings.Contains(got, want) {
+		t.Errorf("Federated metrics = %s, want to contain %s", got, want)
+	}
+}
+
+func checkUnreachable(t *testing.T, cluster string, want int64) {
+	t.Helper()
+	rows, err := view.RetrieveData("federation/cluster_unreachable_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == cluster {
+			if got := row.Data.(*view.CountData).Value; got != want {
+				t.Errorf("federation/cluster_unreachable_total{cluster=%q} = %d, want %d", cluster, got, want)
+			}
+			return
+		}
+	}
+	t.Errorf("No federation/cluster_unreachable_total row for %q in %v", cluster, rows)
+}
+
+func TestMultiClusterMetricsFederatorScrapeInterval(t *testing.T) {
+	scrapes := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scrapes++
+		fmt.Fprintf(w, "up %d\n", scrapes)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	f := NewMultiClusterMetricsFederator(map[string]string{"east": srv.URL + "/federate"}, TestLogger(t))
+	f.now = func() time.Time { return now }
+
+	for _, step := range []struct {
+		advance time.Duration
+		want    string
+	}{
+		{0, `up{cluster="east"} 1`},
+		// Served from the last scrape within the scrape interval.
+		{DefaultFederationScrapeInterval - time.Second, `up{cluster="east"} 1`},
+		{time.Second, `up{cluster="east"} 2`},
+	} {
+		now = now.Add(step.advance)
+		rec := httptest.NewRecorder()
+		f.ServeHTTP(rec, httptest.NewRequest("GET", "/federate", nil))
+		if got := strings.TrimSpace(rec.Body.String()); got != step.want {
+			t.Errorf("After %v, federated metrics = %q, want %q", step.advance, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+)
+
+const (
+	// jaegerTraceLimit bounds the traces fetched per service and poll.
+	jaegerTraceLimit = 1000
+
+	jaegerRequestTimeout = 30 * time.Second
+)
+
+var (
+	traceDerivedRequestCountM = stats.Int64(
+		"trace_derived_request_count",
+		"Number of sampled server spans reported to Jaeger",
+		stats.UnitDimensionless)
+	traceDerivedErrorCountM = stats.Int64(
+		"trace_derived_error_count",
+		"Number of sampled server spans reported to Jaeger that are tagged as errors",
+		stats.UnitDimensionless)
+	traceDerivedLatencyM = stats.Float64(
+		"trace_derived_latency_ms",
+		"Duration of sampled server spans reported to Jaeger in milliseconds",
+		stats.UnitMilliseconds)
+
+	traceServiceTagKey   tag.Key
+	traceOperationTagKey tag.Key
+)
+
+func init() {
+	var err error
+	traceServiceTagKey, err = tag.NewKey("trace_service")
+	if err != nil {
+		panic(err)
+	}
+	traceOperationTagKey, err = tag.NewKey("trace_operation")
+	if err != nil {
+		panic(err)
+	}
+
+	tagKeys := []tag.Key{traceServiceTagKey, traceOperationTagKey}
+	err = RegisterViews(
+		&view.View{
+			Description: "Number of sampled server spans reported to Jaeger",
+			Measure:     traceDerivedRequestCountM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: "Number of sampled server spans reported to Jaeger that are tagged as errors",
+			Measure:     traceDerivedErrorCountM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: "Duration of sampled server spans reported to Jaeger in milliseconds",
+			Measure:     traceDerivedLatencyM,
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
+			TagKeys:     tagKeys,
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// The subset of the Jaeger query API responses we read.
+type jaegerServices struct {
+	Data []string `json:"data"`
+}
+
+type jaegerTraces struct {
+	Data []struct {
+		Spans     []jaegerSpan             `json:"spans"`
+		Processes map[string]jaegerProcess `json:"processes"`
+	} `json:"data"`
+}
+
+type jaegerProcess struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type jaegerSpan struct {
+	OperationName string `json:"operationName"`
+	ProcessID     string `json:"processID"`
+	// StartTime and Duration are in microseconds.
+	StartTime int64       `json:"startTime"`
+	Duration  int64       `json:"duration"`
+	Tags      []jaegerTag `json:"tags"`
+}
+
+type jaegerTag struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// JaegerDerivedMetricsExporter derives request metrics from the traces
+// collected by Jaeger, so that containers emitting spans get request count,
+// error and latency metrics without further instrumentation. The metrics are
+// recorded in OpenCensus and exported by the configured metrics backend.
+//
+// When metrics.jaeger-query-url is set, it runs along with the metrics
+// exporter, see jaegerDerivedExporter.
+//
+// Only sampled traces reach Jaeger, so the counts reflect the sampled
+// traffic rather than the whole of it.
+type JaegerDerivedMetricsExporter struct {
+	queryURL *url.URL
+	client   *http.Client
+	logger   *zap.SugaredLogger
+	now      func() time.Time
+
+	// since is the end of the window of the previous poll, where the window
+	// of a service seen for the first time starts.
+	since time.Time
+	// last is the end of the window of each service polled successfully, so
+	// that a service failing is polled again from there without recording
+	// the others twice.
+	last map[string]time.Time
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewJaegerDerivedMetricsExporter creates an exporter reading traces from the
+// Jaeger query service at jaegerURL.
+func NewJaegerDerivedMetricsExporter(jaegerURL string, logger *zap.SugaredLogger) (*JaegerDerivedMetricsExporter, error) {
+	u, err := url.Parse(jaegerURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Jaeger URL %q must be absolute", jaegerURL)
+	}
+	return &JaegerDerivedMetricsExporter{
+		queryURL: u,
+		client:   &http.Client{Timeout: jaegerRequestTimeout},
+		logger:   logger,
+		now:      time.Now,
+		last:     make(map[string]time.Time),
+		stopCh:   make(chan struct{}),
+	}, nil
+}
+
+// Close stops Run.
+func (e *JaegerDerivedMetricsExporter) Close() {
+	e.closeOnce.Do(func() { close(e.stopCh) })
+}
+
+// Run derives metrics from the spans reported to Jaeger every period until
+// the exporter is closed.
+func (e *JaegerDerivedMetricsExporter) Run(period time.Duration) {
+	e.since = e.now()
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.poll(); err != nil {
+				e.logger.Errorw("Failed to derive metrics from Jaeger traces", zap.Error(err))
+			}
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// poll records the server spans of each service started since its previous
+// successful poll.
+func (e *JaegerDerivedMetricsExporter) poll() error {
+	end := e.now()
+
+	var services jaegerServices
+	if err := e.get("/api/services", nil, &services); err != nil {
+		return err
+	}
+	// Only the services Jaeger still knows are kept.
+	last := make(map[string]time.Time, len(services.Data))
+	var failed []string
+	for _, service := range services.Data {
+		start, ok := e.last[service]
+		if !ok {
+			start = e.since
+		}
+		if err := e.pollService(service, start, end); err != nil {
+			e.logger.Errorw("Failed to get the Jaeger traces of a service", zap.String("service", service), zap.Error(err))
+			failed = append(failed, service)
+			last[service] = start
+			continue
+		}
+		last[service] = end
+	}
+	e.last, e.since = last, end
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to get the Jaeger traces of %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// pollService records the server spans of service started in [start, end).
+// Nothing is recorded unless all the traces were read.
+func (e *JaegerDerivedMetricsExporter) pollService(service string, start, end time.Time) error {
+	var traces jaegerTraces
+	query := url.Values{
+		"service": {service},
+		"start":   {strconv.FormatInt(toMicros(start), 10)},
+		"end":     {strconv.FormatInt(toMicros(end), 10)},
+		"limit":   {strconv.Itoa(jaegerTraceLimit)},
+	}
+	if err := e.get("/api/traces", query, &traces); err != nil {
+		return err
+	}
+	for _, trace := range traces.Data {
+		for _, span := range trace.Spans {
+			// The query matches traces, which may contain spans of other
+			// services, recorded when polling those, and spans outside of
+			// the window.
+			if trace.Processes[span.ProcessID].ServiceName != service {
+				continue
+			}
+			if !span.isServer() || span.StartTime < toMicros(start) || span.StartTime >= toMicros(end) {
+				continue
+			}
+			e.record(service, span)
+		}
+	}
+	return nil
+}
+
+func (e *JaegerDerivedMetricsExporter) record(service string, span jaegerSpan) {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(traceServiceTagKey, service),
+		tag.Insert(traceOperationTagKey, span.OperationName))
+	if err != nil {
+		e.logger.Errorw("Failed to tag span metrics", zap.Error(err))
+		return
+	}
+	stats.Record(ctx, traceDerivedRequestCountM.M(1))
+	if span.isError() {
+		stats.Record(ctx, traceDerivedErrorCountM.M(1))
+	}
+	stats.Record(ctx, traceDerivedLatencyM.M(float64(span.Duration)/1000))
+}
+
+func (e *JaegerDerivedMetricsExporter) get(path string, query url.Values, v interface{}) error {
+	u := *e.queryURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+	resp, err := e.client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from Jaeger %s", resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s jaegerSpan) isServer() bool {
+	return s.tag("span.kind") == "server"
+}
+
+func (s jaegerSpan) isError() bool {
+	return s.tag("error") == true
+}
+
+func (s jaegerSpan) tag(key string) interface{} {
+	for _, t := range s.Tags {
+		if t.Key == key {
+			return t.Value
+		}
+	}
+	return nil
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
+
+// jaegerDerivedExporter wraps the metrics exporter, which exports the metrics
+// derived from Jaeger traces, and runs the JaegerDerivedMetricsExporter until
+// it is closed.
+type jaegerDerivedExporter struct {
+	view.Exporter
+	jaeger *JaegerDerivedMetricsExporter
+}
+
+func newJaegerDerivedExporter(e view.Exporter, config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	je, err := NewJaegerDerivedMetricsExporter(config.JaegerQueryURL, logger)
+	if err != nil {
+		return nil, err
+	}
+	go je.Run(time.Duration(config.ReportingPeriodSeconds) * time.Second)
+	return &jaegerDerivedExporter{Exporter: e, jaeger: je}, nil
+}
+
+// Close stops deriving metrics and closes the wrapped exporter if it buffers
+// view data.
+func (e *jaegerDerivedExporter) Close() {
+	e.jaeger.Close()
+	if c, ok := e.Exporter.(closer); ok {
+		c.Close()
+	}
+}
+
+// Flush flushes the wrapped exporter if it buffers view data.
+func (e *jaegerDerivedExporter) Flush() {
+	if f, ok := e.Exporter.(flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats/view"
+)
+
+func TestNewJaegerDerivedMetricsExporterErrors(t *testing.T) {
+	for _, u := range []string{"jaeger-query:16686", "/api", "http://%zz"} {
+		if _, err := NewJaegerDerivedMetricsExporter(u, TestLogger(t)); err == nil {
+			t.Errorf("NewJaegerDerivedMetricsExporter(%q) = nil, want error", u)
+		}
+	}
+}
+
+func TestJaegerDerivedMetricsExporterPoll(t *testing.T) {
+	start := time.Unix(1000, 0)
+	end := start.Add(time.Minute)
+	micros := func(d time.Duration) int64 { return toMicros(start.Add(d)) }
+
+	var gotQueries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/services":
+			fmt.Fprint(w, `{"data": ["helloworld"]}`)
+		case "/api/traces":
+			gotQueries = append(gotQueries, r.URL.RawQuery)
+			fmt.Fprintf(w, `{"data": [{"spans": [
+				{"operationName": "GET /", "processID": "p1", "startTime": %d, "duration": 20000, "tags": [{"key": "span.kind", "value": "server"}]},
+				{"operationName": "GET /", "processID": "p1", "startTime": %d, "duration": 40000, "tags": [{"key": "span.kind", "value": "server"}, {"key": "error", "value": true}]},
+				{"operationName": "db", "processID": "p1", "startTime": %d, "duration": 5000, "tags": [{"key": "span.kind", "value": "client"}]},
+				{"operationName": "GET /", "processID": "p2", "startTime": %d, "duration": 30000, "tags": [{"key": "span.kind", "value": "server"}]},
+				{"operationName": "GET /", "processID": "p1", "startTime": %d, "duration": 10000, "tags": [{"key": "span.kind", "value": "server"}]}
+			], "processes": {"p1": {"serviceName": "helloworld"}, "p2": {"serviceName": "backend"}}}]}`,
+				micros(time.Second), micros(2*time.Second), micros(3*time.Second), micros(4*time.Second), micros(2*time.Minute))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	e, err := NewJaegerDerivedMetricsExporter(srv.URL, TestLogger(t))
+	if err != nil {
+		t.Fatalf("NewJaegerDerivedMetricsExporter() = %v", err)
+	}
+	e.since = start
+	e.now = func() time.Time { return end }
+	if err := e.poll(); err != nil {
+		t.Fatalf("poll() = %v", err)
+	}
+
+	wantQuery := fmt.Sprintf("end=%d&limit=%d&service=helloworld&start=%d", toMicros(end), jaegerTraceLimit, toMicros(start))
+	if len(gotQueries) != 1 || gotQueries[0] != wantQuery {
+		t.Errorf("Queries = %v, want [%s]", gotQueries, wantQuery)
+	}
+	if got := e.last["helloworld"]; !got.Equal(end) {
+		t.Errorf("last = %v, want %v", got, end)
+	}
+
+	// The client span, the span of the other service and the span after the
+	// window are not counted.
+	checkJaegerView(t, "trace_derived_request_count", func(d view.AggregationData) bool {
+		return d.(*view.CountData).Value == 2
+	})
+	checkJaegerView(t, "trace_derived_error_count", func(d view.AggregationData) bool {
+		return d.(*view.CountData).Value == 1
+	})
+	checkJaegerView(t, "trace_derived_latency_ms", func(d view.AggregationData) bool {
+		dd := d.(*view.DistributionData)
+		return dd.Count == 2 && dd.Mean == 30
+	})
+}
+
+func TestJaegerDerivedMetricsExporterPollError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	e, err := NewJaegerDerivedMetricsExporter(srv.URL, TestLogger(t))
+	if err != nil {
+		t.Fatalf("NewJaegerDerivedMetricsExporter() = %v", err)
+	}
+	start := time.Unix(1000, 0)
+	e.since = start
+	if err := e.poll(); err == nil {
+		t.Error("poll() = nil, want error")
+	}
+	// The window is polled again on the next attempt.
+	if !e.since.Equal(start) {
+		t.Errorf("since = %v, want %v", e.since, start)
+	}
+}
+
+func TestJaegerDerivedMetricsExporterPollServiceError(t *testing.T) {
+	start := time.Unix(1000, 0)
+	end := start.Add(time.Minute)
+
+	failing := true
+	queries := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/services":
+			fmt.Fprint(w, `{"data": ["ok", "failing"]}`)
+		case "/api/traces":
+			service := r.URL.Query().Get("service")
+			queries[service] = append(queries[service], r.URL.Query().Get("start"))
+			if service == "failing" && failing {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"data": []}`)
+		}
+	}))
+	defer srv.Close()
+
+	e, err := NewJaegerDerivedMetricsExporter(srv.URL, TestLogger(t))
+	if err != nil {
+		t.Fatalf("NewJaegerDerivedMetricsExporter() = %v", err)
+	}
+	e.since = start
+	e.now = func() time.Time { return end }
+	if err := e.poll(); err == nil {
+		t.Error("poll() = nil, want error")
+	}
+
+	// The next poll only gets the failing service's window again.
+	failing = false
+	e.now = func() time.Time { return end.Add(time.Minute) }
+	if err := e.poll(); err != nil {
+		t.Fatalf("poll() = %v", err)
+	}
+	want := map[string][]string{
+		"ok":      {fmt.Sprint(toMicros(start)), fmt.Sprint(toMicros(end))},
+		"failing": {fmt.Sprint(toMicros(start)), fmt.Sprint(toMicros(start))},
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("Query starts = %v, want %v", queries, want)
+	}
+}
+
+func TestJaegerDerivedExporter(t *testing.T) {
+	inner := &flushCountingExporter{}
+	e, err := newJaegerDerivedExporter(inner, &MetricsConfig{
+		JaegerQueryURL:         "http://jaeger-query:16686",
+		ReportingPeriodSeconds: 60,
+	}, TestLogger(t))
+	if err != nil {
+		t.Fatalf("newJaegerDerivedExporter() = %v", err)
+	}
+	pe := &pipelineLatencyExporter{Exporter: e, now: time.Now}
+	if got := backendExporters(pe); len(got) != 1 || got[0] != inner {
+		t.Errorf("backendExporters() = %v, want the wrapped exporter", got)
+	}
+	pe.Flush()
+	if inner.flushes != 1 {
+		t.Errorf("Flushes = %d, want 1", inner.flushes)
+	}
+
+	// Closing stops deriving metrics.
+	pe.Close()
+	select {
+	case <-e.(*jaegerDerivedExporter).jaeger.stopCh:
+	default:
+		t.Error("Close() did not stop the Jaeger derived metrics exporter")
+	}
+}
+
+func checkJaegerView(t *testing.T, name string, wantData func(view.AggregationData) bool) {
+	t.Helper()
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		t.Fatalf("RetrieveData(%q) = %v", name, err)
+	}
+	for _, row := range rows {
+		tags := make(map[string]string, len(row.Tags))
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["trace_service"] == "helloworld" && tags["trace_operation"] == "GET /" {
+			if !wantData(row.Data) {
+				t.Errorf("Unexpected data %v for view %q", row.Data, name)
+			}
+			return
+		}
+	}
+	t.Errorf("No row for helloworld GET / in view %q: %v", name, rows)
+}
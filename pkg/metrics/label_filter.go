@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// LabelFilter returns the tags of a row that are exported.
+type LabelFilter func([]tag.Tag) []tag.Tag
+
+// NoOpLabelFilter exports all the tags.
+func NoOpLabelFilter(tags []tag.Tag) []tag.Tag {
+	return tags
+}
+
+// newLabelFilter returns a LabelFilter that drops the tags whose key is not
+// in keys, or NoOpLabelFilter if keys is empty.
+func newLabelFilter(keys []string) LabelFilter {
+	if len(keys) == 0 {
+		return NoOpLabelFilter
+	}
+	allowed := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		allowed[k] = true
+	}
+	return func(tags []tag.Tag) []tag.Tag {
+		filtered := make([]tag.Tag, 0, len(tags))
+		for _, t := range tags {
+			if allowed[t.Key.Name()] {
+				filtered = append(filtered, t)
+			}
+		}
+		return filtered
+	}
+}
+
+// labelFilterExporter wraps the Prometheus exporter to drop the filtered tags.
+// Prometheus takes the label names from the tag keys of the view, so the view
+// is filtered as well, and the rows left with the same tags are merged.
+type labelFilterExporter struct {
+	view.Exporter
+	filter LabelFilter
+}
+
+// ExportView implements view.Exporter.
+func (e *labelFilterExporter) ExportView(vd *view.Data) {
+	tags := make([]tag.Tag, len(vd.View.TagKeys))
+	for i, k := range vd.View.TagKeys {
+		tags[i] = tag.Tag{Key: k}
+	}
+	kept := e.filter(tags)
+	if len(kept) == len(tags) {
+		e.Exporter.ExportView(vd)
+		return
+	}
+
+	v := *vd.View
+	v.TagKeys = make([]tag.Key, len(kept))
+	for i, t := range kept {
+		v.TagKeys[i] = t.Key
+	}
+	rows := make([]*view.Row, 0, len(vd.Rows))
+	byTags := make(map[string]*view.Row, len(vd.Rows))
+	for _, row := range vd.Rows {
+		filtered := e.filter(row.Tags)
+		values := make([]string, len(filtered))
+		for i, t := range filtered {
+			values[i] = t.Value
+		}
+		key := strings.Join(values, "\x00")
+		if r, ok := byTags[key]; ok {
+			r.Data = mergeAggregationData(r.Data, row.Data)
+			continue
+		}
+		r := &view.Row{Tags: filtered, Data: row.Data}
+		byTags[key] = r
+		rows = append(rows, r)
+	}
+	e.Exporter.ExportView(&view.Data{View: &v, Start: vd.Start, End: vd.End, Rows: rows})
+}
+
+// mergeAggregationData combines the data of two rows of the same view. Last
+// values cannot be combined, the latter is kept.
+func mergeAggregationData(a, b view.AggregationData) view.AggregationData {
+	switch a := a.(type) {
+	case *view.CountData:
+		return &view.CountData{Value: a.Value + b.(*view.CountData).Value}
+	case *view.SumData:
+		return &view.SumData{Value: a.Value + b.(*view.SumData).Value}
+	case *view.DistributionData:
+		return mergeDistributionData(a, b.(*view.DistributionData))
+	default:
+		return b
+	}
+}
+
+func mergeDistributionData(a, b *view.DistributionData) *view.DistributionData {
+	if a.Count == 0 {
+		return b
+	}
+	if b.Count == 0 {
+		return a
+	}
+	// Copying a keeps the bounds of the buckets, which are unexported.
+	d := *a
+	d.Count = a.Count + b.Count
+	d.Mean = (a.Sum() + b.Sum()) / float64(d.Count)
+	delta := b.Mean - a.Mean
+	d.SumOfSquaredDev = a.SumOfSquaredDev + b.SumOfSquaredDev +
+		delta*delta*float64(a.Count)*float64(b.Count)/float64(d.Count)
+	if b.Min < d.Min {
+		d.Min = b.Min
+	}
+	if b.Max > d.Max {
+		d.Max = b.Max
+	}
+	d.CountPerBucket = make([]int64, len(a.CountPerBucket))
+	for i := range d.CountPerBucket {
+		d.CountPerBucket[i] = a.CountPerBucket[i] + b.CountPerBucket[i]
+	}
+	d.ExemplarsPerBucket = append(d.ExemplarsPerBucket[:0:0], a.ExemplarsPerBucket...)
+	for i, ex := range b.ExemplarsPerBucket {
+		if ex != nil && i < len(d.ExemplarsPerBucket) {
+			d.ExemplarsPerBucket[i] = ex
+		}
+	}
+	return &d
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+type dataExporter struct {
+	data []*view.Data
+}
+
+func (d *dataExporter) ExportView(vd *view.Data) {
+	d.data = append(d.data, vd)
+}
+
+func TestLabelFilterExporter(t *testing.T) {
+	codeKey, err := tag.NewKey("response_code")
+	if err != nil {
+		t.Fatalf("NewKey() = %v", err)
+	}
+	traceKey, err := tag.NewKey("trace_id")
+	if err != nil {
+		t.Fatalf("NewKey() = %v", err)
+	}
+	m := stats.Float64("label_filter_test", "A test measure.", stats.UnitMilliseconds)
+	row := func(code, trace string, data view.AggregationData) *view.Row {
+		return &view.Row{
+			Tags: []tag.Tag{{Key: codeKey, Value: code}, {Key: traceKey, Value: trace}},
+			Data: data,
+		}
+	}
+
+	d := &dataExporter{}
+	e := &labelFilterExporter{Exporter: d, filter: newLabelFilter([]string{"response_code"})}
+	e.ExportView(&view.Data{
+		View: &view.View{Name: "count", Measure: m, Aggregation: view.Count(), TagKeys: []tag.Key{codeKey, traceKey}},
+		Rows: []*view.Row{
+			row("200", "a", &view.CountData{Value: 1}),
+			row("200", "b", &view.CountData{Value: 2}),
+			row("500", "c", &view.CountData{Value: 4}),
+		},
+	})
+	e.ExportView(&view.Data{
+		View: &view.View{Name: "distribution", Measure: m, Aggregation: view.Distribution(10), TagKeys: []tag.Key{codeKey, traceKey}},
+		Rows: []*view.Row{
+			row("200", "a", &view.DistributionData{Count: 1, Min: 2, Max: 2, Mean: 2, CountPerBucket: []int64{1, 0}}),
+			row("200", "b", &view.DistributionData{Count: 1, Min: 20, Max: 20, Mean: 20, CountPerBucket: []int64{0, 1}}),
+		},
+	})
+	// Views without filtered tags are exported as they are.
+	untouched := &view.Data{View: &view.View{Name: "untouched", Measure: m, Aggregation: view.Count(), TagKeys: []tag.Key{codeKey}}}
+	e.ExportView(untouched)
+
+	if len(d.data) != 3 {
+		t.Fatalf("Exported %d views, want 3", len(d.data))
+	}
+	count := d.data[0]
+	if diff := cmp.Diff([]tag.Key{codeKey}, count.View.TagKeys, cmp.Comparer(func(a, b tag.Key) bool { return a == b })); diff != "" {
+		t.Errorf("TagKeys (-want, +got) = %v", diff)
+	}
+	got := map[string]int64{}
+	for _, r := range count.Rows {
+		if len(r.Tags) != 1 {
+			t.Errorf("Tags = %v, want only the response code", r.Tags)
+			continue
+		}
+		got[r.Tags[0].Value] = r.Data.(*view.CountData).Value
+	}
+	if want := map[string]int64{"200": 3, "500": 4}; !cmp.Equal(want, got) {
+		t.Errorf("Counts = %v, want %v", got, want)
+	}
+
+	rows := d.data[1].Rows
+	if len(rows) != 1 {
+		t.Fatalf("Distribution rows = %d, want 1", len(rows))
+	}
+	dd := rows[0].Data.(*view.DistributionData)
+	if dd.Count != 2 || dd.Min != 2 || dd.Max != 20 || dd.Mean != 11 || dd.SumOfSquaredDev != 162 {
+		t.Errorf("Distribution = %+v, want count 2, min 2, max 20, mean 11 and squared deviation 162", dd)
+	}
+	if !cmp.Equal([]int64{1, 1}, dd.CountPerBucket) {
+		t.Errorf("CountPerBucket = %v, want [1 1]", dd.CountPerBucket)
+	}
+
+	if d.data[2] != untouched {
+		t.Error("The view without filtered tags was not exported as is")
+	}
+}
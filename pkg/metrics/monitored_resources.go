@@ -0,0 +1,240 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"contrib.go.opencensus.io/exporter/stackdriver/monitoredresource"
+	"github.com/knative/pkg/metrics/metricskey"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// KnativeRevision is the knative_revision monitored resource of the metrics
+// of a revision.
+type KnativeRevision struct {
+	Project           string
+	Location          string
+	ClusterName       string
+	NamespaceName     string
+	ServiceName       string
+	ConfigurationName string
+	RevisionName      string
+}
+
+// MonitoredResource implements monitoredresource.Interface
+func (kr *KnativeRevision) MonitoredResource() (resType string, labels map[string]string) {
+	return metricskey.ResourceTypeKnativeRevision, map[string]string{
+		metricskey.LabelProject:           kr.Project,
+		metricskey.LabelLocation:          kr.Location,
+		metricskey.LabelClusterName:       kr.ClusterName,
+		metricskey.LabelNamespaceName:     kr.NamespaceName,
+		metricskey.LabelServiceName:       kr.ServiceName,
+		metricskey.LabelConfigurationName: kr.ConfigurationName,
+		metricskey.LabelRevisionName:      kr.RevisionName,
+	}
+}
+
+// global is the monitored resource of the metrics that are not about a
+// revision.
+type global struct{}
+
+// MonitoredResource implements monitoredresource.Interface
+func (global) MonitoredResource() (resType string, labels map[string]string) {
+	return "global", nil
+}
+
+// gcpLocation is where the metrics are exported from.
+type gcpLocation struct {
+	project     string
+	location    string
+	clusterName string
+}
+
+// unknownGCPLocation is the location of the metrics exported from outside of
+// GKE.
+var unknownGCPLocation = gcpLocation{
+	project:     metricskey.ValueUnknown,
+	location:    metricskey.ValueUnknown,
+	clusterName: metricskey.ValueUnknown,
+}
+
+const (
+	// gcpLocationTTL is how long a detected location is cached.
+	gcpLocationTTL = 10 * time.Minute
+
+	// gcpLocationFailureTTL is how long detection is not retried after the
+	// metadata server could not be reached, e.g. outside of GCP.
+	gcpLocationFailureTTL = 30 * time.Second
+)
+
+// gcpLocationCache holds the last detected location. It is guarded by
+// metricsMux.
+var gcpLocationCache struct {
+	loc    gcpLocation
+	expiry time.Time
+}
+
+// detectGCPLocation returns the project, zone and cluster of the GKE cluster
+// the component runs in, or unknown values on GCE outside of GKE. It fails
+// when the metadata server cannot be reached. It is a variable for testing.
+var detectGCPLocation = func() (gcpLocation, error) {
+	if _, err := metadata.InstanceID(); err != nil {
+		return unknownGCPLocation, err
+	}
+	clusterName, err := metadata.InstanceAttributeValue("cluster-name")
+	if err != nil || strings.TrimSpace(clusterName) == "" {
+		return unknownGCPLocation, nil
+	}
+	project, err := metadata.ProjectID()
+	if err != nil {
+		return unknownGCPLocation, err
+	}
+	zone, err := metadata.Zone()
+	if err != nil {
+		return unknownGCPLocation, err
+	}
+	return gcpLocation{
+		project:     project,
+		location:    zone,
+		clusterName: strings.TrimSpace(clusterName),
+	}, nil
+}
+
+const (
+	podNamespaceEnv          = "POD_NAMESPACE"
+	nodeNameEnv              = "NODE_NAME"
+	kubernetesServiceHostEnv = "KUBERNETES_SERVICE_HOST"
+)
+
+// k8sMetadataDetector detects the location of the components running outside
+// of GKE from the Downward API, which the metadata server knows nothing
+// about. Their deployments set POD_NAME, POD_NAMESPACE and NODE_NAME.
+type k8sMetadataDetector struct {
+	getenv func(string) string
+}
+
+// detect returns the location of the pod, with unknown GCP project and zone
+// and the cluster derived from the address of its Kubernetes API server. It
+// returns false if any of the Downward API variables is missing.
+func (d k8sMetadataDetector) detect() (gcpLocation, bool) {
+	for _, env := range []string{podNameEnv, podNamespaceEnv, nodeNameEnv} {
+		if d.getenv(env) == "" {
+			return gcpLocation{}, false
+		}
+	}
+	loc := unknownGCPLocation
+	if host := d.getenv(kubernetesServiceHostEnv); host != "" {
+		loc.clusterName = host
+	}
+	return loc, true
+}
+
+// k8sDetector is tried ahead of the metadata server. It is a variable for
+// testing.
+var k8sDetector = k8sMetadataDetector{getenv: os.Getenv}
+
+// detectLocation returns the location from the Downward API if it is set,
+// and only queries the metadata server otherwise.
+func detectLocation() (gcpLocation, error) {
+	if loc, ok := k8sDetector.detect(); ok {
+		return loc, nil
+	}
+	return detectGCPLocation()
+}
+
+// cachedGCPLocation returns the location cached at now, detecting it again
+// once it expired. metricsMux is not held while querying the metadata
+// server, so that it does not block the config map updates.
+func cachedGCPLocation(now time.Time) gcpLocation {
+	metricsMux.Lock()
+	if now.Before(gcpLocationCache.expiry) {
+		defer metricsMux.Unlock()
+		return gcpLocationCache.loc
+	}
+	metricsMux.Unlock()
+
+	loc, err := detectLocation()
+	ttl := gcpLocationTTL
+	if err != nil {
+		ttl = gcpLocationFailureTTL
+	}
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	gcpLocationCache.loc, gcpLocationCache.expiry = loc, now.Add(ttl)
+	return loc
+}
+
+// ClearGCPMetadataCache drops the cached GCP location, so that the next
+// export detects it again.
+func ClearGCPMetadataCache() {
+	metricsMux.Lock()
+	defer metricsMux.Unlock()
+	gcpLocationCache.loc, gcpLocationCache.expiry = gcpLocation{}, time.Time{}
+}
+
+// getMonitoredResource returns the GetMonitoredResource of the Stackdriver
+// exporter: the metrics tagged with a revision are exported against the
+// knative_revision of that revision, all others against the global resource.
+// The location of the knative_revision is only looked up on export, from
+// the cache. The tags left are passed through filter.
+func getMonitoredResource(loc func() gcpLocation, filter LabelFilter) func(*view.View, []tag.Tag) ([]tag.Tag, monitoredresource.Interface) {
+	return func(v *view.View, tags []tag.Tag) ([]tag.Tag, monitoredresource.Interface) {
+		for _, t := range tags {
+			if t.Key.Name() == metricskey.LabelRevisionName {
+				return getKnativeRevisionMonitoredResource(loc(), tags, filter)
+			}
+		}
+		return filter(tags), global{}
+	}
+}
+
+// getKnativeRevisionMonitoredResource builds the knative_revision of the
+// given tags, and returns the tags left once those that became resource
+// labels are removed, passed through filter. The resource labels are always
+// kept, they identify the revision.
+func getKnativeRevisionMonitoredResource(loc gcpLocation, tags []tag.Tag, filter LabelFilter) ([]tag.Tag, monitoredresource.Interface) {
+	kr := &KnativeRevision{
+		Project:           loc.project,
+		Location:          loc.location,
+		ClusterName:       loc.clusterName,
+		NamespaceName:     metricskey.ValueUnknown,
+		ServiceName:       metricskey.ValueUnknown,
+		ConfigurationName: metricskey.ValueUnknown,
+		RevisionName:      metricskey.ValueUnknown,
+	}
+	metricTags := make([]tag.Tag, 0, len(tags))
+	for _, t := range tags {
+		switch t.Key.Name() {
+		case metricskey.LabelNamespaceName:
+			kr.NamespaceName = t.Value
+		case metricskey.LabelServiceName:
+			kr.ServiceName = t.Value
+		case metricskey.LabelConfigurationName:
+			kr.ConfigurationName = t.Value
+		case metricskey.LabelRevisionName:
+			kr.RevisionName = t.Value
+		default:
+			metricTags = append(metricTags, t)
+		}
+	}
+	return filter(metricTags), kr
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/pkg/metrics/metricskey"
+	"go.opencensus.io/tag"
+)
+
+func TestGetMonitoredResource(t *testing.T) {
+	loc := gcpLocation{project: "project", location: "us-central1-a", clusterName: "cluster"}
+	newTag := func(key, value string) tag.Tag {
+		k, err := tag.NewKey(key)
+		if err != nil {
+			t.Fatalf("NewKey(%s) = %v", key, err)
+		}
+		return tag.Tag{Key: k, Value: value}
+	}
+
+	tests := []struct {
+		name       string
+		tags       []tag.Tag
+		allowed    []string
+		wantTags   []string
+		wantType   string
+		wantLabels map[string]string
+	}{{
+		name: "revision",
+		tags: []tag.Tag{
+			newTag(metricskey.LabelNamespaceName, "ns"),
+			newTag(metricskey.LabelServiceName, "svc"),
+			newTag(metricskey.LabelConfigurationName, "cfg"),
+			newTag(metricskey.LabelRevisionName, "rev"),
+			newTag("response_code", "200"),
+		},
+		wantTags: []string{"response_code"},
+		wantType: metricskey.ResourceTypeKnativeRevision,
+		wantLabels: map[string]string{
+			metricskey.LabelProject:           "project",
+			metricskey.LabelLocation:          "us-central1-a",
+			metricskey.LabelClusterName:       "cluster",
+			metricskey.LabelNamespaceName:     "ns",
+			metricskey.LabelServiceName:       "svc",
+			metricskey.LabelConfigurationName: "cfg",
+			metricskey.LabelRevisionName:      "rev",
+		},
+	}, {
+		name: "revision without service",
+		tags: []tag.Tag{
+			newTag(metricskey.LabelNamespaceName, "ns"),
+			newTag(metricskey.LabelRevisionName, "rev"),
+		},
+		wantTags: []string{},
+		wantType: metricskey.ResourceTypeKnativeRevision,
+		wantLabels: map[string]string{
+			metricskey.LabelProject:           "project",
+			metricskey.LabelLocation:          "us-central1-a",
+			metricskey.LabelClusterName:       "cluster",
+			metricskey.LabelNamespaceName:     "ns",
+			metricskey.LabelServiceName:       metricskey.ValueUnknown,
+			metricskey.LabelConfigurationName: metricskey.ValueUnknown,
+			metricskey.LabelRevisionName:      "rev",
+		},
+	}, {
+		name: "revision with filtered tags",
+		tags: []tag.Tag{
+			newTag(metricskey.LabelNamespaceName, "ns"),
+			newTag(metricskey.LabelRevisionName, "rev"),
+			newTag("response_code", "200"),
+			newTag("trace_id", "abc"),
+		},
+		allowed:  []string{"response_code"},
+		wantTags: []string{"response_code"},
+		wantType: metricskey.ResourceTypeKnativeRevision,
+		wantLabels: map[string]string{
+			metricskey.LabelProject:           "project",
+			metricskey.LabelLocation:          "us-central1-a",
+			metricskey.LabelClusterName:       "cluster",
+			metricskey.LabelNamespaceName:     "ns",
+			metricskey.LabelServiceName:       metricskey.ValueUnknown,
+			metricskey.LabelConfigurationName: metricskey.ValueUnknown,
+			metricskey.LabelRevisionName:      "rev",
+		},
+	}, {
+		name: "not a revision with filtered tags",
+		tags: []tag.Tag{
+			newTag(metricskey.LabelNamespaceName, "ns"),
+			newTag("resource_type", "Route"),
+		},
+		allowed:  []string{"resource_type"},
+		wantTags: []string{"resource_type"},
+		wantType: "global",
+	}, {
+		name: "not a revision",
+		tags: []tag.Tag{
+			newTag(metricskey.LabelNamespaceName, "ns"),
+			newTag("resource_type", "Route"),
+		},
+		wantTags: []string{metricskey.LabelNamespaceName, "resource_type"},
+		wantType: "global",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tags, mr := getMonitoredResource(func() gcpLocation { return loc }, newLabelFilter(test.allowed))(nil, test.tags)
+			gotTags := []string{}
+			for _, tag := range tags {
+				gotTags = append(gotTags, tag.Key.Name())
+			}
+			if diff := cmp.Diff(test.wantTags, gotTags); diff != "" {
+				t.Errorf("Tags (-want, +got) = %v", diff)
+			}
+			gotType, gotLabels := mr.MonitoredResource()
+			if gotType != test.wantType {
+				t.Errorf("Resource type = %s, want %s", gotType, test.wantType)
+			}
+			if diff := cmp.Diff(test.wantLabels, gotLabels); diff != "" {
+				t.Errorf("Resource labels (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+func TestCachedGCPLocation(t *testing.T) {
+	defer func(d func() (gcpLocation, error)) {
+		detectGCPLocation = d
+		ClearGCPMetadataCache()
+	}(detectGCPLocation)
+	ClearGCPMetadataCache()
+
+	gke := gcpLocation{project: "project", location: "us-central1-a", clusterName: "cluster"}
+	var (
+		detections int
+		err        error
+	)
+	detectGCPLocation = func() (gcpLocation, error) {
+		detections++
+		if err != nil {
+			return unknownGCPLocation, err
+		}
+		return gke, nil
+	}
+
+	now := time.Now()
+	if got := cachedGCPLocation(now); got != gke {
+		t.Errorf("cachedGCPLocation() = %v, want %v", got, gke)
+	}
+	if got := cachedGCPLocation(now.Add(gcpLocationTTL - time.Second)); got != gke || detections != 1 {
+		t.Errorf("cachedGCPLocation() = %v after %d detections, want %v after 1", got, detections, gke)
+	}
+
+	// The failures are cached for a shorter time.
+	err = errors.New("metadata server unreachable")
+	now = now.Add(gcpLocationTTL)
+	if got := cachedGCPLocation(now); got != unknownGCPLocation || detections != 2 {
+		t.Errorf("cachedGCPLocation() = %v after %d detections, want %v after 2", got, detections, unknownGCPLocation)
+	}
+	cachedGCPLocation(now.Add(gcpLocationFailureTTL - time.Second))
+	if detections != 2 {
+		t.Errorf("Detections = %d, want the failure to be cached", detections)
+	}
+	err = nil
+	if got := cachedGCPLocation(now.Add(gcpLocationFailureTTL)); got != gke || detections != 3 {
+		t.Errorf("cachedGCPLocation() = %v after %d detections, want %v after 3", got, detections, gke)
+	}
+
+	ClearGCPMetadataCache()
+	cachedGCPLocation(now.Add(gcpLocationFailureTTL))
+	if detections != 4 {
+		t.Errorf("Detections = %d, want 4 once the cache is cleared", detections)
+	}
+}
+
+func TestK8sMetadataDetector(t *testing.T) {
+	downwardAPI := map[string]string{
+		podNameEnv:      "activator-abcde",
+		podNamespaceEnv: "knative-serving",
+		nodeNameEnv:     "node-1",
+	}
+	tests := []struct {
+		name   string
+		env    map[string]string
+		want   gcpLocation
+		wantOK bool
+	}{{
+		name: "no downward API",
+		env:  map[string]string{kubernetesServiceHostEnv: "10.0.0.1"},
+	}, {
+		name: "missing node name",
+		env: map[string]string{
+			podNameEnv:      "activator-abcde",
+			podNamespaceEnv: "knative-serving",
+		},
+	}, {
+		name:   "downward API",
+		env:    downwardAPI,
+		want:   unknownGCPLocation,
+		wantOK: true,
+	}, {
+		name: "downward API with API server",
+		env: map[string]string{
+			podNameEnv:               "activator-abcde",
+			podNamespaceEnv:          "knative-serving",
+			nodeNameEnv:              "node-1",
+			kubernetesServiceHostEnv: "10.0.0.1",
+		},
+		want: gcpLocation{
+			project:     metricskey.ValueUnknown,
+			location:    metricskey.ValueUnknown,
+			clusterName: "10.0.0.1",
+		},
+		wantOK: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := k8sMetadataDetector{getenv: func(k string) string { return test.env[k] }}
+			if got, ok := d.detect(); got != test.want || ok != test.wantOK {
+				t.Errorf("detect() = %v, %v, want %v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestDetectLocationPrefersDownwardAPI(t *testing.T) {
+	defer func(d func() (gcpLocation, error), k k8sMetadataDetector) {
+		detectGCPLocation, k8sDetector = d, k
+	}(detectGCPLocation, k8sDetector)
+
+	gke := gcpLocation{project: "project", location: "us-central1-a", clusterName: "cluster"}
+	detectGCPLocation = func() (gcpLocation, error) {
+		return gke, nil
+	}
+	env := map[string]string{}
+	k8sDetector = k8sMetadataDetector{getenv: func(k string) string { return env[k] }}
+
+	if got, err := detectLocation(); err != nil || got != gke {
+		t.Errorf("detectLocation() = %v, %v, want %v from the metadata server", got, err, gke)
+	}
+	env[podNameEnv], env[podNamespaceEnv], env[nodeNameEnv] = "pod", "ns", "node"
+	if got, err := detectLocation(); err != nil || got != unknownGCPLocation {
+		t.Errorf("detectLocation() = %v, %v, want %v from the Downward API", got, err, unknownGCPLocation)
+	}
+}
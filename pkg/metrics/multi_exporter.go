@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+// secondaryQueueLength is the number of exports queued for each secondary
+// exporter. Once a secondary exporter falls that far behind, the view data
+// is dropped for it.
+const secondaryQueueLength = 100
+
+// multiExporter sends the view data to the exporters of a primary and a
+// secondary backend at once, e.g. while migrating from one backend to the
+// other. The first exporter is the primary one. Each secondary exporter
+// exports from its own goroutine, so that a slow or failing secondary
+// backend does not delay or affect the primary one.
+type multiExporter struct {
+	exporters []view.Exporter
+	// queues hold the work of the secondary exporters, in order.
+	queues []chan func()
+	stop   chan struct{}
+	once   sync.Once
+	logger *zap.SugaredLogger
+}
+
+// newMultiExporter creates a multiExporter and starts the goroutines of its
+// secondary exporters.
+func newMultiExporter(primary view.Exporter, secondaries []view.Exporter, logger *zap.SugaredLogger) *multiExporter {
+	e := &multiExporter{
+		exporters: append([]view.Exporter{primary}, secondaries...),
+		stop:      make(chan struct{}),
+		logger:    logger,
+	}
+	for range secondaries {
+		q := make(chan func(), secondaryQueueLength)
+		e.queues = append(e.queues, q)
+		go e.runSecondary(q)
+	}
+	return e
+}
+
+// runSecondary does the work queued for a secondary exporter until the
+// multiExporter is closed.
+func (e *multiExporter) runSecondary(q <-chan func()) {
+	for {
+		select {
+		case work := <-q:
+			work()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// ExportView implements view.Exporter. It exports to the primary exporter
+// and queues the export to the secondary ones without waiting for them.
+func (e *multiExporter) ExportView(vd *view.Data) {
+	e.exporters[0].ExportView(vd)
+	for i, q := range e.queues {
+		ce := e.exporters[i+1]
+		select {
+		case q <- func() {
+			defer e.recoverSecondary(vd)
+			ce.ExportView(vd)
+		}:
+		default:
+			e.logger.Warnw("The secondary metrics exporter is falling behind, dropping view data", zap.String("view", vd.View.Name))
+		}
+	}
+}
+
+// recoverSecondary logs the failure of a secondary exporter instead of
+// crashing the component.
+func (e *multiExporter) recoverSecondary(vd *view.Data) {
+	if r := recover(); r != nil {
+		e.logger.Errorw("The secondary metrics exporter failed", zap.String("view", vd.View.Name), zap.Error(fmt.Errorf("%v", r)))
+	}
+}
+
+// Close stops the secondary exporters and closes the exporters that buffer
+// view data.
+func (e *multiExporter) Close() {
+	e.once.Do(func() { close(e.stop) })
+	for _, ce := range e.exporters {
+		if c, ok := ce.(closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// Flush flushes the exporters that buffer view data. The secondary exporters
+// are flushed once they are done with the exports queued before.
+func (e *multiExporter) Flush() {
+	if f, ok := e.exporters[0].(flusher); ok {
+		f.Flush()
+	}
+	for i, q := range e.queues {
+		f, ok := e.exporters[i+1].(flusher)
+		if !ok {
+			continue
+		}
+		done := make(chan struct{})
+		select {
+		case q <- func() {
+			defer close(done)
+			f.Flush()
+		}:
+		case <-e.stop:
+			continue
+		}
+		select {
+		case <-done:
+		case <-e.stop:
+		}
+	}
+}
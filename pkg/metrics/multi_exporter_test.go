@@ -0,0 +1,183 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats/view"
+)
+
+type panickingExporter struct{}
+
+func (panickingExporter) ExportView(*view.Data) {
+	panic("backend unavailable")
+}
+
+// chanExporter sends the names of the views it exports to a channel, and
+// waits for block to be closed, if set, before returning.
+type chanExporter struct {
+	exported chan string
+	block    chan struct{}
+	flushed  chan struct{}
+}
+
+func newChanExporter() *chanExporter {
+	return &chanExporter{exported: make(chan string, 2*secondaryQueueLength), flushed: make(chan struct{}, 1)}
+}
+
+func (c *chanExporter) ExportView(vd *view.Data) {
+	if c.block != nil {
+		<-c.block
+	}
+	c.exported <- vd.View.Name
+}
+
+func (c *chanExporter) Flush() {
+	c.flushed <- struct{}{}
+}
+
+func expectExported(t *testing.T, c *chanExporter, want string) {
+	t.Helper()
+	select {
+	case got := <-c.exported:
+		if got != want {
+			t.Errorf("Exported %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Timed out waiting for %q to be exported", want)
+	}
+}
+
+func TestMultiExporter(t *testing.T) {
+	primary, secondary := &flushCountingExporter{}, newChanExporter()
+	e := newMultiExporter(primary, []view.Exporter{secondary}, TestLogger(t))
+	defer e.Close()
+	e.ExportView(&view.Data{View: &view.View{Name: "multi_exporter_test"}})
+	if len(primary.exported) != 1 {
+		t.Errorf("Exported views = %v, want one", primary.exported)
+	}
+	expectExported(t, secondary, "multi_exporter_test")
+	e.Flush()
+	if primary.flushes != 1 {
+		t.Errorf("Flushes = %d, want 1", primary.flushes)
+	}
+	select {
+	case <-secondary.flushed:
+	default:
+		t.Error("Flush() returned before flushing the secondary exporter")
+	}
+}
+
+func TestMultiExporterFailingSecondary(t *testing.T) {
+	// A failing secondary exporter does not affect the primary one.
+	primary := &fakeExporter{}
+	e := newMultiExporter(primary, []view.Exporter{panickingExporter{}}, TestLogger(t))
+	defer e.Close()
+	e.ExportView(&view.Data{View: &view.View{Name: "multi_exporter_test"}})
+	if len(primary.exported) != 1 {
+		t.Errorf("Exported views = %v, want one", primary.exported)
+	}
+}
+
+func TestMultiExporterSlowSecondary(t *testing.T) {
+	// A slow secondary exporter does not delay the primary one, and drops
+	// the view data it falls behind on.
+	primary, secondary := &fakeExporter{}, newChanExporter()
+	secondary.block = make(chan struct{})
+	e := newMultiExporter(primary, []view.Exporter{secondary}, TestLogger(t))
+	defer e.Close()
+
+	exports := 2 * secondaryQueueLength
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < exports; i++ {
+			e.ExportView(&view.Data{View: &view.View{Name: "multi_exporter_test"}})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ExportView() waited for the secondary exporter")
+	}
+	if len(primary.exported) != exports {
+		t.Errorf("Exported views = %d, want %d", len(primary.exported), exports)
+	}
+
+	close(secondary.block)
+	expectExported(t, secondary, "multi_exporter_test")
+	// The secondary exporter catches up with the exports queued, at most
+	// one more than the queue could hold, and the others were dropped.
+	e.Flush()
+	if got := len(secondary.exported) + 1; got > secondaryQueueLength+1 {
+		t.Errorf("Secondary exports = %d, want at most %d", got, secondaryQueueLength+1)
+	}
+}
+
+func TestNewMetricsExporterSecondaryBackend(t *testing.T) {
+	defer func(ce view.Exporter, cc *MetricsConfig) {
+		resetCurMetricsExporter()
+		resetCurPromSrv()
+		metricsMux.Lock()
+		curMetricsExporter, curMetricsConfig = ce, cc
+		metricsMux.Unlock()
+	}(getCurMetricsExporter(), getCurMetricsConfig())
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	config := &MetricsConfig{
+		Domain:                 metricsDomain,
+		Component:              "component",
+		BackendDestination:     None,
+		SecondaryBackend:       Prometheus,
+		ReportingPeriodSeconds: 60,
+		PrometheusPort:         port,
+	}
+	if err := newMetricsExporter(config, TestLogger(t)); err != nil {
+		t.Fatalf("newMetricsExporter() = %v", err)
+	}
+	if got := len(backendExporters(getCurMetricsExporter())); got != 2 {
+		t.Errorf("Backend exporters = %d, want 2", got)
+	}
+	getCurMetricsExporter().ExportView(&view.Data{View: &view.View{Name: "multi_exporter_test"}})
+	if got := GetNoneExporterData(); len(got) != 1 {
+		t.Errorf("GetNoneExporterData() = %v, want the exported view", got)
+	}
+
+	same := *config
+	if isMetricsConfigChanged(&same) {
+		t.Error("isMetricsConfigChanged() = true for the same config")
+	}
+	same.PrometheusPort++
+	if !isMetricsConfigChanged(&same) {
+		t.Error("isMetricsConfigChanged() = false for another port of the secondary backend")
+	}
+	other := *config
+	other.SecondaryBackend = ""
+	if !isMetricsConfigChanged(&other) {
+		t.Error("isMetricsConfigChanged() = false without the secondary backend")
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+// noneExporter keeps the latest exported data of each view in memory instead
+// of sending it anywhere, for tests and dry runs. As the default backend, it
+// must not grow with the number of exports.
+type noneExporter struct {
+	mu   sync.Mutex
+	data map[string]*view.Data
+}
+
+func newNoneExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	logger.Infof("Created the in-memory metrics exporter with config %v", config)
+	return &noneExporter{data: make(map[string]*view.Data)}, nil
+}
+
+// ExportView implements view.Exporter.
+func (e *noneExporter) ExportView(vd *view.Data) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.data[vd.View.Name] = vd
+}
+
+// GetNoneExporterData returns the latest data exported for each view, sorted
+// by view name, when the metrics backend is None, or nil otherwise.
+func GetNoneExporterData() []*view.Data {
+	for _, ce := range backendExporters(getCurMetricsExporter()) {
+		if e, ok := ce.(*noneExporter); ok {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			data := make([]*view.Data, 0, len(e.data))
+			for _, vd := range e.data {
+				data = append(data, vd)
+			}
+			sort.Slice(data, func(i, j int) bool {
+				return data[i].View.Name < data[j].View.Name
+			})
+			return data
+		}
+	}
+	return nil
+}
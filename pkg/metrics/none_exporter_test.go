@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats/view"
+)
+
+func TestNoneExporter(t *testing.T) {
+	defer func(ce view.Exporter, cc *MetricsConfig) {
+		resetCurMetricsExporter()
+		metricsMux.Lock()
+		curMetricsExporter, curMetricsConfig = ce, cc
+		metricsMux.Unlock()
+	}(getCurMetricsExporter(), getCurMetricsConfig())
+
+	config, err := getMetricsConfig(map[string]string{}, metricsDomain, "component", TestLogger(t))
+	if err != nil {
+		t.Fatalf("getMetricsConfig() = %v", err)
+	}
+	if err := newMetricsExporter(config, TestLogger(t)); err != nil {
+		t.Fatalf("newMetricsExporter() = %v", err)
+	}
+
+	vd := &view.Data{View: &view.View{Name: "none_exporter_test"}}
+	getCurMetricsExporter().ExportView(vd)
+	if got := GetNoneExporterData(); len(got) != 1 || got[0] != vd {
+		t.Errorf("GetNoneExporterData() = %v, want [%v]", got, vd)
+	}
+
+	// Only the latest data of each view is kept.
+	other := &view.Data{View: &view.View{Name: "another_none_exporter_test"}}
+	latest := &view.Data{View: &view.View{Name: "none_exporter_test"}}
+	getCurMetricsExporter().ExportView(latest)
+	getCurMetricsExporter().ExportView(other)
+	if got := GetNoneExporterData(); len(got) != 2 || got[0] != other || got[1] != latest {
+		t.Errorf("GetNoneExporterData() = %v, want [%v %v]", got, other, latest)
+	}
+
+	resetCurMetricsExporter()
+	metricsMux.Lock()
+	curMetricsExporter = &fakeExporter{}
+	metricsMux.Unlock()
+	if got := GetNoneExporterData(); got != nil {
+		t.Errorf("GetNoneExporterData() = %v, want nil for another backend", got)
+	}
+}
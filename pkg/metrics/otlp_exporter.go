@@ -0,0 +1,369 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+const (
+	otlpRequestTimeout = 30 * time.Second
+
+	// otlpMaxQueuedRequests bounds the requests waiting to be sent, the
+	// oldest ones are dropped first.
+	otlpMaxQueuedRequests = 5
+
+	// otlpCumulative is the AGGREGATION_TEMPORALITY_CUMULATIVE enum value,
+	// OpenCensus views aggregate from the time they are registered.
+	otlpCumulative = 2
+)
+
+// The types below are the JSON encoding of an OTLP ExportMetricsServiceRequest.
+// 64 bit integers are encoded as strings, as required by the protobuf JSON
+// mapping.
+type otlpMetricsData struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt,omitempty"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	BucketCounts      []string        `json:"bucketCounts"`
+	ExplicitBounds    []float64       `json:"explicitBounds"`
+	Min               float64         `json:"min"`
+	Max               float64         `json:"max"`
+}
+
+// otlpExporter exports view data to an OpenTelemetry collector with the
+// OTLP/HTTP protocol and its JSON encoding. The views exported during a
+// reporting period are sent in a single request in the background, Close
+// sends what is left.
+type otlpExporter struct {
+	config     *MetricsConfig
+	logger     *zap.SugaredLogger
+	client     *http.Client
+	metricsURL string
+	// onError reports the failed sends to the circuit breaker.
+	onError func(error)
+
+	mux sync.Mutex
+	// metrics holds the latest data of each view since the last batch, the
+	// views are cumulative so the latest data supersedes the earlier ones.
+	metrics map[string]otlpMetric
+	// queue holds the batches waiting to be sent.
+	queue chan *otlpMetricsData
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newOTLPExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	scheme := "https"
+	if config.OTLPInsecure {
+		scheme = "http"
+	}
+	breaker := newCircuitBreaker(logger)
+	e := &otlpExporter{
+		config:     config,
+		logger:     logger,
+		client:     &http.Client{Timeout: otlpRequestTimeout},
+		metricsURL: fmt.Sprintf("%s://%s/v1/metrics", scheme, config.OTLPEndpoint),
+		onError:    breaker.onError,
+		queue:      make(chan *otlpMetricsData, otlpMaxQueuedRequests),
+		stopCh:     make(chan struct{}),
+	}
+	period := time.Duration(config.ReportingPeriodSeconds) * time.Second
+	if period <= 0 {
+		period = defaultReportingPeriodSeconds * time.Second
+	}
+	go e.run(period)
+	go e.sendQueued()
+	logger.Infof("Created OTLP exporter with config %v", config)
+	return &circuitBreakerExporter{Exporter: e, breaker: breaker}, nil
+}
+
+// ExportView implements view.Exporter.
+func (e *otlpExporter) ExportView(vd *view.Data) {
+	if len(vd.Rows) == 0 {
+		return
+	}
+	m := toOTLPMetric(vd)
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if e.metrics == nil {
+		e.metrics = make(map[string]otlpMetric)
+	}
+	e.metrics[vd.View.Name] = m
+}
+
+// run queues the views exported during each period until the exporter is
+// closed.
+func (e *otlpExporter) run(period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if md := e.batch(); md != nil {
+				e.enqueue(md)
+			}
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// sendQueued sends the queued batches until the exporter is closed.
+func (e *otlpExporter) sendQueued() {
+	for {
+		select {
+		case md := <-e.queue:
+			e.sendBatch(md)
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// enqueue queues a batch, dropping the oldest one if the queue is full.
+func (e *otlpExporter) enqueue(md *otlpMetricsData) {
+	for {
+		select {
+		case e.queue <- md:
+			return
+		default:
+		}
+		select {
+		case <-e.queue:
+			e.logger.Warn("The OTLP export queue is full, dropped the oldest batch")
+		default:
+		}
+	}
+}
+
+// Flush sends the queued batches and the views exported since the last batch.
+func (e *otlpExporter) Flush() {
+	for {
+		select {
+		case md := <-e.queue:
+			e.sendBatch(md)
+		default:
+			if md := e.batch(); md != nil {
+				e.sendBatch(md)
+			}
+			return
+		}
+	}
+}
+
+// Close stops sending in the background and flushes the buffered views.
+func (e *otlpExporter) Close() {
+	e.closeOnce.Do(func() {
+		close(e.stopCh)
+		e.Flush()
+	})
+}
+
+func (e *otlpExporter) sendBatch(md *otlpMetricsData) {
+	if err := e.send(md); err != nil {
+		n := len(md.ResourceMetrics[0].ScopeMetrics[0].Metrics)
+		e.onError(fmt.Errorf("failed to export %d metrics over OTLP: %v", n, err))
+	}
+}
+
+// batch returns a request with the views exported since the last batch, or
+// nil if there are none.
+func (e *otlpExporter) batch() *otlpMetricsData {
+	e.mux.Lock()
+	metrics := e.metrics
+	e.metrics = nil
+	e.mux.Unlock()
+
+	if len(metrics) == 0 {
+		return nil
+	}
+	sm := otlpScopeMetrics{Metrics: make([]otlpMetric, 0, len(metrics))}
+	for _, m := range metrics {
+		sm.Metrics = append(sm.Metrics, m)
+	}
+	sort.Slice(sm.Metrics, func(i, j int) bool { return sm.Metrics[i].Name < sm.Metrics[j].Name })
+	sm.Scope.Name = e.config.Domain
+	rm := otlpResourceMetrics{ScopeMetrics: []otlpScopeMetrics{sm}}
+	rm.Resource.Attributes = []otlpAttribute{newOTLPAttribute("service.name", e.config.Component)}
+	return &otlpMetricsData{ResourceMetrics: []otlpResourceMetrics{rm}}
+}
+
+// toOTLPMetric maps a view to a single OTLP metric with one data point per
+// row. Counts and sums are cumulative sums, last values are gauges and
+// distributions are histograms.
+func toOTLPMetric(vd *view.Data) otlpMetric {
+	m := otlpMetric{
+		Name:        vd.View.Name,
+		Description: vd.View.Description,
+		Unit:        vd.View.Measure.Unit(),
+	}
+	start := strconv.FormatInt(vd.Start.UnixNano(), 10)
+	end := strconv.FormatInt(vd.End.UnixNano(), 10)
+
+	for _, row := range vd.Rows {
+		var attrs []otlpAttribute
+		for _, t := range row.Tags {
+			attrs = append(attrs, newOTLPAttribute(t.Key.Name(), t.Value))
+		}
+		point := otlpNumberDataPoint{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end}
+		switch data := row.Data.(type) {
+		case *view.CountData:
+			point.AsInt = strconv.FormatInt(data.Value, 10)
+			m.Sum = appendOTLPSum(m.Sum, point)
+		case *view.SumData:
+			v := data.Value
+			point.AsDouble = &v
+			m.Sum = appendOTLPSum(m.Sum, point)
+		case *view.LastValueData:
+			v := data.Value
+			point.AsDouble = &v
+			if m.Gauge == nil {
+				m.Gauge = &otlpGauge{}
+			}
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, point)
+		case *view.DistributionData:
+			hp := otlpHistogramDataPoint{
+				Attributes:        attrs,
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				Count:             strconv.FormatInt(data.Count, 10),
+				Sum:               data.Mean * float64(data.Count),
+				ExplicitBounds:    vd.View.Aggregation.Buckets,
+				Min:               data.Min,
+				Max:               data.Max,
+			}
+			for _, c := range data.CountPerBucket {
+				hp.BucketCounts = append(hp.BucketCounts, strconv.FormatInt(c, 10))
+			}
+			if m.Histogram == nil {
+				m.Histogram = &otlpHistogram{AggregationTemporality: otlpCumulative}
+			}
+			m.Histogram.DataPoints = append(m.Histogram.DataPoints, hp)
+		}
+	}
+	return m
+}
+
+func newOTLPAttribute(key, value string) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	a.Value.StringValue = value
+	return a
+}
+
+func appendOTLPSum(s *otlpSum, point otlpNumberDataPoint) *otlpSum {
+	if s == nil {
+		s = &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+	}
+	s.DataPoints = append(s.DataPoints, point)
+	return s
+}
+
+func (e *otlpExporter) send(md *otlpMetricsData) error {
+	body, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.metricsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d from the OTLP endpoint: %s", resp.StatusCode, b)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+type fakeCollector struct {
+	metrics []otlpMetricsData
+	fail    bool
+}
+
+func (f *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" {
+		http.NotFound(w, r)
+		return
+	}
+	var md otlpMetricsData
+	if err := json.NewDecoder(r.Body).Decode(&md); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.metrics = append(f.metrics, md)
+}
+
+func TestNewOTLPExporterURL(t *testing.T) {
+	for _, insecure := range []bool{false, true} {
+		e, err := newOTLPExporter(&MetricsConfig{
+			OTLPEndpoint: "collector:4318",
+			OTLPInsecure: insecure,
+		}, TestLogger(t))
+		if err != nil {
+			t.Fatalf("newOTLPExporter() = %v", err)
+		}
+		e.(closer).Close()
+		want := "https://collector:4318/v1/metrics"
+		if insecure {
+			want = "http://collector:4318/v1/metrics"
+		}
+		ce, ok := e.(*circuitBreakerExporter)
+		if !ok {
+			t.Fatalf("newOTLPExporter() = %T, want a *circuitBreakerExporter", e)
+		}
+		if got := ce.Exporter.(*otlpExporter).metricsURL; got != want {
+			t.Errorf("metricsURL = %q, want %q", got, want)
+		}
+	}
+}
+
+func newTestOTLPExporter(t *testing.T, srv *httptest.Server) *otlpExporter {
+	logger := TestLogger(t)
+	return &otlpExporter{
+		config: &MetricsConfig{
+			Domain:    metricsDomain,
+			Component: "testcomponent",
+		},
+		logger:     logger,
+		client:     srv.Client(),
+		metricsURL: srv.URL + "/v1/metrics",
+		onError:    newCircuitBreaker(logger).onError,
+		queue:      make(chan *otlpMetricsData, otlpMaxQueuedRequests),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+func TestOTLPExporterExportView(t *testing.T) {
+	fake := &fakeCollector{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestOTLPExporter(t, srv)
+
+	nsKey, _ := tag.NewKey("namespace_name")
+	start := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	e.ExportView(&view.Data{
+		View: &view.View{
+			Name:        "otlp_count",
+			Description: "test count",
+			Measure:     stats.Int64("otlp_count", "test measure", stats.UnitDimensionless),
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{nsKey},
+		},
+		Start: start,
+		End:   end,
+		Rows: []*view.Row{{
+			Tags: []tag.Tag{{Key: nsKey, Value: "default"}},
+			Data: &view.CountData{Value: 3},
+		}},
+	})
+	e.ExportView(&view.Data{
+		View: &view.View{
+			Name:        "otlp_latency",
+			Measure:     stats.Float64("otlp_latency", "test measure", stats.UnitMilliseconds),
+			Aggregation: view.Distribution(1, 10),
+		},
+		Start: start,
+		End:   end,
+		Rows: []*view.Row{{
+			Data: &view.DistributionData{Count: 4, Min: 1, Max: 12, Mean: 5, CountPerBucket: []int64{0, 3, 1}},
+		}},
+	})
+
+	// The views are buffered until the exporter is flushed, and then sent in
+	// a single request.
+	if len(fake.metrics) != 0 {
+		t.Fatalf("Got %d metric requests before flushing, want 0", len(fake.metrics))
+	}
+	e.Close()
+
+	if len(fake.metrics) != 1 {
+		t.Fatalf("Got %d metric requests, want 1", len(fake.metrics))
+	}
+	rm := fake.metrics[0].ResourceMetrics[0]
+	if diff := cmp.Diff([]otlpAttribute{newOTLPAttribute("service.name", "testcomponent")}, rm.Resource.Attributes); diff != "" {
+		t.Errorf("Unexpected resource attributes (-want +got): %v", diff)
+	}
+	if rm.ScopeMetrics[0].Scope.Name != metricsDomain {
+		t.Errorf("Scope name = %q, want %q", rm.ScopeMetrics[0].Scope.Name, metricsDomain)
+	}
+	got := rm.ScopeMetrics[0].Metrics
+
+	startNanos, endNanos := "1543622400000000000", "1543622460000000000"
+	want := []otlpMetric{{
+		Name:        "otlp_count",
+		Description: "test count",
+		Unit:        "1",
+		Sum: &otlpSum{
+			DataPoints: []otlpNumberDataPoint{{
+				Attributes:        []otlpAttribute{newOTLPAttribute("namespace_name", "default")},
+				StartTimeUnixNano: startNanos,
+				TimeUnixNano:      endNanos,
+				AsInt:             "3",
+			}},
+			AggregationTemporality: otlpCumulative,
+			IsMonotonic:            true,
+		},
+	}, {
+		Name: "otlp_latency",
+		Unit: "ms",
+		Histogram: &otlpHistogram{
+			DataPoints: []otlpHistogramDataPoint{{
+				StartTimeUnixNano: startNanos,
+				TimeUnixNano:      endNanos,
+				Count:             "4",
+				Sum:               20,
+				BucketCounts:      []string{"0", "3", "1"},
+				ExplicitBounds:    []float64{1, 10},
+				Min:               1,
+				Max:               12,
+			}},
+			AggregationTemporality: otlpCumulative,
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected metrics (-want +got): %v", diff)
+	}
+}
+
+func TestOTLPExporterQueue(t *testing.T) {
+	fake := &fakeCollector{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestOTLPExporter(t, srv)
+	var errs []error
+	e.onError = func(err error) { errs = append(errs, err) }
+
+	export := func(name string, v float64) {
+		e.ExportView(&view.Data{
+			View: &view.View{
+				Name:        name,
+				Measure:     stats.Float64(name, "test measure", stats.UnitNone),
+				Aggregation: view.LastValue(),
+			},
+			Rows: []*view.Row{{Data: &view.LastValueData{Value: v}}},
+		})
+	}
+
+	// Each period is queued as one batch with the latest data of each view,
+	// the oldest batches are dropped once the queue is full.
+	for i := 0; i < otlpMaxQueuedRequests+2; i++ {
+		export("otlp_b", float64(i))
+		export("otlp_a", float64(i))
+		export("otlp_b", float64(i)+0.5)
+		e.enqueue(e.batch())
+	}
+	if got := e.batch(); got != nil {
+		t.Errorf("batch() = %v after batching every view, want nil", got)
+	}
+	e.Flush()
+
+	if got := len(fake.metrics); got != otlpMaxQueuedRequests {
+		t.Fatalf("Got %d metric requests, want %d", got, otlpMaxQueuedRequests)
+	}
+	var got []string
+	for _, m := range fake.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		got = append(got, fmt.Sprintf("%s=%v", m.Name, *m.Gauge.DataPoints[0].AsDouble))
+	}
+	if want := []string{"otlp_a=2", "otlp_b=2.5"}; !cmp.Equal(want, got) {
+		t.Errorf("First sent batch = %v, want %v", got, want)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Got errors %v, want none", errs)
+	}
+
+	// A failed send is reported to the circuit breaker.
+	fake.fail = true
+	export("otlp_a", 1)
+	e.Flush()
+	if len(errs) != 1 {
+		t.Errorf("Got %d errors, want 1", len(errs))
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+var (
+	pipelineLatencyProbeM = stats.Int64(
+		"pipeline_latency_probe",
+		"Canary measurement recording its sequence number",
+		stats.UnitDimensionless)
+	pipelineEndToEndLatencyM = stats.Float64(
+		"pipeline_end_to_end_latency_ms",
+		"Time from recording a measurement to exporting it in milliseconds",
+		stats.UnitMilliseconds)
+
+	pipelineLatencyProbeView = &view.View{
+		Description: "Canary measurement recording its sequence number",
+		Measure:     pipelineLatencyProbeM,
+		Aggregation: view.LastValue(),
+	}
+	pipelineEndToEndLatencyView = &view.View{
+		Description: "Time from recording a measurement to exporting it in milliseconds",
+		Measure:     pipelineEndToEndLatencyM,
+		Aggregation: view.Distribution(1000, 5000, 10000, 30000, 60000, 90000, 120000, 300000),
+	}
+
+	registerPipelineLatencyViewsOnce sync.Once
+	registerPipelineLatencyViewsErr  error
+
+	// probeExported is signaled when a probe reaches the exporter.
+	probeExported = make(chan struct{}, 1)
+
+	// The probe records its sequence number rather than its record time,
+	// which is kept here. The exporter correlates the two.
+	probeMu sync.Mutex
+	// probeSeq and probeRecorded are the sequence number and the record time
+	// of the probe in flight.
+	probeSeq      int64
+	probeRecorded time.Time
+	// probeSeen is the sequence number of the last probe seen by the
+	// exporter. The view keeps the probe until the next one is recorded, so
+	// it is exported again at every reporting period in between.
+	probeSeen int64
+)
+
+// RegisterPipelineLatencyViews registers the views of the pipeline latency
+// probe. RunPipelineLatencyProbe registers them, so that the processes which
+// do not run the probe, e.g. the queue-proxy with its own exporter, do not
+// export them.
+func RegisterPipelineLatencyViews() error {
+	registerPipelineLatencyViewsOnce.Do(func() {
+		registerPipelineLatencyViewsErr = RegisterViews(pipelineLatencyProbeView, pipelineEndToEndLatencyView)
+	})
+	return registerPipelineLatencyViewsErr
+}
+
+// RunPipelineLatencyProbe measures the latency of the OpenCensus pipeline,
+// from stats.Record to the exporter, until stopCh is closed. It records a
+// probe and records the next one once the exporter has seen it, so that at
+// most one probe is in flight. The latency includes the time the probe waits
+// for the next reporting period.
+func RunPipelineLatencyProbe(stopCh <-chan struct{}) {
+	if err := RegisterPipelineLatencyViews(); err != nil {
+		return
+	}
+	recordPipelineLatencyProbe(time.Now())
+	for {
+		select {
+		case <-probeExported:
+			recordPipelineLatencyProbe(time.Now())
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func recordPipelineLatencyProbe(now time.Time) {
+	probeMu.Lock()
+	probeSeq++
+	seq := probeSeq
+	probeRecorded = now
+	probeMu.Unlock()
+
+	stats.Record(context.Background(), pipelineLatencyProbeM.M(seq))
+}
+
+// exportedProbe returns the record time of the probe with the given sequence
+// number the first time the exporter sees it.
+func exportedProbe(seq int64) (time.Time, bool) {
+	probeMu.Lock()
+	defer probeMu.Unlock()
+
+	if seq != probeSeq || seq <= probeSeen {
+		return time.Time{}, false
+	}
+	probeSeen = seq
+	return probeRecorded, true
+}
+
+// pipelineLatencyExporter wraps the metrics exporter to turn the probes it
+// sees into pipeline latency measurements. The probes themselves are not
+// exported, their values are only meaningful to the exporter.
+type pipelineLatencyExporter struct {
+	view.Exporter
+	now func() time.Time
+}
+
+// Close closes the wrapped exporter if it buffers view data.
+func (e *pipelineLatencyExporter) Close() {
+	if c, ok := e.Exporter.(closer); ok {
+		c.Close()
+	}
+}
+
+// Flush flushes the wrapped exporter if it buffers view data.
+func (e *pipelineLatencyExporter) Flush() {
+	if f, ok := e.Exporter.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// ExportView implements view.Exporter.
+func (e *pipelineLatencyExporter) ExportView(vd *view.Data) {
+	if vd.View.Name != pipelineLatencyProbeView.Name {
+		e.Exporter.ExportView(vd)
+		return
+	}
+
+	for _, row := range vd.Rows {
+		data, ok := row.Data.(*view.LastValueData)
+		if !ok {
+			continue
+		}
+		recorded, ok := exportedProbe(int64(data.Value))
+		if !ok {
+			continue
+		}
+		latency := e.now().Sub(recorded)
+		stats.Record(context.Background(), pipelineEndToEndLatencyM.M(float64(latency/time.Millisecond)))
+		select {
+		case probeExported <- struct{}{}:
+		default:
+		}
+	}
+}
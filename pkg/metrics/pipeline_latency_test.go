@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
+)
+
+type fakeExporter struct {
+	exported []string
+}
+
+func (f *fakeExporter) ExportView(vd *view.Data) {
+	f.exported = append(f.exported, vd.View.Name)
+}
+
+func TestRecordPipelineLatencyProbe(t *testing.T) {
+	if err := RegisterPipelineLatencyViews(); err != nil {
+		t.Fatalf("RegisterPipelineLatencyViews() = %v", err)
+	}
+	recordPipelineLatencyProbe(time.Now())
+	recordPipelineLatencyProbe(time.Now())
+
+	rows, err := view.RetrieveData(pipelineLatencyProbeView.Name)
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	// The probes share a single row without tags, holding the last one.
+	if len(rows) != 1 {
+		t.Fatalf("Got %d rows, want 1", len(rows))
+	}
+	if len(rows[0].Tags) != 0 {
+		t.Errorf("Probe tags = %v, want none", rows[0].Tags)
+	}
+	probeMu.Lock()
+	want := float64(probeSeq)
+	probeMu.Unlock()
+	if got := rows[0].Data.(*view.LastValueData).Value; got != want {
+		t.Errorf("Probe value = %v, want %v", got, want)
+	}
+}
+
+func TestPipelineLatencyExporter(t *testing.T) {
+	if err := RegisterPipelineLatencyViews(); err != nil {
+		t.Fatalf("RegisterPipelineLatencyViews() = %v", err)
+	}
+	fake := &fakeExporter{}
+	now := time.Now()
+	e := &pipelineLatencyExporter{Exporter: fake, now: func() time.Time { return now }}
+
+	recordPipelineLatencyProbe(now.Add(-1500 * time.Millisecond))
+	probeMu.Lock()
+	seq := probeSeq
+	probeMu.Unlock()
+	probe := func(seq int64) *view.Data {
+		return &view.Data{
+			View: pipelineLatencyProbeView,
+			Rows: []*view.Row{{
+				Data: &view.LastValueData{Value: float64(seq)},
+			}},
+		}
+	}
+	e.ExportView(&view.Data{View: &view.View{Name: "other"}})
+	// A probe which is no longer in flight is not measured.
+	e.ExportView(probe(seq - 1))
+	e.ExportView(probe(seq))
+	// A probe exported once more is only measured once.
+	e.ExportView(probe(seq))
+
+	if diff := cmp.Diff([]string{"other"}, fake.exported); diff != "" {
+		t.Errorf("Exported views (-want +got): %v", diff)
+	}
+	select {
+	case <-probeExported:
+	default:
+		t.Error("The probe was not signaled as exported")
+	}
+
+	rows, err := view.RetrieveData("pipeline_end_to_end_latency_ms")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Got %d rows, want 1", len(rows))
+	}
+	d := rows[0].Data.(*view.DistributionData)
+	if d.Count != 1 || d.Max != 1500 {
+		t.Errorf("pipeline_end_to_end_latency_ms = {Count: %d, Max: %v}, want {Count: 1, Max: 1500}", d.Count, d.Max)
+	}
+}
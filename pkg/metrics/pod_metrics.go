@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PodMetricsPath is the HTTP path serving the metrics of the current pod.
+	PodMetricsPath = "/metrics/pod"
+
+	// PodNameLabel is the label of the metrics of a single pod. Views add a
+	// tag key with this name to be served on PodMetricsPath.
+	PodNameLabel = "pod_name"
+
+	podNameEnv = "POD_NAME"
+)
+
+// PodMetricsHandler serves the Prometheus metrics of metricsHandler whose
+// PodNameLabel is podName, so that the pods of a revision can be compared
+// one by one.
+func PodMetricsHandler(metricsHandler http.Handler, podName string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		metricsHandler.ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK {
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+		w.Header().Set("Content-Type", rec.Header().Get("Content-Type"))
+		w.Write(filterPodMetrics(rec.Body.Bytes(), podName))
+	})
+}
+
+// filterPodMetrics keeps the samples of the Prometheus text exposition in
+// metrics whose PodNameLabel is podName, along with the HELP and TYPE lines
+// of their metric families.
+func filterPodMetrics(metrics []byte, podName string) []byte {
+	want := PodNameLabel + "=" + strconv.Quote(podName)
+
+	var out bytes.Buffer
+	// The comments of the current family, written out ahead of its first
+	// matching sample.
+	var comments []string
+	scanner := bufio.NewScanner(bytes.NewReader(metrics))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "# HELP ") {
+				comments = nil
+			}
+			comments = append(comments, line)
+			continue
+		}
+		if !hasLabel(line, want) {
+			continue
+		}
+		for _, c := range comments {
+			out.WriteString(c + "\n")
+		}
+		comments = nil
+		out.WriteString(line + "\n")
+	}
+	return out.Bytes()
+}
+
+// hasLabel returns whether the label set of the sample contains label,
+// formatted as name="value". Quotes within label values are escaped, so
+// label cannot match the inside of another label's value.
+func hasLabel(sample, label string) bool {
+	return strings.Contains(sample, "{"+label) || strings.Contains(sample, ","+label)
+}
+
+// handlePodMetrics registers PodMetricsHandler on the mux when the pod name
+// is known from the environment.
+func handlePodMetrics(mux *http.ServeMux, metricsHandler http.Handler) {
+	if podName := os.Getenv(podNameEnv); podName != "" {
+		mux.Handle(PodMetricsPath, PodMetricsHandler(metricsHandler, podName))
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const promMetrics = `# HELP activator_request_count The number of requests
+# TYPE activator_request_count counter
+activator_request_count{pod_name="activator-a",revision_name="rev"} 10
+activator_request_count{pod_name="activator-b",revision_name="rev"} 7
+activator_request_count{pod_name="activator-ab",revision_name="rev"} 1
+# HELP activator_latency The request latency
+# TYPE activator_latency histogram
+activator_latency_bucket{revision_name="rev",pod_name="activator-a",le="10"} 3
+activator_latency_bucket{revision_name="rev",pod_name="activator-a",le="+Inf"} 4
+activator_latency_sum{revision_name="rev",pod_name="activator-a"} 52
+activator_latency_count{revision_name="rev",pod_name="activator-a"} 4
+# HELP activator_panic Whether panicking
+# TYPE activator_panic gauge
+activator_panic{revision_name="activator-a"} 0
+activator_panic 1
+`
+
+func TestFilterPodMetrics(t *testing.T) {
+	want := `# HELP activator_request_count The number of requests
+# TYPE activator_request_count counter
+activator_request_count{pod_name="activator-a",revision_name="rev"} 10
+# HELP activator_latency The request latency
+# TYPE activator_latency histogram
+activator_latency_bucket{revision_name="rev",pod_name="activator-a",le="10"} 3
+activator_latency_bucket{revision_name="rev",pod_name="activator-a",le="+Inf"} 4
+activator_latency_sum{revision_name="rev",pod_name="activator-a"} 52
+activator_latency_count{revision_name="rev",pod_name="activator-a"} 4
+`
+	if diff := cmp.Diff(want, string(filterPodMetrics([]byte(promMetrics), "activator-a"))); diff != "" {
+		t.Errorf("filterPodMetrics (-want, +got) = %v", diff)
+	}
+	if got := filterPodMetrics([]byte(promMetrics), "unknown"); len(got) != 0 {
+		t.Errorf("filterPodMetrics() of an unknown pod = %q, want empty", got)
+	}
+}
+
+func TestPodMetricsHandler(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		io.WriteString(w, promMetrics)
+	})
+
+	rec := httptest.NewRecorder()
+	PodMetricsHandler(metrics, "activator-b").ServeHTTP(rec, httptest.NewRequest("GET", PodMetricsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Code = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	want := `# HELP activator_request_count The number of requests
+# TYPE activator_request_count counter
+activator_request_count{pod_name="activator-b",revision_name="rev"} 7
+`
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Errorf("Body (-want, +got) = %v", diff)
+	}
+
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})
+	rec = httptest.NewRecorder()
+	PodMetricsHandler(failing, "activator-b").ServeHTTP(rec, httptest.NewRequest("GET", PodMetricsPath, nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Code = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"go.opencensus.io/exporter/prometheus"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+)
+
+const pushgatewayRequestTimeout = 30 * time.Second
+
+// pushgatewayExporter exports view data to a Prometheus Pushgateway, for the
+// short-lived workloads that exit before Prometheus scrapes them. The view
+// data is collected by a Prometheus exporter with its own registry, whose
+// metrics are pushed at most once per reporting period, and once more by
+// Flush before the process exits.
+type pushgatewayExporter struct {
+	exporter *prometheus.Exporter
+	registry *promclient.Registry
+	logger   *zap.SugaredLogger
+	client   *http.Client
+	// pushURL is the URL of the group of the component's metrics, replaced
+	// by each push.
+	pushURL string
+	now     func() time.Time
+
+	mux      sync.Mutex
+	lastPush time.Time
+}
+
+func newPrometheusPushgatewayExporter(config *MetricsConfig, logger *zap.SugaredLogger) (view.Exporter, error) {
+	registry := promclient.NewRegistry()
+	pe, err := prometheus.NewExporter(prometheus.Options{Namespace: config.Component, Registry: registry})
+	if err != nil {
+		logger.Error("Failed to create the Prometheus Pushgateway exporter.", zap.Error(err))
+		return nil, err
+	}
+	e := &pushgatewayExporter{
+		exporter: pe,
+		registry: registry,
+		logger:   logger,
+		client:   &http.Client{Timeout: pushgatewayRequestTimeout},
+		pushURL:  pushgatewayGroupURL(config.PrometheusPushgatewayURL, config.Component, pushgatewayInstance(os.Getenv, os.Hostname)),
+		now:      time.Now,
+	}
+	logger.Infof("Created Prometheus Pushgateway exporter with config %v", config)
+	return e, nil
+}
+
+// pushgatewayInstance returns the instance label of the pushed metrics, the
+// name of the pod from the Downward API, or the host name which defaults to
+// it.
+func pushgatewayInstance(getenv func(string) string, hostname func() (string, error)) string {
+	if pod := getenv(podNameEnv); pod != "" {
+		return pod
+	}
+	if host, err := hostname(); err == nil && host != "" {
+		return host
+	}
+	return "unknown"
+}
+
+// pushgatewayGroupURL returns the URL of the group of metrics with the given
+// job and instance labels.
+func pushgatewayGroupURL(base, job, instance string) string {
+	return fmt.Sprintf("%s/metrics/job/%s/instance/%s",
+		strings.TrimSuffix(base, "/"), url.PathEscape(job), url.PathEscape(instance))
+}
+
+// ExportView implements view.Exporter.
+func (e *pushgatewayExporter) ExportView(vd *view.Data) {
+	e.exporter.ExportView(vd)
+
+	e.mux.Lock()
+	now := e.now()
+	if now.Sub(e.lastPush) < reportingPeriod() {
+		e.mux.Unlock()
+		return
+	}
+	e.lastPush = now
+	e.mux.Unlock()
+
+	if err := e.push(); err != nil {
+		e.logger.Errorw("Failed to push the metrics to the Prometheus Pushgateway", zap.Error(err))
+	}
+}
+
+// Flush pushes the metrics regardless of the last push, so that the view
+// data exported since then is not lost when the process exits.
+func (e *pushgatewayExporter) Flush() {
+	e.mux.Lock()
+	e.lastPush = e.now()
+	e.mux.Unlock()
+
+	if err := e.push(); err != nil {
+		e.logger.Errorw("Failed to push the metrics to the Prometheus Pushgateway", zap.Error(err))
+	}
+}
+
+// reportingPeriod returns the current reporting period, which is updated
+// without recreating the exporter.
+func reportingPeriod() time.Duration {
+	seconds := defaultReportingPeriodSeconds
+	if cc := getCurMetricsConfig(); cc != nil && cc.ReportingPeriodSeconds > 0 {
+		seconds = cc.ReportingPeriodSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// push replaces the metrics of the group with those of the registry, like
+// the Push function of the Prometheus push package.
+func (e *pushgatewayExporter) push() error {
+	mfs, err := e.registry.Gather()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, e.pushURL, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", string(expfmt.FmtText))
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d from the Prometheus Pushgateway: %s", resp.StatusCode, b)
+	}
+	return nil
+}
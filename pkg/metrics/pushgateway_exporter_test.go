@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+type fakePushgateway struct {
+	mux    sync.Mutex
+	pushes []string
+}
+
+func (f *fakePushgateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut || r.URL.Path != "/metrics/job/component/instance/pod-abcde" {
+		http.NotFound(w, r)
+		return
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.pushes = append(f.pushes, string(b))
+}
+
+func (f *fakePushgateway) getPushes() []string {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.pushes
+}
+
+func TestPushgatewayInstance(t *testing.T) {
+	hostname := func() (string, error) { return "host", nil }
+	noHostname := func() (string, error) { return "", errors.New("no host name") }
+	env := map[string]string{podNameEnv: "pod-abcde"}
+
+	if got := pushgatewayInstance(func(k string) string { return env[k] }, hostname); got != "pod-abcde" {
+		t.Errorf("pushgatewayInstance() = %q, want the pod name", got)
+	}
+	if got := pushgatewayInstance(func(string) string { return "" }, hostname); got != "host" {
+		t.Errorf("pushgatewayInstance() = %q, want the host name", got)
+	}
+	if got := pushgatewayInstance(func(string) string { return "" }, noHostname); got != "unknown" {
+		t.Errorf("pushgatewayInstance() = %q, want unknown", got)
+	}
+}
+
+func TestPushgatewayExporter(t *testing.T) {
+	pg := &fakePushgateway{}
+	srv := httptest.NewServer(pg)
+	defer srv.Close()
+
+	m := stats.Int64("pushgateway_test", "A test measure.", stats.UnitDimensionless)
+	v := &view.View{Measure: m, Aggregation: view.Count()}
+	if err := view.Register(v); err != nil {
+		t.Fatalf("Register() = %v", err)
+	}
+	defer view.Unregister(v)
+	stats.Record(context.Background(), m.M(1))
+	rows, err := view.RetrieveData(v.Name)
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	vd := &view.Data{View: v, Start: time.Now(), End: time.Now(), Rows: rows}
+
+	ve, err := newPrometheusPushgatewayExporter(&MetricsConfig{
+		Component:                "component",
+		PrometheusPushgatewayURL: srv.URL + "/",
+	}, TestLogger(t))
+	if err != nil {
+		t.Fatalf("newPrometheusPushgatewayExporter() = %v", err)
+	}
+	e := ve.(*pushgatewayExporter)
+	e.pushURL = pushgatewayGroupURL(srv.URL+"/", "component", "pod-abcde")
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	// The first export pushes, the following ones wait for the reporting
+	// period to elapse.
+	e.ExportView(vd)
+	e.ExportView(vd)
+	if got := len(pg.getPushes()); got != 1 {
+		t.Fatalf("Pushes = %d, want 1", got)
+	}
+	now = now.Add(reportingPeriod())
+	e.ExportView(vd)
+	if got := len(pg.getPushes()); got != 2 {
+		t.Fatalf("Pushes = %d, want 2 once the reporting period elapsed", got)
+	}
+
+	// Flush pushes regardless of the reporting period.
+	e.Flush()
+	pushes := pg.getPushes()
+	if len(pushes) != 3 {
+		t.Fatalf("Pushes = %d, want 3 after Flush", len(pushes))
+	}
+	if !strings.Contains(pushes[2], "component_pushgateway_test 1") {
+		t.Errorf("Pushed metrics = %q, want component_pushgateway_test 1", pushes[2])
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// ViewsPath is the HTTP path on which components expose their registered views.
+const ViewsPath = "/debug/metrics/views"
+
+// ViewInfo describes a view registered through RegisterViews.
+type ViewInfo struct {
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	MeasureType     string   `json:"measureType"`
+	AggregationType string   `json:"aggregationType"`
+	TagKeys         []string `json:"tagKeys"`
+}
+
+var (
+	viewsMu         sync.RWMutex
+	registeredViews = make(map[string]*view.View)
+)
+
+// RegisterViews registers the given views with OpenCensus and records them
+// so that they are returned by ListRegisteredViews.
+func RegisterViews(views ...*view.View) error {
+	if err := view.Register(views...); err != nil {
+		return err
+	}
+
+	viewsMu.Lock()
+	defer viewsMu.Unlock()
+	for _, v := range views {
+		registeredViews[viewName(v)] = v
+	}
+	return nil
+}
+
+// UnregisterViews unregisters the given views from OpenCensus.
+func UnregisterViews(views ...*view.View) {
+	view.Unregister(views...)
+
+	viewsMu.Lock()
+	defer viewsMu.Unlock()
+	for _, v := range views {
+		delete(registeredViews, viewName(v))
+	}
+}
+
+// DeleteLastValueRows deletes the rows carrying all the given tags from the
+// last value views registered through RegisterViews, e.g. the gauges of a
+// deleted revision, which would otherwise be exported at their last value.
+// OpenCensus cannot delete a row, so each such view is registered anew and the
+// last values of its other rows are recorded again. A value recorded
+// concurrently may be lost until it is recorded again.
+func DeleteLastValueRows(tags ...tag.Tag) error {
+	viewsMu.RLock()
+	views := make([]*view.View, 0, len(registeredViews))
+	for _, v := range registeredViews {
+		if v.Aggregation.Type == view.AggTypeLastValue {
+			views = append(views, v)
+		}
+	}
+	viewsMu.RUnlock()
+
+	for _, v := range views {
+		rows, err := view.RetrieveData(viewName(v))
+		if err != nil {
+			return err
+		}
+		kept := make([]*view.Row, 0, len(rows))
+		for _, row := range rows {
+			if !hasTags(row.Tags, tags) {
+				kept = append(kept, row)
+			}
+		}
+		if len(kept) == len(rows) {
+			continue
+		}
+		view.Unregister(v)
+		if err := view.Register(v); err != nil {
+			return err
+		}
+		for _, row := range kept {
+			if err := recordLastValue(v.Measure, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasTags returns whether all the wanted tags are among the tags.
+func hasTags(tags, wanted []tag.Tag) bool {
+	for _, w := range wanted {
+		found := false
+		for _, t := range tags {
+			if t == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// recordLastValue records the value of a last value row with its tags.
+func recordLastValue(m stats.Measure, row *view.Row) error {
+	data, ok := row.Data.(*view.LastValueData)
+	if !ok {
+		return nil
+	}
+	mutators := make([]tag.Mutator, len(row.Tags))
+	for i, t := range row.Tags {
+		mutators[i] = tag.Upsert(t.Key, t.Value)
+	}
+	switch m := m.(type) {
+	case *stats.Float64Measure:
+		return stats.RecordWithTags(context.Background(), mutators, m.M(data.Value))
+	case *stats.Int64Measure:
+		return stats.RecordWithTags(context.Background(), mutators, m.M(int64(data.Value)))
+	}
+	return nil
+}
+
+// ListRegisteredViews returns the views registered through RegisterViews,
+// sorted by name.
+func ListRegisteredViews() []ViewInfo {
+	viewsMu.RLock()
+	defer viewsMu.RUnlock()
+
+	infos := make([]ViewInfo, 0, len(registeredViews))
+	for name, v := range registeredViews {
+		info := ViewInfo{
+			Name:            name,
+			Description:     v.Description,
+			MeasureType:     measureType(v.Measure),
+			AggregationType: v.Aggregation.Type.String(),
+			TagKeys:         make([]string, 0, len(v.TagKeys)),
+		}
+		for _, k := range v.TagKeys {
+			info.TagKeys = append(info.TagKeys, k.Name())
+		}
+		sort.Strings(info.TagKeys)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// ViewsHandler returns an http.Handler that serves ListRegisteredViews as JSON.
+func ViewsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ListRegisteredViews()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// viewName mirrors OpenCensus in defaulting the view name to the measure name.
+func viewName(v *view.View) string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.Measure.Name()
+}
+
+func measureType(m stats.Measure) string {
+	switch m.(type) {
+	case *stats.Float64Measure:
+		return "Float64"
+	case *stats.Int64Measure:
+		return "Int64"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/activator"
+	_ "github.com/knative/serving/pkg/autoscaler"
+	"github.com/knative/serving/pkg/metrics"
+	"github.com/knative/serving/pkg/queue"
+	_ "github.com/knative/serving/pkg/reconciler/configschema"
+	_ "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress"
+	_ "github.com/knative/serving/pkg/reconciler/v1alpha1/revision"
+	_ "github.com/knative/serving/pkg/reconciler/v1alpha1/route"
+	_ "github.com/knative/serving/pkg/reconciler/v1alpha1/service"
+	_ "github.com/knative/serving/pkg/webhook"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+const goldenViews = "testdata/views.golden"
+
+// The activator reporter cannot register its views twice.
+var activatorOnce sync.Once
+
+// registerAllViews registers the views of the reporters that do not register
+// their views on init, returning a func to unregister them.
+func registerAllViews(t *testing.T) func() {
+	t.Helper()
+	activatorOnce.Do(func() {
+		if _, err := activator.NewStatsReporter(); err != nil {
+			t.Fatalf("activator.NewStatsReporter() = %v", err)
+		}
+	})
+	r, err := queue.NewStatsReporter("namespace", "config", "revision")
+	if err != nil {
+		t.Fatalf("queue.NewStatsReporter() = %v", err)
+	}
+	if err := queue.RegisterQueueProxyViews(nil); err != nil {
+		t.Fatalf("queue.RegisterQueueProxyViews() = %v", err)
+	}
+	if err := metrics.RegisterPipelineLatencyViews(); err != nil {
+		t.Fatalf("metrics.RegisterPipelineLatencyViews() = %v", err)
+	}
+	return func() { r.UnregisterViews() }
+}
+
+func TestAllExpectedViewsRegistered(t *testing.T) {
+	defer registerAllViews(t)()
+
+	b, err := ioutil.ReadFile(goldenViews)
+	if err != nil {
+		t.Fatalf("ReadFile(%q) = %v", goldenViews, err)
+	}
+	var want []metrics.ViewInfo
+	if err := json.Unmarshal(b, &want); err != nil {
+		t.Fatalf("Unmarshal(%q) = %v", goldenViews, err)
+	}
+
+	got := make(map[string]metrics.ViewInfo)
+	for _, v := range metrics.ListRegisteredViews() {
+		got[v.Name] = v
+	}
+	for _, w := range want {
+		g, ok := got[w.Name]
+		if !ok {
+			t.Errorf("View %q is not registered", w.Name)
+			continue
+		}
+		if diff := cmp.Diff(w, g); diff != "" {
+			t.Errorf("View %q differs from %s (-want +got): %v", w.Name, goldenViews, diff)
+		}
+	}
+}
+
+func TestViewsHandler(t *testing.T) {
+	defer registerAllViews(t)()
+
+	rec := httptest.NewRecorder()
+	metrics.ViewsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metrics.ViewsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("StatusCode = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var got []metrics.ViewInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	if diff := cmp.Diff(metrics.ListRegisteredViews(), got); diff != "" {
+		t.Errorf("Unexpected views (-want +got): %v", diff)
+	}
+}
+
+func TestDeleteLastValueRows(t *testing.T) {
+	revisionKey, err := tag.NewKey("revision_name")
+	if err != nil {
+		t.Fatalf("NewKey() = %v", err)
+	}
+	gauge := stats.Float64("delete_rows_test_gauge", "Test gauge", stats.UnitNone)
+	counter := stats.Float64("delete_rows_test_count", "Test counter", stats.UnitNone)
+	views := []*view.View{{
+		Measure:     gauge,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{revisionKey},
+	}, {
+		Measure:     counter,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{revisionKey},
+	}}
+	if err := metrics.RegisterViews(views...); err != nil {
+		t.Fatalf("RegisterViews() = %v", err)
+	}
+	defer metrics.UnregisterViews(views...)
+
+	for rev, value := range map[string]float64{"deleted": 1, "kept": 2} {
+		ctx, err := tag.New(context.Background(), tag.Insert(revisionKey, rev))
+		if err != nil {
+			t.Fatalf("tag.New() = %v", err)
+		}
+		stats.Record(ctx, gauge.M(value), counter.M(value))
+	}
+
+	if err := metrics.DeleteLastValueRows(tag.Tag{Key: revisionKey, Value: "deleted"}); err != nil {
+		t.Fatalf("DeleteLastValueRows() = %v", err)
+	}
+
+	rows, err := view.RetrieveData(gauge.Name())
+	if err != nil {
+		t.Fatalf("RetrieveData(%q) = %v", gauge.Name(), err)
+	}
+	want := []*view.Row{{
+		Tags: []tag.Tag{{Key: revisionKey, Value: "kept"}},
+		Data: &view.LastValueData{Value: 2},
+	}}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("Unexpected gauge rows (-want +got): %v", diff)
+	}
+
+	// Only the last value views are deleted from.
+	rows, err = view.RetrieveData(counter.Name())
+	if err != nil {
+		t.Fatalf("RetrieveData(%q) = %v", counter.Name(), err)
+	}
+	if got, want := len(rows), 2; got != want {
+		t.Errorf("Got %d counter rows, want %d", got, want)
+	}
+}
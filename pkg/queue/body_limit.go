@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// LimitRequestBody enforces a maximum body size of maxBytes on the request.
+// It returns false for requests whose Content-Length exceeds the limit, which
+// should be rejected. Streamed requests, whose size is not known up front,
+// have their body cut off after maxBytes instead and truncated is called
+// when that happens.
+func LimitRequestBody(r *http.Request, maxBytes int64, truncated func()) bool {
+	if r.ContentLength > maxBytes {
+		return false
+	}
+	if r.ContentLength < 0 && r.Body != nil {
+		r.Body = &truncatingReader{
+			ReadCloser: r.Body,
+			remaining:  maxBytes,
+			truncated:  truncated,
+		}
+	}
+	return true
+}
+
+// truncatingReader reads at most remaining bytes from the wrapped body.
+type truncatingReader struct {
+	io.ReadCloser
+	remaining int64
+	truncated func()
+	once      sync.Once
+}
+
+func (t *truncatingReader) Read(p []byte) (int, error) {
+	if t.remaining <= 0 {
+		// Check whether the body had more to give before reporting it as
+		// truncated.
+		var b [1]byte
+		if n, _ := t.ReadCloser.Read(b[:]); n > 0 {
+			t.once.Do(t.truncated)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > t.remaining {
+		p = p[:t.remaining]
+	}
+	n, err := t.ReadCloser.Read(p)
+	t.remaining -= int64(n)
+	return n, err
+}
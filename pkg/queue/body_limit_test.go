@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitRequestBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		wantAccepted  bool
+		wantBody      string
+		wantTruncated bool
+	}{{
+		name:          "within limit",
+		body:          "hello",
+		contentLength: 5,
+		wantAccepted:  true,
+		wantBody:      "hello",
+	}, {
+		name:          "at the limit",
+		body:          "helloworld",
+		contentLength: 10,
+		wantAccepted:  true,
+		wantBody:      "helloworld",
+	}, {
+		name:          "over the limit",
+		body:          "hello world!",
+		contentLength: 12,
+	}, {
+		name:          "streamed within limit",
+		body:          "helloworld",
+		contentLength: -1,
+		wantAccepted:  true,
+		wantBody:      "helloworld",
+	}, {
+		name:          "streamed over the limit",
+		body:          "hello world!",
+		contentLength: -1,
+		wantAccepted:  true,
+		wantBody:      "hello worl",
+		wantTruncated: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Hide the reader type so that the length is not inferred.
+			r := httptest.NewRequest("POST", "http://example.com", ioutil.NopCloser(strings.NewReader(test.body)))
+			r.ContentLength = test.contentLength
+
+			truncations := 0
+			accepted := LimitRequestBody(r, 10, func() { truncations++ })
+			if accepted != test.wantAccepted {
+				t.Fatalf("LimitRequestBody() = %v, want %v", accepted, test.wantAccepted)
+			}
+			if !accepted {
+				return
+			}
+
+			var got bytes.Buffer
+			if _, err := got.ReadFrom(r.Body); err != nil {
+				t.Fatalf("Reading the body = %v", err)
+			}
+			// Reading past the end does not report the truncation again.
+			r.Body.Read(make([]byte, 1))
+			if got.String() != test.wantBody {
+				t.Errorf("Body = %q, want %q", got.String(), test.wantBody)
+			}
+			if want := map[bool]int{true: 1, false: 0}[test.wantTruncated]; truncations != want {
+				t.Errorf("Truncations = %d, want %d", truncations, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is the size of the buffers httputil.ReverseProxy
+// allocates to copy response bodies without a buffer pool.
+const DefaultBufferSize = 32 * 1024
+
+// BufferPool is a httputil.BufferPool that counts how often it has to
+// allocate a buffer. A pool missing often is thrashing, e.g. because its
+// buffers are dropped for being too small for the actual payloads.
+type BufferPool struct {
+	size int
+	pool sync.Pool
+
+	hits       int64
+	misses     int64
+	allocBytes int64
+}
+
+// NewBufferPool creates a BufferPool of buffers of the given size.
+func NewBufferPool(size int) *BufferPool {
+	return &BufferPool{size: size}
+}
+
+// Get returns a buffer from the pool, allocating one if the pool is empty.
+func (p *BufferPool) Get() []byte {
+	// The pool holds pointers so that putting buffers back does not allocate.
+	if b, ok := p.pool.Get().(*[]byte); ok {
+		atomic.AddInt64(&p.hits, 1)
+		return *b
+	}
+	atomic.AddInt64(&p.misses, 1)
+	atomic.AddInt64(&p.allocBytes, int64(p.size))
+	return make([]byte, p.size)
+}
+
+// Put returns a buffer to the pool. Buffers smaller than the pool size are
+// dropped.
+func (p *BufferPool) Put(b []byte) {
+	if cap(b) < p.size {
+		return
+	}
+	b = b[:p.size]
+	p.pool.Put(&b)
+}
+
+// Report returns the number of hits and misses of the pool since the previous
+// call, and the mean size of the buffers allocated for the misses, and resets
+// them.
+func (p *BufferPool) Report() (hits, misses int64, meanAllocBytes float64) {
+	hits = atomic.SwapInt64(&p.hits, 0)
+	misses = atomic.SwapInt64(&p.misses, 0)
+	if allocBytes := atomic.SwapInt64(&p.allocBytes, 0); misses > 0 {
+		meanAllocBytes = float64(allocBytes) / float64(misses)
+	}
+	return hits, misses, meanAllocBytes
+}
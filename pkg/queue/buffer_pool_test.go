@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	p := NewBufferPool(1024)
+
+	b := p.Get()
+	if len(b) != 1024 {
+		t.Errorf("len(Get()) = %d, want 1024", len(b))
+	}
+	p.Put(b)
+	// Buffers too small for the pool are dropped.
+	p.Put(make([]byte, 10))
+
+	if hits, misses, mean := p.Report(); hits != 0 || misses != 1 || mean != 1024 {
+		t.Errorf("Report() = %d, %d, %v, want a single miss of 1024 bytes", hits, misses, mean)
+	}
+
+	if hits, misses, mean := p.Report(); hits != 0 || misses != 0 || mean != 0 {
+		t.Errorf("Report() = %d, %d, %v after reset, want zeros", hits, misses, mean)
+	}
+
+	// sync.Pool may drop buffers at any time, so only the total is known.
+	for i := 0; i < 10; i++ {
+		p.Put(p.Get())
+	}
+	if hits, misses, _ := p.Report(); hits+misses != 10 {
+		t.Errorf("Report() hits = %d, misses = %d, want 10 gets", hits, misses)
+	}
+	if len(p.Get()) != 1024 {
+		t.Error("Get() returned a buffer of the wrong size")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+)
+
+// The phases of the response in which a client gave up on a request.
+const (
+	ResponsePhaseHeadersSent    = "headers_sent"
+	ResponsePhaseHeadersNotSent = "headers_not_sent"
+)
+
+// IsClientTimeout returns whether err, the error of a request's context or of
+// a write of its response, shows that the client gave up on the request.
+// Clients timing out close or reset their connection, which cancels the
+// context of the request. A context whose deadline was exceeded is not
+// reported, as that deadline is the revision timeout rather than the
+// client's.
+func IsClientTimeout(err error) bool {
+	if err == context.Canceled {
+		return true
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	return err == syscall.ECONNRESET || err == syscall.EPIPE
+}
+
+// ResponsePhase returns the phase of the response given whether its headers
+// were sent.
+func ResponsePhase(headersSent bool) string {
+	if headersSent {
+		return ResponsePhaseHeadersSent
+	}
+	return ResponsePhaseHeadersNotSent
+}
+
+// ClientTimeoutHandler detects the requests whose client gave up on them. It
+// must wrap the TimeoutHandler, which buffers the response and so never sees
+// the errors of writing it to the client's connection.
+type ClientTimeoutHandler struct {
+	Next http.Handler
+	// TimedOut is called with the response phase of each request whose
+	// client gave up on it.
+	TimedOut func(phase string)
+}
+
+func (h *ClientTimeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ew := &errorWriter{ResponseWriter: w, ctx: r.Context()}
+	h.Next.ServeHTTP(ew, r)
+
+	err := r.Context().Err()
+	if err == nil {
+		err = ew.err
+	}
+	if IsClientTimeout(err) {
+		h.TimedOut(ResponsePhase(ew.headersSent))
+	}
+}
+
+// errorWriter records whether the headers of the response were written before
+// the client went away, and the first error writing its body.
+type errorWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+	headersSent bool
+	err         error
+}
+
+func (w *errorWriter) writingHeader() {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.headersSent = w.ctx.Err() == nil
+	}
+}
+
+func (w *errorWriter) WriteHeader(statusCode int) {
+	w.writingHeader()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *errorWriter) Write(b []byte) (int, error) {
+	w.writingHeader()
+	n, err := w.ResponseWriter.Write(b)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
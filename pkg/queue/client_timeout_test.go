@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsClientTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "no error",
+	}, {
+		name: "canceled",
+		err:  context.Canceled,
+		want: true,
+	}, {
+		name: "revision timeout",
+		err:  context.DeadlineExceeded,
+	}, {
+		name: "connection reset",
+		err: &net.OpError{
+			Op:  "write",
+			Net: "tcp",
+			Err: os.NewSyscallError("write", syscall.ECONNRESET),
+		},
+		want: true,
+	}, {
+		name: "broken pipe",
+		err:  os.NewSyscallError("write", syscall.EPIPE),
+		want: true,
+	}, {
+		name: "other error",
+		err:  errors.New("boom"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsClientTimeout(test.err); got != test.want {
+				t.Errorf("IsClientTimeout(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestResponsePhase(t *testing.T) {
+	if got := ResponsePhase(true); got != ResponsePhaseHeadersSent {
+		t.Errorf("ResponsePhase(true) = %q, want %q", got, ResponsePhaseHeadersSent)
+	}
+	if got := ResponsePhase(false); got != ResponsePhaseHeadersNotSent {
+		t.Errorf("ResponsePhase(false) = %q, want %q", got, ResponsePhaseHeadersNotSent)
+	}
+}
+
+// brokenPipeWriter fails every write like the connection of a client that
+// went away.
+type brokenPipeWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *brokenPipeWriter) Write(b []byte) (int, error) {
+	return 0, &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}
+}
+
+func TestClientTimeoutHandler(t *testing.T) {
+	respond := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("response"))
+	})
+	hang := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+	tests := []struct {
+		name    string
+		next    http.Handler
+		timeout time.Duration
+		writer  http.ResponseWriter
+		cancel  bool
+		want    []string
+	}{{
+		name:    "served",
+		next:    respond,
+		timeout: time.Second,
+		writer:  httptest.NewRecorder(),
+	}, {
+		name:    "broken pipe",
+		next:    respond,
+		timeout: time.Second,
+		writer:  &brokenPipeWriter{httptest.NewRecorder()},
+		want:    []string{ResponsePhaseHeadersSent},
+	}, {
+		name:    "canceled",
+		next:    hang,
+		timeout: time.Second,
+		writer:  httptest.NewRecorder(),
+		cancel:  true,
+		want:    []string{ResponsePhaseHeadersNotSent},
+	}, {
+		name:    "revision timeout",
+		next:    hang,
+		timeout: time.Millisecond,
+		writer:  httptest.NewRecorder(),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			// The TimeoutHandler buffers the response, so the errors of
+			// writing it only show outside of it.
+			h := &ClientTimeoutHandler{
+				Next:     http.TimeoutHandler(test.next, test.timeout, "timeout"),
+				TimedOut: func(phase string) { got = append(got, phase) },
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancel {
+				cancel()
+			}
+			h.ServeHTTP(test.writer, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("TimedOut phases = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// ConnectionReuse counts how many of the requests proxied to the user
+// container reuse a keep-alive connection and how many need a new one. The
+// reuse ratio drops when the user container closes its keep-alive connections
+// prematurely.
+type ConnectionReuse struct {
+	reused   int64
+	newConns int64
+}
+
+// Transport wraps the given http.RoundTripper to count the connections its
+// requests get.
+func (c *ConnectionReuse) Transport(rt http.RoundTripper) http.RoundTripper {
+	return &connectionReuseTransport{counts: c, transport: rt}
+}
+
+type connectionReuseTransport struct {
+	counts    *ConnectionReuse
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *connectionReuseTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddInt64(&t.counts.reused, 1)
+			} else {
+				atomic.AddInt64(&t.counts.newConns, 1)
+			}
+		},
+	}
+	return t.transport.RoundTrip(r.WithContext(httptrace.WithClientTrace(r.Context(), trace)))
+}
+
+// Report returns the number of reused and new connections since the previous
+// call, and resets them.
+func (c *ConnectionReuse) Report() (reused, newConns int64) {
+	return atomic.SwapInt64(&c.reused, 0), atomic.SwapInt64(&c.newConns, 0)
+}
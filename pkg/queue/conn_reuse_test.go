@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnectionReuse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Close") != "" {
+			w.Header().Set("Connection", "close")
+		}
+	}))
+	defer server.Close()
+
+	c := &ConnectionReuse{}
+	client := &http.Client{Transport: c.Transport(&http.Transport{})}
+	get := func(close bool) {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatalf("NewRequest() = %v", err)
+		}
+		if close {
+			req.Header.Set("Close", "true")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() = %v", err)
+		}
+		// Drain the body so that the connection can be reused.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	get(false)
+	get(false)
+	get(true)
+	get(false)
+	if reused, newConns := c.Report(); reused != 2 || newConns != 2 {
+		t.Errorf("Report() = %d, %d, want 2 reused and 2 new connections", reused, newConns)
+	}
+	if reused, newConns := c.Report(); reused != 0 || newConns != 0 {
+		t.Errorf("Report() = %d, %d after reset, want zeros", reused, newConns)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// DrainTracker tracks the requests in flight once the pod starts draining,
+// to show how long pods take to drain and help tune the termination grace
+// period of revisions.
+type DrainTracker struct {
+	// InFlight is called with the number of requests in flight whenever it
+	// changes while draining.
+	InFlight func(count int)
+	// Drained is called once no requests are in flight anymore, with the
+	// time since the drain started.
+	Drained func(latency time.Duration)
+
+	now func() time.Time
+
+	mux      sync.Mutex
+	inFlight int
+	draining bool
+	drained  bool
+	start    time.Time
+}
+
+// NewDrainTracker creates a DrainTracker calling the given functions.
+func NewDrainTracker(inFlight func(int), drained func(time.Duration)) *DrainTracker {
+	return &DrainTracker{
+		InFlight: inFlight,
+		Drained:  drained,
+		now:      time.Now,
+	}
+}
+
+// RequestStarted records a request starting.
+func (d *DrainTracker) RequestStarted() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.inFlight++
+	if d.draining {
+		d.InFlight(d.inFlight)
+	}
+}
+
+// RequestDone records a request completing.
+func (d *DrainTracker) RequestDone() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.inFlight--
+	if d.draining {
+		d.InFlight(d.inFlight)
+		d.checkDrained()
+	}
+}
+
+// StartDrain records the start of the drain. Only the first call counts.
+func (d *DrainTracker) StartDrain() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.draining {
+		return
+	}
+	d.draining = true
+	d.start = d.now()
+	d.InFlight(d.inFlight)
+	d.checkDrained()
+}
+
+func (d *DrainTracker) checkDrained() {
+	if d.inFlight == 0 && !d.drained {
+		d.drained = true
+		d.Drained(d.now().Sub(d.start))
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDrainTracker(t *testing.T) {
+	var counts []int
+	var drained []time.Duration
+	d := NewDrainTracker(
+		func(count int) { counts = append(counts, count) },
+		func(latency time.Duration) { drained = append(drained, latency) })
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	// Nothing is reported before the drain starts.
+	d.RequestStarted()
+	d.RequestStarted()
+	d.RequestStarted()
+	d.RequestDone()
+
+	d.StartDrain()
+	now = now.Add(time.Second)
+	d.RequestDone()
+	// A request accepted during the drain delays its completion.
+	d.RequestStarted()
+	d.StartDrain()
+	now = now.Add(2 * time.Second)
+	d.RequestDone()
+	d.RequestDone()
+
+	if diff := cmp.Diff([]int{2, 1, 2, 1, 0}, counts); diff != "" {
+		t.Errorf("In flight (-want, +got) = %v", diff)
+	}
+	if diff := cmp.Diff([]time.Duration{3 * time.Second}, drained); diff != "" {
+		t.Errorf("Drained (-want, +got) = %v", diff)
+	}
+}
+
+func TestDrainTrackerIdle(t *testing.T) {
+	var drained []time.Duration
+	d := NewDrainTracker(func(int) {}, func(latency time.Duration) { drained = append(drained, latency) })
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	d.StartDrain()
+	if diff := cmp.Diff([]time.Duration{0}, drained); diff != "" {
+		t.Errorf("Drained (-want, +got) = %v", diff)
+	}
+}
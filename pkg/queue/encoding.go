@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The encodings reported by EncodingNegotiation. Any other encoding is
+// reported as EncodingOther, to bound the cardinality of the tags.
+const (
+	EncodingBrotli   = "br"
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingIdentity = "identity"
+	EncodingOther    = "other"
+)
+
+// EncodingNegotiation compares the encoding the client prefers in the
+// Accept-Encoding header of the request with the Content-Encoding of the
+// response. It returns both, and whether the response is not encoded as the
+// client prefers. Clients without a preference, and responses without a body,
+// never fail the negotiation.
+func EncodingNegotiation(r *http.Request, statusCode int, respHeader http.Header) (requested, actual string, failed bool) {
+	if r.Method == http.MethodHead || statusCode < 200 || statusCode >= 300 || statusCode == http.StatusNoContent {
+		return "", "", false
+	}
+	requested = preferredEncoding(r.Header.Get("Accept-Encoding"))
+	if requested == "" {
+		return "", "", false
+	}
+	actual = normalizeEncoding(respHeader.Get("Content-Encoding"))
+	return requested, actual, requested != actual
+}
+
+// preferredEncoding returns the encoding of the Accept-Encoding header with
+// the highest quality, the first one listed on ties, or "" if the client
+// has no preference or accepts any encoding.
+func preferredEncoding(acceptEncoding string) string {
+	var preferred string
+	best := 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.TrimSpace(fields[0])
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v := strings.TrimSpace(param); strings.HasPrefix(v, "q=") {
+				if f, err := strconv.ParseFloat(v[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q > best {
+			preferred, best = coding, q
+		}
+	}
+	if preferred == "" || preferred == "*" {
+		return ""
+	}
+	return normalizeEncoding(preferred)
+}
+
+func normalizeEncoding(coding string) string {
+	switch c := strings.ToLower(strings.TrimSpace(coding)); c {
+	case "", EncodingIdentity:
+		return EncodingIdentity
+	case EncodingBrotli, EncodingGzip, EncodingDeflate:
+		return c
+	case "x-gzip":
+		return EncodingGzip
+	}
+	return EncodingOther
+}
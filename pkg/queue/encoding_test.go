@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEncodingNegotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		acceptEncoding  string
+		statusCode      int
+		contentEncoding string
+		wantRequested   string
+		wantActual      string
+		wantFailed      bool
+	}{{
+		name:           "brotli not supported",
+		acceptEncoding: "br, gzip",
+		statusCode:     http.StatusOK,
+		// The response is not encoded at all.
+		wantRequested: EncodingBrotli,
+		wantActual:    EncodingIdentity,
+		wantFailed:    true,
+	}, {
+		name:            "fallback to gzip",
+		acceptEncoding:  "gzip;q=0.5, br;q=1.0",
+		statusCode:      http.StatusOK,
+		contentEncoding: "gzip",
+		wantRequested:   EncodingBrotli,
+		wantActual:      EncodingGzip,
+		wantFailed:      true,
+	}, {
+		name:            "preference met",
+		acceptEncoding:  "GZIP, deflate",
+		statusCode:      http.StatusOK,
+		contentEncoding: "gzip",
+		wantRequested:   EncodingGzip,
+		wantActual:      EncodingGzip,
+	}, {
+		name:            "unknown encoding",
+		acceptEncoding:  "zstd",
+		statusCode:      http.StatusOK,
+		contentEncoding: "gzip",
+		wantRequested:   EncodingOther,
+		wantActual:      EncodingGzip,
+		wantFailed:      true,
+	}, {
+		name:            "refused encoding",
+		acceptEncoding:  "br;q=0, identity",
+		statusCode:      http.StatusOK,
+		contentEncoding: "",
+		wantRequested:   EncodingIdentity,
+		wantActual:      EncodingIdentity,
+	}, {
+		name:           "any encoding",
+		acceptEncoding: "*",
+		statusCode:     http.StatusOK,
+	}, {
+		name:       "no preference",
+		statusCode: http.StatusOK,
+	}, {
+		name:           "no body",
+		acceptEncoding: "br",
+		statusCode:     http.StatusNotModified,
+	}, {
+		name:           "head request",
+		method:         http.MethodHead,
+		acceptEncoding: "br",
+		statusCode:     http.StatusOK,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "http://example.com", nil)
+			if test.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			h := http.Header{}
+			if test.contentEncoding != "" {
+				h.Set("Content-Encoding", test.contentEncoding)
+			}
+			requested, actual, failed := EncodingNegotiation(r, test.statusCode, h)
+			if requested != test.wantRequested || actual != test.wantActual || failed != test.wantFailed {
+				t.Errorf("EncodingNegotiation() = %q, %q, %v, want %q, %q, %v",
+					requested, actual, failed, test.wantRequested, test.wantActual, test.wantFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// GRPCStreamClient is the direction of streams of client messages.
+	GRPCStreamClient = "client"
+	// GRPCStreamServer is the direction of streams of server messages.
+	GRPCStreamServer = "server"
+	// GRPCStreamBidi is the direction of streams of client and server
+	// messages.
+	GRPCStreamBidi = "bidi"
+
+	// grpcFrameHeaderSize is the size of the compressed flag and the
+	// message length prefixing each gRPC message.
+	grpcFrameHeaderSize = 5
+)
+
+// IsGRPC returns whether the request is a gRPC call.
+func IsGRPC(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// GRPCStreamDirection returns the direction of a gRPC call that sent the
+// given number of client and server messages. Calls of at most one message
+// each way look like unary calls and have no direction.
+func GRPCStreamDirection(clientMessages, serverMessages int64) (string, bool) {
+	switch {
+	case clientMessages > 1 && serverMessages > 1:
+		return GRPCStreamBidi, true
+	case clientMessages > 1:
+		return GRPCStreamClient, true
+	case serverMessages > 1:
+		return GRPCStreamServer, true
+	}
+	return "", false
+}
+
+// GRPCStreamHandler measures the duration of the gRPC streams it serves. The
+// direction of a stream is inferred from the number of messages sent each
+// way, as the HTTP/2 stream does not tell.
+type GRPCStreamHandler struct {
+	Next http.Handler
+	// Closed is called with the direction and the duration of each stream.
+	Closed func(direction string, duration time.Duration)
+}
+
+func (h *GRPCStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !IsGRPC(r) {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+	client := &grpcMessageCounter{}
+	server := &grpcMessageCounter{}
+	if r.Body != nil {
+		r.Body = &grpcCountingBody{ReadCloser: r.Body, counter: client}
+	}
+	h.Next.ServeHTTP(&grpcCountingWriter{ResponseWriter: w, counter: server}, r)
+
+	if direction, ok := GRPCStreamDirection(client.messages(), server.messages()); ok {
+		h.Closed(direction, time.Since(start))
+	}
+}
+
+// grpcMessageCounter counts the length-prefixed gRPC messages in a byte
+// stream, which may split them at any point.
+type grpcMessageCounter struct {
+	count     int64
+	header    [grpcFrameHeaderSize]byte
+	headerLen int
+	remaining uint32
+}
+
+func (c *grpcMessageCounter) write(b []byte) {
+	for len(b) > 0 {
+		if c.remaining > 0 {
+			n := uint32(len(b))
+			if n > c.remaining {
+				n = c.remaining
+			}
+			b = b[n:]
+			c.remaining -= n
+			continue
+		}
+		n := copy(c.header[c.headerLen:], b)
+		b = b[n:]
+		c.headerLen += n
+		if c.headerLen == grpcFrameHeaderSize {
+			atomic.AddInt64(&c.count, 1)
+			c.remaining = binary.BigEndian.Uint32(c.header[1:])
+			c.headerLen = 0
+		}
+	}
+}
+
+func (c *grpcMessageCounter) messages() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+// grpcCountingBody counts the messages read from a request body.
+type grpcCountingBody struct {
+	io.ReadCloser
+	counter *grpcMessageCounter
+}
+
+func (b *grpcCountingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.counter.write(p[:n])
+	return n, err
+}
+
+// grpcCountingWriter counts the messages written to a response. It flushes
+// like the writer it wraps, as streams need their messages sent right away.
+type grpcCountingWriter struct {
+	http.ResponseWriter
+	counter *grpcMessageCounter
+}
+
+func (w *grpcCountingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.counter.write(b[:n])
+	return n, err
+}
+
+func (w *grpcCountingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
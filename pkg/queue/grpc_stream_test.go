@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// grpcMessages returns the given messages framed like gRPC does.
+func grpcMessages(messages ...string) []byte {
+	var b bytes.Buffer
+	for _, m := range messages {
+		header := make([]byte, grpcFrameHeaderSize)
+		binary.BigEndian.PutUint32(header[1:], uint32(len(m)))
+		b.Write(header)
+		b.WriteString(m)
+	}
+	return b.Bytes()
+}
+
+func TestGRPCMessageCounter(t *testing.T) {
+	b := grpcMessages("hello", "", "world")
+	// Messages and their headers may be split at any point.
+	for split := 0; split <= len(b); split++ {
+		c := &grpcMessageCounter{}
+		c.write(b[:split])
+		c.write(b[split:])
+		if got := c.messages(); got != 3 {
+			t.Errorf("messages() = %d with a split at %d, want 3", got, split)
+		}
+	}
+}
+
+func TestGRPCStreamDirection(t *testing.T) {
+	tests := []struct {
+		client, server int64
+		want           string
+		wantOK         bool
+	}{
+		{1, 1, "", false},
+		{0, 0, "", false},
+		{5, 1, GRPCStreamClient, true},
+		{1, 5, GRPCStreamServer, true},
+		{5, 5, GRPCStreamBidi, true},
+	}
+	for _, test := range tests {
+		if got, ok := GRPCStreamDirection(test.client, test.server); got != test.want || ok != test.wantOK {
+			t.Errorf("GRPCStreamDirection(%d, %d) = %q, %v, want %q, %v", test.client, test.server, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestGRPCStreamHandler(t *testing.T) {
+	var closed []string
+	h := &GRPCStreamHandler{
+		Next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(ioutil.Discard, r.Body)
+			w.Write(grpcMessages("one", "two"))
+			w.(http.Flusher).Flush()
+			w.Write(grpcMessages("three"))
+		}),
+		Closed: func(direction string, duration time.Duration) {
+			closed = append(closed, direction)
+		},
+	}
+
+	for _, contentType := range []string{"application/grpc", "application/grpc+proto", "application/json"} {
+		req := httptest.NewRequest("POST", "http://example.com", bytes.NewReader(grpcMessages("request")))
+		req.Header.Set("Content-Type", contentType)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(closed) != 2 || closed[0] != GRPCStreamServer || closed[1] != GRPCStreamServer {
+		t.Errorf("Closed streams = %v, want two server streams", closed)
+	}
+}
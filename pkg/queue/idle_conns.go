@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// IdleConnections tracks the keep-alive connections to the user container
+// that sit idle in the transport's pool, and counts those closed while idle,
+// by the idle connection timeout, CloseIdleConnections or the user container.
+// Idle connections piling up while few of them time out point to a leak in
+// the connection pool.
+type IdleConnections struct {
+	idle     int64
+	timeouts int64
+}
+
+// Transport returns a transport with the settings of http.DefaultTransport
+// whose connections are tracked. HTTP/2 connections are multiplexed and never
+// idle in the same way, so only HTTP/1 is supported.
+func (c *IdleConnections) Transport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	dial := t.DialContext
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return conn, err
+		}
+		return &idleTrackedConn{Conn: conn, counts: c}, nil
+	}
+	return &idleConnectionsTransport{transport: t}
+}
+
+// Report returns the number of idle connections and the number of
+// connections closed while idle since the previous call, which it resets.
+func (c *IdleConnections) Report() (idle, timeouts int64) {
+	return atomic.LoadInt64(&c.idle), atomic.SwapInt64(&c.timeouts, 0)
+}
+
+type idleConnectionsTransport struct {
+	transport *http.Transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *idleConnectionsTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// The transport reports the connection it returns to the pool through
+	// the trace of the request that used it last.
+	var conn *idleTrackedConn
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if c, ok := info.Conn.(*idleTrackedConn); ok {
+				conn = c
+				conn.setIdle(false)
+			}
+		},
+		PutIdleConn: func(err error) {
+			if err == nil && conn != nil {
+				conn.setIdle(true)
+			}
+		},
+	}
+	return t.transport.RoundTrip(r.WithContext(httptrace.WithClientTrace(r.Context(), trace)))
+}
+
+// CloseIdleConnections closes the idle connections of the transport, which
+// are counted as timed out.
+func (t *idleConnectionsTransport) CloseIdleConnections() {
+	t.transport.CloseIdleConnections()
+}
+
+const (
+	connActive int32 = iota
+	connIdle
+	connClosed
+)
+
+// idleTrackedConn keeps the idle connection count of its IdleConnections up
+// to date.
+type idleTrackedConn struct {
+	net.Conn
+	counts *IdleConnections
+	state  int32
+}
+
+func (c *idleTrackedConn) setIdle(idle bool) {
+	from, to, delta := connActive, connIdle, int64(1)
+	if !idle {
+		from, to, delta = connIdle, connActive, -1
+	}
+	if atomic.CompareAndSwapInt32(&c.state, from, to) {
+		atomic.AddInt64(&c.counts.idle, delta)
+	}
+}
+
+func (c *idleTrackedConn) Close() error {
+	if atomic.SwapInt32(&c.state, connClosed) == connIdle {
+		atomic.AddInt64(&c.counts.idle, -1)
+		atomic.AddInt64(&c.counts.timeouts, 1)
+	}
+	return c.Conn.Close()
+}
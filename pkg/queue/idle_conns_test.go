@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdleConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := &IdleConnections{}
+	transport := c.Transport()
+	client := &http.Client{Transport: transport}
+	get := func() {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() = %v", err)
+		}
+		// Drain the body so that the connection goes back to the pool.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	// The transport returns the connection to the pool asynchronously.
+	waitForReport := func(wantIdle, wantTimeouts int64) {
+		t.Helper()
+		var idle, timeouts int64
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			if idle, timeouts = c.Report(); idle == wantIdle && timeouts == wantTimeouts {
+				return
+			}
+		}
+		t.Errorf("Report() = %d, %d, want %d idle and %d timeouts", idle, timeouts, wantIdle, wantTimeouts)
+	}
+
+	get()
+	waitForReport(1, 0)
+	get()
+	waitForReport(1, 0)
+
+	transport.(interface{ CloseIdleConnections() }).CloseIdleConnections()
+	waitForReport(0, 1)
+	waitForReport(0, 0)
+}
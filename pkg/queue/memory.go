@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The files in which the kernel reports the memory usage and limit of the
+// container's memory cgroup, for cgroups v2 and v1. Only the cgroup of the
+// queue-proxy container itself is mounted there, not those of the user
+// container or of the pod.
+var cgroupMemoryFiles = []cgroupMemory{{
+	usage: "/sys/fs/cgroup/memory.current",
+	limit: "/sys/fs/cgroup/memory.max",
+}, {
+	usage: "/sys/fs/cgroup/memory/memory.usage_in_bytes",
+	limit: "/sys/fs/cgroup/memory/memory.limit_in_bytes",
+}}
+
+// cgroupsV1Unlimited is the smallest limit cgroups v1 reports for a memory
+// cgroup without a limit, which is the maximum int64 rounded down to the
+// page size.
+const cgroupsV1Unlimited = 1 << 62
+
+type cgroupMemory struct {
+	usage string
+	limit string
+}
+
+// CgroupsMemoryReader reads the memory usage and limit of the memory cgroup
+// of the queue-proxy container.
+type CgroupsMemoryReader struct {
+	files cgroupMemory
+}
+
+// NewCgroupsMemoryReader creates a CgroupsMemoryReader for the cgroups
+// version in use. It fails when neither version exposes the memory usage.
+func NewCgroupsMemoryReader() (*CgroupsMemoryReader, error) {
+	return newCgroupsMemoryReader(cgroupMemoryFiles)
+}
+
+func newCgroupsMemoryReader(files []cgroupMemory) (*CgroupsMemoryReader, error) {
+	for _, f := range files {
+		_, err := readCgroupValue(f.usage)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return &CgroupsMemoryReader{files: f}, nil
+	}
+	return nil, fmt.Errorf("no cgroups memory usage found in %v", files)
+}
+
+// Read returns the memory usage and limit of the memory cgroup in bytes. The
+// limit is 0 if the cgroup has none.
+func (r *CgroupsMemoryReader) Read() (usage, limit int64, err error) {
+	if usage, err = readCgroupValue(r.files.usage); err != nil {
+		return 0, 0, err
+	}
+	if limit, err = readCgroupValue(r.files.limit); err != nil {
+		return 0, 0, err
+	}
+	if limit >= cgroupsV1Unlimited {
+		limit = 0
+	}
+	return usage, limit, nil
+}
+
+// readCgroupValue reads a cgroup file holding a single number of bytes, or
+// "max" for no limit, which is returned as 0.
+func readCgroupValue(path string) (int64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	v := strings.TrimSpace(string(b))
+	if v == "max" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
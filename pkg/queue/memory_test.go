@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupsMemoryReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	v2 := cgroupMemory{
+		usage: write("memory.current", "1048576\n"),
+		limit: write("memory.max", "max\n"),
+	}
+	v1 := cgroupMemory{
+		usage: write("memory.usage_in_bytes", "2097152\n"),
+		limit: write("memory.limit_in_bytes", "9223372036854771712\n"),
+	}
+	missing := cgroupMemory{
+		usage: filepath.Join(dir, "missing"),
+		limit: filepath.Join(dir, "missing"),
+	}
+
+	tests := []struct {
+		name      string
+		files     []cgroupMemory
+		limit     string
+		wantUsage int64
+		wantLimit int64
+	}{{
+		name:      "cgroups v2 without limit",
+		files:     []cgroupMemory{v2, v1},
+		wantUsage: 1048576,
+	}, {
+		name:      "cgroups v2 with limit",
+		files:     []cgroupMemory{v2, v1},
+		limit:     "536870912\n",
+		wantUsage: 1048576,
+		wantLimit: 536870912,
+	}, {
+		name:      "cgroups v1 without limit",
+		files:     []cgroupMemory{missing, v1},
+		wantUsage: 2097152,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			write("memory.max", "max\n")
+			if test.limit != "" {
+				write("memory.max", test.limit)
+			}
+			r, err := newCgroupsMemoryReader(test.files)
+			if err != nil {
+				t.Fatalf("newCgroupsMemoryReader() = %v", err)
+			}
+			usage, limit, err := r.Read()
+			if err != nil {
+				t.Fatalf("Read() = %v", err)
+			}
+			if usage != test.wantUsage || limit != test.wantLimit {
+				t.Errorf("Read() = %d, %d, want %d, %d", usage, limit, test.wantUsage, test.wantLimit)
+			}
+		})
+	}
+
+	if _, err := newCgroupsMemoryReader([]cgroupMemory{missing}); err == nil {
+		t.Error("newCgroupsMemoryReader() = nil, want an error without memory usage")
+	}
+}
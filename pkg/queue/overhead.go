@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/stats/view"
+)
+
+// ObservabilityTimer accumulates the time the queue-proxy spends recording,
+// exporting and serving metrics, which stands for the CPU consumed by
+// observability.
+type ObservabilityTimer struct {
+	nanos int64
+}
+
+// Since adds the time elapsed since start to the timer.
+func (t *ObservabilityTimer) Since(start time.Time) {
+	atomic.AddInt64(&t.nanos, int64(time.Since(start)))
+}
+
+// Spent returns the time accumulated so far.
+func (t *ObservabilityTimer) Spent() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.nanos))
+}
+
+// Exporter wraps e to time the views it exports.
+func (t *ObservabilityTimer) Exporter(e view.Exporter) view.Exporter {
+	return &timedExporter{Exporter: e, timer: t}
+}
+
+// Handler wraps h to time the requests it serves, e.g. metric scrapes.
+func (t *ObservabilityTimer) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer t.Since(time.Now())
+		h.ServeHTTP(w, r)
+	})
+}
+
+type timedExporter struct {
+	view.Exporter
+	timer *ObservabilityTimer
+}
+
+// ExportView implements view.Exporter.
+func (e *timedExporter) ExportView(vd *view.Data) {
+	defer e.timer.Since(time.Now())
+	e.Exporter.ExportView(vd)
+}
+
+// HeapBytes returns the bytes of heap in use after a garbage collection, to
+// compare the memory in use before and after metrics are set up.
+func HeapBytes() int64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return int64(ms.HeapAlloc)
+}
+
+// ObservabilityOverhead returns the time spent on observability over elapsed
+// as a percentage of the user container's CPU limit, and the memory set aside
+// for observability as a percentage of its memory limit. A zero limit yields a
+// zero percentage.
+func ObservabilityOverhead(spent, elapsed time.Duration, cpuLimitMillicores int64,
+	memoryBytes, memoryLimitBytes int64) (cpuPercent, memoryPercent float64) {
+	if elapsed > 0 && cpuLimitMillicores > 0 {
+		cores := spent.Seconds() / elapsed.Seconds()
+		cpuPercent = 100 * cores / (float64(cpuLimitMillicores) / 1000)
+	}
+	if memoryBytes > 0 && memoryLimitBytes > 0 {
+		memoryPercent = 100 * float64(memoryBytes) / float64(memoryLimitBytes)
+	}
+	return cpuPercent, memoryPercent
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats/view"
+)
+
+type sleepingExporter struct {
+	exported int
+}
+
+func (e *sleepingExporter) ExportView(*view.Data) {
+	e.exported++
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestObservabilityTimer(t *testing.T) {
+	timer := &ObservabilityTimer{}
+
+	e := &sleepingExporter{}
+	timer.Exporter(e).ExportView(&view.Data{})
+	if e.exported != 1 {
+		t.Errorf("Exported views = %d, want 1", e.exported)
+	}
+	if got := timer.Spent(); got < 10*time.Millisecond {
+		t.Errorf("Spent() = %v after exporting, want at least %v", got, 10*time.Millisecond)
+	}
+
+	h := timer.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := timer.Spent(); got < 20*time.Millisecond {
+		t.Errorf("Spent() = %v after serving, want at least %v", got, 20*time.Millisecond)
+	}
+}
+
+func TestObservabilityOverhead(t *testing.T) {
+	tests := []struct {
+		name        string
+		spent       time.Duration
+		elapsed     time.Duration
+		cpuLimit    int64
+		memory      int64
+		memoryLimit int64
+		wantCPU     float64
+		wantMemory  float64
+	}{{
+		name:        "overhead",
+		spent:       time.Second,
+		elapsed:     10 * time.Second,
+		cpuLimit:    1000,
+		memory:      2 << 20,
+		memoryLimit: 100 << 20,
+		wantCPU:     10,
+		wantMemory:  2,
+	}, {
+		name:    "no limits",
+		spent:   time.Second,
+		elapsed: 10 * time.Second,
+		memory:  2 << 20,
+	}, {
+		name:        "fractional cpu limit",
+		spent:       time.Second,
+		elapsed:     10 * time.Second,
+		cpuLimit:    500,
+		memoryLimit: 100 << 20,
+		wantCPU:     20,
+	}, {
+		name:     "nothing elapsed",
+		spent:    time.Second,
+		cpuLimit: 1000,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotCPU, gotMemory := ObservabilityOverhead(test.spent, test.elapsed, test.cpuLimit, test.memory, test.memoryLimit)
+			if gotCPU != test.wantCPU {
+				t.Errorf("CPU overhead = %v, want %v", gotCPU, test.wantCPU)
+			}
+			if gotMemory != test.wantMemory {
+				t.Errorf("Memory overhead = %v, want %v", gotMemory, test.wantMemory)
+			}
+		})
+	}
+}
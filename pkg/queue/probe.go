@@ -0,0 +1,50 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The probe types and results reported for readiness probes. Only probes made
+// over HTTP reach the queue-proxy's handler; TCP probes are answered by the
+// kernel when the kubelet opens the connection.
+const (
+	ProbeTypeHTTP = "http"
+	ProbeTypeGRPC = "grpc"
+
+	ProbeResultPass = "pass"
+	ProbeResultFail = "fail"
+)
+
+// ProbeType returns the type of the probe request.
+func ProbeType(r *http.Request) string {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		return ProbeTypeGRPC
+	}
+	return ProbeTypeHTTP
+}
+
+// ProbeResult returns the result of a probe answered with the status code,
+// following the kubelet, which treats any code in [200, 400) as success.
+func ProbeResult(statusCode int) string {
+	if statusCode >= http.StatusOK && statusCode < http.StatusBadRequest {
+		return ProbeResultPass
+	}
+	return ProbeResultFail
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbeType(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/healthz", nil)
+	if got := ProbeType(r); got != ProbeTypeHTTP {
+		t.Errorf("ProbeType() = %q, want %q", got, ProbeTypeHTTP)
+	}
+	r.Header.Set("Content-Type", "application/grpc+proto")
+	if got := ProbeType(r); got != ProbeTypeGRPC {
+		t.Errorf("ProbeType() = %q, want %q", got, ProbeTypeGRPC)
+	}
+}
+
+func TestProbeResult(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{{
+		code: http.StatusOK,
+		want: ProbeResultPass,
+	}, {
+		code: http.StatusFound,
+		want: ProbeResultPass,
+	}, {
+		code: http.StatusBadRequest,
+		want: ProbeResultFail,
+	}, {
+		code: http.StatusServiceUnavailable,
+		want: ProbeResultFail,
+	}, {
+		code: http.StatusContinue,
+		want: ProbeResultFail,
+	}}
+
+	for _, test := range tests {
+		if got := ProbeResult(test.code); got != test.want {
+			t.Errorf("ProbeResult(%d) = %q, want %q", test.code, got, test.want)
+		}
+	}
+}
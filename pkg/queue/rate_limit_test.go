@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	b := NewTokenBucket(2, 3, start)
+	for i := 0; i < 3; i++ {
+		if !b.Allow(start) {
+			t.Fatalf("Allow() #%d of the burst = false, want true", i)
+		}
+	}
+	if b.Allow(start) {
+		t.Error("Allow() on an empty bucket = true, want false")
+	}
+
+	// Half a second refills one token at 2 per second.
+	if got := b.Tokens(at(500 * time.Millisecond)); got != 1 {
+		t.Errorf("Tokens() = %v, want 1", got)
+	}
+	if !b.Allow(at(500 * time.Millisecond)) {
+		t.Error("Allow() after a refill = false, want true")
+	}
+
+	// The bucket never holds more than the burst.
+	if got := b.Tokens(at(time.Minute)); got != 3 {
+		t.Errorf("Tokens() = %v, want the burst of 3", got)
+	}
+	// Going back in time does not refill the bucket.
+	if got := b.Tokens(start); got != 3 {
+		t.Errorf("Tokens() = %v, want 3", got)
+	}
+}
+
+func TestTokenBucketMinimumBurst(t *testing.T) {
+	b := NewTokenBucket(1, 0, time.Now())
+	if got := b.Tokens(time.Now()); got != 1 {
+		t.Errorf("Tokens() = %v, want a burst of at least 1", got)
+	}
+}
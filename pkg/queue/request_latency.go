@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// RequestLatenciesN is the name of the latency of the requests served by the
+// queue-proxy.
+const RequestLatenciesN = "request_latencies"
+
+// DefaultRequestLatencyBuckets are the bucket boundaries of the request
+// latency histogram in milliseconds, those of the Prometheus client
+// libraries.
+var DefaultRequestLatencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+var (
+	requestLatenciesM = stats.Float64(
+		RequestLatenciesN,
+		"The latency of the requests served by the queue-proxy in milliseconds",
+		stats.UnitMilliseconds)
+
+	// The tags of the request latency are those of the knative_revision
+	// monitored resource, so that Stackdriver attributes the latencies to
+	// the revision like those of the activator.
+	revisionNamespaceTagKey = mustNewTagKey(metricskey.LabelNamespaceName)
+	revisionConfigTagKey    = mustNewTagKey(metricskey.LabelConfigurationName)
+	revisionNameTagKey      = mustNewTagKey(metricskey.LabelRevisionName)
+	responseCodeClassTagKey = mustNewTagKey("response_code_class")
+)
+
+func mustNewTagKey(name string) tag.Key {
+	k, err := tag.NewKey(name)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// RegisterQueueProxyViews registers the view of the request latency with the
+// given bucket boundaries in milliseconds, or DefaultRequestLatencyBuckets if
+// there are none. It replaces the view registered by a previous call.
+func RegisterQueueProxyViews(buckets []float64) error {
+	if len(buckets) == 0 {
+		buckets = DefaultRequestLatencyBuckets
+	}
+	if v := view.Find(RequestLatenciesN); v != nil {
+		metrics.UnregisterViews(v)
+	}
+	return metrics.RegisterViews(&view.View{
+		Description: "The latency of the requests served by the queue-proxy in milliseconds",
+		Measure:     requestLatenciesM,
+		Aggregation: view.Distribution(buckets...),
+		TagKeys:     []tag.Key{revisionNamespaceTagKey, revisionConfigTagKey, revisionNameTagKey, responseCodeClassTagKey},
+	})
+}
+
+// ReportRequestLatency captures the latency of a request served with the
+// given response code. It is only exported once RegisterQueueProxyViews is
+// called
+func (r *Reporter) ReportRequestLatency(responseCode int, latency time.Duration) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.revisionCtx, tag.Insert(responseCodeClassTagKey, responseCodeClass(responseCode)))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, requestLatenciesM.M(float64(latency/time.Millisecond)))
+	return nil
+}
+
+// responseCodeClass returns the class of the response code, e.g. "5xx" for
+// 503.
+func responseCodeClass(responseCode int) string {
+	return strconv.Itoa((responseCode/100)%10) + "xx"
+}
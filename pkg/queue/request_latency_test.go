@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats/view"
+)
+
+func TestReportRequestLatency(t *testing.T) {
+	reporter, err := NewStatsReporter(namespace, config, revision)
+	if err != nil {
+		t.Fatalf("NewStatsReporter() = %v", err)
+	}
+	defer reporter.UnregisterViews()
+
+	buckets := []float64{100, 1000}
+	if err := RegisterQueueProxyViews(buckets); err != nil {
+		t.Fatalf("RegisterQueueProxyViews() = %v", err)
+	}
+	if err := reporter.ReportRequestLatency(http.StatusServiceUnavailable, 500*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+
+	rows, err := view.RetrieveData(RequestLatenciesN)
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Got %d rows for %s, want 1", len(rows), RequestLatenciesN)
+	}
+	checkTags(t, rows[0].Tags, map[string]string{
+		"namespace_name":      namespace,
+		"configuration_name":  config,
+		"revision_name":       revision,
+		"response_code_class": "5xx",
+	})
+	d := rows[0].Data.(*view.DistributionData)
+	if d.Count != 1 || d.CountPerBucket[1] != 1 {
+		t.Errorf("Distribution = %+v, want the request in the second bucket", d)
+	}
+	if got := view.Find(RequestLatenciesN).Aggregation.Buckets; len(got) != len(buckets) {
+		t.Errorf("Buckets = %v, want %v", got, buckets)
+	}
+
+	// Registering again replaces the view, with the default buckets.
+	if err := RegisterQueueProxyViews(nil); err != nil {
+		t.Fatalf("RegisterQueueProxyViews() = %v", err)
+	}
+	if got := view.Find(RequestLatenciesN).Aggregation.Buckets; len(got) != len(DefaultRequestLatencyBuckets) {
+		t.Errorf("Buckets = %v, want %v", got, DefaultRequestLatencyBuckets)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	// RSTStreamSent is the direction of the RST_STREAM frames sent by the
+	// queue-proxy.
+	RSTStreamSent = "sent"
+	// RSTStreamReceived is the direction of the RST_STREAM frames received
+	// from the client, e.g. the ingress.
+	RSTStreamReceived = "received"
+
+	// RSTStreamOtherCode is the error code reported for the RST_STREAM
+	// frames whose error code is not defined by HTTP/2. Peers may send any
+	// code, which must not each become a tag value.
+	RSTStreamOtherCode = "OTHER"
+
+	// http2FrameHeaderSize is the size of the header of each HTTP/2 frame.
+	http2FrameHeaderSize = 9
+	// rstStreamPayloadSize is the size of the error code of a RST_STREAM.
+	rstStreamPayloadSize = 4
+)
+
+var (
+	// h2cUpgradeStatus and h2cUpgradeHeader are what the h2c handler writes
+	// when it accepts an upgrade to HTTP/2.
+	h2cUpgradeStatus = []byte("HTTP/1.1 101 Switching Protocols\r\n")
+	h2cUpgradeHeader = []byte("\r\nUpgrade: h2c\r\n")
+	headerEnd        = []byte("\r\n\r\n")
+)
+
+// NewRSTStreamListener wraps ln so that reset is called with the direction
+// and the error code, e.g. "CANCEL" or "OTHER", of each RST_STREAM frame sent
+// or received over its h2c connections, with prior knowledge or upgraded. The
+// frames are found on the wire, as the http2.Server we vendor has no hook for
+// the streams it resets.
+func NewRSTStreamListener(ln net.Listener, reset func(direction, code string)) net.Listener {
+	return &rstStreamListener{Listener: ln, reset: reset}
+}
+
+type rstStreamListener struct {
+	net.Listener
+	reset func(direction, code string)
+}
+
+func (l *rstStreamListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &rstStreamConn{Conn: c, reset: l.reset}, nil
+}
+
+// rstStreamConn finds the RST_STREAM frames read and written once the
+// connection speaks HTTP/2.
+type rstStreamConn struct {
+	net.Conn
+	reset func(direction, code string)
+
+	// h2 is set once the client preface was read; the bytes written from
+	// then on are HTTP/2 frames.
+	h2 int32
+	// upgraded is set once an upgrade to h2c was accepted; the client
+	// preface is read next.
+	upgraded int32
+
+	readMu sync.Mutex
+	// prefaceLen is the number of bytes of the client preface read, or -1
+	// if the bytes read are not HTTP/2.
+	prefaceLen int
+	read       *http2FrameScanner
+
+	writeMu sync.Mutex
+	written *http2FrameScanner
+}
+
+func (c *rstStreamConn) newScanner(direction string) *http2FrameScanner {
+	return &http2FrameScanner{reset: func(code http2.ErrCode) {
+		c.reset(direction, rstStreamCode(code))
+	}}
+}
+
+// rstStreamCode returns the name of the HTTP/2 error code, or
+// RSTStreamOtherCode for the codes HTTP/2 does not define.
+func rstStreamCode(code http2.ErrCode) string {
+	if code > http2.ErrCodeHTTP11Required {
+		return RSTStreamOtherCode
+	}
+	return code.String()
+}
+
+func (c *rstStreamConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	c.scanRead(b[:n])
+	return n, err
+}
+
+func (c *rstStreamConn) scanRead(b []byte) {
+	if atomic.CompareAndSwapInt32(&c.upgraded, 1, 0) {
+		c.prefaceLen = 0
+	}
+	if c.read != nil {
+		c.read.write(b)
+		return
+	}
+	if c.prefaceLen < 0 || len(b) == 0 {
+		return
+	}
+	n := len(http2.ClientPreface) - c.prefaceLen
+	if n > len(b) {
+		n = len(b)
+	}
+	if string(b[:n]) != http2.ClientPreface[c.prefaceLen:c.prefaceLen+n] {
+		c.prefaceLen = -1
+		return
+	}
+	c.prefaceLen += n
+	if c.prefaceLen == len(http2.ClientPreface) {
+		c.read = c.newScanner(RSTStreamReceived)
+		atomic.StoreInt32(&c.h2, 1)
+		c.read.write(b[n:])
+	}
+}
+
+func (c *rstStreamConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	frames := 0
+	if c.written == nil {
+		switch {
+		case atomic.LoadInt32(&c.h2) == 1:
+			c.written = c.newScanner(RSTStreamSent)
+		case bytes.HasPrefix(b, h2cUpgradeStatus):
+			end := bytes.Index(b, headerEnd)
+			if end < 0 || !bytes.Contains(b[:end+2], h2cUpgradeHeader) {
+				break
+			}
+			// The client preface must be expected before the client gets
+			// the response and sends it.
+			atomic.StoreInt32(&c.upgraded, 1)
+			c.written = c.newScanner(RSTStreamSent)
+			frames = end + len(headerEnd)
+		}
+	}
+	n, err := c.Conn.Write(b)
+	if c.written != nil && n > frames {
+		c.written.write(b[frames:n])
+	}
+	return n, err
+}
+
+// http2FrameScanner finds the RST_STREAM frames in a stream of HTTP/2
+// frames, which may split them at any point.
+type http2FrameScanner struct {
+	header    [http2FrameHeaderSize]byte
+	headerLen int
+	remaining uint32
+	// isReset is whether the current frame is a RST_STREAM, whose error code
+	// is read into code.
+	isReset bool
+	code    [rstStreamPayloadSize]byte
+	codeLen int
+	reset   func(http2.ErrCode)
+}
+
+func (s *http2FrameScanner) write(b []byte) {
+	for len(b) > 0 {
+		if s.remaining > 0 {
+			n := uint32(len(b))
+			if n > s.remaining {
+				n = s.remaining
+			}
+			if s.isReset {
+				s.codeLen += copy(s.code[s.codeLen:], b[:n])
+			}
+			b = b[n:]
+			s.remaining -= n
+			if s.remaining == 0 && s.isReset {
+				s.reset(http2.ErrCode(binary.BigEndian.Uint32(s.code[:])))
+			}
+			continue
+		}
+		n := copy(s.header[s.headerLen:], b)
+		b = b[n:]
+		s.headerLen += n
+		if s.headerLen == http2FrameHeaderSize {
+			s.headerLen = 0
+			s.remaining = uint32(s.header[0])<<16 | uint32(s.header[1])<<8 | uint32(s.header[2])
+			s.isReset = http2.FrameType(s.header[3]) == http2.FrameRSTStream && s.remaining == rstStreamPayloadSize
+			s.codeLen = 0
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/knative/serving/pkg/http/h2c"
+	"golang.org/x/net/http2"
+)
+
+func TestHTTP2FrameScanner(t *testing.T) {
+	var buf bytes.Buffer
+	fr := http2.NewFramer(&buf, nil)
+	fr.WriteSettings()
+	fr.WriteData(1, false, []byte("hello"))
+	fr.WriteRSTStream(1, http2.ErrCodeCancel)
+	fr.WriteData(3, true, make([]byte, 1000))
+	fr.WriteRSTStream(3, http2.ErrCodeRefusedStream)
+	want := []http2.ErrCode{http2.ErrCodeCancel, http2.ErrCodeRefusedStream}
+
+	for _, chunk := range []int{1, 7, buf.Len()} {
+		var got []http2.ErrCode
+		s := &http2FrameScanner{reset: func(code http2.ErrCode) {
+			got = append(got, code)
+		}}
+		for b := buf.Bytes(); len(b) > 0; {
+			n := chunk
+			if n > len(b) {
+				n = len(b)
+			}
+			s.write(b[:n])
+			b = b[n:]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Resets in chunks of %d = %v, want %v", chunk, got, want)
+		}
+	}
+}
+
+func TestRSTStreamListener(t *testing.T) {
+	resets := make(chan string, 10)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	server := h2c.NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic(http.ErrAbortHandler)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	go server.Serve(NewRSTStreamListener(ln, func(direction, code string) {
+		resets <- direction + "/" + code
+	}))
+	defer server.Close()
+	url := "http://" + ln.Addr().String()
+
+	expectReset := func(want string) {
+		t.Helper()
+		select {
+		case got := <-resets:
+			if got != want {
+				t.Errorf("Reset = %s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for reset %s", want)
+		}
+	}
+
+	// With prior knowledge, the queue-proxy resets the streams whose handler
+	// panics, and the client those it cancels.
+	client := &http.Client{Transport: h2c.DefaultTransport}
+	if resp, err := client.Get(url + "/panic"); err == nil {
+		resp.Body.Close()
+		t.Error("Get(/panic) = nil, want an error")
+	}
+	expectReset(RSTStreamSent + "/INTERNAL_ERROR")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest(http.MethodGet, url+"/block", nil)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Do() = %v", err)
+	}
+	cancel()
+	resp.Body.Close()
+	expectReset(RSTStreamReceived + "/CANCEL")
+
+	// Upgraded to h2c, the frames follow the 101 response.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /panic HTTP/1.1\r\nHost: queue\r\n" +
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n\r\n"))
+	br := bufio.NewReader(conn)
+	upgrade, err := http.ReadResponse(br, nil)
+	if err != nil || upgrade.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("ReadResponse() = %v, %v, want %d", upgrade, err, http.StatusSwitchingProtocols)
+	}
+	conn.Write([]byte(http2.ClientPreface))
+	http2.NewFramer(conn, nil).WriteSettings()
+	expectReset(RSTStreamSent + "/INTERNAL_ERROR")
+	http2.NewFramer(conn, nil).WriteRSTStream(3, http2.ErrCodeCancel)
+	expectReset(RSTStreamReceived + "/CANCEL")
+
+	select {
+	case got := <-resets:
+		t.Errorf("Unexpected reset %s", got)
+	default:
+	}
+}
+
+func TestRSTStreamCode(t *testing.T) {
+	tests := []struct {
+		code http2.ErrCode
+		want string
+	}{
+		{http2.ErrCodeNo, "NO_ERROR"},
+		{http2.ErrCodeCancel, "CANCEL"},
+		{http2.ErrCodeHTTP11Required, "HTTP_1_1_REQUIRED"},
+		{http2.ErrCodeHTTP11Required + 1, RSTStreamOtherCode},
+		{0xdeadbeef, RSTStreamOtherCode},
+	}
+	for _, test := range tests {
+		if got := rstStreamCode(test.code); got != test.want {
+			t.Errorf("rstStreamCode(0x%x) = %q, want %q", uint32(test.code), got, test.want)
+		}
+	}
+}
+
+func TestRSTStreamListenerHTTP1(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+	server := h2c.NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A body that looks like a RST_STREAM frame.
+		w.Write([]byte{0, 0, 4, byte(http2.FrameRSTStream), 0, 0, 0, 0, 1, 0, 0, 0, 8})
+	}))
+	go server.Serve(NewRSTStreamListener(ln, func(direction, code string) {
+		t.Errorf("Unexpected reset %s/%s over HTTP/1", direction, code)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// ShadowHeaderName is the header set to "true" by the ingress mirroring
+	// layer on requests shadowed to a second revision.
+	ShadowHeaderName = "X-Knative-Shadow"
+
+	// ShadowPrimaryHeaderName is the header set by the ingress mirroring
+	// layer to the name of the revision serving the original request.
+	ShadowPrimaryHeaderName = "X-Knative-Shadow-Primary"
+
+	// unknownPrimaryRevision is reported when a shadow request does not name
+	// its primary revision.
+	unknownPrimaryRevision = "unknown"
+)
+
+// IsShadowRequest returns whether the request is a mirrored copy of a
+// request served by another revision.
+func IsShadowRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(ShadowHeaderName), "true")
+}
+
+// ShadowPrimaryRevision returns the revision serving the original request of
+// a shadow request.
+func ShadowPrimaryRevision(r *http.Request) string {
+	if primary := r.Header.Get(ShadowPrimaryHeaderName); primary != "" {
+		return primary
+	}
+	return unknownPrimaryRevision
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestShadowRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantShadow  bool
+		wantPrimary string
+	}{{
+		name:        "regular request",
+		wantPrimary: unknownPrimaryRevision,
+	}, {
+		name:        "not shadowed",
+		headers:     map[string]string{ShadowHeaderName: "false"},
+		wantPrimary: unknownPrimaryRevision,
+	}, {
+		name:        "shadowed without primary",
+		headers:     map[string]string{ShadowHeaderName: "true"},
+		wantShadow:  true,
+		wantPrimary: unknownPrimaryRevision,
+	}, {
+		name: "shadowed",
+		headers: map[string]string{
+			ShadowHeaderName:        "True",
+			ShadowPrimaryHeaderName: "helloworld-00001",
+		},
+		wantShadow:  true,
+		wantPrimary: "helloworld-00001",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com", nil)
+			for k, v := range test.headers {
+				r.Header.Set(k, v)
+			}
+			if got := IsShadowRequest(r); got != test.wantShadow {
+				t.Errorf("IsShadowRequest() = %v, want %v", got, test.wantShadow)
+			}
+			if got := ShadowPrimaryRevision(r); got != test.wantPrimary {
+				t.Errorf("ShadowPrimaryRevision() = %q, want %q", got, test.wantPrimary)
+			}
+		})
+	}
+}
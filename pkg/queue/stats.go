@@ -26,6 +26,11 @@ import (
 type ReqEvent struct {
 	Time      time.Time
 	EventType ReqEventType
+
+	// Failed and Slow describe the outcome of a closed request: whether it
+	// returned a server error and whether it missed the latency SLO.
+	Failed bool
+	Slow   bool
 }
 
 // ReqEventType denotes the type (incoming/closed) of a ReqEvent.
@@ -64,6 +69,8 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 
 	go func() {
 		var requestCount int32
+		var errorCount int32
+		var slowRequestCount int32
 		var concurrency int32
 
 		lastChange := startedAt
@@ -93,6 +100,12 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 					concurrency = concurrency + 1
 				case ReqOut:
 					concurrency = concurrency - 1
+					if event.Failed {
+						errorCount = errorCount + 1
+					}
+					if event.Slow {
+						slowRequestCount = slowRequestCount + 1
+					}
 				}
 			case now := <-s.ch.ReportChan:
 				updateState(now)
@@ -115,6 +128,8 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 					PodName:                   s.podName,
 					AverageConcurrentRequests: avg,
 					RequestCount:              requestCount,
+					ErrorCount:                errorCount,
+					SlowRequestCount:          slowRequestCount,
 				}
 				// Send the stat to another goroutine to transmit
 				// so we can continue bucketing stats.
@@ -123,6 +138,8 @@ func NewStats(podName string, channels Channels, startedAt time.Time) *Stats {
 				// Reset the stat counts which have been reported.
 				timeOnConcurrency = make(map[int32]time.Duration)
 				requestCount = 0
+				errorCount = 0
+				slowRequestCount = 0
 			}
 		}
 	}()
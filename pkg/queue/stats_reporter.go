@@ -21,6 +21,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/knative/serving/pkg/metrics"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
@@ -39,6 +40,74 @@ const (
 	AverageConcurrentRequestsN = "average_concurrent_requests"
 	// LameDuckN
 	LameDuckN = "lame_duck"
+	// ObservabilityCPUOverheadPercentN
+	ObservabilityCPUOverheadPercentN = "observability_cpu_overhead_percent"
+	// ObservabilityMemoryOverheadBytesN
+	ObservabilityMemoryOverheadBytesN = "observability_memory_overhead_bytes"
+	// ObservabilityMemoryOverheadPercentN
+	ObservabilityMemoryOverheadPercentN = "observability_memory_overhead_percent"
+	// RequestTimeoutBudgetUsedPercentN
+	RequestTimeoutBudgetUsedPercentN = "request_timeout_budget_used_percent"
+	// ExternalRequestTotalN
+	ExternalRequestTotalN = "external_request_total"
+	// InternalRequestTotalN
+	InternalRequestTotalN = "internal_request_total"
+	// ShadowRequestTotalN
+	ShadowRequestTotalN = "shadow_request_total"
+	// ShadowRequestLatencyMsN
+	ShadowRequestLatencyMsN = "shadow_request_latency_ms"
+	// RequestBodyTooLargeTotalN
+	RequestBodyTooLargeTotalN = "request_body_too_large_total"
+	// RequestBodyTruncatedTotalN
+	RequestBodyTruncatedTotalN = "request_body_truncated_total"
+	// ReadinessProbeLatencyMsN
+	ReadinessProbeLatencyMsN = "readiness_probe_latency_ms"
+	// QueueProxyMemoryUsageBytesN
+	QueueProxyMemoryUsageBytesN = "queue_proxy_memory_usage_bytes"
+	// QueueProxyMemoryLimitBytesN
+	QueueProxyMemoryLimitBytesN = "queue_proxy_memory_limit_bytes"
+	// HTTPClientTimeoutTotalN
+	HTTPClientTimeoutTotalN = "http_client_timeout_total"
+	// WebSocketUpgradeRejectedTotalN
+	WebSocketUpgradeRejectedTotalN = "websocket_upgrade_rejected_total"
+	// EncodingNegotiationFailureTotalN
+	EncodingNegotiationFailureTotalN = "request_encoding_negotiation_failure_total"
+	// HTTP2RSTStreamTotalN
+	HTTP2RSTStreamTotalN = "http2_rst_stream_total"
+	// DrainingRequestCountN
+	DrainingRequestCountN = "draining_request_count"
+	// DrainCompletionLatencyMsN
+	DrainCompletionLatencyMsN = "drain_completion_latency_ms"
+	// TimeoutCascadeTotalN
+	TimeoutCascadeTotalN = "timeout_cascade_total"
+	// BufferPoolHitTotalN
+	BufferPoolHitTotalN = "buffer_pool_hit_total"
+	// BufferPoolMissTotalN
+	BufferPoolMissTotalN = "buffer_pool_miss_total"
+	// BufferPoolAllocBytesN
+	BufferPoolAllocBytesN = "buffer_pool_alloc_bytes"
+	// UpstreamCallTotalN
+	UpstreamCallTotalN = "upstream_call_total"
+	// VolumeUtilizationBytesN
+	VolumeUtilizationBytesN = "volume_utilization_bytes"
+	// VolumeCapacityBytesN
+	VolumeCapacityBytesN = "volume_capacity_bytes"
+	// RateLimitAcceptedTotalN
+	RateLimitAcceptedTotalN = "rate_limit_accepted_total"
+	// RateLimitRejectedTotalN
+	RateLimitRejectedTotalN = "rate_limit_rejected_total"
+	// RateLimitTokensRemainingN
+	RateLimitTokensRemainingN = "rate_limit_tokens_remaining"
+	// HTTPConnectionReuseTotalN
+	HTTPConnectionReuseTotalN = "http_connection_reuse_total"
+	// HTTPConnectionNewTotalN
+	HTTPConnectionNewTotalN = "http_connection_new_total"
+	// GRPCStreamDurationMsN
+	GRPCStreamDurationMsN = "grpc_stream_duration_ms"
+	// IdleConnectionCountN
+	IdleConnectionCountN = "idle_connection_count"
+	// IdleConnectionTimeoutTotalN
+	IdleConnectionTimeoutTotalN = "idle_connection_timeout_total"
 
 	// OperationsPerSecondM number of operations per second.
 	OperationsPerSecondM Measurement = iota
@@ -46,6 +115,97 @@ const (
 	AverageConcurrentRequestsM
 	// LameDuckM indicates this Pod has received a shutdown signal.
 	LameDuckM
+	// ObservabilityCPUOverheadPercentM estimated CPU spent on observability as a
+	// percentage of the user container's CPU limit.
+	ObservabilityCPUOverheadPercentM
+	// ObservabilityMemoryOverheadBytesM estimated memory spent on observability.
+	ObservabilityMemoryOverheadBytesM
+	// ObservabilityMemoryOverheadPercentM estimated memory spent on
+	// observability as a percentage of the user container's memory limit.
+	ObservabilityMemoryOverheadPercentM
+	// RequestTimeoutBudgetUsedPercentM 95th percentile of request latency as a
+	// percentage of the revision timeout.
+	RequestTimeoutBudgetUsedPercentM
+	// ExternalRequestTotalM number of requests received from outside the cluster.
+	ExternalRequestTotalM
+	// InternalRequestTotalM number of requests received from within the cluster.
+	InternalRequestTotalM
+	// ShadowRequestTotalM number of shadow requests received.
+	ShadowRequestTotalM
+	// ShadowRequestLatencyMsM latency of shadow requests in milliseconds.
+	ShadowRequestLatencyMsM
+	// RequestBodyTooLargeTotalM number of requests rejected for exceeding
+	// the maximum request body size.
+	RequestBodyTooLargeTotalM
+	// RequestBodyTruncatedTotalM number of streamed request bodies truncated
+	// at the maximum request body size.
+	RequestBodyTruncatedTotalM
+	// ReadinessProbeLatencyMsM latency of the readiness probes proxied to
+	// the user container.
+	ReadinessProbeLatencyMsM
+	// QueueProxyMemoryUsageBytesM memory used by the queue-proxy container's
+	// memory cgroup.
+	QueueProxyMemoryUsageBytesM
+	// QueueProxyMemoryLimitBytesM memory limit of the queue-proxy container's
+	// memory cgroup.
+	QueueProxyMemoryLimitBytesM
+	// HTTPClientTimeoutTotalM number of requests the client gave up on.
+	HTTPClientTimeoutTotalM
+	// WebSocketUpgradeRejectedTotalM number of protocol upgrades rejected
+	// because the protocol is not allowed.
+	WebSocketUpgradeRejectedTotalM
+	// EncodingNegotiationFailureTotalM number of responses not encoded as
+	// the client prefers.
+	EncodingNegotiationFailureTotalM
+	// HTTP2RSTStreamTotalM number of HTTP/2 RST_STREAM frames sent or
+	// received.
+	HTTP2RSTStreamTotalM
+	// DrainingRequestCountM number of requests in flight while the pod is
+	// draining.
+	DrainingRequestCountM
+	// DrainCompletionLatencyMsM time from the start of the drain to no
+	// requests being in flight.
+	DrainCompletionLatencyMsM
+	// TimeoutCascadeTotalM number of requests that timed out because the
+	// service they called timed out.
+	TimeoutCascadeTotalM
+	// BufferPoolHitTotalM number of buffers reused from the proxy buffer
+	// pool.
+	BufferPoolHitTotalM
+	// BufferPoolMissTotalM number of buffers the proxy buffer pool had to
+	// allocate.
+	BufferPoolMissTotalM
+	// BufferPoolAllocBytesM mean size of the buffers allocated by the proxy
+	// buffer pool.
+	BufferPoolAllocBytesM
+	// UpstreamCallTotalM number of calls between services, exported with
+	// the TopologyNamespace prefix.
+	UpstreamCallTotalM
+	// VolumeUtilizationBytesM bytes used on a volume mounted in the pod.
+	VolumeUtilizationBytesM
+	// VolumeCapacityBytesM capacity in bytes of a volume mounted in the pod.
+	VolumeCapacityBytesM
+	// RateLimitAcceptedTotalM number of requests the rate limiter let through.
+	RateLimitAcceptedTotalM
+	// RateLimitRejectedTotalM number of requests the rate limiter rejected.
+	RateLimitRejectedTotalM
+	// RateLimitTokensRemainingM number of tokens left in the rate limiter.
+	RateLimitTokensRemainingM
+	// HTTPConnectionReuseTotalM number of requests proxied to the user
+	// container over a reused keep-alive connection.
+	HTTPConnectionReuseTotalM
+	// HTTPConnectionNewTotalM number of requests proxied to the user
+	// container over a new connection.
+	HTTPConnectionNewTotalM
+	// GRPCStreamDurationMsM duration of the gRPC streams served by the user
+	// container.
+	GRPCStreamDurationMsM
+	// IdleConnectionCountM number of idle keep-alive connections to the user
+	// container.
+	IdleConnectionCountM
+	// IdleConnectionTimeoutTotalM number of keep-alive connections to the
+	// user container closed while idle.
+	IdleConnectionTimeoutTotalM
 )
 
 var (
@@ -63,21 +223,177 @@ var (
 			LameDuckN,
 			"Indicates this Pod has received a shutdown signal with 1 else 0",
 			stats.UnitNone),
+		ObservabilityCPUOverheadPercentM: stats.Float64(
+			ObservabilityCPUOverheadPercentN,
+			"Estimated CPU spent on observability as a percentage of the user container's CPU limit",
+			stats.UnitNone),
+		ObservabilityMemoryOverheadBytesM: stats.Float64(
+			ObservabilityMemoryOverheadBytesN,
+			"Estimated memory spent on observability in bytes",
+			stats.UnitBytes),
+		ObservabilityMemoryOverheadPercentM: stats.Float64(
+			ObservabilityMemoryOverheadPercentN,
+			"Estimated memory spent on observability as a percentage of the user container's memory limit",
+			stats.UnitNone),
+		RequestTimeoutBudgetUsedPercentM: stats.Float64(
+			RequestTimeoutBudgetUsedPercentN,
+			"95th percentile of request latency as a percentage of the revision timeout",
+			stats.UnitNone),
+		ExternalRequestTotalM: stats.Float64(
+			ExternalRequestTotalN,
+			"Number of requests received from outside the cluster",
+			stats.UnitNone),
+		InternalRequestTotalM: stats.Float64(
+			InternalRequestTotalN,
+			"Number of requests received from within the cluster",
+			stats.UnitNone),
+		ShadowRequestTotalM: stats.Float64(
+			ShadowRequestTotalN,
+			"Number of shadow requests received",
+			stats.UnitNone),
+		ShadowRequestLatencyMsM: stats.Float64(
+			ShadowRequestLatencyMsN,
+			"Latency of shadow requests in milliseconds",
+			stats.UnitMilliseconds),
+		RequestBodyTooLargeTotalM: stats.Float64(
+			RequestBodyTooLargeTotalN,
+			"Number of requests rejected for exceeding the maximum request body size",
+			stats.UnitNone),
+		RequestBodyTruncatedTotalM: stats.Float64(
+			RequestBodyTruncatedTotalN,
+			"Number of streamed request bodies truncated at the maximum request body size",
+			stats.UnitNone),
+		ReadinessProbeLatencyMsM: stats.Float64(
+			ReadinessProbeLatencyMsN,
+			"Latency of readiness probes in milliseconds",
+			stats.UnitMilliseconds),
+		QueueProxyMemoryUsageBytesM: stats.Float64(
+			QueueProxyMemoryUsageBytesN,
+			"Memory used by the queue-proxy container's memory cgroup in bytes",
+			stats.UnitBytes),
+		QueueProxyMemoryLimitBytesM: stats.Float64(
+			QueueProxyMemoryLimitBytesN,
+			"Memory limit of the queue-proxy container's memory cgroup in bytes",
+			stats.UnitBytes),
+		HTTPClientTimeoutTotalM: stats.Float64(
+			HTTPClientTimeoutTotalN,
+			"Number of requests the client gave up on",
+			stats.UnitNone),
+		WebSocketUpgradeRejectedTotalM: stats.Float64(
+			WebSocketUpgradeRejectedTotalN,
+			"Number of protocol upgrades rejected because the protocol is not allowed",
+			stats.UnitNone),
+		EncodingNegotiationFailureTotalM: stats.Float64(
+			EncodingNegotiationFailureTotalN,
+			"Number of responses not encoded as the client prefers",
+			stats.UnitNone),
+		HTTP2RSTStreamTotalM: stats.Float64(
+			HTTP2RSTStreamTotalN,
+			"Number of HTTP/2 RST_STREAM frames sent or received",
+			stats.UnitNone),
+		DrainingRequestCountM: stats.Float64(
+			DrainingRequestCountN,
+			"Number of requests in flight while the pod is draining",
+			stats.UnitNone),
+		DrainCompletionLatencyMsM: stats.Float64(
+			DrainCompletionLatencyMsN,
+			"Time from the start of the drain to no requests being in flight in milliseconds",
+			stats.UnitMilliseconds),
+		TimeoutCascadeTotalM: stats.Float64(
+			TimeoutCascadeTotalN,
+			"Number of requests that timed out because the service they called timed out",
+			stats.UnitNone),
+		BufferPoolHitTotalM: stats.Float64(
+			BufferPoolHitTotalN,
+			"Number of buffers reused from the proxy buffer pool",
+			stats.UnitNone),
+		BufferPoolMissTotalM: stats.Float64(
+			BufferPoolMissTotalN,
+			"Number of buffers the proxy buffer pool had to allocate",
+			stats.UnitNone),
+		BufferPoolAllocBytesM: stats.Float64(
+			BufferPoolAllocBytesN,
+			"Mean size of the buffers allocated by the proxy buffer pool",
+			stats.UnitBytes),
+		UpstreamCallTotalM: stats.Float64(
+			UpstreamCallTotalN,
+			"Number of calls from a source service to a target service",
+			stats.UnitNone),
+		VolumeUtilizationBytesM: stats.Float64(
+			VolumeUtilizationBytesN,
+			"Bytes used on a volume mounted in the pod",
+			stats.UnitBytes),
+		VolumeCapacityBytesM: stats.Float64(
+			VolumeCapacityBytesN,
+			"Capacity in bytes of a volume mounted in the pod",
+			stats.UnitBytes),
+		RateLimitAcceptedTotalM: stats.Float64(
+			RateLimitAcceptedTotalN,
+			"Number of requests accepted by the rate limiter",
+			stats.UnitNone),
+		RateLimitRejectedTotalM: stats.Float64(
+			RateLimitRejectedTotalN,
+			"Number of requests rejected by the rate limiter",
+			stats.UnitNone),
+		RateLimitTokensRemainingM: stats.Float64(
+			RateLimitTokensRemainingN,
+			"Number of tokens left in the rate limiter",
+			stats.UnitNone),
+		HTTPConnectionReuseTotalM: stats.Float64(
+			HTTPConnectionReuseTotalN,
+			"Number of requests proxied to the user container over a reused keep-alive connection",
+			stats.UnitNone),
+		HTTPConnectionNewTotalM: stats.Float64(
+			HTTPConnectionNewTotalN,
+			"Number of requests proxied to the user container over a new connection",
+			stats.UnitNone),
+		GRPCStreamDurationMsM: stats.Float64(
+			GRPCStreamDurationMsN,
+			"Duration of gRPC streams in milliseconds",
+			stats.UnitMilliseconds),
+		IdleConnectionCountM: stats.Float64(
+			IdleConnectionCountN,
+			"Number of idle keep-alive connections to the user container",
+			stats.UnitNone),
+		IdleConnectionTimeoutTotalM: stats.Float64(
+			IdleConnectionTimeoutTotalN,
+			"Number of keep-alive connections to the user container closed while idle",
+			stats.UnitNone),
 	}
 )
 
 // Reporter structure representing a prometheus expoerter.
 type Reporter struct {
-	Initialized     bool
-	ctx             context.Context
-	configTagKey    tag.Key
-	namespaceTagKey tag.Key
-	revisionTagKey  tag.Key
+	Initialized           bool
+	ctx                   context.Context
+	revisionCtx           context.Context
+	configTagKey          tag.Key
+	namespaceTagKey       tag.Key
+	revisionTagKey        tag.Key
+	primaryRevisionTagKey tag.Key
+	shadowRevisionTagKey  tag.Key
+	probeTypeTagKey       tag.Key
+	probeResultTagKey     tag.Key
+	responsePhaseTagKey   tag.Key
+	upgradeTagKey         tag.Key
+	upstreamTagKey        tag.Key
+	sourceServiceTagKey   tag.Key
+	targetServiceTagKey   tag.Key
+	targetNamespaceTagKey tag.Key
+	volumeTagKey          tag.Key
+	limitTypeTagKey       tag.Key
+	streamDirectionTagKey tag.Key
+	requestedEncodingKey  tag.Key
+	actualEncodingKey     tag.Key
+	rstStreamCodeTagKey   tag.Key
+
+	// timer accumulates the time spent recording measurements.
+	timer *ObservabilityTimer
 }
 
 // NewStatsReporter creates a reporter that collects and reports queue metrics
 func NewStatsReporter(namespace string, config string, revision string) (*Reporter, error) {
-	var r = &Reporter{}
+	var r = &Reporter{timer: &ObservabilityTimer{}}
 	if len(namespace) < 1 {
 		return nil, errors.New("Namespace must not be empty")
 	}
@@ -104,11 +420,91 @@ func NewStatsReporter(namespace string, config string, revision string) (*Report
 		return nil, err
 	}
 	r.revisionTagKey = revTag
+	primaryRevTag, err := tag.NewKey("primary_revision")
+	if err != nil {
+		return nil, err
+	}
+	r.primaryRevisionTagKey = primaryRevTag
+	shadowRevTag, err := tag.NewKey("shadow_revision")
+	if err != nil {
+		return nil, err
+	}
+	r.shadowRevisionTagKey = shadowRevTag
+	probeTypeTag, err := tag.NewKey("probe_type")
+	if err != nil {
+		return nil, err
+	}
+	r.probeTypeTagKey = probeTypeTag
+	probeResultTag, err := tag.NewKey("result")
+	if err != nil {
+		return nil, err
+	}
+	r.probeResultTagKey = probeResultTag
+	responsePhaseTag, err := tag.NewKey("response_phase")
+	if err != nil {
+		return nil, err
+	}
+	r.responsePhaseTagKey = responsePhaseTag
+	upgradeTag, err := tag.NewKey("upgrade")
+	if err != nil {
+		return nil, err
+	}
+	r.upgradeTagKey = upgradeTag
+	upstreamTag, err := tag.NewKey("upstream_service")
+	if err != nil {
+		return nil, err
+	}
+	r.upstreamTagKey = upstreamTag
+	sourceServiceTag, err := tag.NewKey("source_service")
+	if err != nil {
+		return nil, err
+	}
+	r.sourceServiceTagKey = sourceServiceTag
+	targetServiceTag, err := tag.NewKey("target_service")
+	if err != nil {
+		return nil, err
+	}
+	r.targetServiceTagKey = targetServiceTag
+	targetNamespaceTag, err := tag.NewKey("target_namespace")
+	if err != nil {
+		return nil, err
+	}
+	r.targetNamespaceTagKey = targetNamespaceTag
+	volumeTag, err := tag.NewKey("volume_name")
+	if err != nil {
+		return nil, err
+	}
+	r.volumeTagKey = volumeTag
+	limitTypeTag, err := tag.NewKey("limit_type")
+	if err != nil {
+		return nil, err
+	}
+	r.limitTypeTagKey = limitTypeTag
+	streamDirectionTag, err := tag.NewKey("stream_direction")
+	if err != nil {
+		return nil, err
+	}
+	r.streamDirectionTagKey = streamDirectionTag
+	requestedEncodingTag, err := tag.NewKey("requested_encoding")
+	if err != nil {
+		return nil, err
+	}
+	r.requestedEncodingKey = requestedEncodingTag
+	actualEncodingTag, err := tag.NewKey("actual_encoding")
+	if err != nil {
+		return nil, err
+	}
+	r.actualEncodingKey = actualEncodingTag
+	rstStreamCodeTag, err := tag.NewKey("error_code")
+	if err != nil {
+		return nil, err
+	}
+	r.rstStreamCodeTagKey = rstStreamCodeTag
 
 	// Create views to see our measurements. This can return an error if
 	// a previously-registered view has the same name with a different value.
 	// View name defaults to the measure name if unspecified.
-	err = view.Register(
+	err = metrics.RegisterViews(
 		&view.View{
 			Description: "Number of requests received since last Stat",
 			Measure:     measurements[OperationsPerSecondM],
@@ -127,6 +523,211 @@ func NewStatsReporter(namespace string, config string, revision string) (*Report
 			Aggregation: view.LastValue(),
 			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
 		},
+		&view.View{
+			Description: "Estimated CPU spent on observability as a percentage of the user container's CPU limit",
+			Measure:     measurements[ObservabilityCPUOverheadPercentM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Estimated memory spent on observability in bytes",
+			Measure:     measurements[ObservabilityMemoryOverheadBytesM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Estimated memory spent on observability as a percentage of the user container's memory limit",
+			Measure:     measurements[ObservabilityMemoryOverheadPercentM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "95th percentile of request latency as a percentage of the revision timeout",
+			Measure:     measurements[RequestTimeoutBudgetUsedPercentM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of requests received from outside the cluster",
+			Measure:     measurements[ExternalRequestTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of requests received from within the cluster",
+			Measure:     measurements[InternalRequestTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of shadow requests received",
+			Measure:     measurements[ShadowRequestTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.primaryRevisionTagKey, r.shadowRevisionTagKey},
+		},
+		&view.View{
+			Description: "Latency of shadow requests in milliseconds",
+			Measure:     measurements[ShadowRequestLatencyMsM],
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.primaryRevisionTagKey, r.shadowRevisionTagKey},
+		},
+		&view.View{
+			Description: "Number of requests rejected for exceeding the maximum request body size",
+			Measure:     measurements[RequestBodyTooLargeTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of streamed request bodies truncated at the maximum request body size",
+			Measure:     measurements[RequestBodyTruncatedTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Latency of readiness probes in milliseconds",
+			Measure:     measurements[ReadinessProbeLatencyMsM],
+			Aggregation: view.Distribution(1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.probeTypeTagKey, r.probeResultTagKey},
+		},
+		&view.View{
+			Description: "Memory used by the queue-proxy container's memory cgroup in bytes",
+			Measure:     measurements[QueueProxyMemoryUsageBytesM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Memory limit of the queue-proxy container's memory cgroup in bytes",
+			Measure:     measurements[QueueProxyMemoryLimitBytesM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of requests the client gave up on",
+			Measure:     measurements[HTTPClientTimeoutTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.responsePhaseTagKey},
+		},
+		&view.View{
+			Description: "Number of protocol upgrades rejected because the protocol is not allowed",
+			Measure:     measurements[WebSocketUpgradeRejectedTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.upgradeTagKey},
+		},
+		&view.View{
+			Description: "Number of responses not encoded as the client prefers",
+			Measure:     measurements[EncodingNegotiationFailureTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.requestedEncodingKey, r.actualEncodingKey},
+		},
+		&view.View{
+			Description: "Number of HTTP/2 RST_STREAM frames sent or received",
+			Measure:     measurements[HTTP2RSTStreamTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.streamDirectionTagKey, r.rstStreamCodeTagKey},
+		},
+		&view.View{
+			Description: "Number of requests in flight while the pod is draining",
+			Measure:     measurements[DrainingRequestCountM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Time from the start of the drain to no requests being in flight in milliseconds",
+			Measure:     measurements[DrainCompletionLatencyMsM],
+			Aggregation: view.Distribution(100, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000, 300000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of requests that timed out because the service they called timed out",
+			Measure:     measurements[TimeoutCascadeTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.upstreamTagKey},
+		},
+		&view.View{
+			Description: "Number of buffers reused from the proxy buffer pool",
+			Measure:     measurements[BufferPoolHitTotalM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of buffers the proxy buffer pool had to allocate",
+			Measure:     measurements[BufferPoolMissTotalM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Mean size of the buffers allocated by the proxy buffer pool",
+			Measure:     measurements[BufferPoolAllocBytesM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of calls from a source service to a target service",
+			Measure:     measurements[UpstreamCallTotalM],
+			Aggregation: view.Count(),
+			// Only the edge of the service graph, the revision is irrelevant.
+			TagKeys: []tag.Key{r.sourceServiceTagKey, r.targetServiceTagKey, r.targetNamespaceTagKey},
+		},
+		&view.View{
+			Description: "Bytes used on a volume mounted in the pod",
+			Measure:     measurements[VolumeUtilizationBytesM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.volumeTagKey},
+		},
+		&view.View{
+			Description: "Capacity in bytes of a volume mounted in the pod",
+			Measure:     measurements[VolumeCapacityBytesM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.volumeTagKey},
+		},
+		&view.View{
+			Description: "Number of requests accepted by the rate limiter",
+			Measure:     measurements[RateLimitAcceptedTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.limitTypeTagKey},
+		},
+		&view.View{
+			Description: "Number of requests rejected by the rate limiter",
+			Measure:     measurements[RateLimitRejectedTotalM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.limitTypeTagKey},
+		},
+		&view.View{
+			Description: "Number of tokens left in the rate limiter",
+			Measure:     measurements[RateLimitTokensRemainingM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.limitTypeTagKey},
+		},
+		&view.View{
+			Description: "Number of requests proxied to the user container over a reused keep-alive connection",
+			Measure:     measurements[HTTPConnectionReuseTotalM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of requests proxied to the user container over a new connection",
+			Measure:     measurements[HTTPConnectionNewTotalM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Duration of gRPC streams in milliseconds",
+			Measure:     measurements[GRPCStreamDurationMsM],
+			Aggregation: view.Distribution(100, 1000, 5000, 10000, 30000, 60000, 300000, 600000, 1800000, 3600000),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey, r.streamDirectionTagKey},
+		},
+		&view.View{
+			Description: "Number of idle keep-alive connections to the user container",
+			Measure:     measurements[IdleConnectionCountM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of keep-alive connections to the user container closed while idle",
+			Measure:     measurements[IdleConnectionTimeoutTotalM],
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{r.namespaceTagKey, r.configTagKey, r.revisionTagKey},
+		},
 	)
 	if err != nil {
 		return nil, err
@@ -137,15 +738,38 @@ func NewStatsReporter(namespace string, config string, revision string) (*Report
 		tag.Insert(r.namespaceTagKey, namespace),
 		tag.Insert(r.configTagKey, config),
 		tag.Insert(r.revisionTagKey, revision),
+		// Requests received by this revision are shadowed to it.
+		tag.Insert(r.shadowRevisionTagKey, revision),
 	)
 	if err != nil {
 		return nil, err
 	}
 	r.ctx = ctx
+	r.revisionCtx, err = tag.New(
+		context.Background(),
+		tag.Insert(revisionNamespaceTagKey, namespace),
+		tag.Insert(revisionConfigTagKey, config),
+		tag.Insert(revisionNameTagKey, revision),
+	)
+	if err != nil {
+		return nil, err
+	}
 	r.Initialized = true
 	return r, nil
 }
 
+// ObservabilityTimer returns the timer of the time spent recording
+// measurements, which also times the exporters and handlers it wraps.
+func (r *Reporter) ObservabilityTimer() *ObservabilityTimer {
+	return r.timer
+}
+
+// record records the measurements and times it.
+func (r *Reporter) record(ctx context.Context, ms ...stats.Measurement) {
+	defer r.timer.Since(time.Now())
+	stats.Record(ctx, ms...)
+}
+
 // Report captures request metrics
 func (r *Reporter) Report(lameDuck bool, operationsPerSecond float64, averageConcurrentRequests float64) error {
 	if !r.Initialized {
@@ -155,9 +779,315 @@ func (r *Reporter) Report(lameDuck bool, operationsPerSecond float64, averageCon
 	if !lameDuck {
 		_lameDuck = float64(1)
 	}
-	stats.Record(r.ctx, measurements[LameDuckM].M(_lameDuck))
-	stats.Record(r.ctx, measurements[OperationsPerSecondM].M(operationsPerSecond))
-	stats.Record(r.ctx, measurements[AverageConcurrentRequestsM].M(averageConcurrentRequests))
+	r.record(r.ctx, measurements[LameDuckM].M(_lameDuck))
+	r.record(r.ctx, measurements[OperationsPerSecondM].M(operationsPerSecond))
+	r.record(r.ctx, measurements[AverageConcurrentRequestsM].M(averageConcurrentRequests))
+	return nil
+}
+
+// ReportObservabilityOverhead captures the estimated observability overhead
+func (r *Reporter) ReportObservabilityOverhead(cpuPercent float64, memoryBytes int64, memoryPercent float64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx,
+		measurements[ObservabilityCPUOverheadPercentM].M(cpuPercent),
+		measurements[ObservabilityMemoryOverheadBytesM].M(float64(memoryBytes)),
+		measurements[ObservabilityMemoryOverheadPercentM].M(memoryPercent))
+	return nil
+}
+
+// ReportTimeoutBudgetUsed captures the percentage of the revision timeout used
+func (r *Reporter) ReportTimeoutBudgetUsed(percent float64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx, measurements[RequestTimeoutBudgetUsedPercentM].M(percent))
+	return nil
+}
+
+// ReportRequest counts a request as external or internal traffic
+func (r *Reporter) ReportRequest(external bool) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	if external {
+		r.record(r.ctx, measurements[ExternalRequestTotalM].M(1))
+	} else {
+		r.record(r.ctx, measurements[InternalRequestTotalM].M(1))
+	}
+	return nil
+}
+
+// ReportShadowRequest captures a shadow request mirroring a request served
+// by primaryRevision
+func (r *Reporter) ReportShadowRequest(primaryRevision string, latency time.Duration) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.primaryRevisionTagKey, primaryRevision))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[ShadowRequestTotalM].M(1))
+	r.record(ctx, measurements[ShadowRequestLatencyMsM].M(float64(latency/time.Millisecond)))
+	return nil
+}
+
+// ReportRequestBodyTooLarge counts a request rejected for its body size
+func (r *Reporter) ReportRequestBodyTooLarge() error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx, measurements[RequestBodyTooLargeTotalM].M(1))
+	return nil
+}
+
+// ReportRequestBodyTruncated counts a streamed request body that was truncated
+func (r *Reporter) ReportRequestBodyTruncated() error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx, measurements[RequestBodyTruncatedTotalM].M(1))
+	return nil
+}
+
+// ReportReadinessProbe captures the latency and result of a readiness probe
+func (r *Reporter) ReportReadinessProbe(probeType, result string, latency time.Duration) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx,
+		tag.Insert(r.probeTypeTagKey, probeType),
+		tag.Insert(r.probeResultTagKey, result))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[ReadinessProbeLatencyMsM].M(float64(latency/time.Millisecond)))
+	return nil
+}
+
+// ReportMemoryUsage captures the memory usage and limit of the queue-proxy
+// container's memory cgroup. A limit of 0, for no limit, is not reported
+func (r *Reporter) ReportMemoryUsage(usageBytes, limitBytes int64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx, measurements[QueueProxyMemoryUsageBytesM].M(float64(usageBytes)))
+	if limitBytes > 0 {
+		r.record(r.ctx, measurements[QueueProxyMemoryLimitBytesM].M(float64(limitBytes)))
+	}
+	return nil
+}
+
+// ReportClientTimeout counts a request the client gave up on in the given
+// response phase
+func (r *Reporter) ReportClientTimeout(phase string) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.responsePhaseTagKey, phase))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[HTTPClientTimeoutTotalM].M(1))
+	return nil
+}
+
+// ReportUpgradeRejected counts a rejected upgrade to the given protocol
+func (r *Reporter) ReportUpgradeRejected(protocol string) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.upgradeTagKey, protocol))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[WebSocketUpgradeRejectedTotalM].M(1))
+	return nil
+}
+
+// ReportEncodingNegotiationFailure counts a response encoded with the actual
+// encoding instead of the one the client requested
+func (r *Reporter) ReportEncodingNegotiationFailure(requested, actual string) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx,
+		tag.Insert(r.requestedEncodingKey, requested),
+		tag.Insert(r.actualEncodingKey, actual))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[EncodingNegotiationFailureTotalM].M(1))
+	return nil
+}
+
+// ReportHTTP2RSTStream counts an HTTP/2 RST_STREAM frame sent or received
+// with the given error code, e.g. "CANCEL".
+func (r *Reporter) ReportHTTP2RSTStream(direction, code string) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx,
+		tag.Insert(r.streamDirectionTagKey, direction),
+		tag.Insert(r.rstStreamCodeTagKey, code))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[HTTP2RSTStreamTotalM].M(1))
+	return nil
+}
+
+// ReportDrainingRequestCount captures the number of requests in flight while
+// the pod is draining
+func (r *Reporter) ReportDrainingRequestCount(count int) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx, measurements[DrainingRequestCountM].M(float64(count)))
+	return nil
+}
+
+// ReportDrainCompletion captures the time it took to complete the requests in
+// flight when the drain started
+func (r *Reporter) ReportDrainCompletion(latency time.Duration) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx, measurements[DrainCompletionLatencyMsM].M(float64(latency/time.Millisecond)))
+	return nil
+}
+
+// ReportTimeoutCascade counts a request that timed out because the given
+// upstream service timed out
+func (r *Reporter) ReportTimeoutCascade(upstream string) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.upstreamTagKey, upstream))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[TimeoutCascadeTotalM].M(1))
+	return nil
+}
+
+// ReportBufferPool captures the hits and misses of the proxy buffer pool in
+// the last period and the mean size of the buffers it allocated
+func (r *Reporter) ReportBufferPool(hits, misses int64, meanAllocBytes float64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx,
+		measurements[BufferPoolHitTotalM].M(float64(hits)),
+		measurements[BufferPoolMissTotalM].M(float64(misses)))
+	// Without misses there is no allocation to average.
+	if misses > 0 {
+		r.record(r.ctx, measurements[BufferPoolAllocBytesM].M(meanAllocBytes))
+	}
+	return nil
+}
+
+// ReportUpstreamCall counts a call from the source service to the target
+// service
+func (r *Reporter) ReportUpstreamCall(source, target, targetNamespace string) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx,
+		tag.Insert(r.sourceServiceTagKey, source),
+		tag.Insert(r.targetServiceTagKey, target),
+		tag.Insert(r.targetNamespaceTagKey, targetNamespace))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[UpstreamCallTotalM].M(1))
+	return nil
+}
+
+// ReportVolumeUsage captures the bytes used and the capacity of a volume
+func (r *Reporter) ReportVolumeUsage(volume string, usedBytes, capacityBytes int64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.volumeTagKey, volume))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[VolumeUtilizationBytesM].M(float64(usedBytes)))
+	r.record(ctx, measurements[VolumeCapacityBytesM].M(float64(capacityBytes)))
+	return nil
+}
+
+// ReportRateLimit counts a request accepted or rejected by the rate limiter
+// of the given limit type
+func (r *Reporter) ReportRateLimit(limitType string, accepted bool) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.limitTypeTagKey, limitType))
+	if err != nil {
+		return err
+	}
+	m := RateLimitRejectedTotalM
+	if accepted {
+		m = RateLimitAcceptedTotalM
+	}
+	r.record(ctx, measurements[m].M(1))
+	return nil
+}
+
+// ReportRateLimitTokens captures the tokens left in the rate limiter of the
+// given limit type
+func (r *Reporter) ReportRateLimitTokens(limitType string, tokens float64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.limitTypeTagKey, limitType))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[RateLimitTokensRemainingM].M(tokens))
+	return nil
+}
+
+// ReportConnectionReuse captures the number of requests proxied to the user
+// container over reused and new connections in the last period
+func (r *Reporter) ReportConnectionReuse(reused, newConns int64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx,
+		measurements[HTTPConnectionReuseTotalM].M(float64(reused)),
+		measurements[HTTPConnectionNewTotalM].M(float64(newConns)))
+	return nil
+}
+
+// ReportIdleConnections captures the number of idle connections to the user
+// container and the number of connections closed while idle in the last
+// period
+func (r *Reporter) ReportIdleConnections(idle, timeouts int64) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	r.record(r.ctx,
+		measurements[IdleConnectionCountM].M(float64(idle)),
+		measurements[IdleConnectionTimeoutTotalM].M(float64(timeouts)))
+	return nil
+}
+
+// ReportGRPCStream captures the duration of a gRPC stream in the given
+// direction
+func (r *Reporter) ReportGRPCStream(direction string, duration time.Duration) error {
+	if !r.Initialized {
+		return errors.New("StatsReporter is not Initialized yet")
+	}
+	ctx, err := tag.New(r.ctx, tag.Insert(r.streamDirectionTagKey, direction))
+	if err != nil {
+		return err
+	}
+	r.record(ctx, measurements[GRPCStreamDurationMsM].M(float64(duration/time.Millisecond)))
 	return nil
 }
 
@@ -176,7 +1106,112 @@ func (r *Reporter) UnregisterViews() error {
 	if v := view.Find(LameDuckN); v != nil {
 		views = append(views, v)
 	}
-	view.Unregister(views...)
+	if v := view.Find(ObservabilityCPUOverheadPercentN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(ObservabilityMemoryOverheadBytesN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(ObservabilityMemoryOverheadPercentN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RequestTimeoutBudgetUsedPercentN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(ExternalRequestTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(InternalRequestTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(ShadowRequestTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(ShadowRequestLatencyMsN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RequestBodyTooLargeTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RequestBodyTruncatedTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(ReadinessProbeLatencyMsN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(QueueProxyMemoryUsageBytesN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(QueueProxyMemoryLimitBytesN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(HTTPClientTimeoutTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(WebSocketUpgradeRejectedTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(EncodingNegotiationFailureTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(HTTP2RSTStreamTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(DrainingRequestCountN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(DrainCompletionLatencyMsN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(TimeoutCascadeTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(BufferPoolHitTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(BufferPoolMissTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(BufferPoolAllocBytesN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(UpstreamCallTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(VolumeUtilizationBytesN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(VolumeCapacityBytesN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RateLimitAcceptedTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RateLimitRejectedTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RateLimitTokensRemainingN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(HTTPConnectionReuseTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(HTTPConnectionNewTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(GRPCStreamDurationMsN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(IdleConnectionCountN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(IdleConnectionTimeoutTotalN); v != nil {
+		views = append(views, v)
+	}
+	if v := view.Find(RequestLatenciesN); v != nil {
+		views = append(views, v)
+	}
+	metrics.UnregisterViews(views...)
 	r.Initialized = false
 	return nil
 }
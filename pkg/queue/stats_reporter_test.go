@@ -19,8 +19,10 @@ package queue
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 )
 
 const (
@@ -98,6 +100,203 @@ func TestReporter_Report(t *testing.T) {
 	checkData(t, LameDuckN, 0)
 	checkData(t, OperationsPerSecondN, 39)
 	checkData(t, AverageConcurrentRequestsN, 3)
+	if err := reporter.ReportObservabilityOverhead(2.5, 1<<20, 0.5); err != nil {
+		t.Error(err)
+	}
+	checkData(t, ObservabilityCPUOverheadPercentN, 2.5)
+	checkData(t, ObservabilityMemoryOverheadBytesN, 1<<20)
+	checkData(t, ObservabilityMemoryOverheadPercentN, 0.5)
+	if reporter.ObservabilityTimer().Spent() <= 0 {
+		t.Error("ObservabilityTimer().Spent() = 0, want the time spent recording")
+	}
+	if err := reporter.ReportTimeoutBudgetUsed(95); err != nil {
+		t.Error(err)
+	}
+	checkData(t, RequestTimeoutBudgetUsedPercentN, 95)
+	for _, external := range []bool{true, false, false} {
+		if err := reporter.ReportRequest(external); err != nil {
+			t.Error(err)
+		}
+	}
+	checkCount(t, ExternalRequestTotalN, 1)
+	checkCount(t, InternalRequestTotalN, 2)
+	if err := reporter.ReportShadowRequest("helloworld-go-00000", 42*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+	checkShadowData(t, "helloworld-go-00000", 1, 42)
+	if err := reporter.ReportRequestBodyTooLarge(); err != nil {
+		t.Error(err)
+	}
+	checkCount(t, RequestBodyTooLargeTotalN, 1)
+	for i := 0; i < 2; i++ {
+		if err := reporter.ReportRequestBodyTruncated(); err != nil {
+			t.Error(err)
+		}
+	}
+	checkCount(t, RequestBodyTruncatedTotalN, 2)
+	if err := reporter.ReportReadinessProbe(ProbeTypeHTTP, ProbeResultFail, 30*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+	checkProbeData(t, ProbeTypeHTTP, ProbeResultFail, 30)
+	if err := reporter.ReportMemoryUsage(300, 1000); err != nil {
+		t.Error(err)
+	}
+	checkData(t, QueueProxyMemoryUsageBytesN, 300)
+	checkData(t, QueueProxyMemoryLimitBytesN, 1000)
+	if err := reporter.ReportEncodingNegotiationFailure(EncodingBrotli, EncodingGzip); err != nil {
+		t.Error(err)
+	}
+	checkCount(t, EncodingNegotiationFailureTotalN, 1)
+	if v, err := view.RetrieveData(EncodingNegotiationFailureTotalN); err == nil && len(v) == 1 {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"requested_encoding":        EncodingBrotli,
+			"actual_encoding":           EncodingGzip,
+		})
+	}
+	if err := reporter.ReportClientTimeout(ResponsePhaseHeadersSent); err != nil {
+		t.Error(err)
+	}
+	if v, err := view.RetrieveData(HTTPClientTimeoutTotalN); err != nil {
+		t.Errorf("Reporter.ReportClientTimeout() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), HTTPClientTimeoutTotalN)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"response_phase":            ResponsePhaseHeadersSent,
+		})
+	}
+	if err := reporter.ReportDrainingRequestCount(4); err != nil {
+		t.Error(err)
+	}
+	checkData(t, DrainingRequestCountN, 4)
+	if err := reporter.ReportDrainCompletion(1500 * time.Millisecond); err != nil {
+		t.Error(err)
+	}
+	if v, err := view.RetrieveData(DrainCompletionLatencyMsN); err != nil {
+		t.Errorf("Reporter.ReportDrainCompletion() error = %v", err)
+	} else if got := v[0].Data.(*view.DistributionData); got.Count != 1 || got.Mean != 1500 {
+		t.Errorf("Wanted one drain of 1500ms, Got %d with mean %v", got.Count, got.Mean)
+	}
+	if err := reporter.ReportTimeoutCascade("upstream"); err != nil {
+		t.Error(err)
+	}
+	if v, err := view.RetrieveData(TimeoutCascadeTotalN); err != nil {
+		t.Errorf("Reporter.ReportTimeoutCascade() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), TimeoutCascadeTotalN)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"upstream_service":          "upstream",
+		})
+	}
+	if err := reporter.ReportBufferPool(3, 2, DefaultBufferSize); err != nil {
+		t.Error(err)
+	}
+	checkSum(t, BufferPoolHitTotalN, 3)
+	checkSum(t, BufferPoolMissTotalN, 2)
+	checkData(t, BufferPoolAllocBytesN, DefaultBufferSize)
+	if err := reporter.ReportUpstreamCall("source", "target", "target-ns"); err != nil {
+		t.Error(err)
+	}
+	if v, err := view.RetrieveData(UpstreamCallTotalN); err != nil {
+		t.Errorf("Reporter.ReportUpstreamCall() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), UpstreamCallTotalN)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"source_service":   "source",
+			"target_service":   "target",
+			"target_namespace": "target-ns",
+		})
+	}
+	if err := reporter.ReportVolumeUsage("/data", 900, 1000); err != nil {
+		t.Error(err)
+	}
+	checkData(t, VolumeUtilizationBytesN, 900)
+	checkData(t, VolumeCapacityBytesN, 1000)
+	if v, err := view.RetrieveData(VolumeCapacityBytesN); err != nil {
+		t.Errorf("Reporter.ReportVolumeUsage() error = %v", err)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"volume_name":               "/data",
+		})
+	}
+	for _, accepted := range []bool{true, true, false} {
+		if err := reporter.ReportRateLimit(RateLimitRequestsPerSecond, accepted); err != nil {
+			t.Error(err)
+		}
+	}
+	checkCount(t, RateLimitAcceptedTotalN, 2)
+	checkCount(t, RateLimitRejectedTotalN, 1)
+	if err := reporter.ReportRateLimitTokens(RateLimitRequestsPerSecond, 7); err != nil {
+		t.Error(err)
+	}
+	checkData(t, RateLimitTokensRemainingN, 7)
+	if err := reporter.ReportConnectionReuse(9, 1); err != nil {
+		t.Error(err)
+	}
+	checkSum(t, HTTPConnectionReuseTotalN, 9)
+	checkSum(t, HTTPConnectionNewTotalN, 1)
+	if err := reporter.ReportIdleConnections(3, 2); err != nil {
+		t.Error(err)
+	}
+	checkData(t, IdleConnectionCountN, 3)
+	checkSum(t, IdleConnectionTimeoutTotalN, 2)
+	if err := reporter.ReportGRPCStream(GRPCStreamBidi, 90*time.Second); err != nil {
+		t.Error(err)
+	}
+	if v, err := view.RetrieveData(GRPCStreamDurationMsN); err != nil {
+		t.Errorf("Reporter.ReportGRPCStream() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), GRPCStreamDurationMsN)
+	} else {
+		if got := v[0].Data.(*view.DistributionData).Max; got != 90000 {
+			t.Errorf("%s max = %v, want 90000", GRPCStreamDurationMsN, got)
+		}
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"stream_direction":          GRPCStreamBidi,
+		})
+	}
+	for i := 0; i < 2; i++ {
+		if err := reporter.ReportHTTP2RSTStream(RSTStreamReceived, "CANCEL"); err != nil {
+			t.Error(err)
+		}
+	}
+	checkCount(t, HTTP2RSTStreamTotalN, 2)
+	if v, err := view.RetrieveData(HTTP2RSTStreamTotalN); err == nil && len(v) == 1 {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"stream_direction":          RSTStreamReceived,
+			"error_code":                "CANCEL",
+		})
+	}
+	if v, err := view.RetrieveData(RateLimitRejectedTotalN); err != nil {
+		t.Errorf("Reporter.ReportRateLimit() error = %v", err)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"limit_type":                RateLimitRequestsPerSecond,
+		})
+	}
 	if err := reporter.UnregisterViews(); err != nil {
 		t.Errorf("Error with unregistering views, %v", err)
 	}
@@ -118,3 +317,81 @@ func checkData(t *testing.T, measurementName string, wanted float64) {
 		}
 	}
 }
+
+func checkSum(t *testing.T, measurementName string, wanted float64) {
+	if v, err := view.RetrieveData(measurementName); err != nil {
+		t.Errorf("Reporter.Report() error = %v", err)
+	} else {
+		if got := v[0].Data.(*view.SumData); wanted != got.Value {
+			t.Errorf("Wanted %v, Got %v", wanted, got.Value)
+		}
+	}
+}
+
+func checkCount(t *testing.T, measurementName string, wanted int64) {
+	if v, err := view.RetrieveData(measurementName); err != nil {
+		t.Errorf("Reporter.Report() error = %v", err)
+	} else {
+		if got := v[0].Data.(*view.CountData); wanted != got.Value {
+			t.Errorf("Wanted %v, Got %v", wanted, got.Value)
+		}
+	}
+}
+
+func checkShadowData(t *testing.T, primary string, wantCount int64, wantLatency float64) {
+	wantTags := map[string]string{
+		"destination_namespace": namespace,
+		"primary_revision":      primary,
+		"shadow_revision":       revision,
+	}
+	if v, err := view.RetrieveData(ShadowRequestTotalN); err != nil {
+		t.Errorf("Reporter.ReportShadowRequest() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), ShadowRequestTotalN)
+	} else {
+		checkTags(t, v[0].Tags, wantTags)
+		if got := v[0].Data.(*view.CountData); got.Value != wantCount {
+			t.Errorf("Wanted %v, Got %v", wantCount, got.Value)
+		}
+	}
+	if v, err := view.RetrieveData(ShadowRequestLatencyMsN); err != nil {
+		t.Errorf("Reporter.ReportShadowRequest() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), ShadowRequestLatencyMsN)
+	} else {
+		checkTags(t, v[0].Tags, wantTags)
+		if got := v[0].Data.(*view.DistributionData); got.Mean != wantLatency {
+			t.Errorf("Wanted %v, Got %v", wantLatency, got.Mean)
+		}
+	}
+}
+
+func checkProbeData(t *testing.T, probeType, result string, wantLatency float64) {
+	if v, err := view.RetrieveData(ReadinessProbeLatencyMsN); err != nil {
+		t.Errorf("Reporter.ReportReadinessProbe() error = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), ReadinessProbeLatencyMsN)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"probe_type":                probeType,
+			"result":                    result,
+		})
+		if got := v[0].Data.(*view.DistributionData); got.Mean != wantLatency {
+			t.Errorf("Wanted %v, Got %v", wantLatency, got.Mean)
+		}
+	}
+}
+
+func checkTags(t *testing.T, tags []tag.Tag, want map[string]string) {
+	if len(tags) != len(want) {
+		t.Errorf("Got tags %v, want %v", tags, want)
+	}
+	for _, tag := range tags {
+		if want[tag.Key.Name()] != tag.Value {
+			t.Errorf("Got tag %s=%q, want %q", tag.Key.Name(), tag.Value, want[tag.Key.Name()])
+		}
+	}
+}
@@ -195,6 +195,42 @@ func TestOneRequestAcrossReportings(t *testing.T) {
 	}
 }
 
+func TestFailedAndSlowRequests(t *testing.T) {
+	now := time.Now()
+	s := newTestStats(now)
+
+	s.requestStart(now)
+	s.requestStart(now)
+	s.requestStart(now)
+	now = now.Add(1 * time.Second)
+	s.ch.ReqChan <- ReqEvent{Time: now, EventType: ReqOut, Failed: true}
+	s.ch.ReqChan <- ReqEvent{Time: now, EventType: ReqOut, Slow: true}
+	s.ch.ReqChan <- ReqEvent{Time: now, EventType: ReqOut, Failed: true, Slow: true}
+
+	got := s.report(now)
+	want := &autoscaler.Stat{
+		Time:                      &now,
+		PodName:                   podName,
+		AverageConcurrentRequests: 3.0,
+		RequestCount:              3,
+		ErrorCount:                2,
+		SlowRequestCount:          2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected stat (-want +got): %v", diff)
+	}
+
+	// The counts are reset after every report.
+	got = s.report(now)
+	want = &autoscaler.Stat{
+		Time:    &now,
+		PodName: podName,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected stat (-want +got): %v", diff)
+	}
+}
+
 // Test type to hold the bi-directional time channels
 type testStats struct {
 	Stats
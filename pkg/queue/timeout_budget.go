@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// TimeoutBudgetThreshold is the fraction of the revision timeout above
+	// which the 95th percentile request is considered too close to timing out.
+	TimeoutBudgetThreshold = 0.9
+
+	// timeoutBudgetPeriods is the number of consecutive reporting periods the
+	// threshold has to be exceeded before the timeout is flagged as too tight.
+	timeoutBudgetPeriods = 3
+
+	// timeoutBudgetSamples is the number of the most recent requests of a
+	// reporting period the percentile is computed over, which bounds the
+	// memory used however many requests are served.
+	timeoutBudgetSamples = 1024
+
+	// LatencySLOFraction is the fraction of the revision timeout a request
+	// may take before it counts as missing the latency SLO.
+	LatencySLOFraction = 0.5
+)
+
+// TimeoutBudget tracks how much of the revision timeout requests use.
+type TimeoutBudget struct {
+	timeout time.Duration
+
+	mux sync.Mutex
+	// ratios is a ring buffer of the fractions of the timeout used by the
+	// requests; count of them are recorded and next is overwritten next.
+	ratios      []float64
+	count       int
+	next        int
+	consecutive int
+}
+
+// NewTimeoutBudget creates a TimeoutBudget for the given revision timeout.
+func NewTimeoutBudget(timeout time.Duration) *TimeoutBudget {
+	return &TimeoutBudget{
+		timeout: timeout,
+		ratios:  make([]float64, timeoutBudgetSamples),
+	}
+}
+
+// Record records the latency of a completed request.
+func (b *TimeoutBudget) Record(latency time.Duration) {
+	if b.timeout <= 0 {
+		return
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.ratios[b.next] = float64(latency) / float64(b.timeout)
+	b.next = (b.next + 1) % len(b.ratios)
+	if b.count < len(b.ratios) {
+		b.count++
+	}
+}
+
+// Report returns the 95th percentile of the fraction of the timeout used by
+// the requests recorded since the previous call, up to the most recent
+// timeoutBudgetSamples of them, and resets them. The returned boolean is true
+// once the percentile has exceeded TimeoutBudgetThreshold for three
+// consecutive reporting periods.
+func (b *TimeoutBudget) Report() (float64, bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	// The order of the ratios does not matter once they are reset.
+	p95 := percentile(b.ratios[:b.count], 0.95)
+	b.count, b.next = 0, 0
+
+	if p95 > TimeoutBudgetThreshold {
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+	return p95, b.consecutive >= timeoutBudgetPeriods
+}
+
+// Exceeded returns the boolean returned by the most recent call to Report.
+func (b *TimeoutBudget) Exceeded() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.consecutive >= timeoutBudgetPeriods
+}
+
+// percentile returns the nearest-rank percentile p of values, sorting them in
+// place. It returns 0 for no values.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(p*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeoutBudgetPercentile(t *testing.T) {
+	b := NewTimeoutBudget(10 * time.Second)
+	for i := 1; i <= 100; i++ {
+		b.Record(time.Duration(i) * 100 * time.Millisecond)
+	}
+
+	if got, _ := b.Report(); got != 0.95 {
+		t.Errorf("Report() = %v, want %v", got, 0.95)
+	}
+	if got, _ := b.Report(); got != 0 {
+		t.Errorf("Report() after reset = %v, want 0", got)
+	}
+}
+
+func TestTimeoutBudgetWindow(t *testing.T) {
+	b := NewTimeoutBudget(10 * time.Second)
+	// Only the most recent requests are kept.
+	for i := 0; i < 10*timeoutBudgetSamples; i++ {
+		b.Record(10 * time.Second)
+	}
+	for i := 0; i < timeoutBudgetSamples; i++ {
+		b.Record(time.Second)
+	}
+	if got := len(b.ratios); got != timeoutBudgetSamples {
+		t.Errorf("Ratios held = %d, want %d", got, timeoutBudgetSamples)
+	}
+	if got, _ := b.Report(); got != 0.1 {
+		t.Errorf("Report() = %v, want %v", got, 0.1)
+	}
+}
+
+func TestTimeoutBudgetConsecutivePeriods(t *testing.T) {
+	b := NewTimeoutBudget(time.Second)
+
+	tests := []struct {
+		latency      time.Duration
+		wantExceeded bool
+	}{
+		{950 * time.Millisecond, false},
+		{950 * time.Millisecond, false},
+		{500 * time.Millisecond, false},
+		{950 * time.Millisecond, false},
+		{950 * time.Millisecond, false},
+		{950 * time.Millisecond, true},
+		{990 * time.Millisecond, true},
+		{100 * time.Millisecond, false},
+	}
+
+	for i, test := range tests {
+		b.Record(test.latency)
+		if _, got := b.Report(); got != test.wantExceeded {
+			t.Errorf("Period %d: Report() exceeded = %v, want %v", i, got, test.wantExceeded)
+		}
+		if got := b.Exceeded(); got != test.wantExceeded {
+			t.Errorf("Period %d: Exceeded() = %v, want %v", i, got, test.wantExceeded)
+		}
+	}
+}
+
+func TestTimeoutBudgetNoTimeout(t *testing.T) {
+	b := NewTimeoutBudget(0)
+	b.Record(time.Second)
+	if got, _ := b.Report(); got != 0 {
+		t.Errorf("Report() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// ServiceHeaderName is the header naming the service a request or response
+// came from.
+const ServiceHeaderName = "X-Knative-Service"
+
+// UnknownUpstreamService is reported for the timeout cascades of responses
+// that do not name their service.
+const UnknownUpstreamService = "unknown"
+
+type upstreamKey struct{}
+
+// upstreamResponse is the response of the user container to a request. It is
+// guarded by a mutex as the TimeoutHandler may return before the user
+// container responds.
+type upstreamResponse struct {
+	mux        sync.Mutex
+	statusCode int
+	service    string
+}
+
+// RecordUpstreamResponse is a httputil.ReverseProxy ModifyResponse func
+// recording the response of the user container for the TimeoutCascadeHandler
+// serving the request.
+func RecordUpstreamResponse(resp *http.Response) error {
+	if u, ok := resp.Request.Context().Value(upstreamKey{}).(*upstreamResponse); ok {
+		u.mux.Lock()
+		defer u.mux.Unlock()
+		u.statusCode = resp.StatusCode
+		u.service = resp.Header.Get(ServiceHeaderName)
+	}
+	return nil
+}
+
+// IsTimeoutCascade returns whether a request cascaded the timeout of its
+// upstream, i.e. it responded with a 504 after receiving a 504 upstream.
+func IsTimeoutCascade(upstreamStatus, clientStatus int) bool {
+	return upstreamStatus == http.StatusGatewayTimeout && clientStatus == http.StatusGatewayTimeout
+}
+
+// TimeoutCascadeHandler detects timeout cascades by comparing the status code
+// of the response of the user container with the one sent to the client. It
+// must wrap the TimeoutHandler, whose timeouts are not cascades.
+type TimeoutCascadeHandler struct {
+	Next http.Handler
+	// Cascaded is called with the upstream service of each timeout cascade.
+	Cascaded func(upstream string)
+}
+
+func (h *TimeoutCascadeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u := &upstreamResponse{}
+	sw := &statusWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	h.Next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), upstreamKey{}, u)))
+
+	u.mux.Lock()
+	statusCode, service := u.statusCode, u.service
+	u.mux.Unlock()
+	if !IsTimeoutCascade(statusCode, sw.statusCode) {
+		return
+	}
+	if service == "" {
+		service = UnknownUpstreamService
+	}
+	h.Cascaded(service)
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
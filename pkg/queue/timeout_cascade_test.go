@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsTimeoutCascade(t *testing.T) {
+	tests := []struct {
+		upstream, client int
+		want             bool
+	}{
+		{http.StatusGatewayTimeout, http.StatusGatewayTimeout, true},
+		{http.StatusGatewayTimeout, http.StatusServiceUnavailable, false},
+		{http.StatusGatewayTimeout, http.StatusOK, false},
+		{http.StatusOK, http.StatusGatewayTimeout, false},
+		{0, http.StatusGatewayTimeout, false},
+	}
+	for _, test := range tests {
+		if got := IsTimeoutCascade(test.upstream, test.client); got != test.want {
+			t.Errorf("IsTimeoutCascade(%d, %d) = %v, want %v", test.upstream, test.client, got, test.want)
+		}
+	}
+}
+
+func TestTimeoutCascadeHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		service string
+		delay   time.Duration
+		want    []string
+	}{{
+		name:   "success",
+		status: http.StatusOK,
+	}, {
+		name:   "upstream error",
+		status: http.StatusInternalServerError,
+	}, {
+		name:    "cascade",
+		status:  http.StatusGatewayTimeout,
+		service: "upstream",
+		want:    []string{"upstream"},
+	}, {
+		name:   "cascade from unknown service",
+		status: http.StatusGatewayTimeout,
+		want:   []string{UnknownUpstreamService},
+	}, {
+		// The revision timed out itself before the upstream did.
+		name:    "revision timeout",
+		status:  http.StatusGatewayTimeout,
+		service: "upstream",
+		delay:   time.Second,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			container := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(test.delay)
+				if test.service != "" {
+					w.Header().Set(ServiceHeaderName, test.service)
+				}
+				w.WriteHeader(test.status)
+			}))
+			defer container.Close()
+			target, err := url.Parse(container.URL)
+			if err != nil {
+				t.Fatalf("url.Parse() = %v", err)
+			}
+			proxy := httputil.NewSingleHostReverseProxy(target)
+			proxy.ModifyResponse = RecordUpstreamResponse
+
+			var got []string
+			handler := &TimeoutCascadeHandler{
+				Next:     http.TimeoutHandler(proxy, 100*time.Millisecond, "request timeout"),
+				Cascaded: func(upstream string) { got = append(got, upstream) },
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com", nil))
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Cascaded (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
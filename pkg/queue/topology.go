@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/stats/view"
+)
+
+const (
+	// TopologyNamespace is the Prometheus namespace of the service topology
+	// metrics. They are exported apart from the other queue-proxy metrics so
+	// that dashboards can render the service dependency graph from them.
+	TopologyNamespace = "serving_topology"
+
+	// UnknownSourceService is reported for the calls of callers that do not
+	// name their service.
+	UnknownSourceService = "unknown"
+
+	// DownstreamServiceClusterHeaderName is the header the Istio sidecar of
+	// a caller in the mesh sets to its service cluster, e.g. "app.ns". The
+	// app is the app label of the caller's pods, which defaults to the
+	// revision name for Knative pods.
+	DownstreamServiceClusterHeaderName = "X-Envoy-Downstream-Service-Cluster"
+
+	// defaultServiceCluster is the service cluster of the sidecars of the
+	// pods without an app label.
+	defaultServiceCluster = "istio-proxy"
+)
+
+// ParseServiceHost returns the service and namespace addressed by the Host
+// header of a request, e.g. "svc.ns.svc.cluster.local" or
+// "svc.ns.example.com". The returned boolean is false when the host does not
+// name a service.
+func ParseServiceHost(host string) (string, string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(host, ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// SourceService returns the service calling with the request. That is the
+// service named in the X-Knative-Service header, which the activator sets for
+// the requests it forwards, or else the app of the caller's service cluster.
+func SourceService(r *http.Request) string {
+	if s := r.Header.Get(ServiceHeaderName); s != "" {
+		return s
+	}
+	cluster := r.Header.Get(DownstreamServiceClusterHeaderName)
+	if app := strings.SplitN(cluster, ".", 2)[0]; app != "" && app != defaultServiceCluster {
+		return app
+	}
+	return UnknownSourceService
+}
+
+// topologyExporter exports the service topology views with the topology
+// exporter and all the other views with the default one.
+type topologyExporter struct {
+	def      view.Exporter
+	topology view.Exporter
+}
+
+// NewTopologyExporter returns an exporter exporting the service topology
+// views to topology and the other views to def.
+func NewTopologyExporter(def, topology view.Exporter) view.Exporter {
+	return &topologyExporter{def: def, topology: topology}
+}
+
+// ExportView implements view.Exporter.
+func (e *topologyExporter) ExportView(vd *view.Data) {
+	if vd.View.Name == UpstreamCallTotalN {
+		e.topology.ExportView(vd)
+		return
+	}
+	e.def.ExportView(vd)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/stats/view"
+)
+
+func TestParseServiceHost(t *testing.T) {
+	tests := []struct {
+		host          string
+		wantService   string
+		wantNamespace string
+		wantOK        bool
+	}{
+		{"svc.ns.svc.cluster.local", "svc", "ns", true},
+		{"svc.ns.svc.cluster.local:80", "svc", "ns", true},
+		{"svc.ns.example.com", "svc", "ns", true},
+		{"svc.ns", "svc", "ns", true},
+		{"svc", "", "", false},
+		{"localhost:8012", "", "", false},
+		{"10.0.0.1:8012", "", "", false},
+		{".ns", "", "", false},
+	}
+	for _, test := range tests {
+		service, namespace, ok := ParseServiceHost(test.host)
+		if service != test.wantService || namespace != test.wantNamespace || ok != test.wantOK {
+			t.Errorf("ParseServiceHost(%q) = %q, %q, %v, want %q, %q, %v", test.host,
+				service, namespace, ok, test.wantService, test.wantNamespace, test.wantOK)
+		}
+	}
+}
+
+func TestSourceService(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{{
+		name: "no headers",
+		want: UnknownSourceService,
+	}, {
+		name:    "service header",
+		headers: map[string]string{ServiceHeaderName: "caller"},
+		want:    "caller",
+	}, {
+		name:    "service cluster",
+		headers: map[string]string{DownstreamServiceClusterHeaderName: "caller.ns"},
+		want:    "caller",
+	}, {
+		name:    "service cluster without namespace",
+		headers: map[string]string{DownstreamServiceClusterHeaderName: "caller"},
+		want:    "caller",
+	}, {
+		name:    "default service cluster",
+		headers: map[string]string{DownstreamServiceClusterHeaderName: "istio-proxy.ns"},
+		want:    UnknownSourceService,
+	}, {
+		name: "service header wins",
+		headers: map[string]string{
+			ServiceHeaderName:                  "caller",
+			DownstreamServiceClusterHeaderName: "activator.knative-serving",
+		},
+		want: "caller",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://svc.ns.svc.cluster.local", nil)
+			for k, v := range test.headers {
+				r.Header.Set(k, v)
+			}
+			if got := SourceService(r); got != test.want {
+				t.Errorf("SourceService() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+type recordingExporter struct {
+	views []string
+}
+
+func (e *recordingExporter) ExportView(vd *view.Data) {
+	e.views = append(e.views, vd.View.Name)
+}
+
+func TestTopologyExporter(t *testing.T) {
+	def, topology := &recordingExporter{}, &recordingExporter{}
+	e := NewTopologyExporter(def, topology)
+
+	e.ExportView(&view.Data{View: &view.View{Name: UpstreamCallTotalN}})
+	e.ExportView(&view.Data{View: &view.View{Name: OperationsPerSecondN}})
+
+	if len(topology.views) != 1 || topology.views[0] != UpstreamCallTotalN {
+		t.Errorf("Topology exporter got %v, want %v", topology.views, []string{UpstreamCallTotalN})
+	}
+	if len(def.views) != 1 || def.views[0] != OperationsPerSecondN {
+		t.Errorf("Default exporter got %v, want %v", def.views, []string{OperationsPerSecondN})
+	}
+}
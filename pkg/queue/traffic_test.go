@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsExternalRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		wantExternal bool
+	}{{
+		name:       "10/8",
+		remoteAddr: "10.4.2.1:45678",
+	}, {
+		name:       "172.16/12",
+		remoteAddr: "172.31.255.1:45678",
+	}, {
+		name:       "192.168/16",
+		remoteAddr: "192.168.1.1:45678",
+	}, {
+		name:       "loopback",
+		remoteAddr: "127.0.0.1:45678",
+	}, {
+		name:       "unparseable address",
+		remoteAddr: "pipe",
+	}, {
+		name:         "public address",
+		remoteAddr:   "8.8.8.8:45678",
+		wantExternal: true,
+	}, {
+		name:         "just outside 172.16/12",
+		remoteAddr:   "172.32.0.1:45678",
+		wantExternal: true,
+	}, {
+		name:         "public IPv6 address",
+		remoteAddr:   "[2001:4860::8888]:45678",
+		wantExternal: true,
+	}, {
+		name:         "forwarded by an ingress",
+		remoteAddr:   "10.4.2.1:45678",
+		forwardedFor: "203.0.113.7",
+		wantExternal: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com", nil)
+			r.RemoteAddr = test.remoteAddr
+			if test.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+			if got := IsExternalRequest(r); got != test.wantExternal {
+				t.Errorf("IsExternalRequest() = %v, want %v", got, test.wantExternal)
+			}
+		})
+	}
+}
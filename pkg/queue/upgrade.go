@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The protocols reported for rejected upgrades.
+const (
+	UpgradeProtocolWebSocket = "websocket"
+	UpgradeProtocolH2C       = "h2c"
+	UpgradeProtocolOther     = "other"
+)
+
+// ParseUpgradeAllowlist parses a comma separated list of the protocols
+// requests may upgrade to.
+func ParseUpgradeAllowlist(s string) map[string]bool {
+	allowed := make(map[string]bool)
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			allowed[p] = true
+		}
+	}
+	return allowed
+}
+
+// UpgradeProtocol returns the protocol reported for the upgrade header.
+func UpgradeProtocol(upgrade string) string {
+	switch p := strings.ToLower(upgrade); p {
+	case UpgradeProtocolWebSocket, UpgradeProtocolH2C:
+		return p
+	default:
+		return UpgradeProtocolOther
+	}
+}
+
+// UpgradeHandler rejects the requests upgrading to a protocol missing from
+// Allowed with a 426 Upgrade Required. Requests may upgrade to any protocol
+// when Allowed is nil.
+type UpgradeHandler struct {
+	Next    http.Handler
+	Allowed map[string]bool
+	// Rejected is called with the protocol of each rejected upgrade.
+	Rejected func(protocol string)
+}
+
+func (h *UpgradeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrade := r.Header.Get("Upgrade")
+	if upgrade == "" || h.Allowed == nil || h.Allowed[strings.ToLower(upgrade)] {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+	h.Rejected(UpgradeProtocol(upgrade))
+	http.Error(w, "upgrade to "+upgrade+" is not allowed", http.StatusUpgradeRequired)
+}
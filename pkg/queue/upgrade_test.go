@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
+)
+
+func TestParseUpgradeAllowlist(t *testing.T) {
+	got := ParseUpgradeAllowlist(" WebSocket, h2c,,")
+	want := map[string]bool{"websocket": true, "h2c": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseUpgradeAllowlist (-want, +got) = %v", diff)
+	}
+	if got := ParseUpgradeAllowlist(""); len(got) != 0 {
+		t.Errorf("ParseUpgradeAllowlist(\"\") = %v, want empty", got)
+	}
+}
+
+func TestUpgradeProtocol(t *testing.T) {
+	for upgrade, want := range map[string]string{
+		"websocket": UpgradeProtocolWebSocket,
+		"WebSocket": UpgradeProtocolWebSocket,
+		"h2c":       UpgradeProtocolH2C,
+		"TLS/1.2":   UpgradeProtocolOther,
+	} {
+		if got := UpgradeProtocol(upgrade); got != want {
+			t.Errorf("UpgradeProtocol(%q) = %q, want %q", upgrade, got, want)
+		}
+	}
+}
+
+func TestUpgradeHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  map[string]bool
+		upgrade  string
+		wantCode int
+	}{{
+		name:     "no upgrade",
+		allowed:  ParseUpgradeAllowlist(""),
+		wantCode: http.StatusOK,
+	}, {
+		name:     "no allowlist",
+		upgrade:  "websocket",
+		wantCode: http.StatusOK,
+	}, {
+		name:     "allowed",
+		allowed:  ParseUpgradeAllowlist("websocket"),
+		upgrade:  "WebSocket",
+		wantCode: http.StatusOK,
+	}, {
+		name:     "not allowed",
+		allowed:  ParseUpgradeAllowlist("websocket"),
+		upgrade:  "h2c",
+		wantCode: http.StatusUpgradeRequired,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rejected []string
+			h := &UpgradeHandler{
+				Next:     http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+				Allowed:  test.allowed,
+				Rejected: func(p string) { rejected = append(rejected, p) },
+			}
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			if test.upgrade != "" {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", test.upgrade)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != test.wantCode {
+				t.Errorf("Code = %d, want %d", rec.Code, test.wantCode)
+			}
+			if wantRejected := test.wantCode == http.StatusUpgradeRequired; wantRejected != (len(rejected) == 1) {
+				t.Errorf("Rejected = %v, want rejected %v", rejected, wantRejected)
+			}
+		})
+	}
+}
+
+func TestUpgradeHandlerEmptyAllowlistCountsRejection(t *testing.T) {
+	reporter, err := NewStatsReporter(namespace, config, revision)
+	if err != nil {
+		t.Fatalf("NewStatsReporter() = %v", err)
+	}
+	defer reporter.UnregisterViews()
+
+	h := &UpgradeHandler{
+		Next:    http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		Allowed: ParseUpgradeAllowlist(""),
+		Rejected: func(p string) {
+			if err := reporter.ReportUpgradeRejected(p); err != nil {
+				t.Errorf("ReportUpgradeRejected() = %v", err)
+			}
+		},
+	}
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUpgradeRequired {
+		t.Errorf("Code = %d, want %d", rec.Code, http.StatusUpgradeRequired)
+	}
+	if v, err := view.RetrieveData(WebSocketUpgradeRejectedTotalN); err != nil {
+		t.Errorf("RetrieveData() = %v", err)
+	} else if len(v) != 1 {
+		t.Errorf("Got %d rows for %s, want 1", len(v), WebSocketUpgradeRejectedTotalN)
+	} else {
+		checkTags(t, v[0].Tags, map[string]string{
+			"destination_namespace":     namespace,
+			"destination_configuration": config,
+			"destination_revision":      revision,
+			"upgrade":                   UpgradeProtocolWebSocket,
+		})
+		if got := v[0].Data.(*view.CountData).Value; got != 1 {
+			t.Errorf("Count = %d, want 1", got)
+		}
+	}
+}
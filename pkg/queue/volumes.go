@@ -0,0 +1,182 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// VolumeUtilizationThreshold is the fraction of the capacity of a volume
+// above which the volume is considered close to full.
+const VolumeUtilizationThreshold = 0.9
+
+// mountsPath lists the mounts of the container.
+const mountsPath = "/proc/mounts"
+
+var (
+	// nonVolumeFSTypes are the pseudo and in-memory filesystems mounted in
+	// every container, which do not hold data.
+	nonVolumeFSTypes = map[string]bool{
+		"autofs":     true,
+		"bpf":        true,
+		"cgroup":     true,
+		"cgroup2":    true,
+		"configfs":   true,
+		"debugfs":    true,
+		"devpts":     true,
+		"devtmpfs":   true,
+		"fusectl":    true,
+		"hugetlbfs":  true,
+		"mqueue":     true,
+		"overlay":    true,
+		"proc":       true,
+		"pstore":     true,
+		"securityfs": true,
+		"sysfs":      true,
+		"tmpfs":      true,
+		"tracefs":    true,
+	}
+
+	// nonVolumeMountPoints are the files the kubelet mounts in every
+	// container.
+	nonVolumeMountPoints = map[string]bool{
+		"/dev/termination-log": true,
+		"/etc/hostname":        true,
+		"/etc/hosts":           true,
+		"/etc/resolv.conf":     true,
+	}
+
+	// mountPointEscapes undoes the octal escaping of the mount points in
+	// mountsPath.
+	mountPointEscapes = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+)
+
+// VolumeUsage is the usage of a volume mounted in the container, named after
+// its mount point.
+type VolumeUsage struct {
+	Name          string
+	UsedBytes     int64
+	CapacityBytes int64
+}
+
+// Utilization returns the fraction of the capacity of the volume in use.
+func (v VolumeUsage) Utilization() float64 {
+	if v.CapacityBytes == 0 {
+		return 0
+	}
+	return float64(v.UsedBytes) / float64(v.CapacityBytes)
+}
+
+// FullVolumes tracks the volumes of the container that are close to full, to
+// be reported to the autoscaler with the stats of the pod.
+type FullVolumes struct {
+	mux   sync.Mutex
+	names []string
+}
+
+// Update records the volumes above VolumeUtilizationThreshold among usage,
+// and returns those that were not at the previous update.
+func (f *FullVolumes) Update(usage []VolumeUsage) []VolumeUsage {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	prev := make(map[string]bool, len(f.names))
+	for _, name := range f.names {
+		prev[name] = true
+	}
+	f.names = nil
+	var crossed []VolumeUsage
+	for _, v := range usage {
+		if v.Utilization() <= VolumeUtilizationThreshold {
+			continue
+		}
+		f.names = append(f.names, v.Name)
+		if !prev[v.Name] {
+			crossed = append(crossed, v)
+		}
+	}
+	return crossed
+}
+
+// Names returns the names of the volumes close to full as of the latest
+// update.
+func (f *FullVolumes) Names() []string {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.names
+}
+
+// ReadVolumeUsage returns the usage of the volumes mounted in the container.
+func ReadVolumeUsage() ([]VolumeUsage, error) {
+	f, err := os.Open(mountsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	mountPoints, err := parseVolumeMountPoints(f)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]VolumeUsage, 0, len(mountPoints))
+	for _, mountPoint := range mountPoints {
+		u, err := statVolume(mountPoint)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// parseVolumeMountPoints extracts the mount points of the volumes from the
+// "device mountpoint fstype options dump pass" lines of mountsPath.
+func parseVolumeMountPoints(r io.Reader) ([]string, error) {
+	var mountPoints []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		mountPoint, fsType := mountPointEscapes.Replace(fields[1]), fields[2]
+		if mountPoint == "/" || nonVolumeFSTypes[fsType] || nonVolumeMountPoints[mountPoint] || seen[mountPoint] {
+			continue
+		}
+		seen[mountPoint] = true
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	return mountPoints, scanner.Err()
+}
+
+func statVolume(mountPoint string) (VolumeUsage, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(mountPoint, &fs); err != nil {
+		return VolumeUsage{}, err
+	}
+	return VolumeUsage{
+		Name:          mountPoint,
+		UsedBytes:     int64(fs.Blocks-fs.Bfree) * int64(fs.Bsize),
+		CapacityBytes: int64(fs.Blocks) * int64(fs.Bsize),
+	}, nil
+}
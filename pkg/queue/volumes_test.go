@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVolumeMountPoints(t *testing.T) {
+	mounts := `overlay / overlay rw,relatime,lowerdir=/var/lib/docker/overlay2/l/A 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+tmpfs /dev tmpfs rw,nosuid,size=65536k,mode=755 0 0
+devpts /dev/pts devpts rw,nosuid,noexec,relatime,gid=5,mode=620 0 0
+sysfs /sys sysfs ro,nosuid,nodev,noexec,relatime 0 0
+cgroup /sys/fs/cgroup/memory cgroup ro,nosuid,nodev,noexec,relatime,memory 0 0
+/dev/sda1 /dev/termination-log ext4 rw,relatime 0 0
+/dev/sda1 /etc/resolv.conf ext4 rw,relatime 0 0
+/dev/sda1 /etc/hostname ext4 rw,relatime 0 0
+/dev/sda1 /etc/hosts ext4 rw,relatime 0 0
+/dev/sdb /var/data ext4 rw,relatime 0 0
+/dev/sdb /var/data ext4 rw,relatime 0 0
+10.0.0.2:/exports /mnt/shared\040files nfs4 rw,relatime 0 0
+tmpfs /var/run/secrets/kubernetes.io/serviceaccount tmpfs ro,relatime 0 0
+`
+	got, err := parseVolumeMountPoints(strings.NewReader(mounts))
+	if err != nil {
+		t.Fatalf("parseVolumeMountPoints() = %v", err)
+	}
+	if diff := cmp.Diff([]string{"/var/data", "/mnt/shared files"}, got); diff != "" {
+		t.Errorf("Volume mount points (-want, +got) = %v", diff)
+	}
+}
+
+func TestStatVolume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	got, err := statVolume(dir)
+	if err != nil {
+		t.Fatalf("statVolume() = %v", err)
+	}
+	if got.Name != dir || got.CapacityBytes <= 0 || got.UsedBytes < 0 || got.UsedBytes > got.CapacityBytes {
+		t.Errorf("statVolume() = %+v, want a usage within the capacity of %s", got, dir)
+	}
+
+	if _, err := statVolume(dir + "/missing"); err == nil {
+		t.Error("statVolume() = nil, want an error for a missing mount point")
+	}
+}
+
+func TestVolumeUtilization(t *testing.T) {
+	if got := (VolumeUsage{UsedBytes: 900, CapacityBytes: 1000}).Utilization(); got != 0.9 {
+		t.Errorf("Utilization() = %v, want 0.9", got)
+	}
+	if got := (VolumeUsage{}).Utilization(); got != 0 {
+		t.Errorf("Utilization() = %v, want 0 for an empty volume", got)
+	}
+}
+
+func TestFullVolumes(t *testing.T) {
+	var full FullVolumes
+	data := VolumeUsage{Name: "/data", UsedBytes: 950, CapacityBytes: 1000}
+	cache := VolumeUsage{Name: "/cache", UsedBytes: 100, CapacityBytes: 1000}
+
+	if diff := cmp.Diff([]VolumeUsage{data}, full.Update([]VolumeUsage{data, cache})); diff != "" {
+		t.Errorf("Update() (-want, +got) = %v", diff)
+	}
+	if diff := cmp.Diff([]string{"/data"}, full.Names()); diff != "" {
+		t.Errorf("Names() (-want, +got) = %v", diff)
+	}
+	if got := full.Update([]VolumeUsage{data, cache}); len(got) != 0 {
+		t.Errorf("Update() = %v, want no volume crossing while /data stays full", got)
+	}
+
+	data.UsedBytes = 500
+	if got := full.Update([]VolumeUsage{data, cache}); len(got) != 0 {
+		t.Errorf("Update() = %v, want no volume crossing", got)
+	}
+	if got := full.Names(); len(got) != 0 {
+		t.Errorf("Names() = %v, want none", got)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// InformerCacheSizePeriod is how often the sizes of the informer caches are
+// reported.
+const InformerCacheSizePeriod = 30 * time.Second
+
+var (
+	informerCacheSizeM = stats.Int64(
+		"controller_informer_cache_size",
+		"Number of objects in the informer cache of a resource kind",
+		stats.UnitDimensionless)
+
+	resourceKindTagKey = mustNewTagKey("resource_kind")
+)
+
+func init() {
+	err := metrics.RegisterViews(
+		&view.View{
+			Description: "Number of objects in the informer cache of a resource kind",
+			Measure:     informerCacheSizeM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{resourceKindTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CacheSizeFunc returns the number of objects in an informer cache, usually
+// by counting the objects listed by its lister.
+type CacheSizeFunc func() (int, error)
+
+// ReportInformerCacheSizes reports the size of the informer cache of each
+// resource kind every InformerCacheSizePeriod, until stopCh is closed. The
+// sizes help budgeting the memory of the controller for the scale of the
+// cluster.
+func ReportInformerCacheSizes(sizes map[string]CacheSizeFunc, logger *zap.SugaredLogger, stopCh <-chan struct{}) {
+	wait.Until(func() { recordInformerCacheSizes(sizes, logger) }, InformerCacheSizePeriod, stopCh)
+}
+
+func recordInformerCacheSizes(sizes map[string]CacheSizeFunc, logger *zap.SugaredLogger) {
+	for kind, size := range sizes {
+		n, err := size()
+		if err != nil {
+			logger.Errorf("Failed to list the cached %s objects: %v", kind, err)
+			continue
+		}
+		ctx, err := tag.New(context.Background(), tag.Insert(resourceKindTagKey, kind))
+		if err != nil {
+			logger.Errorf("Failed to report the %s informer cache size: %v", kind, err)
+			continue
+		}
+		stats.Record(ctx, informerCacheSizeM.M(int64(n)))
+	}
+}
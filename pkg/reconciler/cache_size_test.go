@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"errors"
+	"testing"
+
+	"go.opencensus.io/stats/view"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+func TestRecordInformerCacheSizes(t *testing.T) {
+	recordInformerCacheSizes(map[string]CacheSizeFunc{
+		"Revision": func() (int, error) { return 12, nil },
+		"Route":    func() (int, error) { return 3, nil },
+		"Service":  func() (int, error) { return 0, errors.New("not synced") },
+	}, TestLogger(t))
+
+	rows, err := view.RetrieveData("controller_informer_cache_size")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	got := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == resourceKindTagKey {
+				got[tag.Value] = row.Data.(*view.LastValueData).Value
+			}
+		}
+	}
+	want := map[string]float64{"Revision": 12, "Route": 3}
+	if len(got) != len(want) || got["Revision"] != 12 || got["Route"] != 3 {
+		t.Errorf("Cache sizes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configschema
+
+import (
+	"github.com/knative/pkg/configmap"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const controllerAgentName = "configschema-controller"
+
+// Watcher reports the schema version of the ConfigMaps of Knative Serving
+// and warns about ConfigMaps using an older schema than the controller, or
+// failing to parse.
+type Watcher struct {
+	*reconciler.Base
+
+	statsReporter StatsReporter
+}
+
+// NewWatcher creates a Watcher.
+func NewWatcher(opt reconciler.Options) *Watcher {
+	return &Watcher{
+		Base:          reconciler.NewBase(opt, controllerAgentName),
+		statsReporter: NewStatsReporter(),
+	}
+}
+
+// Watch registers the Watcher for every ConfigMap in ConfigMapNames.
+func (w *Watcher) Watch(cmw configmap.Watcher) {
+	for _, name := range ConfigMapNames {
+		cmw.Watch(name, w.observe)
+	}
+}
+
+func (w *Watcher) observe(cm *corev1.ConfigMap) {
+	w.checkParse(cm)
+
+	version, err := SchemaVersion(cm)
+	if err != nil {
+		w.Logger.Errorf("Failed to read the ConfigMap schema version: %v", err)
+		w.Recorder.Eventf(cm, corev1.EventTypeWarning, "InvalidSchemaVersion", "%v", err)
+		return
+	}
+	if err := w.statsReporter.ReportSchemaVersion(cm.Name, version); err != nil {
+		w.Logger.Errorf("Failed to report the ConfigMap schema version: %v", err)
+	}
+	if version < CurrentVersion {
+		w.Recorder.Eventf(cm, corev1.EventTypeWarning, "OutdatedSchema",
+			"ConfigMap %q uses schema version %d, but the controller supports version %d",
+			cm.Name, version, CurrentVersion)
+	}
+}
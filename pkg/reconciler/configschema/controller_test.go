@@ -0,0 +1,170 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configschema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+type fakeStatsReporter struct {
+	versions    map[string]int
+	parseErrors []string
+}
+
+func (r *fakeStatsReporter) ReportSchemaVersion(configMap string, version int) error {
+	r.versions[configMap] = version
+	return nil
+}
+
+func (r *fakeStatsReporter) ReportParseError(configMap, errorType string) error {
+	r.parseErrors = append(r.parseErrors, configMap+"/"+errorType)
+	return nil
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantVersion int
+		wantEvent   bool
+	}{{
+		name:        "current schema",
+		annotations: map[string]string{serving.ConfigSchemaVersionAnnotationKey: "2"},
+		wantVersion: 2,
+	}, {
+		name:        "outdated schema",
+		wantVersion: 1,
+		wantEvent:   true,
+	}, {
+		name:        "invalid schema version",
+		annotations: map[string]string{serving.ConfigSchemaVersionAnnotationKey: "two"},
+		wantEvent:   true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			reporter := &fakeStatsReporter{versions: make(map[string]int)}
+			w := &Watcher{
+				Base: &reconciler.Base{
+					Recorder: recorder,
+					Logger:   TestLogger(t),
+				},
+				statsReporter: reporter,
+			}
+
+			w.observe(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "config-network",
+					Annotations: test.annotations,
+				},
+			})
+
+			if got := reporter.versions["config-network"]; got != test.wantVersion {
+				t.Errorf("Reported schema version = %d, want %d", got, test.wantVersion)
+			}
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected an event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestCheckParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		configMap      string
+		data           map[string]string
+		wantParseError string
+	}{{
+		name:      "valid",
+		configMap: "config-gc",
+		data:      map[string]string{"stale-revision-timeout": "1h"},
+	}, {
+		name:           "invalid number",
+		configMap:      "config-gc",
+		data:           map[string]string{"stale-revision-minimum-generations": "one"},
+		wantParseError: "config-gc/invalid_number",
+	}, {
+		name:           "invalid duration",
+		configMap:      "config-gc",
+		data:           map[string]string{"stale-revision-timeout": "an hour"},
+		wantParseError: "config-gc/invalid_duration",
+	}, {
+		name:           "invalid config",
+		configMap:      "config-domain",
+		data:           map[string]string{},
+		wantParseError: "config-domain/invalid_config",
+	}, {
+		name:      "not parsed",
+		configMap: "config-unknown",
+		data:      map[string]string{"foo": "bar"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			reporter := &fakeStatsReporter{versions: make(map[string]int)}
+			w := &Watcher{
+				Base: &reconciler.Base{
+					Recorder: recorder,
+					Logger:   TestLogger(t),
+				},
+				statsReporter: reporter,
+			}
+
+			w.checkParse(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: test.configMap},
+				Data:       test.data,
+			})
+
+			var want []string
+			if test.wantParseError != "" {
+				want = []string{test.wantParseError}
+			}
+			if diff := cmp.Diff(want, reporter.parseErrors); diff != "" {
+				t.Errorf("Reported parse errors (-want, +got) = %v", diff)
+			}
+			select {
+			case event := <-recorder.Events:
+				if test.wantParseError == "" {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantParseError != "" {
+					t.Error("Expected an event, got none")
+				}
+			}
+		})
+	}
+}
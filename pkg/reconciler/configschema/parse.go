@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configschema
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/knative/serving/pkg/autoscaler"
+	"github.com/knative/serving/pkg/gc"
+	"github.com/knative/serving/pkg/logging"
+	"github.com/knative/serving/pkg/metrics"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
+	routeconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The error_type values parse errors are classified into.
+const (
+	errorTypeInvalidNumber   = "invalid_number"
+	errorTypeInvalidDuration = "invalid_duration"
+	errorTypeInvalidConfig   = "invalid_config"
+)
+
+// parsers parse the ConfigMaps of ConfigMapNames the way the config stores
+// of the reconcilers do. The stores keep the previous config when a ConfigMap
+// fails to parse, which only shows in their logs.
+var parsers = map[string]func(*corev1.ConfigMap) error{
+	autoscaler.ConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := autoscaler.NewConfigFromConfigMap(cm)
+		return err
+	},
+	revisionconfig.ControllerConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := revisionconfig.NewControllerConfigFromConfigMap(cm)
+		return err
+	},
+	routeconfig.DomainConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := routeconfig.NewDomainFromConfigMap(cm)
+		return err
+	},
+	gc.ConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := gc.NewConfigFromConfigMap(cm)
+		return err
+	},
+	ingressconfig.IstioConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := ingressconfig.NewIstioFromConfigMap(cm)
+		return err
+	},
+	logging.ConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := logging.NewConfigFromConfigMap(cm)
+		return err
+	},
+	revisionconfig.NetworkConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := revisionconfig.NewNetworkFromConfigMap(cm)
+		return err
+	},
+	metrics.ObservabilityConfigName: func(cm *corev1.ConfigMap) error {
+		_, err := revisionconfig.NewObservabilityFromConfigMap(cm)
+		return err
+	},
+}
+
+// checkParse reports the ConfigMap if it fails to parse, and warns about it
+// on the ConfigMap so that the operators can fix it.
+func (w *Watcher) checkParse(cm *corev1.ConfigMap) {
+	parse, ok := parsers[cm.Name]
+	if !ok {
+		return
+	}
+	err := parse(cm)
+	if err == nil {
+		return
+	}
+	w.Logger.Errorf("Failed to parse ConfigMap %q: %v", cm.Name, err)
+	w.Recorder.Eventf(cm, corev1.EventTypeWarning, "ParseFailed",
+		"Failed to parse ConfigMap %q, the previous config is kept: %v", cm.Name, err)
+	if rerr := w.statsReporter.ReportParseError(cm.Name, parseErrorType(err)); rerr != nil {
+		w.Logger.Errorf("Failed to report the ConfigMap parse error: %v", rerr)
+	}
+}
+
+// parseErrorType classifies a parse error into one of the error_type values.
+func parseErrorType(err error) string {
+	if _, ok := err.(*strconv.NumError); ok {
+		return errorTypeInvalidNumber
+	}
+	// time.ParseDuration returns plain errors.
+	if strings.HasPrefix(err.Error(), "time: ") {
+		return errorTypeInvalidDuration
+	}
+	return errorTypeInvalidConfig
+}
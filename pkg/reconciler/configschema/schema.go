@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configschema
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/autoscaler"
+	"github.com/knative/serving/pkg/gc"
+	"github.com/knative/serving/pkg/logging"
+	"github.com/knative/serving/pkg/metrics"
+	ingressconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/config"
+	revisionconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
+	routeconfig "github.com/knative/serving/pkg/reconciler/v1alpha1/route/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CurrentVersion is the newest ConfigMap schema version supported by
+	// this controller.
+	CurrentVersion = 2
+
+	// legacyVersion is the schema version of ConfigMaps written before the
+	// schema version annotation was introduced.
+	legacyVersion = 1
+)
+
+// ConfigMapNames are the ConfigMaps of Knative Serving whose schema
+// version is tracked.
+var ConfigMapNames = []string{
+	autoscaler.ConfigName,
+	revisionconfig.ControllerConfigName,
+	routeconfig.DomainConfigName,
+	gc.ConfigName,
+	ingressconfig.IstioConfigName,
+	logging.ConfigName,
+	revisionconfig.NetworkConfigName,
+	metrics.ObservabilityConfigName,
+}
+
+// migrations maps a schema version to the function upgrading the data of a
+// ConfigMap from that version to the next one.
+var migrations = map[int]func(name string, data map[string]string){
+	1: migrateFromV1,
+}
+
+// migrateFromV1 removes the config-autoscaler keys that are no longer read.
+// The scale-to-zero-threshold is not carried over to the
+// scale-to-zero-grace-period as the two have different meanings.
+func migrateFromV1(name string, data map[string]string) {
+	if name != autoscaler.ConfigName {
+		return
+	}
+	delete(data, "scale-to-zero-threshold")
+	delete(data, "concurrency-quantum-of-time")
+}
+
+// SchemaVersion returns the schema version of the ConfigMap, read from its
+// serving.knative.dev/configSchemaVersion annotation.
+func SchemaVersion(cm *corev1.ConfigMap) (int, error) {
+	raw, ok := cm.Annotations[serving.ConfigSchemaVersionAnnotationKey]
+	if !ok {
+		return legacyVersion, nil
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < legacyVersion {
+		return 0, fmt.Errorf("invalid schema version %q of ConfigMap %q", raw, cm.Name)
+	}
+	return version, nil
+}
+
+// MigrateConfigMap returns a copy of the ConfigMap upgraded to the current
+// schema version. The ConfigMap passed in is not modified.
+func MigrateConfigMap(old *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	version, err := SchemaVersion(old)
+	if err != nil {
+		return nil, err
+	}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("schema version %d of ConfigMap %q is newer than the supported version %d",
+			version, old.Name, CurrentVersion)
+	}
+
+	cm := old.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	for v := version; v < CurrentVersion; v++ {
+		migrations[v](cm.Name, cm.Data)
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.Annotations[serving.ConfigSchemaVersionAnnotationKey] = strconv.Itoa(CurrentVersion)
+	return cm, nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configschema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/autoscaler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/knative/serving/pkg/reconciler/testing"
+)
+
+func TestShippedConfigMapsAreCurrent(t *testing.T) {
+	for _, name := range ConfigMapNames {
+		t.Run(name, func(t *testing.T) {
+			cm := ConfigMapFromTestFile(t, name)
+			if got, err := SchemaVersion(cm); err != nil {
+				t.Errorf("SchemaVersion() = %v", err)
+			} else if got != CurrentVersion {
+				t.Errorf("SchemaVersion() = %d, want %d", got, CurrentVersion)
+			}
+		})
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        int
+		wantErr     bool
+	}{{
+		name: "no annotation",
+		want: legacyVersion,
+	}, {
+		name:        "annotated",
+		annotations: map[string]string{serving.ConfigSchemaVersionAnnotationKey: "2"},
+		want:        2,
+	}, {
+		name:        "not a number",
+		annotations: map[string]string{serving.ConfigSchemaVersionAnnotationKey: "two"},
+		wantErr:     true,
+	}, {
+		name:        "below the first version",
+		annotations: map[string]string{serving.ConfigSchemaVersionAnnotationKey: "0"},
+		wantErr:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        autoscaler.ConfigName,
+					Annotations: test.annotations,
+				},
+			}
+			got, err := SchemaVersion(cm)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("SchemaVersion() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("SchemaVersion() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigMap(t *testing.T) {
+	old := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: autoscaler.ConfigName,
+		},
+		Data: map[string]string{
+			"stable-window":               "60s",
+			"scale-to-zero-threshold":     "5m",
+			"concurrency-quantum-of-time": "100ms",
+		},
+	}
+	want := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: autoscaler.ConfigName,
+			Annotations: map[string]string{
+				serving.ConfigSchemaVersionAnnotationKey: "2",
+			},
+		},
+		Data: map[string]string{
+			"stable-window": "60s",
+		},
+	}
+	oldCopy := old.DeepCopy()
+
+	got, err := MigrateConfigMap(old)
+	if err != nil {
+		t.Fatalf("MigrateConfigMap() = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MigrateConfigMap() (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff(oldCopy, old); diff != "" {
+		t.Errorf("MigrateConfigMap() modified its input (-want +got): %s", diff)
+	}
+
+	// Migrating a current ConfigMap is a no-op.
+	again, err := MigrateConfigMap(got)
+	if err != nil {
+		t.Fatalf("MigrateConfigMap() = %v", err)
+	}
+	if diff := cmp.Diff(got, again); diff != "" {
+		t.Errorf("MigrateConfigMap() of a current ConfigMap (-want +got): %s", diff)
+	}
+}
+
+func TestMigrateConfigMapErrors(t *testing.T) {
+	for _, version := range []string{"3", "invalid"} {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        autoscaler.ConfigName,
+				Annotations: map[string]string{serving.ConfigSchemaVersionAnnotationKey: version},
+			},
+		}
+		if _, err := MigrateConfigMap(cm); err == nil {
+			t.Errorf("MigrateConfigMap() with schema version %q = nil, wanted an error", version)
+		}
+	}
+}
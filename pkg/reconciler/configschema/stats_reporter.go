@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configschema
+
+import (
+	"context"
+
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	schemaVersionM = stats.Int64(
+		"configmap_schema_version",
+		"Schema version of a ConfigMap of Knative Serving",
+		stats.UnitDimensionless)
+	parseErrorM = stats.Int64(
+		"configmap_parse_error_total",
+		"Number of times a ConfigMap of Knative Serving failed to parse",
+		stats.UnitDimensionless)
+
+	configMapTagKey tag.Key
+	errorTypeTagKey tag.Key
+)
+
+func init() {
+	var err error
+	// Create the tag keys that will be used to add tags to our measurements.
+	// Tag keys must conform to the restrictions described in
+	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
+	// - length between 1 and 255 inclusive
+	// - characters are printable US-ASCII
+	configMapTagKey, err = tag.NewKey("configmap_name")
+	if err != nil {
+		panic(err)
+	}
+	errorTypeTagKey, err = tag.NewKey("error_type")
+	if err != nil {
+		panic(err)
+	}
+
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	err = metrics.RegisterViews(
+		&view.View{
+			Description: "Schema version of a ConfigMap of Knative Serving",
+			Measure:     schemaVersionM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{configMapTagKey},
+		},
+		&view.View{
+			Description: "Number of times a ConfigMap of Knative Serving failed to parse",
+			Measure:     parseErrorM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{configMapTagKey, errorTypeTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// StatsReporter defines the interface for sending ConfigMap schema metrics
+type StatsReporter interface {
+	// ReportSchemaVersion captures the schema version of a ConfigMap.
+	ReportSchemaVersion(configMap string, version int) error
+
+	// ReportParseError captures a ConfigMap failing to parse.
+	ReportParseError(configMap, errorType string) error
+}
+
+// Reporter holds cached metric objects to report ConfigMap schema metrics
+type Reporter struct{}
+
+// NewStatsReporter creates a reporter that collects and reports ConfigMap
+// schema metrics
+func NewStatsReporter() *Reporter {
+	return &Reporter{}
+}
+
+// ReportSchemaVersion captures the schema version of a ConfigMap.
+func (r *Reporter) ReportSchemaVersion(configMap string, version int) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(configMapTagKey, configMap))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, schemaVersionM.M(int64(version)))
+	return nil
+}
+
+// ReportParseError captures a ConfigMap failing to parse.
+func (r *Reporter) ReportParseError(configMap, errorType string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(configMapTagKey, configMap),
+		tag.Insert(errorTypeTagKey, errorType))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, parseErrorM.M(1))
+	return nil
+}
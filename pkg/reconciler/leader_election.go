@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	leaderChangeCountM = stats.Int64(
+		"leader_election_change_total",
+		"Number of times the leader of a controller changed",
+		stats.UnitDimensionless)
+	leaderHoldDurationM = stats.Float64(
+		"leader_hold_duration_seconds",
+		"Duration of each leader term of a controller in seconds",
+		"s")
+
+	componentTagKey = mustNewTagKey("component")
+)
+
+func init() {
+	err := metrics.RegisterViews(
+		&view.View{
+			Description: "Number of times the leader of a controller changed",
+			Measure:     leaderChangeCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{componentTagKey},
+		},
+		&view.View{
+			Description: "Duration of each leader term of a controller in seconds",
+			Measure:     leaderHoldDurationM,
+			// Terms range from seconds during failovers to days in steady state.
+			Aggregation: view.Distribution(10, 60, 300, 900, 3600, 4*3600, 24*3600, 7*24*3600),
+			TagKeys:     []tag.Key{componentTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// LeaderElectionReporter reports the leadership changes of a controller.
+// Its methods are meant to be called from the leader election callbacks.
+// Reconciliation pauses while leadership moves, so more than one change per
+// hour points at network partitions or resource contention and is worth
+// alerting on.
+type LeaderElectionReporter struct {
+	ctx context.Context
+	now func() time.Time
+
+	mux       sync.Mutex
+	leader    string
+	termStart time.Time
+}
+
+// NewLeaderElectionReporter creates a LeaderElectionReporter for the given
+// component, e.g. "controller".
+func NewLeaderElectionReporter(component string) (*LeaderElectionReporter, error) {
+	ctx, err := tag.New(context.Background(), tag.Insert(componentTagKey, component))
+	if err != nil {
+		return nil, err
+	}
+	return &LeaderElectionReporter{
+		ctx: ctx,
+		now: time.Now,
+	}, nil
+}
+
+// OnNewLeader counts a change of leader. It is called with the identity of
+// every observed leader; observing the same leader again is not a change.
+func (r *LeaderElectionReporter) OnNewLeader(identity string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if identity == r.leader {
+		return
+	}
+	// The first leader observed by this process is not a change.
+	if r.leader != "" {
+		stats.Record(r.ctx, leaderChangeCountM.M(1))
+	}
+	r.leader = identity
+}
+
+// OnStartedLeading records the start of a leader term of this process.
+func (r *LeaderElectionReporter) OnStartedLeading() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.termStart = r.now()
+}
+
+// OnStoppedLeading records how long the leader term of this process lasted.
+func (r *LeaderElectionReporter) OnStoppedLeading() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.termStart.IsZero() {
+		return
+	}
+	stats.Record(r.ctx, leaderHoldDurationM.M(r.now().Sub(r.termStart).Seconds()))
+	r.termStart = time.Time{}
+}
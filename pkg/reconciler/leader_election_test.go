@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats/view"
+)
+
+func TestLeaderElectionReporter(t *testing.T) {
+	r, err := NewLeaderElectionReporter("controller")
+	if err != nil {
+		t.Fatalf("NewLeaderElectionReporter() = %v", err)
+	}
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+
+	// A controller observes itself becoming the first leader, then loses
+	// leadership to another replica after an hour, and takes it back.
+	r.OnNewLeader("pod-a")
+	r.OnNewLeader("pod-a")
+	r.OnStartedLeading()
+	now = now.Add(time.Hour)
+	r.OnStoppedLeading()
+	r.OnStoppedLeading()
+	r.OnNewLeader("pod-b")
+	r.OnNewLeader("pod-a")
+
+	if rows, err := view.RetrieveData("leader_election_change_total"); err != nil {
+		t.Errorf("RetrieveData() = %v", err)
+	} else if got := rows[0].Data.(*view.CountData).Value; got != 2 {
+		t.Errorf("leader_election_change_total = %d, want 2", got)
+	}
+	if rows, err := view.RetrieveData("leader_hold_duration_seconds"); err != nil {
+		t.Errorf("RetrieveData() = %v", err)
+	} else {
+		data := rows[0].Data.(*view.DistributionData)
+		if data.Count != 1 || data.Mean != 3600 {
+			t.Errorf("leader_hold_duration_seconds count = %d, mean = %v, want 1 term of 3600", data.Count, data.Mean)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/knative/pkg/controller"
+	"github.com/knative/pkg/logging/logkey"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// RequeueReasonRateLimited is the reason of a key added through the rate
+	// limiter of the work queue, e.g. on an informer event.
+	RequeueReasonRateLimited = "rate_limited"
+	// RequeueReasonExponentialBackoff is the reason of a key added again
+	// after failing to reconcile.
+	RequeueReasonExponentialBackoff = "exponential_backoff"
+	// RequeueReasonImmediate is the reason of a key added directly, without
+	// going through the rate limiter.
+	RequeueReasonImmediate = "immediate"
+
+	// StormRequeueCount is the number of requeues of a single key within
+	// StormRequeueWindow above which the controller is considered to be
+	// stuck in a requeue loop.
+	StormRequeueCount = 100
+	// StormRequeueWindow is the window over which requeues of a key are
+	// counted.
+	StormRequeueWindow = time.Minute
+)
+
+var (
+	requeueCountM = stats.Int64(
+		"controller_reconcile_requeue_total",
+		"Number of keys added to the work queue of a controller",
+		stats.UnitDimensionless)
+
+	requeueReasonTagKey = mustNewTagKey("requeue_reason")
+)
+
+func init() {
+	err := metrics.RegisterViews(
+		&view.View{
+			Description: "Number of keys added to the work queue of a controller",
+			Measure:     requeueCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{resourceTypeTagKey, requeueReasonTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CountRequeues wraps the work queue of the controller to count the keys
+// added to it by reason, and to log an error when a single key of the given
+// kind is requeued more than StormRequeueCount times within
+// StormRequeueWindow, which usually means its reconciliation never settles.
+// Keys added with AddAfter are not counted.
+func CountRequeues(impl *controller.Impl, gvk schema.GroupVersionKind, logger *zap.SugaredLogger) {
+	impl.WorkQueue = newRequeueCounter(impl.WorkQueue, gvk, logger, time.Now)
+}
+
+type requeueWindow struct {
+	start time.Time
+	count int
+}
+
+type requeueCounter struct {
+	workqueue.RateLimitingInterface
+
+	gvk    schema.GroupVersionKind
+	logger *zap.SugaredLogger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	windows   map[interface{}]*requeueWindow
+	lastSweep time.Time
+
+	// inFlight tracks the keys being reconciled, between Get and Done.
+	inFlight map[interface{}]*reconcileAttempt
+}
+
+// reconcileAttempt records what happened to a key while it was reconciled.
+type reconcileAttempt struct {
+	// rateLimited is the number of times the key was added through the
+	// rate limiter.
+	rateLimited int
+	// forgotten is set when the key was reconciled successfully, or failed
+	// permanently.
+	forgotten bool
+}
+
+func newRequeueCounter(q workqueue.RateLimitingInterface, gvk schema.GroupVersionKind,
+	logger *zap.SugaredLogger, now func() time.Time) *requeueCounter {
+	return &requeueCounter{
+		RateLimitingInterface: q,
+		gvk:                   gvk,
+		logger:                logger,
+		now:                   now,
+		windows:               make(map[interface{}]*requeueWindow),
+		lastSweep:             now(),
+		inFlight:              make(map[interface{}]*reconcileAttempt),
+	}
+}
+
+// Add implements workqueue.Interface
+func (q *requeueCounter) Add(item interface{}) {
+	q.record(item, RequeueReasonImmediate)
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddRateLimited implements workqueue.RateLimitingInterface
+func (q *requeueCounter) AddRateLimited(item interface{}) {
+	// The controller adds keys through the rate limiter both on informer
+	// events and when they fail to reconcile. Which one it was is only
+	// known once the reconciliation of a key in flight is done.
+	q.mu.Lock()
+	attempt, ok := q.inFlight[item]
+	if ok {
+		attempt.rateLimited++
+	}
+	q.mu.Unlock()
+	if !ok {
+		q.record(item, RequeueReasonRateLimited)
+	}
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+// Get implements workqueue.Interface
+func (q *requeueCounter) Get() (interface{}, bool) {
+	item, shutdown := q.RateLimitingInterface.Get()
+	if !shutdown {
+		q.mu.Lock()
+		q.inFlight[item] = &reconcileAttempt{}
+		q.mu.Unlock()
+	}
+	return item, shutdown
+}
+
+// Forget implements workqueue.RateLimitingInterface
+func (q *requeueCounter) Forget(item interface{}) {
+	q.mu.Lock()
+	if attempt, ok := q.inFlight[item]; ok {
+		attempt.forgotten = true
+	}
+	q.mu.Unlock()
+	q.RateLimitingInterface.Forget(item)
+}
+
+// Done implements workqueue.Interface. The keys added through the rate
+// limiter while in flight were requeued to retry a failed reconciliation,
+// unless the key was then forgotten.
+func (q *requeueCounter) Done(item interface{}) {
+	q.mu.Lock()
+	attempt := q.inFlight[item]
+	delete(q.inFlight, item)
+	q.mu.Unlock()
+	if attempt != nil {
+		reason := RequeueReasonExponentialBackoff
+		if attempt.forgotten {
+			reason = RequeueReasonRateLimited
+		}
+		for i := 0; i < attempt.rateLimited; i++ {
+			q.record(item, reason)
+		}
+	}
+	q.RateLimitingInterface.Done(item)
+}
+
+func (q *requeueCounter) record(item interface{}, reason string) {
+	if ctx, err := tag.New(context.Background(),
+		tag.Insert(resourceTypeTagKey, q.gvk.Kind),
+		tag.Insert(requeueReasonTagKey, reason)); err == nil {
+		stats.Record(ctx, requeueCountM.M(1))
+	}
+
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Drop the windows of the keys that have not been requeued lately.
+	if now.Sub(q.lastSweep) > StormRequeueWindow {
+		for k, w := range q.windows {
+			if now.Sub(w.start) > StormRequeueWindow {
+				delete(q.windows, k)
+			}
+		}
+		q.lastSweep = now
+	}
+
+	w, ok := q.windows[item]
+	if !ok || now.Sub(w.start) > StormRequeueWindow {
+		w = &requeueWindow{start: now}
+		q.windows[item] = w
+	}
+	w.count++
+	// Only log once per window.
+	if w.count == StormRequeueCount+1 {
+		q.logger.With(zap.Any(logkey.Key, item), zap.String(logkey.Kind, q.gvk.String())).
+			Errorf("Key requeued more than %d times within %v, its reconciliation may be looping",
+				StormRequeueCount, StormRequeueWindow)
+	}
+}
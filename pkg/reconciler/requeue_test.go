@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+func requeueCount(t *testing.T, kind, reason string) int64 {
+	rows, err := view.RetrieveData("controller_reconcile_requeue_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["resource_type"] == kind && tags["requeue_reason"] == reason {
+			return row.Data.(*view.CountData).Value
+		}
+	}
+	return 0
+}
+
+func TestCountRequeues(t *testing.T) {
+	now := time.Now()
+	gvk := schema.GroupVersionKind{Group: "test.knative.dev", Version: "v1", Kind: "TestRequeue"}
+	q := newRequeueCounter(workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		gvk, TestLogger(t), func() time.Time { return now })
+	defer q.ShutDown()
+
+	q.Add("immediate")
+	q.AddRateLimited("event")
+
+	// Keys are reconciled as the controller does: a key that fails to
+	// reconcile is added again through the rate limiter, and a key that is
+	// reconciled is forgotten.
+	reconcile := func(failed bool) {
+		item, _ := q.Get()
+		if failed {
+			q.AddRateLimited(item)
+		} else {
+			q.Forget(item)
+		}
+		q.Done(item)
+	}
+	// The first failure of a key is a backoff, like the next ones.
+	reconcile(true)
+	reconcile(true)
+	// A key added on an event while it was reconciled, then reconciled
+	// successfully, was not backing off.
+	item, _ := q.Get()
+	q.AddRateLimited(item)
+	q.Forget(item)
+	q.Done(item)
+
+	for reason, want := range map[string]int64{
+		RequeueReasonImmediate:          1,
+		RequeueReasonRateLimited:        2,
+		RequeueReasonExponentialBackoff: 2,
+	} {
+		if got := requeueCount(t, "TestRequeue", reason); got != want {
+			t.Errorf("Requeues with reason %s = %d, want %d", reason, got, want)
+		}
+	}
+
+	for i := 0; i < StormRequeueCount+10; i++ {
+		q.Add("storm")
+	}
+	if got, want := q.windows["storm"].count, StormRequeueCount+10; got != want {
+		t.Errorf("Requeues in the window = %d, want %d", got, want)
+	}
+
+	// The window of a key starts over once it has passed, and the windows
+	// of the keys that are no longer requeued are dropped.
+	now = now.Add(StormRequeueWindow + time.Second)
+	q.Add("storm")
+	if got := q.windows["storm"].count; got != 1 {
+		t.Errorf("Requeues in the new window = %d, want 1", got)
+	}
+	if _, ok := q.windows["immediate"]; ok {
+		t.Error("Window of a key no longer requeued was not dropped")
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/knative/pkg/controller"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// OverloadedResyncDuration is the duration of a global resync above
+	// which the controller is considered overloaded and may miss real events.
+	OverloadedResyncDuration = 30 * time.Second
+
+	// SlowResyncDuration is the duration of a global resync above which a
+	// Warning event is emitted.
+	SlowResyncDuration = 60 * time.Second
+)
+
+var (
+	globalResyncCountM = stats.Int64(
+		"global_resync_total",
+		"Number of global resyncs of a controller",
+		stats.UnitDimensionless)
+	globalResyncDurationM = stats.Float64(
+		"global_resync_duration_ms",
+		"Duration of each global resync of a controller in milliseconds",
+		stats.UnitMilliseconds)
+
+	resourceTypeTagKey = mustNewTagKey("resource_type")
+)
+
+func init() {
+	err := metrics.RegisterViews(
+		&view.View{
+			Description: "Number of global resyncs of a controller",
+			Measure:     globalResyncCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{resourceTypeTagKey},
+		},
+		&view.View{
+			Description: "Duration of each global resync of a controller in milliseconds",
+			Measure:     globalResyncDurationM,
+			Aggregation: view.Distribution(1000, 5000, 10000, 30000, 60000, 120000, 300000, 600000),
+			TagKeys:     []tag.Key{resourceTypeTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func mustNewTagKey(name string) tag.Key {
+	key, err := tag.NewKey(name)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// GlobalResyncer enqueues all the objects of the primary informer of a
+// controller, like controller.Impl.GlobalResync, and records the resync once
+// all of them have been reconciled.
+type GlobalResyncer struct {
+	// FilterFunc, if not nil, selects the objects of the informer to enqueue.
+	FilterFunc func(interface{}) bool
+
+	impl     *controller.Impl
+	queue    *resyncTracker
+	resource string
+	logger   *zap.SugaredLogger
+}
+
+// NewGlobalResyncer wraps the work queue of the controller to track the keys
+// of the global resyncs of the given resource type until they are reconciled.
+// It must be called before the controller is started.
+func NewGlobalResyncer(impl *controller.Impl, resource string, logger *zap.SugaredLogger) *GlobalResyncer {
+	q := newResyncTracker(impl.WorkQueue)
+	impl.WorkQueue = q
+	return &GlobalResyncer{
+		impl:     impl,
+		queue:    q,
+		resource: resource,
+		logger:   logger,
+	}
+}
+
+// Resync enqueues all the objects of the informer and records the resync once
+// every one of them has been reconciled without being requeued. done, if not
+// nil, is called with the duration of the resync. Nothing is recorded if the
+// controller shuts down first.
+func (r *GlobalResyncer) Resync(si cache.SharedInformer, done func(time.Duration)) {
+	start := time.Now()
+	var keys []string
+	for _, obj := range si.GetStore().List() {
+		if r.FilterFunc != nil && !r.FilterFunc(obj) {
+			continue
+		}
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			r.logger.Errorw("Failed to get the key of an object to resync", zap.Error(err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	// Track the keys before enqueuing them, so that none of them can be
+	// reconciled unnoticed.
+	finished := r.queue.track(keys)
+	for _, key := range keys {
+		r.impl.EnqueueKey(key)
+	}
+
+	go func() {
+		select {
+		case <-finished:
+		case <-r.queue.stopped:
+			return
+		}
+		duration := time.Since(start)
+
+		if ctx, err := tag.New(context.Background(), tag.Insert(resourceTypeTagKey, r.resource)); err == nil {
+			stats.Record(ctx,
+				globalResyncCountM.M(1),
+				globalResyncDurationM.M(float64(duration/time.Millisecond)))
+		}
+		if duration > OverloadedResyncDuration {
+			r.logger.Warnf("Global resync of %d %s objects took %v, the controller may be overloaded",
+				len(keys), r.resource, duration)
+		}
+		if done != nil {
+			done(duration)
+		}
+	}()
+}
+
+// ResyncPeriodically resyncs all the objects of the informer every period
+// until stopCh is closed. It replaces the periodic resync of the informer,
+// which cannot tell when the objects it replays have been reconciled.
+func (r *GlobalResyncer) ResyncPeriodically(si cache.SharedInformer, period time.Duration, stopCh <-chan struct{}) {
+	if period <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				r.Resync(si, nil)
+			}
+		}
+	}()
+}
+
+// pendingResync holds the keys of a global resync that have not been
+// reconciled yet. A key is true once a worker has picked it up after the
+// resync enqueued it.
+type pendingResync struct {
+	keys     map[interface{}]bool
+	finished chan struct{}
+}
+
+// resyncTracker wraps a work queue to tell when all the keys of a global
+// resync have been reconciled. A key is reconciled when the controller
+// forgets it after getting it from the queue, i.e. when it was processed
+// without being requeued with backoff.
+type resyncTracker struct {
+	workqueue.RateLimitingInterface
+
+	mu       sync.Mutex
+	pending  map[*pendingResync]struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newResyncTracker(q workqueue.RateLimitingInterface) *resyncTracker {
+	return &resyncTracker{
+		RateLimitingInterface: q,
+		pending:               make(map[*pendingResync]struct{}),
+		stopped:               make(chan struct{}),
+	}
+}
+
+// track starts tracking the given keys and returns a channel closed once all
+// of them have been reconciled.
+func (q *resyncTracker) track(keys []string) <-chan struct{} {
+	p := &pendingResync{
+		keys:     make(map[interface{}]bool, len(keys)),
+		finished: make(chan struct{}),
+	}
+	for _, key := range keys {
+		p.keys[key] = false
+	}
+	if len(p.keys) == 0 {
+		close(p.finished)
+		return p.finished
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[p] = struct{}{}
+	return p.finished
+}
+
+// Get implements workqueue.Interface
+func (q *resyncTracker) Get() (interface{}, bool) {
+	item, shutdown := q.RateLimitingInterface.Get()
+	if shutdown {
+		return item, shutdown
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for p := range q.pending {
+		if _, ok := p.keys[item]; ok {
+			p.keys[item] = true
+		}
+	}
+	return item, shutdown
+}
+
+// Forget implements workqueue.RateLimitingInterface
+func (q *resyncTracker) Forget(item interface{}) {
+	q.RateLimitingInterface.Forget(item)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for p := range q.pending {
+		// A key forgotten before it was picked up again was being
+		// reconciled when the resync enqueued it, so it must be
+		// reconciled once more.
+		if picked, ok := p.keys[item]; ok && picked {
+			delete(p.keys, item)
+			if len(p.keys) == 0 {
+				close(p.finished)
+				delete(q.pending, p)
+			}
+		}
+	}
+}
+
+// ShutDown implements workqueue.Interface
+func (q *resyncTracker) ShutDown() {
+	q.RateLimitingInterface.ShutDown()
+	q.stopOnce.Do(func() { close(q.stopped) })
+}
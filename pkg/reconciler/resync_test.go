@@ -0,0 +1,228 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knative/pkg/controller"
+	"go.opencensus.io/stats/view"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+type countingReconciler struct {
+	mux  sync.Mutex
+	keys map[string]int
+
+	// release, if not nil, blocks every reconciliation until it is closed.
+	release chan struct{}
+	// failures is the number of times each key fails to reconcile first.
+	failures map[string]int
+}
+
+func (r *countingReconciler) Reconcile(ctx context.Context, key string) error {
+	if r.release != nil {
+		<-r.release
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.keys[key]++
+	if r.failures[key] > 0 {
+		r.failures[key]--
+		return fmt.Errorf("inducing failure for %s", key)
+	}
+	return nil
+}
+
+func (r *countingReconciler) reconciled() map[string]int {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	keys := make(map[string]int, len(r.keys))
+	for k, v := range r.keys {
+		keys[k] = v
+	}
+	return keys
+}
+
+func newConfigMapInformer(n int) cache.SharedInformer {
+	informer := kubeinformers.NewSharedInformerFactory(fakekubeclientset.NewSimpleClientset(), 0).Core().V1().ConfigMaps().Informer()
+	for i := 0; i < n; i++ {
+		informer.GetIndexer().Add(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("config-%d", i),
+				Namespace: "default",
+			},
+		})
+	}
+	return informer
+}
+
+// runController runs the controller until the returned function is called.
+func runController(impl *controller.Impl) func() {
+	stopCh := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		impl.Run(1, stopCh)
+		close(stopped)
+	}()
+	return func() {
+		close(stopCh)
+		<-stopped
+	}
+}
+
+func TestGlobalResync(t *testing.T) {
+	logger := TestLogger(t)
+	r := &countingReconciler{keys: map[string]int{}}
+	impl := controller.NewImpl(r, logger, "Test", MustNewStatsReporter("test", logger))
+	resyncer := NewGlobalResyncer(impl, "ConfigMap", logger)
+	informer := newConfigMapInformer(3)
+	defer runController(impl)()
+
+	done := make(chan time.Duration, 1)
+	resyncer.Resync(informer, func(d time.Duration) { done <- d })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the global resync to finish")
+	}
+
+	if got, want := len(r.reconciled()), 3; got != want {
+		t.Errorf("Reconciled keys = %v, want %d", r.reconciled(), want)
+	}
+
+	rows, err := view.RetrieveData("global_resync_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	found := false
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == "ConfigMap" {
+			found = true
+			if got := row.Data.(*view.CountData).Value; got != 1 {
+				t.Errorf("global_resync_total = %d, want 1", got)
+			}
+		}
+	}
+	if !found {
+		t.Error("No global_resync_total reported for ConfigMap")
+	}
+}
+
+func TestGlobalResyncWaitsForQueuedKeys(t *testing.T) {
+	logger := TestLogger(t)
+	r := &countingReconciler{
+		keys:    map[string]int{},
+		release: make(chan struct{}),
+	}
+	impl := controller.NewImpl(r, logger, "Test", MustNewStatsReporter("test", logger))
+	resyncer := NewGlobalResyncer(impl, "QueuedConfigMap", logger)
+	defer runController(impl)()
+
+	done := make(chan time.Duration, 1)
+	resyncer.Resync(newConfigMapInformer(3), func(d time.Duration) { done <- d })
+	select {
+	case <-done:
+		t.Fatal("Global resync finished before any key was reconciled")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(r.release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the global resync to finish")
+	}
+	if got, want := len(r.reconciled()), 3; got != want {
+		t.Errorf("Reconciled keys = %v, want %d", r.reconciled(), want)
+	}
+}
+
+func TestGlobalResyncWaitsForFailedKeys(t *testing.T) {
+	logger := TestLogger(t)
+	r := &countingReconciler{
+		keys:     map[string]int{},
+		failures: map[string]int{"default/config-1": 2},
+	}
+	impl := controller.NewImpl(r, logger, "Test", MustNewStatsReporter("test", logger))
+	resyncer := NewGlobalResyncer(impl, "FailedConfigMap", logger)
+	defer runController(impl)()
+
+	done := make(chan time.Duration, 1)
+	resyncer.Resync(newConfigMapInformer(3), func(d time.Duration) { done <- d })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the global resync to finish")
+	}
+	if got, want := r.reconciled()["default/config-1"], 3; got != want {
+		t.Errorf("Reconciliations of default/config-1 = %d, want %d", got, want)
+	}
+}
+
+func TestGlobalResyncFilter(t *testing.T) {
+	logger := TestLogger(t)
+	r := &countingReconciler{keys: map[string]int{}}
+	impl := controller.NewImpl(r, logger, "Test", MustNewStatsReporter("test", logger))
+	resyncer := NewGlobalResyncer(impl, "FilteredConfigMap", logger)
+	resyncer.FilterFunc = func(obj interface{}) bool {
+		return obj.(*corev1.ConfigMap).Name == "config-0"
+	}
+	defer runController(impl)()
+
+	done := make(chan time.Duration, 1)
+	resyncer.Resync(newConfigMapInformer(3), func(d time.Duration) { done <- d })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the global resync to finish")
+	}
+	if got := r.reconciled(); len(got) != 1 || got["default/config-0"] != 1 {
+		t.Errorf("Reconciled keys = %v, want only default/config-0 once", got)
+	}
+}
+
+func TestGlobalResyncPeriodically(t *testing.T) {
+	logger := TestLogger(t)
+	r := &countingReconciler{keys: map[string]int{}}
+	impl := controller.NewImpl(r, logger, "Test", MustNewStatsReporter("test", logger))
+	resyncer := NewGlobalResyncer(impl, "PeriodicConfigMap", logger)
+	defer runController(impl)()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	resyncer.ResyncPeriodically(newConfigMapInformer(2), 10*time.Millisecond, stopCh)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := r.reconciled(); got["default/config-0"] >= 2 && got["default/config-1"] >= 2 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Reconciled keys = %v, want every key reconciled at least twice", r.reconciled())
+}
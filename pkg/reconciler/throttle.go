@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+const (
+	// ThrottleWarningFraction is the fraction of the API server calls
+	// throttled within a ThrottleCheckPeriod above which the controller is
+	// warned.
+	ThrottleWarningFraction = 0.1
+
+	// ThrottleCheckPeriod is how often the fraction of throttled API server
+	// calls is checked.
+	ThrottleCheckPeriod = time.Minute
+)
+
+var (
+	apiServerThrottleCountM = stats.Int64(
+		"controller_apiserver_throttle_total",
+		"Number of API server calls of a controller throttled with a 429 response",
+		stats.UnitDimensionless)
+	apiServerThrottleDurationM = stats.Float64(
+		"controller_apiserver_throttle_duration_ms",
+		"Delay requested by the API server when throttling a call of a controller in milliseconds",
+		stats.UnitMilliseconds)
+)
+
+func init() {
+	err := metrics.RegisterViews(
+		&view.View{
+			Description: "Number of API server calls of a controller throttled with a 429 response",
+			Measure:     apiServerThrottleCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{componentTagKey},
+		},
+		&view.View{
+			Description: "Delay requested by the API server when throttling a call of a controller in milliseconds",
+			Measure:     apiServerThrottleDurationM,
+			Aggregation: view.Distribution(100, 500, 1000, 2000, 5000, 10000, 30000, 60000),
+			TagKeys:     []tag.Key{componentTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// APIServerThrottleReporter reports the API server calls of a controller
+// that were throttled. Reconciliation stalls while the API server throttles
+// the controller, as client-go waits for the delay requested in the
+// Retry-After header before retrying.
+type APIServerThrottleReporter struct {
+	ctx context.Context
+
+	mux       sync.Mutex
+	total     int64
+	throttled int64
+}
+
+// NewAPIServerThrottleReporter creates an APIServerThrottleReporter for the
+// given component, e.g. "controller".
+func NewAPIServerThrottleReporter(component string) (*APIServerThrottleReporter, error) {
+	ctx, err := tag.New(context.Background(), tag.Insert(componentTagKey, component))
+	if err != nil {
+		return nil, err
+	}
+	return &APIServerThrottleReporter{ctx: ctx}, nil
+}
+
+// WrapTransport wraps the transport of the API server calls to observe
+// them. It is meant to be set as the WrapTransport of a rest.Config.
+func (r *APIServerThrottleReporter) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &throttleRoundTripper{next: rt, reporter: r}
+}
+
+// Watch calls warn with the number of throttled and total API server calls
+// every ThrottleCheckPeriod in which more than ThrottleWarningFraction of
+// the calls were throttled, until stopCh is closed.
+func (r *APIServerThrottleReporter) Watch(stopCh <-chan struct{}, warn func(throttled, total int64)) {
+	ticker := time.NewTicker(ThrottleCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if throttled, total, ok := r.check(); ok {
+				warn(throttled, total)
+			}
+		}
+	}
+}
+
+// check returns the number of throttled and total calls since the last
+// check, and whether the throttled ones exceed ThrottleWarningFraction.
+func (r *APIServerThrottleReporter) check() (int64, int64, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	throttled, total := r.throttled, r.total
+	r.throttled, r.total = 0, 0
+	return throttled, total, total > 0 && float64(throttled)/float64(total) > ThrottleWarningFraction
+}
+
+func (r *APIServerThrottleReporter) observe(resp *http.Response) {
+	throttled := resp.StatusCode == http.StatusTooManyRequests
+
+	r.mux.Lock()
+	r.total++
+	if throttled {
+		r.throttled++
+	}
+	r.mux.Unlock()
+
+	if !throttled {
+		return
+	}
+	stats.Record(r.ctx, apiServerThrottleCountM.M(1))
+	// Without a Retry-After header client-go does not retry the call.
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		stats.Record(r.ctx, apiServerThrottleDurationM.M(float64(time.Duration(seconds)*time.Second/time.Millisecond)))
+	}
+}
+
+type throttleRoundTripper struct {
+	next     http.RoundTripper
+	reporter *APIServerThrottleReporter
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *throttleRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err == nil {
+		rt.reporter.observe(resp)
+	}
+	return resp, err
+}
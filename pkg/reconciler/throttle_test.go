@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/stats/view"
+)
+
+func TestAPIServerThrottleReporter(t *testing.T) {
+	r, err := NewAPIServerThrottleReporter("throttle-test")
+	if err != nil {
+		t.Fatalf("NewAPIServerThrottleReporter() = %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/throttled" {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: r.WrapTransport(http.DefaultTransport)}
+
+	get := func(path string) {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get(%s) = %v", path, err)
+		}
+		resp.Body.Close()
+	}
+	for i := 0; i < 9; i++ {
+		get("/")
+	}
+	get("/throttled")
+
+	// 1 throttled call out of 10 does not exceed the warning fraction.
+	if throttled, total, warn := r.check(); throttled != 1 || total != 10 || warn {
+		t.Errorf("check() = %d, %d, %v, want 1, 10, false", throttled, total, warn)
+	}
+	get("/")
+	get("/throttled")
+	if throttled, total, warn := r.check(); throttled != 1 || total != 2 || !warn {
+		t.Errorf("check() = %d, %d, %v, want 1, 2, true", throttled, total, warn)
+	}
+
+	rows, err := view.RetrieveData("controller_apiserver_throttle_duration_ms")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	found := false
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == "throttle-test" {
+			found = true
+			if got := row.Data.(*view.DistributionData); got.Count != 2 || got.Mean != 2000 {
+				t.Errorf("Throttle durations = %d with mean %v, want 2 with mean 2000", got.Count, got.Mean)
+			}
+		}
+	}
+	if !found {
+		t.Error("No controller_apiserver_throttle_duration_ms reported for throttle-test")
+	}
+}
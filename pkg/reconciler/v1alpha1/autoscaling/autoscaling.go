@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/knative/pkg/controller"
 	"github.com/knative/pkg/logging"
@@ -29,19 +30,29 @@ import (
 	"github.com/knative/serving/pkg/autoscaler"
 	informers "github.com/knative/serving/pkg/client/informers/externalversions/autoscaling/v1alpha1"
 	listers "github.com/knative/serving/pkg/client/listers/autoscaling/v1alpha1"
+	"github.com/knative/serving/pkg/queue"
 	"github.com/knative/serving/pkg/reconciler"
 	"go.uber.org/zap"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	hpainformers "k8s.io/client-go/informers/autoscaling/v1"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	hpalisters "k8s.io/client-go/listers/autoscaling/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
 const (
 	controllerAgentName = "autoscaling-controller"
+
+	// underProvisionedScalingFactor is the ratio of observed to target
+	// concurrency above which a revision is considered severely
+	// under-provisioned.
+	underProvisionedScalingFactor = 10
 )
 
 // KPAMetrics is an interface for notifying the presence or absence of KPAs.
@@ -57,6 +68,10 @@ type KPAMetrics interface {
 
 	// Watch registers a function to call when Metrics change.
 	Watch(watcher func(string))
+
+	// Poke requests the Metric resource for a given key to be recomputed
+	// because of an event which happened at the triggered time.
+	Poke(key string, triggered time.Time)
 }
 
 // KPAScaler knows how to scale the targets of KPAs
@@ -72,9 +87,15 @@ type Reconciler struct {
 
 	kpaLister       listers.PodAutoscalerLister
 	endpointsLister corev1listers.EndpointsLister
-
-	kpaMetrics KPAMetrics
-	kpaScaler  KPAScaler
+	hpaLister       hpalisters.HorizontalPodAutoscalerLister
+
+	kpaMetrics       KPAMetrics
+	kpaScaler        KPAScaler
+	conflictDetector *autoscaler.ConflictDetector
+	driftDetector    *autoscaler.DriftDetector
+	warmPool         *autoscaler.WarmPoolTracker
+	fullVolumes      *autoscaler.FullVolumeTracker
+	warnings         *warningTracker
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -86,19 +107,27 @@ func NewController(
 
 	kpaInformer informers.PodAutoscalerInformer,
 	endpointsInformer corev1informers.EndpointsInformer,
+	hpaInformer hpainformers.HorizontalPodAutoscalerInformer,
 
 	kpaMetrics KPAMetrics,
 	kpaScaler KPAScaler,
 ) *controller.Impl {
 
 	c := &Reconciler{
-		Base:            reconciler.NewBase(*opts, controllerAgentName),
-		kpaLister:       kpaInformer.Lister(),
-		endpointsLister: endpointsInformer.Lister(),
-		kpaMetrics:      kpaMetrics,
-		kpaScaler:       kpaScaler,
+		Base:             reconciler.NewBase(*opts, controllerAgentName),
+		kpaLister:        kpaInformer.Lister(),
+		endpointsLister:  endpointsInformer.Lister(),
+		hpaLister:        hpaInformer.Lister(),
+		kpaMetrics:       kpaMetrics,
+		kpaScaler:        kpaScaler,
+		conflictDetector: autoscaler.NewConflictDetector(),
+		driftDetector:    autoscaler.NewDriftDetector(),
+		warmPool:         autoscaler.NewWarmPoolTracker(),
+		fullVolumes:      autoscaler.NewFullVolumeTracker(),
+		warnings:         newWarningTracker(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "Autoscaling", reconciler.MustNewStatsReporter("Autoscaling", c.Logger))
+	reconciler.CountRequeues(impl, kpa.SchemeGroupVersion.WithKind("PodAutoscaler"), c.Logger)
 
 	c.Logger.Info("Setting up event handlers")
 	kpaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -112,6 +141,13 @@ func NewController(
 		UpdateFunc: controller.PassNew(impl.EnqueueLabelOfNamespaceScopedResource("", autoscaling.KPALabelKey)),
 		DeleteFunc: impl.EnqueueLabelOfNamespaceScopedResource("", autoscaling.KPALabelKey),
 	})
+	// Have the KPAMetrics recompute the desired scale as soon as the ready
+	// pods change, rather than at the next tick.
+	endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.pokeKPA,
+		UpdateFunc: c.pokeKPAOnReadinessChange,
+		DeleteFunc: c.pokeKPA,
+	})
 
 	// Have the KPAMetrics enqueue the KPAs whose metrics have changed.
 	kpaMetrics.Watch(impl.EnqueueKey)
@@ -132,7 +168,19 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	original, err := c.kpaLister.PodAutoscalers(namespace).Get(name)
 	if errors.IsNotFound(err) {
 		logger.Debug("KPA no longer exists")
-		return c.kpaMetrics.Delete(ctx, key)
+		c.warmPool.Forget(key)
+		c.fullVolumes.Forget(key)
+		c.driftDetector.Forget(key)
+		c.conflictDetector.Forget(key)
+		c.warnings.forget(key)
+		if err := c.kpaMetrics.Delete(ctx, key); err != nil {
+			return err
+		}
+		// The autoscaler of the KPA is stopped, its gauges can be deleted.
+		if err := autoscaler.DeleteRevisionStats(namespace, name); err != nil {
+			logger.Error("Failed to delete the KPA gauges", zap.Error(err))
+		}
+		return nil
 	} else if err != nil {
 		return err
 	}
@@ -156,6 +204,32 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	return err
 }
 
+// pokeKPA requests a scale decision for the KPA of the endpoints, which just
+// changed.
+func (c *Reconciler) pokeKPA(obj interface{}) {
+	endpoints, ok := obj.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	if name, ok := endpoints.Labels[autoscaling.KPALabelKey]; ok {
+		c.kpaMetrics.Poke(autoscaler.NewKpaKey(endpoints.Namespace, name), time.Now())
+	}
+}
+
+// pokeKPAOnReadinessChange requests a scale decision for the KPA of the
+// endpoints when their addresses changed, i.e. pods became ready or unready.
+func (c *Reconciler) pokeKPAOnReadinessChange(old, new interface{}) {
+	oldEndpoints, ok := old.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	newEndpoints, ok := new.(*corev1.Endpoints)
+	if !ok || equality.Semantic.DeepEqual(oldEndpoints.Subsets, newEndpoints.Subsets) {
+		return
+	}
+	c.pokeKPA(newEndpoints)
+}
+
 func (c *Reconciler) reconcile(ctx context.Context, key string, kpa *kpa.PodAutoscaler) error {
 	logger := logging.FromContext(ctx)
 
@@ -214,6 +288,17 @@ func (c *Reconciler) reconcile(ctx context.Context, key string, kpa *kpa.PodAuto
 
 	reporter.Report(autoscaler.ActualPodCountM, float64(got))
 	reporter.Report(autoscaler.RequestedPodCountM, float64(want))
+	reporter.Report(autoscaler.KPATargetConcurrencyM, metric.TargetConcurrency)
+
+	if err := c.reconcileHPAConflict(ctx, key, kpa, want, metric.TargetConcurrency, reporter); err != nil {
+		return err
+	}
+
+	c.reportUnderProvisioning(key, kpa, metric.ScalingFactor)
+	c.reportTimeoutBudget(key, kpa, metric.TimeoutBudgetExceeded)
+	c.reportFullVolumes(key, kpa, metric.FullVolumes)
+	c.reportHealth(key, kpa, metric.Health, got, want, reporter)
+	c.reportWarmPool(key, kpa, metric.DesiredScale, got, reporter)
 
 	switch {
 	case want == 0:
@@ -230,6 +315,122 @@ func (c *Reconciler) reconcile(ctx context.Context, key string, kpa *kpa.PodAuto
 	return nil
 }
 
+// reconcileHPAConflict reports the pod count desired by any HPA targeting the
+// same resource as the KPA, and warns when the two autoscalers start to
+// disagree.
+func (c *Reconciler) reconcileHPAConflict(ctx context.Context, key string, kpa *kpa.PodAutoscaler, want int32, targetConcurrency float64, reporter autoscaler.StatsReporter) error {
+	logger := logging.FromContext(ctx)
+
+	hpas, err := c.hpaLister.HorizontalPodAutoscalers(kpa.Namespace).List(labels.Everything())
+	if err != nil {
+		logger.Errorf("Error listing HPAs: %v", err)
+		return err
+	}
+	for _, hpa := range hpas {
+		ref := hpa.Spec.ScaleTargetRef
+		if ref.Kind != kpa.Spec.ScaleTargetRef.Kind || ref.Name != kpa.Spec.ScaleTargetRef.Name {
+			continue
+		}
+		hpaWant := hpa.Status.DesiredReplicas
+		reporter.Report(autoscaler.HPADesiredPodsM, float64(hpaWant))
+		if c.conflictDetector.StartedConflicting(key, hpa.Name, want, hpaWant) {
+			logger.Warnf("KPA wants %d pods but HPA %q wants %d pods", want, hpa.Name, hpaWant)
+			c.Recorder.Eventf(kpa, corev1.EventTypeWarning, "AutoscalerConflict",
+				"KPA wants %d pods but HPA %q wants %d pods", want, hpa.Name, hpaWant)
+		}
+		c.reportHPADrift(ctx, key, kpa, hpa, targetConcurrency, reporter)
+	}
+	return nil
+}
+
+// reportHPADrift reports the CPU utilization targeted by the HPA and warns
+// when it starts drifting from the utilization implied by the KPA target.
+func (c *Reconciler) reportHPADrift(ctx context.Context, key string, kpa *kpa.PodAutoscaler, hpa *autoscalingv1.HorizontalPodAutoscaler, targetConcurrency float64, reporter autoscaler.StatsReporter) {
+	hpaTarget := hpa.Spec.TargetCPUUtilizationPercentage
+	if hpaTarget == nil {
+		return
+	}
+	reporter.Report(autoscaler.HPATargetCPUUtilizationM, float64(*hpaTarget))
+
+	implied, ok := autoscaler.ImpliedCPUUtilization(targetConcurrency, kpa.Spec.ContainerConcurrency)
+	if !ok {
+		return
+	}
+	reporter.Report(autoscaler.TargetUtilizationDriftM, c.driftDetector.Drift(implied, *hpaTarget))
+	if c.driftDetector.StartedDrifting(key, hpa.Name, implied, *hpaTarget) {
+		logging.FromContext(ctx).Warnf("KPA target implies %.0f%% CPU utilization but HPA %q targets %d%%", implied, hpa.Name, *hpaTarget)
+		c.Recorder.Eventf(kpa, corev1.EventTypeWarning, "AutoscalerTargetDrift",
+			"KPA target implies %.0f%% CPU utilization but HPA %q targets %d%%", implied, hpa.Name, *hpaTarget)
+	}
+}
+
+// reportUnderProvisioning warns when the observed concurrency of the KPA
+// starts exceeding its target concurrency many times over.
+func (c *Reconciler) reportUnderProvisioning(key string, kpa *kpa.PodAutoscaler, scalingFactor float64) {
+	if c.warnings.set(key, "UnderProvisioned", scalingFactor > underProvisionedScalingFactor) {
+		c.Recorder.Eventf(kpa, corev1.EventTypeWarning, "UnderProvisioned",
+			"Observed concurrency is %.1fx the target concurrency", scalingFactor)
+	}
+}
+
+// reportTimeoutBudget warns when the requests of the KPA's revision start
+// using most of the revision timeout.
+func (c *Reconciler) reportTimeoutBudget(key string, kpa *kpa.PodAutoscaler, exceeded bool) {
+	if c.warnings.set(key, "TimeoutBudgetExceeded", exceeded) {
+		c.Recorder.Event(kpa, corev1.EventTypeWarning, "TimeoutBudgetExceeded",
+			"Requests have been using more than 90% of the revision timeout; consider raising timeoutSeconds")
+	}
+}
+
+// reportHealth completes the health signals known to the autoscaler with the
+// pod availability, reports the revision health score and warns when the
+// revision becomes critically unhealthy.
+func (c *Reconciler) reportHealth(key string, kpa *kpa.PodAutoscaler, signals autoscaler.HealthSignals, got int, want int32, reporter autoscaler.StatsReporter) {
+	signals.PodAvailability = 1
+	if want > 0 {
+		signals.PodAvailability = float64(got) / float64(want)
+	}
+	score := signals.Score()
+	reporter.Report(autoscaler.RevisionHealthScoreM, score)
+	if c.warnings.set(key, "HealthCritical", score < autoscaler.CriticalHealthScore) {
+		c.Recorder.Eventf(kpa, corev1.EventTypeWarning, "HealthCritical",
+			"Health score %.2f is below %.2f (error rate %.2f, latency SLO adherence %.2f, pod availability %.2f, panic fraction %.2f)",
+			score, autoscaler.CriticalHealthScore, signals.ErrorRate, signals.LatencySLOAdherence, signals.PodAvailability, signals.PanicFraction)
+	}
+}
+
+// reportFullVolumes records an event on the pods whose volumes have become
+// close to full since the previous reconcile. The queue-proxy reports the
+// volumes, but it is not allowed to record events itself.
+func (c *Reconciler) reportFullVolumes(key string, kpa *kpa.PodAutoscaler, volumes []autoscaler.PodVolume) {
+	for _, v := range c.fullVolumes.Observe(key, volumes) {
+		pod := &corev1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  kpa.Namespace,
+			Name:       v.Pod,
+		}
+		c.Recorder.Eventf(pod, corev1.EventTypeWarning, "VolumeAlmostFull",
+			"Volume %s is more than %.0f%% full", v.Volume, queue.VolumeUtilizationThreshold*100)
+	}
+}
+
+// reportWarmPool reports the warm pool of a KPA with a minimum scale, which
+// keeps pods around while the load does not require them.
+func (c *Reconciler) reportWarmPool(key string, kpa *kpa.PodAutoscaler, demand int32, got int, reporter autoscaler.StatsReporter) {
+	if min, _ := kpa.ScaleBounds(); min == 0 || demand < 0 {
+		return
+	}
+	size, hits, misses := c.warmPool.Observe(key, demand, int32(got))
+	reporter.Report(autoscaler.WarmPoolSizeM, float64(size))
+	if hits > 0 {
+		reporter.Report(autoscaler.WarmPoolHitCountM, float64(hits))
+	}
+	if misses > 0 {
+		reporter.Report(autoscaler.WarmPoolMissCountM, float64(misses))
+	}
+}
+
 func (c *Reconciler) updateStatus(desired *kpa.PodAutoscaler) (*kpa.PodAutoscaler, error) {
 	kpa, err := c.kpaLister.PodAutoscalers(desired.Namespace).Get(desired.Name)
 	if err != nil {
@@ -19,10 +19,13 @@ package autoscaling
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/knative/pkg/configmap"
+	"github.com/knative/serving/pkg/apis/autoscaling"
 	kpa "github.com/knative/serving/pkg/apis/autoscaling/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/autoscaler"
@@ -32,12 +35,14 @@ import (
 	revisionresources "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources"
 	"github.com/knative/serving/pkg/system"
 	"go.uber.org/atomic"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
 	fakeK8s "k8s.io/client-go/kubernetes/fake"
 	scalefake "k8s.io/client-go/scale/fake"
+	"k8s.io/client-go/tools/record"
 
 	. "github.com/knative/pkg/logging/testing"
 )
@@ -90,6 +95,7 @@ func TestControllerSynchronizesCreatesAndDeletes(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		fakeMetrics,
 		kpaScaler,
 	)
@@ -177,6 +183,7 @@ func TestNoEndpoints(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		fakeMetrics,
 		kpaScaler,
 	)
@@ -238,6 +245,7 @@ func TestEmptyEndpoints(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		fakeMetrics,
 		kpaScaler,
 	)
@@ -296,6 +304,7 @@ func TestControllerCreateError(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		&failingKPAMetrics{
 			getErr:    errors.NewNotFound(kpa.Resource("Metrics"), key),
 			createErr: want,
@@ -334,6 +343,7 @@ func TestControllerUpdateError(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		&failingKPAMetrics{
 			getErr:    errors.NewNotFound(kpa.Resource("Metrics"), key),
 			createErr: want,
@@ -372,6 +382,7 @@ func TestControllerGetError(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		&failingKPAMetrics{
 			getErr: want,
 		},
@@ -412,6 +423,7 @@ func TestScaleFailure(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		fakeMetrics,
 		kpaScaler,
 	)
@@ -453,6 +465,7 @@ func TestBadKey(t *testing.T) {
 	ctl := NewController(&opts,
 		servingInformer.Autoscaling().V1alpha1().PodAutoscalers(),
 		kubeInformer.Core().V1().Endpoints(),
+		kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers(),
 		&failingKPAMetrics{},
 		kpaScaler,
 	)
@@ -482,7 +495,10 @@ func (km *testKPAMetrics) Get(ctx context.Context, key string) (*autoscaler.Metr
 func (km *testKPAMetrics) Create(ctx context.Context, kpa *kpa.PodAutoscaler) (*autoscaler.Metric, error) {
 	km.createCallCount.Add(1)
 	km.createdCh <- struct{}{}
-	return &autoscaler.Metric{1}, nil
+	return &autoscaler.Metric{
+		DesiredScale: 1,
+		Health:       autoscaler.HealthSignals{LatencySLOAdherence: 1},
+	}, nil
 }
 
 func (km *testKPAMetrics) Delete(ctx context.Context, key string) error {
@@ -501,6 +517,9 @@ func (km *testKPAMetrics) Delete(ctx context.Context, key string) error {
 func (km *testKPAMetrics) Watch(fn func(string)) {
 }
 
+func (km *testKPAMetrics) Poke(key string, triggered time.Time) {
+}
+
 type failingKPAMetrics struct {
 	getErr    error
 	createErr error
@@ -522,6 +541,9 @@ func (km *failingKPAMetrics) Delete(ctx context.Context, key string) error {
 func (km *failingKPAMetrics) Watch(fn func(string)) {
 }
 
+func (km *failingKPAMetrics) Poke(key string, triggered time.Time) {
+}
+
 func newTestRevision(namespace string, name string) *v1alpha1.Revision {
 	return &v1alpha1.Revision{
 		ObjectMeta: metav1.ObjectMeta{
@@ -557,3 +579,401 @@ func addEndpoint(ep *corev1.Endpoints) *corev1.Endpoints {
 	}}
 	return ep
 }
+
+func TestReportHPADrift(t *testing.T) {
+	rev := newTestRevision(testNamespace, testRevision)
+	rev.Spec.ContainerConcurrency = 10
+	kpa := revisionresources.MakeKPA(rev)
+	key := testNamespace + "/" + testRevision
+	hpaTarget := int32(70)
+	hpa := &autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "user-hpa",
+		},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef:                 kpa.Spec.ScaleTargetRef,
+			TargetCPUUtilizationPercentage: &hpaTarget,
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base: &reconciler.Base{
+			Recorder: recorder,
+		},
+		driftDetector: autoscaler.NewDriftDetector(),
+	}
+
+	tests := []struct {
+		name              string
+		targetConcurrency float64
+		wantDrift         float64
+		wantEvent         bool
+	}{{
+		name:              "aligned targets",
+		targetConcurrency: 7,
+	}, {
+		name:              "drifting targets",
+		targetConcurrency: 10.5,
+		wantDrift:         0.5,
+		wantEvent:         true,
+	}, {
+		name:              "still drifting targets",
+		targetConcurrency: 10.5,
+		wantDrift:         0.5,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &fakeStatsReporter{reported: make(map[autoscaler.Measurement]float64)}
+			c.reportHPADrift(TestContextWithLogger(t), key, kpa, hpa, test.targetConcurrency, reporter)
+			if got, want := reporter.reported[autoscaler.HPATargetCPUUtilizationM], float64(70); got != want {
+				t.Errorf("Reported HPATargetCPUUtilizationM = %v, want %v", got, want)
+			}
+			if got := reporter.reported[autoscaler.TargetUtilizationDriftM]; math.Abs(got-test.wantDrift) > 1e-9 {
+				t.Errorf("Reported TargetUtilizationDriftM = %v, want %v", got, test.wantDrift)
+			}
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected an AutoscalerTargetDrift event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileHPAConflict(t *testing.T) {
+	kubeClient := fakeK8s.NewSimpleClientset()
+	kubeInformer := kubeinformers.NewSharedInformerFactory(kubeClient, 0)
+	hpaInformer := kubeInformer.Autoscaling().V1().HorizontalPodAutoscalers()
+
+	kpa := revisionresources.MakeKPA(newTestRevision(testNamespace, testRevision))
+	key := testNamespace + "/" + testRevision
+	hpaInformer.Informer().GetIndexer().Add(&autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "user-hpa",
+		},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: kpa.Spec.ScaleTargetRef,
+		},
+		Status: autoscalingv1.HorizontalPodAutoscalerStatus{
+			DesiredReplicas: 10,
+		},
+	})
+
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base: &reconciler.Base{
+			Recorder: recorder,
+		},
+		hpaLister:        hpaInformer.Lister(),
+		conflictDetector: autoscaler.NewConflictDetector(),
+	}
+
+	tests := []struct {
+		name      string
+		want      int32
+		wantEvent bool
+	}{{
+		name: "agreeing autoscalers",
+		want: 11,
+	}, {
+		name:      "conflicting autoscalers",
+		want:      20,
+		wantEvent: true,
+	}, {
+		name: "still conflicting autoscalers",
+		want: 20,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &fakeStatsReporter{reported: make(map[autoscaler.Measurement]float64)}
+			if err := c.reconcileHPAConflict(TestContextWithLogger(t), key, kpa, test.want, 100, reporter); err != nil {
+				t.Fatalf("reconcileHPAConflict() = %v", err)
+			}
+			if got, want := reporter.reported[autoscaler.HPADesiredPodsM], float64(10); got != want {
+				t.Errorf("Reported HPADesiredPodsM = %v, want %v", got, want)
+			}
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected an AutoscalerConflict event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestReportUnderProvisioning(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base: &reconciler.Base{
+			Recorder: recorder,
+		},
+		warnings: newWarningTracker(),
+	}
+	kpa := revisionresources.MakeKPA(newTestRevision(testNamespace, testRevision))
+	key := testNamespace + "/" + testRevision
+
+	tests := []struct {
+		name          string
+		scalingFactor float64
+		wantEvent     bool
+	}{{
+		name:          "provisioned",
+		scalingFactor: 1,
+	}, {
+		name:          "becomes under-provisioned",
+		scalingFactor: 20,
+		wantEvent:     true,
+	}, {
+		name:          "stays under-provisioned",
+		scalingFactor: 20,
+	}, {
+		name:          "catches up",
+		scalingFactor: 2,
+	}, {
+		name:          "under-provisioned again",
+		scalingFactor: 11,
+		wantEvent:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c.reportUnderProvisioning(key, kpa, test.scalingFactor)
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected an UnderProvisioned event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestReportTimeoutBudget(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base: &reconciler.Base{
+			Recorder: recorder,
+		},
+		warnings: newWarningTracker(),
+	}
+	kpa := revisionresources.MakeKPA(newTestRevision(testNamespace, testRevision))
+	key := testNamespace + "/" + testRevision
+
+	tests := []struct {
+		name      string
+		exceeded  bool
+		wantEvent bool
+	}{{
+		name: "within budget",
+	}, {
+		name:      "budget exceeded",
+		exceeded:  true,
+		wantEvent: true,
+	}, {
+		name:     "budget still exceeded",
+		exceeded: true,
+	}, {
+		name: "back within budget",
+	}, {
+		name:      "budget exceeded again",
+		exceeded:  true,
+		wantEvent: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c.reportTimeoutBudget(key, kpa, test.exceeded)
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected a TimeoutBudgetExceeded event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestReportHealth(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base: &reconciler.Base{
+			Recorder: recorder,
+		},
+		warnings: newWarningTracker(),
+	}
+	kpa := revisionresources.MakeKPA(newTestRevision(testNamespace, testRevision))
+	key := testNamespace + "/" + testRevision
+
+	tests := []struct {
+		name      string
+		signals   autoscaler.HealthSignals
+		got       int
+		want      int32
+		wantScore float64
+		wantEvent bool
+	}{{
+		name:      "healthy",
+		signals:   autoscaler.HealthSignals{LatencySLOAdherence: 1},
+		got:       2,
+		want:      2,
+		wantScore: 1,
+	}, {
+		name:      "scaled to zero",
+		signals:   autoscaler.HealthSignals{LatencySLOAdherence: 1},
+		wantScore: 1,
+	}, {
+		name:      "failing and missing pods",
+		signals:   autoscaler.HealthSignals{ErrorRate: 1, LatencySLOAdherence: 0.5, PanicFraction: 1},
+		got:       1,
+		want:      2,
+		wantScore: 0.25,
+		wantEvent: true,
+	}, {
+		name:      "still failing",
+		signals:   autoscaler.HealthSignals{ErrorRate: 1, LatencySLOAdherence: 0.5, PanicFraction: 1},
+		got:       1,
+		want:      2,
+		wantScore: 0.25,
+	}, {
+		name:      "recovered",
+		signals:   autoscaler.HealthSignals{LatencySLOAdherence: 1},
+		got:       2,
+		want:      2,
+		wantScore: 1,
+	}, {
+		name:      "failing again",
+		signals:   autoscaler.HealthSignals{ErrorRate: 1, LatencySLOAdherence: 0.5, PanicFraction: 1},
+		got:       1,
+		want:      2,
+		wantScore: 0.25,
+		wantEvent: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &fakeStatsReporter{reported: make(map[autoscaler.Measurement]float64)}
+			c.reportHealth(key, kpa, test.signals, test.got, test.want, reporter)
+			if got := reporter.reported[autoscaler.RevisionHealthScoreM]; math.Abs(got-test.wantScore) > 1e-9 {
+				t.Errorf("Reported RevisionHealthScoreM = %v, want %v", got, test.wantScore)
+			}
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected a HealthCritical event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestReportFullVolumes(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base: &reconciler.Base{
+			Recorder: recorder,
+		},
+		fullVolumes: autoscaler.NewFullVolumeTracker(),
+	}
+	kpa := revisionresources.MakeKPA(newTestRevision(testNamespace, testRevision))
+	key := testNamespace + "/" + testRevision
+	volumes := []autoscaler.PodVolume{{Pod: "pod-1", Volume: "/data"}}
+
+	tests := []struct {
+		name      string
+		volumes   []autoscaler.PodVolume
+		wantEvent bool
+	}{{
+		name: "no full volume",
+	}, {
+		name:      "volume becomes full",
+		volumes:   volumes,
+		wantEvent: true,
+	}, {
+		name:    "volume stays full",
+		volumes: volumes,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c.reportFullVolumes(key, kpa, test.volumes)
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("Unexpected event %q", event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("Expected a VolumeAlmostFull event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestReportWarmPool(t *testing.T) {
+	c := &Reconciler{
+		warmPool: autoscaler.NewWarmPoolTracker(),
+	}
+	kpa := revisionresources.MakeKPA(newTestRevision(testNamespace, testRevision))
+	key := testNamespace + "/" + testRevision
+
+	// Without a minimum scale there is no warm pool.
+	reporter := &fakeStatsReporter{reported: make(map[autoscaler.Measurement]float64)}
+	c.reportWarmPool(key, kpa, 0, 3, reporter)
+	if len(reporter.reported) != 0 {
+		t.Errorf("Reported %v without a minimum scale, want nothing", reporter.reported)
+	}
+
+	kpa.Annotations = map[string]string{autoscaling.MinScaleAnnotationKey: "3"}
+	c.reportWarmPool(key, kpa, 0, 3, reporter)
+	if got, want := reporter.reported[autoscaler.WarmPoolSizeM], 3.0; got != want {
+		t.Errorf("Reported WarmPoolSizeM = %v, want %v", got, want)
+	}
+
+	reporter = &fakeStatsReporter{reported: make(map[autoscaler.Measurement]float64)}
+	c.reportWarmPool(key, kpa, 4, 3, reporter)
+	want := map[autoscaler.Measurement]float64{
+		autoscaler.WarmPoolSizeM:      0,
+		autoscaler.WarmPoolHitCountM:  3,
+		autoscaler.WarmPoolMissCountM: 1,
+	}
+	if diff := cmp.Diff(want, reporter.reported); diff != "" {
+		t.Errorf("Reported warm pool metrics (-want +got): %s", diff)
+	}
+}
+
+type fakeStatsReporter struct {
+	reported map[autoscaler.Measurement]float64
+}
+
+func (r *fakeStatsReporter) Report(m autoscaler.Measurement, v float64) error {
+	r.reported[m] = v
+	return nil
+}
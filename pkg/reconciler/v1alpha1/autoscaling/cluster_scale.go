@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaling
+
+import (
+	"time"
+
+	"github.com/knative/pkg/controller"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/autoscaler"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// clusterScaleHandler reports the interactions of revisions with the cluster
+// autoscaler: their pods that could not be scheduled for lack of node
+// capacity, and how long those pods waited for new nodes.
+type clusterScaleHandler struct {
+	logger      *zap.SugaredLogger
+	tracker     *autoscaler.ClusterScaleTracker
+	newReporter func(pod *corev1.Pod) (autoscaler.StatsReporter, error)
+}
+
+// WatchClusterScale reports the cluster autoscaler interactions of the
+// revision pods seen by the informer.
+func WatchClusterScale(podInformer corev1informers.PodInformer, logger *zap.SugaredLogger) {
+	h := &clusterScaleHandler{
+		logger:      logger,
+		tracker:     autoscaler.NewClusterScaleTracker(),
+		newReporter: newPodStatsReporter,
+	}
+	podInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isRevisionPod,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    h.observe,
+			UpdateFunc: controller.PassNew(h.observe),
+			DeleteFunc: h.forget,
+		},
+	})
+}
+
+func isRevisionPod(obj interface{}) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	_, ok = pod.Labels[serving.RevisionLabelKey]
+	return ok
+}
+
+func newPodStatsReporter(pod *corev1.Pod) (autoscaler.StatsReporter, error) {
+	return autoscaler.NewStatsReporter(pod.Namespace, pod.Labels[serving.ServiceLabelKey],
+		pod.Labels[serving.ConfigurationLabelKey], pod.Labels[serving.RevisionLabelKey])
+}
+
+func (h *clusterScaleHandler) observe(obj interface{}) {
+	pod := obj.(*corev1.Pod)
+	key, err := cache.MetaNamespaceKeyFunc(pod)
+	if err != nil {
+		h.logger.Errorf("Error getting key for pod: %v", err)
+		return
+	}
+
+	triggered, latency, scheduled := h.tracker.Observe(key, pod)
+	if !triggered && !scheduled {
+		return
+	}
+	reporter, err := h.newReporter(pod)
+	if err != nil {
+		h.logger.Errorf("Error creating stats reporter for pod %q: %v", key, err)
+		return
+	}
+	if triggered {
+		if err := reporter.Report(autoscaler.ClusterScaleTriggeredM, 1); err != nil {
+			h.logger.Errorf("Error reporting cluster scale trigger: %v", err)
+		}
+	}
+	if scheduled {
+		if err := reporter.Report(autoscaler.ClusterScaleLatencyMsM, float64(latency/time.Millisecond)); err != nil {
+			h.logger.Errorf("Error reporting cluster scale latency: %v", err)
+		}
+	}
+}
+
+func (h *clusterScaleHandler) forget(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		h.logger.Errorf("Error getting key for pod: %v", err)
+		return
+	}
+	h.tracker.Forget(key)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/autoscaler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+func TestClusterScaleHandler(t *testing.T) {
+	start := time.Now()
+	pod := func(status corev1.ConditionStatus, reason string, at time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test-namespace",
+				Name:      "test-revision-deployment-abcde",
+				Labels: map[string]string{
+					serving.RevisionLabelKey: "test-revision",
+				},
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             status,
+					Reason:             reason,
+					LastTransitionTime: metav1.NewTime(start.Add(at)),
+				}},
+			},
+		}
+	}
+
+	reporter := &fakeStatsReporter{reported: make(map[autoscaler.Measurement]float64)}
+	h := &clusterScaleHandler{
+		logger:  TestLogger(t),
+		tracker: autoscaler.NewClusterScaleTracker(),
+		newReporter: func(*corev1.Pod) (autoscaler.StatsReporter, error) {
+			return reporter, nil
+		},
+	}
+
+	h.observe(pod(corev1.ConditionFalse, corev1.PodReasonUnschedulable, 0))
+	want := map[autoscaler.Measurement]float64{autoscaler.ClusterScaleTriggeredM: 1}
+	if diff := cmp.Diff(want, reporter.reported); diff != "" {
+		t.Errorf("Reported metrics (-want, +got) = %v", diff)
+	}
+
+	h.observe(pod(corev1.ConditionTrue, "", 45*time.Second))
+	want[autoscaler.ClusterScaleLatencyMsM] = 45000
+	if diff := cmp.Diff(want, reporter.reported); diff != "" {
+		t.Errorf("Reported metrics (-want, +got) = %v", diff)
+	}
+}
+
+func TestIsRevisionPod(t *testing.T) {
+	revisionPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{serving.RevisionLabelKey: "test-revision"},
+		},
+	}
+	if !isRevisionPod(revisionPod) {
+		t.Error("isRevisionPod() of a revision pod = false, want true")
+	}
+	if isRevisionPod(&corev1.Pod{}) {
+		t.Error("isRevisionPod() of another pod = true, want false")
+	}
+	if isRevisionPod(&corev1.Service{}) {
+		t.Error("isRevisionPod() of a service = true, want false")
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaling
+
+import "sync"
+
+// warningTracker remembers the warnings each KPA is in, so that a warning is
+// only recorded when a KPA enters it. KPAs are reconciled on every autoscaler
+// tick, so recording a warning on every reconcile would flood the events.
+type warningTracker struct {
+	mu       sync.Mutex
+	warnings map[string]map[string]bool
+}
+
+func newWarningTracker() *warningTracker {
+	return &warningTracker{
+		warnings: make(map[string]map[string]bool),
+	}
+}
+
+// set records whether the keyed KPA is in the warning, and returns whether
+// it just entered it.
+func (w *warningTracker) set(key, warning string, active bool) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.warnings[key] == nil {
+		w.warnings[key] = make(map[string]bool)
+	}
+	entered := active && !w.warnings[key][warning]
+	w.warnings[key][warning] = active
+	return entered
+}
+
+// forget stops tracking the keyed KPA.
+func (w *warningTracker) forget(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.warnings, key)
+}
@@ -27,11 +27,13 @@ import (
 	"github.com/knative/pkg/logging"
 	"github.com/knative/serving/pkg/apis/networking"
 	"github.com/knative/serving/pkg/apis/networking/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	informers "github.com/knative/serving/pkg/client/informers/externalversions/networking/v1alpha1"
 	listers "github.com/knative/serving/pkg/client/listers/networking/v1alpha1"
 	"github.com/knative/serving/pkg/reconciler"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/clusteringress/resources/names"
+	"github.com/knative/serving/pkg/system"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -54,6 +56,10 @@ type Reconciler struct {
 	// listers index properties about resources
 	clusterIngressLister listers.ClusterIngressLister
 	virtualServiceLister istiolisters.VirtualServiceLister
+
+	clock         system.Clock
+	generations   *generationTracker
+	statsReporter StatsReporter
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -71,11 +77,19 @@ func NewController(
 		Base:                 reconciler.NewBase(opt, controllerAgentName),
 		clusterIngressLister: clusterIngressInformer.Lister(),
 		virtualServiceLister: virtualServiceInformer.Lister(),
+		clock:                system.RealClock{},
+		generations:          newGenerationTracker(),
+		statsReporter:        NewStatsReporter(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "ClusterIngresses", reconciler.MustNewStatsReporter("ClusterIngress", c.Logger))
+	reconciler.CountRequeues(impl, v1alpha1.SchemeGroupVersion.WithKind("ClusterIngress"), c.Logger)
 
 	c.Logger.Info("Setting up event handlers")
 	myFilterFunc := reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, IstioIngressClassName, true)
+	resyncer := reconciler.NewGlobalResyncer(impl, "ClusterIngress", c.Logger)
+	resyncer.FilterFunc = myFilterFunc
+	resyncer.ResyncPeriodically(clusterIngressInformer.Informer(), opt.ResyncPeriod, opt.StopChannel)
+
 	clusterIngressInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: myFilterFunc,
 		Handler: cache.ResourceEventHandlerFuncs{
@@ -114,12 +128,14 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	if apierrs.IsNotFound(err) {
 		// The resource may no longer exist, in which case we stop processing.
 		logger.Errorf("clusteringress %q in work queue no longer exists", key)
+		c.generations.forget(name)
 		return nil
 	} else if err != nil {
 		return err
 	}
 	// Don't modify the informers copy
 	ci := original.DeepCopy()
+	c.generations.observe(ci.Name, ci.Spec.Generation, ci.Status.IsReady(), c.clock.Now())
 
 	// Reconcile this copy of the ClusterIngress and then write back any status
 	// updates regardless of whether the reconciliation errored out.
@@ -131,13 +147,63 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 		// to status with this stale state.
 	} else if _, err := c.updateStatus(ci); err != nil {
 		logger.Warn("Failed to update clusterIngress status", zap.Error(err))
+		c.reportReconcileError(ctx, "Ingress", err)
 		c.Recorder.Eventf(ci, corev1.EventTypeWarning, "UpdateFailed",
 			"Failed to update status for ClusterIngress %q: %v", ci.Name, err)
 		return err
+	} else {
+		c.reportAddressAllocation(ctx, original, ci)
+	}
+	if ci.Status.IsReady() {
+		c.reportGenerationSync(ctx, ci)
 	}
 	return err
 }
 
+// reportGenerationSync reports how long the VirtualService of the current
+// generation of the ClusterIngress took to be synced, the first time it is
+// seen ready. The ClusterIngress is marked ready as soon as its VirtualService
+// is synced, so this covers the time spent in reconciliation and retries, not
+// the time the gateways take to pick up the VirtualService.
+func (c *Reconciler) reportGenerationSync(ctx context.Context, ci *v1alpha1.ClusterIngress) {
+	latency, operation, ok := c.generations.sync(ci.Name, ci.Spec.Generation, c.clock.Now())
+	if !ok {
+		return
+	}
+	logger := logging.FromContext(ctx)
+	ns, route := ci.Labels[serving.RouteNamespaceLabelKey], ci.Labels[serving.RouteLabelKey]
+	if err := c.statsReporter.ReportRouteGenerationSync(ns, route, latency); err != nil {
+		logger.Errorf("Failed to report route generation sync: %v", err)
+	}
+	if err := c.statsReporter.ReportVirtualServiceSyncLatency(BackendKindIstio, operation, latency); err != nil {
+		logger.Errorf("Failed to report VirtualService sync latency: %v", err)
+	}
+}
+
+// reportAddressAllocation reports the ClusterIngress getting a load balancer
+// address, when the status written for it has one and the previous did not.
+func (c *Reconciler) reportAddressAllocation(ctx context.Context, original, ci *v1alpha1.ClusterIngress) {
+	if hasAddress(original) || !hasAddress(ci) {
+		return
+	}
+	latency := c.clock.Now().Sub(ci.CreationTimestamp.Time)
+	if err := c.statsReporter.ReportAddressAllocation(BackendKindIstio, latency); err != nil {
+		logging.FromContext(ctx).Errorf("Failed to report address allocation: %v", err)
+	}
+}
+
+func hasAddress(ci *v1alpha1.ClusterIngress) bool {
+	return ci.Status.LoadBalancer != nil && len(ci.Status.LoadBalancer.Ingress) > 0
+}
+
+// reportReconcileError records an error reconciling a resource of the given
+// kind. Istio is the only backend this reconciler programs.
+func (c *Reconciler) reportReconcileError(ctx context.Context, resourceKind string, err error) {
+	if rerr := c.statsReporter.ReportReconcileError(BackendKindIstio, resourceKind, err); rerr != nil {
+		logging.FromContext(ctx).Errorf("Failed to report reconcile error: %v", rerr)
+	}
+}
+
 // Update the Status of the ClusterIngress.  Caller is responsible for checking
 // for semantic differences before calling.
 func (c *Reconciler) updateStatus(desired *v1alpha1.ClusterIngress) (*v1alpha1.ClusterIngress, error) {
@@ -190,6 +256,7 @@ func (c *Reconciler) reconcileVirtualService(ctx context.Context, ci *v1alpha1.C
 		vs, err = c.SharedClientSet.NetworkingV1alpha3().VirtualServices(ns).Create(desired)
 		if err != nil {
 			logger.Error("Failed to create VirtualService", zap.Error(err))
+			c.reportReconcileError(ctx, "VirtualService", err)
 			c.Recorder.Eventf(ci, corev1.EventTypeWarning, "CreationFailed",
 				"Failed to create VirtualService %q/%q: %v", ns, name, err)
 			return err
@@ -197,6 +264,7 @@ func (c *Reconciler) reconcileVirtualService(ctx context.Context, ci *v1alpha1.C
 		c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Created",
 			"Created VirtualService %q", desired.Name)
 	} else if err != nil {
+		c.reportReconcileError(ctx, "VirtualService", err)
 		return err
 	} else if !equality.Semantic.DeepEqual(vs.Spec, desired.Spec) {
 		// Don't modify the informers copy
@@ -205,6 +273,7 @@ func (c *Reconciler) reconcileVirtualService(ctx context.Context, ci *v1alpha1.C
 		_, err = c.SharedClientSet.NetworkingV1alpha3().VirtualServices(ns).Update(existing)
 		if err != nil {
 			logger.Error("Failed to update VirtualService", zap.Error(err))
+			c.reportReconcileError(ctx, "VirtualService", err)
 			return err
 		}
 		c.Recorder.Eventf(ci, corev1.EventTypeNormal, "Updated",
@@ -17,7 +17,9 @@ limitations under the License.
 package clusteringress
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	clientgotesting "k8s.io/client-go/testing"
 
+	"github.com/google/go-cmp/cmp"
 	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
 	"github.com/knative/pkg/apis/istio/v1alpha3"
 	"github.com/knative/pkg/controller"
@@ -170,10 +173,91 @@ func TestReconcile(t *testing.T) {
 			Base:                 reconciler.NewBase(opt, controllerAgentName),
 			virtualServiceLister: listers.GetVirtualServiceLister(),
 			clusterIngressLister: listers.GetClusterIngressLister(),
+			clock:                system.RealClock{},
+			generations:          newGenerationTracker(),
+			statsReporter:        NewStatsReporter(),
 		}
 	}))
 }
 
+type fakeStatsReporter struct {
+	syncs       map[string]time.Duration
+	operations  []string
+	allocations []time.Duration
+}
+
+func (r *fakeStatsReporter) ReportRouteGenerationSync(ns, route string, latency time.Duration) error {
+	r.syncs[ns+"/"+route] = latency
+	return nil
+}
+
+func (r *fakeStatsReporter) ReportReconcileError(backend, resourceKind string, err error) error {
+	return nil
+}
+
+func (r *fakeStatsReporter) ReportVirtualServiceSyncLatency(backend, operation string, latency time.Duration) error {
+	r.operations = append(r.operations, backend+"/"+operation)
+	return nil
+}
+
+func (r *fakeStatsReporter) ReportAddressAllocation(backend string, latency time.Duration) error {
+	r.allocations = append(r.allocations, latency)
+	return nil
+}
+
+func TestReconcilerReportAddressAllocation(t *testing.T) {
+	created := time.Now()
+	reporter := &fakeStatsReporter{}
+	c := &Reconciler{
+		clock:         FakeClock{Time: created.Add(3 * time.Second)},
+		statsReporter: reporter,
+	}
+
+	withAddress := func(ci *v1alpha1.ClusterIngress) *v1alpha1.ClusterIngress {
+		ci = ci.DeepCopy()
+		ci.Status.MarkLoadBalancerReady([]v1alpha1.LoadBalancerIngressStatus{
+			{DomainInternal: "istio-ingressgateway.istio-system.svc.cluster.local"},
+		})
+		return ci
+	}
+	ci := ingress("address-allocation", 1234)
+	ci.CreationTimestamp = metav1.NewTime(created)
+
+	c.reportAddressAllocation(context.Background(), ci, ci)
+	c.reportAddressAllocation(context.Background(), ci, withAddress(ci))
+	// An ingress that already had an address is not reported again.
+	c.reportAddressAllocation(context.Background(), withAddress(ci), withAddress(ci))
+
+	if diff := cmp.Diff([]time.Duration{3 * time.Second}, reporter.allocations); diff != "" {
+		t.Errorf("Reported allocations (-want, +got) = %v", diff)
+	}
+}
+
+func TestReportGenerationSync(t *testing.T) {
+	start := time.Now()
+	reporter := &fakeStatsReporter{syncs: make(map[string]time.Duration)}
+	c := &Reconciler{
+		generations:   newGenerationTracker(),
+		statsReporter: reporter,
+	}
+
+	ci := ingress("reconcile-virtualservice", 1234)
+	c.generations.observe(ci.Name, ci.Spec.Generation, false, start)
+	c.clock = FakeClock{Time: start.Add(5 * time.Second)}
+	c.reportGenerationSync(context.Background(), ci)
+	// Only the first time the generation is seen ready is reported.
+	c.clock = FakeClock{Time: start.Add(10 * time.Second)}
+	c.reportGenerationSync(context.Background(), ci)
+
+	want := map[string]time.Duration{"test-ns/test-route": 5 * time.Second}
+	if diff := cmp.Diff(want, reporter.syncs); diff != "" {
+		t.Errorf("Reported syncs (-want, +got) = %v", diff)
+	}
+	if diff := cmp.Diff([]string{"istio/create"}, reporter.operations); diff != "" {
+		t.Errorf("Reported operations (-want, +got) = %v", diff)
+	}
+}
+
 func addAnnotations(ing *v1alpha1.ClusterIngress, annos map[string]string) *v1alpha1.ClusterIngress {
 	if ing.ObjectMeta.Annotations == nil {
 		ing.ObjectMeta.Annotations = make(map[string]string)
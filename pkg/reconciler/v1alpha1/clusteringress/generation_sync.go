@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusteringress
+
+import (
+	"sync"
+	"time"
+)
+
+// generationTracker remembers when each ClusterIngress generation was first
+// observed, so that the time it takes to sync its VirtualService can be measured.
+type generationTracker struct {
+	mu          sync.Mutex
+	generations map[string]*observedGeneration
+}
+
+type observedGeneration struct {
+	generation int64
+	observed   time.Time
+	synced     bool
+	// operation is operationCreate until a generation of the ClusterIngress
+	// has been synced, operationUpdate after.
+	operation string
+}
+
+func newGenerationTracker() *generationTracker {
+	return &generationTracker{
+		generations: make(map[string]*observedGeneration),
+	}
+}
+
+// observe records the time a generation of the named ClusterIngress was first
+// seen. An ingress seen for the first time while already ready was synced
+// before we started watching it, so its latency is unknown and is not tracked.
+func (g *generationTracker) observe(name string, generation int64, ready bool, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	og, ok := g.generations[name]
+	if ok && og.generation == generation {
+		return
+	}
+	operation := operationCreate
+	if ok && (og.synced || og.operation == operationUpdate) {
+		operation = operationUpdate
+	}
+	g.generations[name] = &observedGeneration{
+		generation: generation,
+		observed:   now,
+		synced:     !ok && ready,
+		operation:  operation,
+	}
+}
+
+// sync marks the VirtualService of the generation of the named ClusterIngress
+// as synced. It returns the time elapsed since the generation was observed and
+// whether it created or updated the ingress, the first time it is synced only.
+func (g *generationTracker) sync(name string, generation int64, now time.Time) (time.Duration, string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	og, ok := g.generations[name]
+	if !ok || og.generation != generation || og.synced {
+		return 0, "", false
+	}
+	og.synced = true
+	return now.Sub(og.observed), og.operation, true
+}
+
+// forget stops tracking the named ClusterIngress.
+func (g *generationTracker) forget(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.generations, name)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusteringress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerationTracker(t *testing.T) {
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	g := newGenerationTracker()
+	g.observe("ingress", 1, false, at(0))
+	// Observing the same generation again keeps the first observation.
+	g.observe("ingress", 1, false, at(time.Second))
+	if latency, op, ok := g.sync("ingress", 1, at(3*time.Second)); !ok || latency != 3*time.Second || op != operationCreate {
+		t.Errorf("sync() = %v, %q, %v, want %v, %q, true", latency, op, ok, 3*time.Second, operationCreate)
+	}
+	if _, _, ok := g.sync("ingress", 1, at(4*time.Second)); ok {
+		t.Error("sync() of a synced generation = true, want false")
+	}
+
+	// A new generation on a ready ingress is tracked.
+	g.observe("ingress", 2, true, at(10*time.Second))
+	if _, _, ok := g.sync("ingress", 1, at(11*time.Second)); ok {
+		t.Error("sync() of a stale generation = true, want false")
+	}
+	// Generations superseded before being synced remain updates.
+	g.observe("ingress", 3, false, at(11*time.Second))
+	if latency, op, ok := g.sync("ingress", 3, at(12*time.Second)); !ok || latency != time.Second || op != operationUpdate {
+		t.Errorf("sync() = %v, %q, %v, want %v, %q, true", latency, op, ok, time.Second, operationUpdate)
+	}
+
+	// An ingress that is ready when first seen has an unknown latency, the
+	// generations after it are updates.
+	g.observe("ready", 5, true, at(0))
+	if _, _, ok := g.sync("ready", 5, at(time.Second)); ok {
+		t.Error("sync() of an ingress first seen ready = true, want false")
+	}
+	g.observe("ready", 6, false, at(2*time.Second))
+	if _, op, ok := g.sync("ready", 6, at(3*time.Second)); !ok || op != operationUpdate {
+		t.Errorf("sync() = %q, %v, want %q, true", op, ok, operationUpdate)
+	}
+
+	g.forget("ingress")
+	if _, _, ok := g.sync("ingress", 3, at(20*time.Second)); ok {
+		t.Error("sync() of a forgotten ingress = true, want false")
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusteringress
+
+import (
+	"context"
+	"time"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	routeGenerationSyncM = stats.Float64(
+		"route_generation_sync_seconds",
+		"Time from the controller observing a route generation until its VirtualService is synced",
+		"s")
+	ingressReconcileErrorM = stats.Int64(
+		"ingress_reconcile_error_total",
+		"Number of errors reconciling the resources backing a ClusterIngress",
+		stats.UnitDimensionless)
+	virtualServiceSyncLatencyM = stats.Float64(
+		"ingress_virtualservice_sync_latency_ms",
+		"Time from the controller observing a ClusterIngress generation until its VirtualService is synced in milliseconds",
+		stats.UnitMilliseconds)
+	addressAllocationM = stats.Int64(
+		"ingress_address_allocation_total",
+		"Number of ClusterIngresses that got a load balancer address assigned",
+		stats.UnitDimensionless)
+	addressAllocationLatencyM = stats.Float64(
+		"ingress_address_allocation_latency_ms",
+		"Time from the creation of a ClusterIngress until it gets a load balancer address in milliseconds",
+		stats.UnitMilliseconds)
+
+	namespaceTagKey    tag.Key
+	routeTagKey        tag.Key
+	backendKindTagKey  tag.Key
+	errorTypeTagKey    tag.Key
+	resourceKindTagKey tag.Key
+	operationTagKey    tag.Key
+)
+
+const (
+	// BackendKindIstio is the backend_kind of errors programming Istio.
+	BackendKindIstio = "istio"
+
+	// The error_type values errors are classified into.
+	errorTypeAPI      = "api_error"
+	errorTypeTimeout  = "timeout"
+	errorTypeConflict = "conflict"
+
+	// The operation values of the generations of a ClusterIngress.
+	operationCreate = "create"
+	operationUpdate = "update"
+)
+
+func init() {
+	var err error
+	// Create the tag keys that will be used to add tags to our measurements.
+	// Tag keys must conform to the restrictions described in
+	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
+	// - length between 1 and 255 inclusive
+	// - characters are printable US-ASCII
+	namespaceTagKey, err = tag.NewKey(metricskey.LabelNamespaceName)
+	if err != nil {
+		panic(err)
+	}
+	routeTagKey, err = tag.NewKey("route_name")
+	if err != nil {
+		panic(err)
+	}
+	backendKindTagKey, err = tag.NewKey("backend_kind")
+	if err != nil {
+		panic(err)
+	}
+	errorTypeTagKey, err = tag.NewKey("error_type")
+	if err != nil {
+		panic(err)
+	}
+	resourceKindTagKey, err = tag.NewKey("resource_kind")
+	if err != nil {
+		panic(err)
+	}
+	operationTagKey, err = tag.NewKey("operation")
+	if err != nil {
+		panic(err)
+	}
+
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	err = metrics.RegisterViews(
+		&view.View{
+			Description: "Time from the controller observing a route generation until its VirtualService is synced",
+			Measure:     routeGenerationSyncM,
+			Aggregation: view.Distribution(0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300),
+			TagKeys:     []tag.Key{namespaceTagKey, routeTagKey},
+		},
+		&view.View{
+			Description: "Number of errors reconciling the resources backing a ClusterIngress",
+			Measure:     ingressReconcileErrorM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{backendKindTagKey, errorTypeTagKey, resourceKindTagKey},
+		},
+		&view.View{
+			Description: "Time from the controller observing a ClusterIngress generation until its VirtualService is synced in milliseconds",
+			Measure:     virtualServiceSyncLatencyM,
+			Aggregation: view.Distribution(100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000, 300000),
+			TagKeys:     []tag.Key{backendKindTagKey, operationTagKey},
+		},
+		&view.View{
+			Description: "Number of ClusterIngresses that got a load balancer address assigned",
+			Measure:     addressAllocationM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{backendKindTagKey},
+		},
+		&view.View{
+			Description: "Time from the creation of a ClusterIngress until it gets a load balancer address in milliseconds",
+			Measure:     addressAllocationLatencyM,
+			Aggregation: view.Distribution(100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000, 300000, 600000),
+			TagKeys:     []tag.Key{backendKindTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// StatsReporter defines the interface for sending ClusterIngress metrics
+type StatsReporter interface {
+	// ReportRouteGenerationSync captures the time it took to sync the
+	// VirtualService of a new generation of a route's ClusterIngress.
+	ReportRouteGenerationSync(ns, route string, latency time.Duration) error
+
+	// ReportReconcileError captures an error reconciling a resource of the
+	// given kind on the given ingress backend.
+	ReportReconcileError(backend, resourceKind string, err error) error
+
+	// ReportVirtualServiceSyncLatency captures the time it took to sync the
+	// VirtualService of a generation that created or updated a
+	// ClusterIngress on the given backend.
+	ReportVirtualServiceSyncLatency(backend, operation string, latency time.Duration) error
+
+	// ReportAddressAllocation captures a ClusterIngress on the given backend
+	// getting a load balancer address, and the time it took since the
+	// ClusterIngress was created.
+	ReportAddressAllocation(backend string, latency time.Duration) error
+}
+
+// Reporter holds cached metric objects to report ClusterIngress metrics
+type Reporter struct{}
+
+// NewStatsReporter creates a reporter that collects and reports ClusterIngress metrics
+func NewStatsReporter() *Reporter {
+	return &Reporter{}
+}
+
+// ReportRouteGenerationSync captures the sync delay of a route generation.
+func (r *Reporter) ReportRouteGenerationSync(ns, route string, latency time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(routeTagKey, route))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, routeGenerationSyncM.M(latency.Seconds()))
+	return nil
+}
+
+// ReportReconcileError captures a reconcile error, classified by its type.
+func (r *Reporter) ReportReconcileError(backend, resourceKind string, err error) error {
+	ctx, tagErr := tag.New(
+		context.Background(),
+		tag.Insert(backendKindTagKey, backend),
+		tag.Insert(errorTypeTagKey, errorType(err)),
+		tag.Insert(resourceKindTagKey, resourceKind))
+	if tagErr != nil {
+		return tagErr
+	}
+
+	stats.Record(ctx, ingressReconcileErrorM.M(1))
+	return nil
+}
+
+// ReportVirtualServiceSyncLatency captures the time the VirtualService of a
+// ClusterIngress generation took to be synced.
+func (r *Reporter) ReportVirtualServiceSyncLatency(backend, operation string, latency time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(backendKindTagKey, backend),
+		tag.Insert(operationTagKey, operation))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, virtualServiceSyncLatencyM.M(float64(latency/time.Millisecond)))
+	return nil
+}
+
+// ReportAddressAllocation captures the assignment of an address to a
+// ClusterIngress.
+func (r *Reporter) ReportAddressAllocation(backend string, latency time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(backendKindTagKey, backend))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, addressAllocationM.M(1), addressAllocationLatencyM.M(float64(latency/time.Millisecond)))
+	return nil
+}
+
+// errorType classifies a reconcile error into one of the error_type values.
+func errorType(err error) string {
+	switch {
+	case apierrs.IsConflict(err):
+		return errorTypeConflict
+	case apierrs.IsTimeout(err), apierrs.IsServerTimeout(err), err == context.DeadlineExceeded:
+		return errorTypeTimeout
+	default:
+		return errorTypeAPI
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusteringress
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/pkg/metrics/metricskey"
+	"go.opencensus.io/stats/view"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestReportRouteGenerationSync(t *testing.T) {
+	r := NewStatsReporter()
+
+	for _, latency := range []time.Duration{time.Second, 3 * time.Second} {
+		if err := r.ReportRouteGenerationSync("testns", "testroute", latency); err != nil {
+			t.Errorf("ReportRouteGenerationSync() = %v", err)
+		}
+	}
+
+	rows, err := view.RetrieveData("route_generation_sync_seconds")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		tags := make(map[string]string, len(row.Tags))
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags[metricskey.LabelNamespaceName] != "testns" || tags["route_name"] != "testroute" {
+			continue
+		}
+		d := row.Data.(*view.DistributionData)
+		if d.Count != 2 || d.Max != 3 {
+			t.Errorf("Distribution count, max = %d, %v, want 2, 3", d.Count, d.Max)
+		}
+		return
+	}
+	t.Errorf("No row for route testns/testroute in %v", rows)
+}
+
+func TestReportReconcileError(t *testing.T) {
+	r := NewStatsReporter()
+	gr := schema.GroupResource{Group: "networking.istio.io", Resource: "virtualservices"}
+
+	for _, err := range []error{
+		apierrs.NewConflict(gr, "test-vs", errors.New("conflict")),
+		apierrs.NewTimeoutError("timeout", 1),
+		apierrs.NewServerTimeout(gr, "update", 1),
+		context.DeadlineExceeded,
+		apierrs.NewForbidden(gr, "test-vs", errors.New("forbidden")),
+	} {
+		if err := r.ReportReconcileError(BackendKindIstio, "VirtualService", err); err != nil {
+			t.Errorf("ReportReconcileError() = %v", err)
+		}
+	}
+
+	rows, err := view.RetrieveData("ingress_reconcile_error_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	got := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		tags := make(map[string]string, len(row.Tags))
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["backend_kind"] != BackendKindIstio || tags["resource_kind"] != "VirtualService" {
+			continue
+		}
+		got[tags["error_type"]] = row.Data.(*view.CountData).Value
+	}
+	want := map[string]int64{"conflict": 1, "timeout": 3, "api_error": 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Reconcile errors by type (-want, +got) = %v", diff)
+	}
+}
+
+func TestReportVirtualServiceSyncLatency(t *testing.T) {
+	r := NewStatsReporter()
+	// The reconciler tests report for Istio as well.
+	const backend = "test-backend"
+
+	for _, op := range []struct {
+		operation string
+		latency   time.Duration
+	}{
+		{operationCreate, 2 * time.Second},
+		{operationUpdate, 500 * time.Millisecond},
+		{operationUpdate, 1500 * time.Millisecond},
+	} {
+		if err := r.ReportVirtualServiceSyncLatency(backend, op.operation, op.latency); err != nil {
+			t.Errorf("ReportVirtualServiceSyncLatency() = %v", err)
+		}
+	}
+
+	rows, err := view.RetrieveData("ingress_virtualservice_sync_latency_ms")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	got := make(map[string][2]float64, len(rows))
+	for _, row := range rows {
+		tags := make(map[string]string, len(row.Tags))
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["backend_kind"] != backend {
+			continue
+		}
+		d := row.Data.(*view.DistributionData)
+		got[tags["operation"]] = [2]float64{float64(d.Count), d.Max}
+	}
+	want := map[string][2]float64{"create": {1, 2000}, "update": {2, 1500}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Latency count and max by operation (-want, +got) = %v", diff)
+	}
+}
+
+func TestReportAddressAllocation(t *testing.T) {
+	r := NewStatsReporter()
+	const backend = "test-allocation-backend"
+
+	for _, latency := range []time.Duration{time.Second, 4 * time.Second} {
+		if err := r.ReportAddressAllocation(backend, latency); err != nil {
+			t.Errorf("ReportAddressAllocation() = %v", err)
+		}
+	}
+
+	find := func(name string) *view.Row {
+		rows, err := view.RetrieveData(name)
+		if err != nil {
+			t.Fatalf("RetrieveData(%s) = %v", name, err)
+		}
+		for _, row := range rows {
+			for _, tag := range row.Tags {
+				if tag.Key.Name() == "backend_kind" && tag.Value == backend {
+					return row
+				}
+			}
+		}
+		t.Fatalf("No row for backend %s in %v", backend, rows)
+		return nil
+	}
+	if got := find("ingress_address_allocation_total").Data.(*view.CountData).Value; got != 2 {
+		t.Errorf("ingress_address_allocation_total = %d, want 2", got)
+	}
+	d := find("ingress_address_allocation_latency_ms").Data.(*view.DistributionData)
+	if d.Count != 2 || d.Max != 4000 {
+		t.Errorf("Distribution count, max = %d, %v, want 2, 4000", d.Count, d.Max)
+	}
+}
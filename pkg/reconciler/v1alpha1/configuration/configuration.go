@@ -78,6 +78,9 @@ func NewController(
 		revisionLister:      revisionInformer.Lister(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "Configurations", reconciler.MustNewStatsReporter("Configurations", c.Logger))
+	reconciler.CountRequeues(impl, v1alpha1.SchemeGroupVersion.WithKind("Configuration"), c.Logger)
+	reconciler.NewGlobalResyncer(impl, "Configuration", c.Logger).
+		ResyncPeriodically(configurationInformer.Informer(), opt.ResyncPeriod, opt.StopChannel)
 
 	c.Logger.Info("Setting up event handlers")
 	configurationInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
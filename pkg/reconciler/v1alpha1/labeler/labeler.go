@@ -62,6 +62,9 @@ func NewRouteToConfigurationController(
 		revisionLister:      revisionInformer.Lister(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "Labels", reconciler.MustNewStatsReporter("Labels", c.Logger))
+	// The Route controller already resyncs the Routes as "Route".
+	reconciler.NewGlobalResyncer(impl, "Labels", c.Logger).
+		ResyncPeriodically(routeInformer.Informer(), opt.ResyncPeriod, opt.StopChannel)
 
 	c.Logger.Info("Setting up event handlers")
 	routeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/knative/serving/pkg/apis/serving"
+)
+
+// AnnotationCompressor archives the annotations whose keys match a prefix,
+// such as the ones CI systems stamp on every deploy, into a single JSON
+// encoded annotation to keep revisions well below the etcd object size limit.
+type AnnotationCompressor struct {
+	prefix *regexp.Regexp
+}
+
+// NewAnnotationCompressor creates an AnnotationCompressor archiving the
+// annotations whose keys start with a match of the prefix regular expression.
+func NewAnnotationCompressor(prefix string) (*AnnotationCompressor, error) {
+	re, err := regexp.Compile("^(?:" + prefix + ")")
+	if err != nil {
+		return nil, fmt.Errorf("invalid annotation prefix %q: %v", prefix, err)
+	}
+	return &AnnotationCompressor{prefix: re}, nil
+}
+
+// Compress returns a copy of the annotations with the matching annotations
+// moved under serving.ArchivedAnnotationsAnnotationKey, merged with the ones
+// archived previously. The annotations are returned as is when none match.
+func (c *AnnotationCompressor) Compress(annotations map[string]string) (map[string]string, error) {
+	archived := make(map[string]string)
+	compressed := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k != serving.ArchivedAnnotationsAnnotationKey && c.prefix.MatchString(k) {
+			archived[k] = v
+		} else {
+			compressed[k] = v
+		}
+	}
+	if len(archived) == 0 {
+		return annotations, nil
+	}
+
+	if prev, ok := annotations[serving.ArchivedAnnotationsAnnotationKey]; ok {
+		old := make(map[string]string)
+		if err := json.Unmarshal([]byte(prev), &old); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", serving.ArchivedAnnotationsAnnotationKey, err)
+		}
+		for k, v := range old {
+			// Newer values win over the archived ones.
+			if _, ok := archived[k]; !ok {
+				archived[k] = v
+			}
+		}
+	}
+	b, err := json.Marshal(archived)
+	if err != nil {
+		return nil, err
+	}
+	compressed[serving.ArchivedAnnotationsAnnotationKey] = string(b)
+	return compressed, nil
+}
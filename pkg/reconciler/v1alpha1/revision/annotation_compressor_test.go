@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/apis/serving"
+)
+
+func TestAnnotationCompressor(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]string
+		want map[string]string
+	}{{
+		name: "nothing to archive",
+		in:   map[string]string{"team": "a"},
+		want: map[string]string{"team": "a"},
+	}, {
+		name: "archive ci annotations",
+		in: map[string]string{
+			"team":                 "a",
+			"ci.example.com/build": "42",
+			"cd.example.com/sha":   "abc",
+			"other/ci.example.com": "x",
+		},
+		want: map[string]string{
+			"team":                                   "a",
+			"other/ci.example.com":                   "x",
+			serving.ArchivedAnnotationsAnnotationKey: `{"cd.example.com/sha":"abc","ci.example.com/build":"42"}`,
+		},
+	}, {
+		name: "merge with the archive",
+		in: map[string]string{
+			"ci.example.com/build":                   "43",
+			serving.ArchivedAnnotationsAnnotationKey: `{"ci.example.com/build":"42","ci.example.com/job":"deploy"}`,
+		},
+		want: map[string]string{
+			serving.ArchivedAnnotationsAnnotationKey: `{"ci.example.com/build":"43","ci.example.com/job":"deploy"}`,
+		},
+	}}
+
+	c, err := NewAnnotationCompressor(`c[id]\.example\.com/`)
+	if err != nil {
+		t.Fatalf("NewAnnotationCompressor() = %v", err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := c.Compress(test.in)
+			if err != nil {
+				t.Fatalf("Compress() = %v", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Compress (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+func TestAnnotationCompressorErrors(t *testing.T) {
+	if _, err := NewAnnotationCompressor("("); err == nil {
+		t.Error("NewAnnotationCompressor(\"(\") = nil, wanted an error")
+	}
+
+	c, err := NewAnnotationCompressor("ci/")
+	if err != nil {
+		t.Fatalf("NewAnnotationCompressor() = %v", err)
+	}
+	if _, err := c.Compress(map[string]string{
+		"ci/build":                               "1",
+		serving.ArchivedAnnotationsAnnotationKey: "not json",
+	}); err == nil {
+		t.Error("Compress() = nil, wanted an error")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	commonlogging "github.com/knative/pkg/logging"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// readyFlapThreshold is the number of transitions of the Ready condition
+	// within readyFlapWindow above which a revision is considered flapping.
+	readyFlapThreshold = 10
+	readyFlapWindow    = time.Hour
+)
+
+// flapTracker remembers the recent transitions of the Ready condition of each
+// revision.
+type flapTracker struct {
+	mu          sync.Mutex
+	transitions map[string][]time.Time
+}
+
+func newFlapTracker() *flapTracker {
+	return &flapTracker{
+		transitions: make(map[string][]time.Time),
+	}
+}
+
+// record adds a transition of the keyed revision and returns the number of
+// its transitions within readyFlapWindow, this one included.
+func (f *flapTracker) record(key string, now time.Time) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	recent := f.transitions[key][:0]
+	for _, t := range f.transitions[key] {
+		if now.Sub(t) < readyFlapWindow {
+			recent = append(recent, t)
+		}
+	}
+	f.transitions[key] = append(recent, now)
+	return len(f.transitions[key])
+}
+
+// forget stops tracking the keyed revision.
+func (f *flapTracker) forget(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.transitions, key)
+}
+
+// reportConditionChanges counts the transitions of the status conditions of
+// the revision whose status was just written, and warns once its Ready
+// condition flaps more than readyFlapThreshold times within readyFlapWindow.
+func (c *Reconciler) reportConditionChanges(ctx context.Context, key string, original, rev *v1alpha1.Revision) {
+	logger := commonlogging.FromContext(ctx)
+
+	for _, cond := range rev.Status.Conditions {
+		before := corev1.ConditionUnknown
+		if oc := original.Status.GetCondition(cond.Type); oc != nil {
+			before = oc.Status
+		}
+		if before == cond.Status {
+			continue
+		}
+		transition := strings.ToLower(string(before)) + "_to_" + strings.ToLower(string(cond.Status))
+		if err := c.statsReporter.ReportConditionChange(string(cond.Type), transition); err != nil {
+			logger.Errorf("Failed to report condition change: %v", err)
+		}
+		if cond.Type != v1alpha1.RevisionConditionReady {
+			continue
+		}
+		if n := c.readyFlaps.record(key, time.Now()); n == readyFlapThreshold+1 {
+			c.Recorder.Eventf(rev, corev1.EventTypeWarning, "ReadyFlapping",
+				"Revision readiness changed %d times within %v", n, readyFlapWindow)
+		}
+	}
+}
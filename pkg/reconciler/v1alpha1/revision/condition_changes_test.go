@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+type conditionChangeReporter struct {
+	StatsReporter
+	changes []string
+}
+
+func (r *conditionChangeReporter) ReportConditionChange(conditionType, transition string) error {
+	r.changes = append(r.changes, conditionType+"/"+transition)
+	return nil
+}
+
+func revisionWithConditions(conds ...duckv1alpha1.Condition) *v1alpha1.Revision {
+	return &v1alpha1.Revision{
+		Status: v1alpha1.RevisionStatus{Conditions: conds},
+	}
+}
+
+func TestReportConditionChanges(t *testing.T) {
+	reporter := &conditionChangeReporter{}
+	recorder := record.NewFakeRecorder(10)
+	c := &Reconciler{
+		Base:          &reconciler.Base{Recorder: recorder},
+		statsReporter: reporter,
+		readyFlaps:    newFlapTracker(),
+	}
+
+	original := revisionWithConditions(duckv1alpha1.Condition{
+		Type:   v1alpha1.RevisionConditionReady,
+		Status: corev1.ConditionFalse,
+	}, duckv1alpha1.Condition{
+		Type:   v1alpha1.RevisionConditionActive,
+		Status: corev1.ConditionTrue,
+	})
+	rev := revisionWithConditions(duckv1alpha1.Condition{
+		Type:   v1alpha1.RevisionConditionReady,
+		Status: corev1.ConditionTrue,
+	}, duckv1alpha1.Condition{
+		Type:   v1alpha1.RevisionConditionActive,
+		Status: corev1.ConditionTrue,
+	}, duckv1alpha1.Condition{
+		Type:   v1alpha1.RevisionConditionContainerHealthy,
+		Status: corev1.ConditionTrue,
+	})
+	c.reportConditionChanges(context.Background(), "ns/rev", original, rev)
+
+	want := []string{"Ready/false_to_true", "ContainerHealthy/unknown_to_true"}
+	if diff := cmp.Diff(want, reporter.changes); diff != "" {
+		t.Errorf("Reported changes (-want, +got) = %v", diff)
+	}
+
+	// Flapping is only warned about once the threshold is exceeded.
+	for i := 1; i < readyFlapThreshold; i++ {
+		c.reportConditionChanges(context.Background(), "ns/rev", rev, original)
+	}
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("Unexpected event %q", event)
+	default:
+	}
+	c.reportConditionChanges(context.Background(), "ns/rev", original, rev)
+	select {
+	case <-recorder.Events:
+	default:
+		t.Error("Expected a ReadyFlapping event, got none")
+	}
+}
+
+func TestFlapTracker(t *testing.T) {
+	f := newFlapTracker()
+	now := time.Now()
+
+	f.record("ns/rev", now.Add(-2*readyFlapWindow))
+	f.record("ns/rev", now.Add(-readyFlapWindow/2))
+	if got := f.record("ns/rev", now); got != 2 {
+		t.Errorf("record() = %d, want 2 transitions within the window", got)
+	}
+	f.forget("ns/rev")
+	if got := f.record("ns/rev", now); got != 1 {
+		t.Errorf("record() after forget() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"time"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// getReadyPods returns the names of the pods whose addresses are ready in
+// the given endpoints.
+func getReadyPods(e *corev1.Endpoints) sets.String {
+	pods := sets.NewString()
+	for _, es := range e.Subsets {
+		for _, addr := range es.Addresses {
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+				pods.Insert(addr.TargetRef.Name)
+			}
+		}
+	}
+	return pods
+}
+
+// getPodReadyTime returns when the given pod last became ready. It returns
+// false when the pod is not ready.
+func getPodReadyTime(pod *corev1.Pod) (time.Time, bool) {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return cond.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// reportEndpointUpdateLatency records, for each pod whose address became
+// ready in the endpoints of a revision, the time since the pod became ready.
+// This is the part of the scale up spent propagating the pod readiness to
+// the endpoints, before kube-proxy and the DNS can route to the pod. The
+// end is when the controller observes the update, so it includes the delay
+// of the informer.
+func (c *Reconciler) reportEndpointUpdateLatency(oldObj, newObj interface{}) {
+	oldEndpoints, ok := oldObj.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	newEndpoints, ok := newObj.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	rev, ok := newEndpoints.Labels[serving.RevisionLabelKey]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	added := getReadyPods(newEndpoints).Difference(getReadyPods(oldEndpoints))
+	for _, name := range added.List() {
+		pod, err := c.podLister.Pods(newEndpoints.Namespace).Get(name)
+		if err != nil {
+			c.Logger.Errorf("Failed to get pod %q added to the endpoints: %v", name, err)
+			continue
+		}
+		ready, ok := getPodReadyTime(pod)
+		// The ready time comes from the clock of the kubelet, a pod that
+		// became ready in our future is skipped rather than reported with
+		// a negative latency.
+		if !ok || ready.After(now) {
+			continue
+		}
+		if err := c.statsReporter.ReportEndpointUpdateLatency(newEndpoints.Namespace, rev, now.Sub(ready)); err != nil {
+			c.Logger.Errorf("Failed to report endpoint update latency for pod %q: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type endpointLatencyReporter struct {
+	StatsReporter
+	latencies map[string]time.Duration
+}
+
+func (r *endpointLatencyReporter) ReportEndpointUpdateLatency(ns, revision string, d time.Duration) error {
+	r.latencies[ns+"/"+revision] = d
+	return nil
+}
+
+func readyPod(name string, ready time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(ready),
+			}},
+		},
+	}
+}
+
+func revisionEndpoints(ready ...string) *corev1.Endpoints {
+	e := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "rev-service",
+			Labels:    map[string]string{serving.RevisionLabelKey: "rev"},
+		},
+		Subsets: []corev1.EndpointSubset{{}},
+	}
+	for _, name := range ready {
+		e.Subsets[0].Addresses = append(e.Subsets[0].Addresses, corev1.EndpointAddress{
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: name},
+		})
+	}
+	return e
+}
+
+func TestGetPodReadyTime(t *testing.T) {
+	ready := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if got, ok := getPodReadyTime(readyPod("pod", ready)); !ok || !got.Equal(ready) {
+		t.Errorf("getPodReadyTime() = %v, %v, want %v, true", got, ok, ready)
+	}
+	if got, ok := getPodReadyTime(&corev1.Pod{}); ok {
+		t.Errorf("getPodReadyTime() = %v, true, want false for a pod that is not ready", got)
+	}
+}
+
+func TestReportEndpointUpdateLatencyOfNewPods(t *testing.T) {
+	now := time.Now()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(readyPod("old-pod", now.Add(-time.Hour)))
+	indexer.Add(readyPod("new-pod", now.Add(-2*time.Second)))
+	indexer.Add(readyPod("future-pod", now.Add(time.Hour)))
+	reporter := &endpointLatencyReporter{latencies: map[string]time.Duration{}}
+	c := &Reconciler{
+		Base:          &reconciler.Base{Logger: TestLogger(t)},
+		statsReporter: reporter,
+		podLister:     corev1listers.NewPodLister(indexer),
+	}
+
+	// Only the pods newly added to the endpoints are reported, the pods
+	// missing from the lister and those ready in the future are skipped.
+	c.reportEndpointUpdateLatency(revisionEndpoints("old-pod"),
+		revisionEndpoints("old-pod", "new-pod", "missing-pod", "future-pod"))
+
+	if len(reporter.latencies) != 1 {
+		t.Fatalf("Reported latencies = %v, want one for ns/rev", reporter.latencies)
+	}
+	if got := reporter.latencies["ns/rev"]; got < 2*time.Second || got > time.Minute {
+		t.Errorf("Endpoint update latency = %v, want about 2s", got)
+	}
+
+	// Endpoints of no revision are ignored.
+	reporter.latencies = map[string]time.Duration{}
+	other := revisionEndpoints("new-pod")
+	other.Labels = nil
+	c.reportEndpointUpdateLatency(revisionEndpoints(), other)
+	if len(reporter.latencies) != 0 {
+		t.Errorf("Reported latencies = %v, want none", reporter.latencies)
+	}
+}
@@ -17,12 +17,18 @@ limitations under the License.
 package revision
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
 	"time"
 
 	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 )
 
 // TODO(mattmoor): This should be a helper on Build (upstream)
@@ -45,6 +51,20 @@ func getIsServiceReady(e *corev1.Endpoints) bool {
 	return false
 }
 
+// getReadyEndpointFraction returns the fraction of the addresses of the
+// endpoints that are ready. It returns false when there are no addresses.
+func getReadyEndpointFraction(e *corev1.Endpoints) (float64, bool) {
+	ready, total := 0, 0
+	for _, es := range e.Subsets {
+		ready += len(es.Addresses)
+		total += len(es.Addresses) + len(es.NotReadyAddresses)
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(ready) / float64(total), true
+}
+
 func getRevisionLastTransitionTime(r *v1alpha1.Revision) time.Time {
 	ready := r.Status.GetCondition(v1alpha1.RevisionConditionReady)
 	if ready == nil {
@@ -68,3 +88,87 @@ func hasDeploymentTimedOut(deployment *appsv1.Deployment) bool {
 	}
 	return false
 }
+
+func getIsPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// getUserContainerStartupLatency returns the time between the user container
+// of the given pod starting and the pod becoming ready. It returns false when
+// the pod is not ready, the user container has not started yet or it was
+// restarted.
+func getUserContainerStartupLatency(pod *corev1.Pod) (time.Duration, bool) {
+	var readyTime time.Time
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			readyTime = cond.LastTransitionTime.Time
+		}
+	}
+	if readyTime.IsZero() {
+		return 0, false
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != resources.UserContainerName || cs.State.Running == nil {
+			continue
+		}
+		// A restarted container started after the pod first became ready.
+		if cs.RestartCount > 0 {
+			return 0, false
+		}
+		return readyTime.Sub(cs.State.Running.StartedAt.Time), true
+	}
+	return 0, false
+}
+
+// getStartupCommandHash returns a short, stable hash of the user container's
+// command and arguments. We hash them to keep the cardinality of the
+// startup_command tag bounded and to avoid leaking arguments into metrics.
+func getStartupCommandHash(pod *corev1.Pod) string {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != resources.UserContainerName {
+			continue
+		}
+		cmd := strings.Join(append(append([]string{}, c.Command...), c.Args...), " ")
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(cmd)))[:12]
+	}
+	return ""
+}
+
+// reconcileResult classifies the outcome of a revision reconcile. Errors
+// expected to go away on their own, such as update conflicts, are retries.
+func reconcileResult(err error, notFound bool) string {
+	switch {
+	case notFound:
+		return reconcileResultNotFound
+	case err == nil:
+		return reconcileResultSuccess
+	case apierrs.IsConflict(err), apierrs.IsTimeout(err), apierrs.IsServerTimeout(err), apierrs.IsTooManyRequests(err):
+		return reconcileResultRetry
+	default:
+		return reconcileResultError
+	}
+}
+
+// getUpdateTrigger returns what triggered the update of a revision from old
+// to new, or false if nothing of interest changed, e.g. on resyncs.
+func getUpdateTrigger(old, new *v1alpha1.Revision) (string, bool) {
+	switch {
+	case !equality.Semantic.DeepEqual(old.Spec, new.Spec):
+		return updateTriggerUserInitiated, true
+	case !equality.Semantic.DeepEqual(old.Annotations, new.Annotations):
+		return updateTriggerAnnotationUpdate, true
+	case !equality.Semantic.DeepEqual(old.Labels, new.Labels):
+		return updateTriggerConfigChange, true
+	}
+	oldActive := old.Status.GetCondition(v1alpha1.RevisionConditionActive)
+	newActive := new.Status.GetCondition(v1alpha1.RevisionConditionActive)
+	if oldActive != nil && newActive != nil && oldActive.Status != newActive.Status {
+		return updateTriggerScaleEvent, true
+	}
+	return "", false
+}
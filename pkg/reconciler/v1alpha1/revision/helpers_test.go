@@ -17,6 +17,7 @@ limitations under the License.
 package revision
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -26,7 +27,9 @@ import (
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestGetBuildDoneCondition(t *testing.T) {
@@ -234,3 +237,227 @@ func TestGetDeploymentProgressCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserContainerStartupLatency(t *testing.T) {
+	startedAt := time.Now()
+	tests := []struct {
+		description string
+		pod         *corev1.Pod
+		latency     time.Duration
+		ok          bool
+	}{{
+		description: "no conditions",
+		pod:         &corev1.Pod{},
+	}, {
+		description: "not ready",
+		pod: &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:   corev1.PodReady,
+					Status: corev1.ConditionFalse,
+				}},
+			},
+		},
+	}, {
+		description: "ready without user container status",
+		pod: &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(startedAt.Add(3 * time.Second)),
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "queue-proxy",
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)},
+					},
+				}},
+			},
+		},
+	}, {
+		description: "ready",
+		pod: &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(startedAt.Add(3 * time.Second)),
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "user-container",
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)},
+					},
+				}},
+			},
+		},
+		latency: 3 * time.Second,
+		ok:      true,
+	}, {
+		description: "ready after a restart",
+		pod: &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(startedAt.Add(3 * time.Second)),
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "user-container",
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)},
+					},
+					RestartCount: 1,
+				}},
+			},
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			latency, ok := getUserContainerStartupLatency(test.pod)
+			if ok != test.ok {
+				t.Errorf("getUserContainerStartupLatency() ok = %v, want %v", ok, test.ok)
+			}
+			// metav1.Time is serialized with second precision, so compare at that granularity.
+			if latency.Round(time.Second) != test.latency {
+				t.Errorf("getUserContainerStartupLatency() = %v, want %v", latency, test.latency)
+			}
+		})
+	}
+}
+
+func TestGetStartupCommandHash(t *testing.T) {
+	pod := func(command ...string) *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:    "user-container",
+					Command: command,
+				}},
+			},
+		}
+	}
+
+	if got := getStartupCommandHash(&corev1.Pod{}); got != "" {
+		t.Errorf("getStartupCommandHash() = %q, want empty for a pod without user container", got)
+	}
+	a, b := getStartupCommandHash(pod("/ko-app/helloworld")), getStartupCommandHash(pod("/ko-app/helloworld"))
+	if a != b {
+		t.Errorf("getStartupCommandHash() is not stable: %q != %q", a, b)
+	}
+	if got, want := len(a), 12; got != want {
+		t.Errorf("len(getStartupCommandHash()) = %d, want %d", got, want)
+	}
+	if c := getStartupCommandHash(pod("/ko-app/other")); a == c {
+		t.Errorf("getStartupCommandHash() = %q for different commands", c)
+	}
+}
+
+func TestReconcileResult(t *testing.T) {
+	gr := schema.GroupResource{Group: "serving.knative.dev", Resource: "revisions"}
+	tests := []struct {
+		description string
+		err         error
+		notFound    bool
+		want        string
+	}{{
+		description: "success",
+		want:        "success",
+	}, {
+		description: "not found",
+		notFound:    true,
+		want:        "not-found",
+	}, {
+		description: "conflict",
+		err:         apierrs.NewConflict(gr, "rev", errors.New("conflict")),
+		want:        "retry",
+	}, {
+		description: "server timeout",
+		err:         apierrs.NewServerTimeout(gr, "update", 1),
+		want:        "retry",
+	}, {
+		description: "too many requests",
+		err:         apierrs.NewTooManyRequests("slow down", 1),
+		want:        "retry",
+	}, {
+		description: "other error",
+		err:         errors.New("boom"),
+		want:        "error",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := reconcileResult(tc.err, tc.notFound); got != tc.want {
+				t.Errorf("reconcileResult() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetUpdateTrigger(t *testing.T) {
+	rev := func(mutate func(*v1alpha1.Revision)) *v1alpha1.Revision {
+		r := &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"serving.knative.dev/configuration": "config"},
+				Annotations: map[string]string{"note": "a"},
+			},
+			Spec: v1alpha1.RevisionSpec{
+				Container: corev1.Container{Image: "busybox"},
+			},
+		}
+		r.Status.MarkActive()
+		if mutate != nil {
+			mutate(r)
+		}
+		return r
+	}
+
+	tests := []struct {
+		description string
+		new         *v1alpha1.Revision
+		want        string
+		wantOK      bool
+	}{{
+		description: "resync",
+		new:         rev(nil),
+	}, {
+		description: "spec",
+		new: rev(func(r *v1alpha1.Revision) {
+			r.Spec.Container.Image = "helloworld"
+		}),
+		want:   "user_initiated",
+		wantOK: true,
+	}, {
+		description: "annotations",
+		new: rev(func(r *v1alpha1.Revision) {
+			r.Annotations["note"] = "b"
+		}),
+		want:   "annotation_update",
+		wantOK: true,
+	}, {
+		description: "labels",
+		new: rev(func(r *v1alpha1.Revision) {
+			r.Labels["serving.knative.dev/route"] = "route"
+		}),
+		want:   "config_change",
+		wantOK: true,
+	}, {
+		description: "scaled to zero",
+		new: rev(func(r *v1alpha1.Revision) {
+			r.Status.MarkInactive("NoTraffic", "The target is not receiving traffic.")
+		}),
+		want:   "scale_event",
+		wantOK: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			got, ok := getUpdateTrigger(rev(nil), tc.new)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("getUpdateTrigger() = %v, %v, want %v, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"strings"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// evictedReason is the reason of the pods evicted by the kubelet.
+const evictedReason = "Evicted"
+
+// nodePressures are the node conditions that make the kubelet evict pods,
+// along with the parts of the eviction messages naming them: either the
+// condition itself, or the resource the node was low on.
+var nodePressures = []struct {
+	condition corev1.NodeConditionType
+	markers   []string
+}{{
+	condition: corev1.NodeMemoryPressure,
+	markers:   []string{string(corev1.NodeMemoryPressure), "resource: memory"},
+}, {
+	condition: corev1.NodeDiskPressure,
+	markers:   []string{string(corev1.NodeDiskPressure), "resource: ephemeral-storage", "resource: inodes"},
+}, {
+	condition: corev1.NodePIDPressure,
+	markers:   []string{string(corev1.NodePIDPressure), "resource: pids"},
+}}
+
+// getNodePressure returns the node condition a pod was evicted for, if it
+// was evicted by the kubelet of a node under pressure.
+func getNodePressure(pod *corev1.Pod) (corev1.NodeConditionType, bool) {
+	if pod.Status.Phase != corev1.PodFailed || pod.Status.Reason != evictedReason {
+		return "", false
+	}
+	for _, p := range nodePressures {
+		for _, m := range p.markers {
+			if strings.Contains(pod.Status.Message, m) {
+				return p.condition, true
+			}
+		}
+	}
+	return "", false
+}
+
+// reportNodePressureEviction counts the revision pods evicted by a node under
+// pressure, and warns on their revision so that the node health shows up
+// next to the pods it took down.
+func (c *Reconciler) reportNodePressureEviction(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if _, ok := getNodePressure(oldPod); ok {
+		return
+	}
+	pressure, ok := getNodePressure(newPod)
+	if !ok {
+		return
+	}
+	node := newPod.Spec.NodeName
+	if err := c.statsReporter.ReportNodePressureEviction(newPod.Namespace, string(pressure), node); err != nil {
+		c.Logger.Errorf("Failed to report node pressure eviction of pod %q: %v", newPod.Name, err)
+	}
+
+	rev, err := c.revisionLister.Revisions(newPod.Namespace).Get(newPod.Labels[serving.RevisionLabelKey])
+	if err != nil {
+		c.Logger.Errorf("Failed to get the revision of evicted pod %q: %v", newPod.Name, err)
+		return
+	}
+	c.Recorder.Eventf(rev, corev1.EventTypeWarning, "NodePressureEviction",
+		"Pod %q was evicted by node %q under %s: %s", newPod.Name, node, pressure, newPod.Status.Message)
+}
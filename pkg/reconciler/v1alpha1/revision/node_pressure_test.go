@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/knative/pkg/logging/testing"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	listers "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+type evictionReporter struct {
+	StatsReporter
+	evictions []string
+}
+
+func (r *evictionReporter) ReportNodePressureEviction(ns, pressureType, node string) error {
+	r.evictions = append(r.evictions, ns+"/"+pressureType+"/"+node)
+	return nil
+}
+
+func evictedPod(message string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "rev-pod",
+			Labels:    map[string]string{serving.RevisionLabelKey: "rev"},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{
+			Phase:   corev1.PodFailed,
+			Reason:  evictedReason,
+			Message: message,
+		},
+	}
+}
+
+func TestGetNodePressure(t *testing.T) {
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		want   corev1.NodeConditionType
+		wantOK bool
+	}{{
+		name: "running",
+		pod:  &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+	}, {
+		name:   "low on memory",
+		pod:    evictedPod("The node was low on resource: memory. Container user-container was using 1Gi, which exceeds its request of 0. "),
+		want:   corev1.NodeMemoryPressure,
+		wantOK: true,
+	}, {
+		name:   "low on ephemeral storage",
+		pod:    evictedPod("The node was low on resource: ephemeral-storage. "),
+		want:   corev1.NodeDiskPressure,
+		wantOK: true,
+	}, {
+		name:   "low on inodes",
+		pod:    evictedPod("The node was low on resource: inodes. "),
+		want:   corev1.NodeDiskPressure,
+		wantOK: true,
+	}, {
+		name:   "rejected under PID pressure",
+		pod:    evictedPod("The node had condition: [PIDPressure]. "),
+		want:   corev1.NodePIDPressure,
+		wantOK: true,
+	}, {
+		name: "evicted for another reason",
+		pod:  evictedPod("Pod ephemeral local storage usage exceeds the total limit of containers 1Gi. "),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, ok := getNodePressure(test.pod); got != test.want || ok != test.wantOK {
+				t.Errorf("getNodePressure() = %q, %v, want %q, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestReportNodePressureEvictionEvent(t *testing.T) {
+	reporter := &evictionReporter{}
+	recorder := record.NewFakeRecorder(10)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(&v1alpha1.Revision{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rev"}})
+	c := &Reconciler{
+		Base:           &reconciler.Base{Recorder: recorder, Logger: TestLogger(t)},
+		statsReporter:  reporter,
+		revisionLister: listers.NewRevisionLister(indexer),
+	}
+
+	running := evictedPod("")
+	running.Status = corev1.PodStatus{Phase: corev1.PodRunning}
+	evicted := evictedPod("The node was low on resource: memory. ")
+	c.reportNodePressureEviction(running, evicted)
+	// Later updates of the evicted pod are not counted again.
+	c.reportNodePressureEviction(evicted, evicted)
+
+	if want := []string{"ns/MemoryPressure/node-1"}; !cmp.Equal(reporter.evictions, want) {
+		t.Errorf("Reported evictions = %v, want %v", reporter.evictions, want)
+	}
+	select {
+	case event := <-recorder.Events:
+		if want := "Warning NodePressureEviction"; len(event) < len(want) || event[:len(want)] != want {
+			t.Errorf("Event = %q, want a %s event", event, want)
+		}
+	default:
+		t.Error("Expected a NodePressureEviction event, got none")
+	}
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("Unexpected event %q", event)
+	default:
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"github.com/knative/serving/pkg/apis/serving"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// oomKilledReason is the reason of the containers killed for running out of
+// memory.
+const oomKilledReason = "OOMKilled"
+
+// getOOMKill returns the last termination of the container if it was killed
+// for running out of memory. The container is either still terminated, or
+// already restarted.
+func getOOMKill(cs corev1.ContainerStatus) (*corev1.ContainerStateTerminated, bool) {
+	t := cs.State.Terminated
+	if t == nil {
+		t = cs.LastTerminationState.Terminated
+	}
+	if t == nil || t.Reason != oomKilledReason {
+		return nil, false
+	}
+	return t, true
+}
+
+// getNewOOMKills returns the names of the containers of newPod killed for
+// running out of memory since oldPod. A termination stays in the status
+// until the next one, so only the terminations oldPod did not have count.
+func getNewOOMKills(oldPod, newPod *corev1.Pod) []string {
+	old := make(map[string]*corev1.ContainerStateTerminated, len(oldPod.Status.ContainerStatuses))
+	for _, cs := range oldPod.Status.ContainerStatuses {
+		if t, ok := getOOMKill(cs); ok {
+			old[cs.Name] = t
+		}
+	}
+	var killed []string
+	for _, cs := range newPod.Status.ContainerStatuses {
+		t, ok := getOOMKill(cs)
+		if !ok {
+			continue
+		}
+		if o, ok := old[cs.Name]; ok && o.ContainerID == t.ContainerID && o.FinishedAt.Equal(&t.FinishedAt) {
+			continue
+		}
+		killed = append(killed, cs.Name)
+	}
+	return killed
+}
+
+// reportContainerOOM counts the containers of revision pods killed for
+// running out of memory, and warns on their revision. The kubelet reports
+// the kill in the pod status, so unlike the memory cgroup of the
+// queue-proxy this covers the user container.
+func (c *Reconciler) reportContainerOOM(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	killed := getNewOOMKills(oldPod, newPod)
+	if len(killed) == 0 {
+		return
+	}
+	revName := newPod.Labels[serving.RevisionLabelKey]
+	for _, container := range killed {
+		if err := c.statsReporter.ReportContainerOOM(newPod.Namespace, revName, container); err != nil {
+			c.Logger.Errorf("Failed to report OOM kill of pod %q: %v", newPod.Name, err)
+		}
+	}
+
+	rev, err := c.revisionLister.Revisions(newPod.Namespace).Get(revName)
+	if err != nil {
+		c.Logger.Errorf("Failed to get the revision of OOM killed pod %q: %v", newPod.Name, err)
+		return
+	}
+	for _, container := range killed {
+		c.Recorder.Eventf(rev, corev1.EventTypeWarning, "OOMKilled",
+			"Container %q of pod %q was killed for running out of memory", container, newPod.Name)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/knative/pkg/logging/testing"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	listers "github.com/knative/serving/pkg/client/listers/serving/v1alpha1"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+type oomReporter struct {
+	StatsReporter
+	kills []string
+}
+
+func (r *oomReporter) ReportContainerOOM(ns, revision, container string) error {
+	r.kills = append(r.kills, ns+"/"+revision+"/"+container)
+	return nil
+}
+
+func oomPod(statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "rev-pod",
+			Labels:    map[string]string{serving.RevisionLabelKey: "rev"},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: statuses},
+	}
+}
+
+func terminated(reason, id string, finished time.Time) *corev1.ContainerStateTerminated {
+	return &corev1.ContainerStateTerminated{
+		Reason:      reason,
+		ContainerID: id,
+		FinishedAt:  metav1.NewTime(finished),
+	}
+}
+
+func TestGetNewOOMKills(t *testing.T) {
+	finished := time.Now().Truncate(time.Second)
+	running := corev1.ContainerStatus{
+		Name:  "user-container",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}
+	killed := corev1.ContainerStatus{
+		Name:  "user-container",
+		State: corev1.ContainerState{Terminated: terminated(oomKilledReason, "docker://1", finished)},
+	}
+	restarted := corev1.ContainerStatus{
+		Name:                 "user-container",
+		State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		LastTerminationState: corev1.ContainerState{Terminated: terminated(oomKilledReason, "docker://1", finished)},
+		RestartCount:         1,
+	}
+	killedAgain := corev1.ContainerStatus{
+		Name:                 "user-container",
+		State:                corev1.ContainerState{Terminated: terminated(oomKilledReason, "docker://2", finished.Add(time.Minute))},
+		LastTerminationState: corev1.ContainerState{Terminated: terminated(oomKilledReason, "docker://1", finished)},
+		RestartCount:         1,
+	}
+	crashed := corev1.ContainerStatus{
+		Name:  "user-container",
+		State: corev1.ContainerState{Terminated: terminated("Error", "docker://1", finished)},
+	}
+
+	tests := []struct {
+		name     string
+		old, new *corev1.Pod
+		want     []string
+	}{{
+		name: "killed",
+		old:  oomPod(running),
+		new:  oomPod(killed),
+		want: []string{"user-container"},
+	}, {
+		name: "restarted after the kill",
+		old:  oomPod(killed),
+		new:  oomPod(restarted),
+	}, {
+		name: "killed again",
+		old:  oomPod(restarted),
+		new:  oomPod(killedAgain),
+		want: []string{"user-container"},
+	}, {
+		name: "crashed",
+		old:  oomPod(running),
+		new:  oomPod(crashed),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getNewOOMKills(test.old, test.new); !cmp.Equal(got, test.want) {
+				t.Errorf("getNewOOMKills() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReportContainerOOMEvent(t *testing.T) {
+	reporter := &oomReporter{}
+	recorder := record.NewFakeRecorder(10)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(&v1alpha1.Revision{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rev"}})
+	c := &Reconciler{
+		Base:           &reconciler.Base{Recorder: recorder, Logger: TestLogger(t)},
+		statsReporter:  reporter,
+		revisionLister: listers.NewRevisionLister(indexer),
+	}
+
+	running := oomPod(corev1.ContainerStatus{Name: "user-container"})
+	killed := oomPod(corev1.ContainerStatus{
+		Name:  "user-container",
+		State: corev1.ContainerState{Terminated: terminated(oomKilledReason, "docker://1", time.Now())},
+	})
+	c.reportContainerOOM(running, killed)
+	// Later updates of the killed pod are not counted again.
+	c.reportContainerOOM(killed, killed)
+
+	if want := []string{"ns/rev/user-container"}; !cmp.Equal(reporter.kills, want) {
+		t.Errorf("Reported OOM kills = %v, want %v", reporter.kills, want)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, "Warning OOMKilled") {
+			t.Errorf("Event = %q, want a Warning OOMKilled event", event)
+		}
+	default:
+		t.Error("Expected an OOMKilled event, got none")
+	}
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("Unexpected event %q", event)
+	default:
+	}
+}
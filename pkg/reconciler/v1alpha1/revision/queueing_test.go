@@ -180,6 +180,7 @@ func newTestController(t *testing.T, stopCh <-chan struct{}, servingObjects ...r
 		kubeInformer.Core().V1().Services(),
 		kubeInformer.Core().V1().Endpoints(),
 		kubeInformer.Core().V1().ConfigMaps(),
+		kubeInformer.Core().V1().Pods(),
 		buildInformerFactory,
 	)
 
@@ -177,6 +177,7 @@ func (c *Reconciler) reconcileService(ctx context.Context, rev *v1alpha1.Revisio
 		logger.Errorf("Error checking Active Endpoints %q: %v", serviceName, err)
 		return err
 	}
+	c.reportReadyEndpointFraction(ctx, rev, endpoints)
 
 	// If the endpoints resource indicates that the Service it sits in front of is ready,
 	// then surface this in our Revision status as resources available (pods were scheduled)
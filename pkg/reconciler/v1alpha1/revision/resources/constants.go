@@ -41,6 +41,9 @@ const (
 	// ServicePort is the external port of the service
 	ServicePort = int32(80)
 	AppLabelKey = "app"
+
+	// UserContainerName is the name of the container running the user's code
+	UserContainerName = userContainerName
 )
 
 var ProgressDeadlineSeconds int32 = 120
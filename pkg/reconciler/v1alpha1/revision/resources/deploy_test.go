@@ -128,6 +128,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -217,6 +234,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -310,6 +344,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -412,6 +463,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -512,6 +580,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -614,6 +699,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -712,6 +814,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -801,6 +920,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
@@ -942,6 +1078,23 @@ func TestMakePodSpec(t *testing.T) {
 					ValueFrom: &corev1.EnvVarSource{
 						FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 					},
+				}, {
+					Name: "USER_CONTAINER_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}, {
+					Name: "USER_CONTAINER_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: userContainerName,
+							Resource:      "limits.memory",
+						},
+					},
 				}, {
 					Name: "SERVING_LOGGING_CONFIG",
 					// No logging configuration
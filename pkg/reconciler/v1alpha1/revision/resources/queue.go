@@ -20,11 +20,13 @@ import (
 	"strconv"
 
 	"github.com/knative/pkg/logging"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/autoscaler"
 	"github.com/knative/serving/pkg/queue"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -83,7 +85,7 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, a
 		loggingLevel = ll.String()
 	}
 
-	return &corev1.Container{
+	container := &corev1.Container{
 		Name:           queueContainerName,
 		Image:          controllerConfig.QueueSidecarImage,
 		Resources:      queueResources,
@@ -118,6 +120,25 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, a
 					FieldPath: "metadata.name",
 				},
 			},
+		}, {
+			// The user container's limits let the queue-proxy report its own
+			// observability overhead relative to them.
+			Name: "USER_CONTAINER_CPU_LIMIT",
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: userContainerName,
+					Resource:      "limits.cpu",
+					Divisor:       resource.MustParse("1m"),
+				},
+			},
+		}, {
+			Name: "USER_CONTAINER_MEMORY_LIMIT",
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: userContainerName,
+					Resource:      "limits.memory",
+				},
+			},
 		}, {
 			Name:  "SERVING_LOGGING_CONFIG",
 			Value: loggingConfig.LoggingConfig,
@@ -126,4 +147,23 @@ func makeQueueContainer(rev *v1alpha1.Revision, loggingConfig *logging.Config, a
 			Value: loggingLevel,
 		}},
 	}
+	if v, ok := rev.Annotations[serving.MaxRequestBodySizeAnnotationKey]; ok {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "MAX_REQUEST_BODY_SIZE_BYTES",
+			Value: v,
+		})
+	}
+	if v, ok := rev.Annotations[serving.UpgradeAllowlistAnnotationKey]; ok {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "UPGRADE_ALLOWLIST",
+			Value: v,
+		})
+	}
+	if v, ok := rev.Annotations[serving.RateLimitAnnotationKey]; ok {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "RATE_LIMIT_REQUESTS_PER_SECOND",
+			Value: v,
+		})
+	}
+	return container
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/knative/pkg/logging"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/knative/serving/pkg/autoscaler"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/config"
@@ -94,6 +95,23 @@ func TestMakeQueueContainer(t *testing.T) {
 				ValueFrom: &corev1.EnvVarSource{
 					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 				},
+			}, {
+				Name: "USER_CONTAINER_CPU_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.cpu",
+						Divisor:       resource.MustParse("1m"),
+					},
+				},
+			}, {
+				Name: "USER_CONTAINER_MEMORY_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.memory",
+					},
+				},
 			}, {
 				Name: "SERVING_LOGGING_CONFIG",
 				// No logging configuration
@@ -157,6 +175,23 @@ func TestMakeQueueContainer(t *testing.T) {
 				ValueFrom: &corev1.EnvVarSource{
 					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 				},
+			}, {
+				Name: "USER_CONTAINER_CPU_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.cpu",
+						Divisor:       resource.MustParse("1m"),
+					},
+				},
+			}, {
+				Name: "USER_CONTAINER_MEMORY_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.memory",
+					},
+				},
 			}, {
 				Name: "SERVING_LOGGING_CONFIG",
 				// No logging configuration
@@ -224,6 +259,23 @@ func TestMakeQueueContainer(t *testing.T) {
 				ValueFrom: &corev1.EnvVarSource{
 					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 				},
+			}, {
+				Name: "USER_CONTAINER_CPU_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.cpu",
+						Divisor:       resource.MustParse("1m"),
+					},
+				},
+			}, {
+				Name: "USER_CONTAINER_MEMORY_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.memory",
+					},
+				},
 			}, {
 				Name: "SERVING_LOGGING_CONFIG",
 				// No logging configuration
@@ -289,6 +341,23 @@ func TestMakeQueueContainer(t *testing.T) {
 				ValueFrom: &corev1.EnvVarSource{
 					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 				},
+			}, {
+				Name: "USER_CONTAINER_CPU_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.cpu",
+						Divisor:       resource.MustParse("1m"),
+					},
+				},
+			}, {
+				Name: "USER_CONTAINER_MEMORY_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.memory",
+					},
+				},
 			}, {
 				Name:  "SERVING_LOGGING_CONFIG",
 				Value: "The logging configuration goes here", // from logging config
@@ -349,6 +418,23 @@ func TestMakeQueueContainer(t *testing.T) {
 				ValueFrom: &corev1.EnvVarSource{
 					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
 				},
+			}, {
+				Name: "USER_CONTAINER_CPU_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.cpu",
+						Divisor:       resource.MustParse("1m"),
+					},
+				},
+			}, {
+				Name: "USER_CONTAINER_MEMORY_LIMIT",
+				ValueFrom: &corev1.EnvVarSource{
+					ResourceFieldRef: &corev1.ResourceFieldSelector{
+						ContainerName: userContainerName,
+						Resource:      "limits.memory",
+					},
+				},
 			}, {
 				Name: "SERVING_LOGGING_CONFIG",
 				// No logging configuration
@@ -368,3 +454,78 @@ func TestMakeQueueContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeQueueContainerMaxRequestBodySize(t *testing.T) {
+	rev := &v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+			Name:      "bar",
+			Annotations: map[string]string{
+				serving.MaxRequestBodySizeAnnotationKey: "1048576",
+			},
+		},
+		Spec: v1alpha1.RevisionSpec{
+			TimeoutSeconds: &metav1.Duration{
+				Duration: 45 * time.Second,
+			},
+		},
+	}
+	got := makeQueueContainer(rev, &logging.Config{}, &autoscaler.Config{}, &config.Controller{})
+	want := corev1.EnvVar{
+		Name:  "MAX_REQUEST_BODY_SIZE_BYTES",
+		Value: "1048576",
+	}
+	if diff := cmp.Diff(want, got.Env[len(got.Env)-1]); diff != "" {
+		t.Errorf("Last env var (-want, +got) = %v", diff)
+	}
+}
+
+func TestMakeQueueContainerUpgradeAllowlist(t *testing.T) {
+	rev := &v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+			Name:      "bar",
+			Annotations: map[string]string{
+				serving.UpgradeAllowlistAnnotationKey: "",
+			},
+		},
+		Spec: v1alpha1.RevisionSpec{
+			TimeoutSeconds: &metav1.Duration{
+				Duration: 45 * time.Second,
+			},
+		},
+	}
+	got := makeQueueContainer(rev, &logging.Config{}, &autoscaler.Config{}, &config.Controller{})
+	want := corev1.EnvVar{
+		Name:  "UPGRADE_ALLOWLIST",
+		Value: "",
+	}
+	if diff := cmp.Diff(want, got.Env[len(got.Env)-1]); diff != "" {
+		t.Errorf("Last env var (-want, +got) = %v", diff)
+	}
+}
+
+func TestMakeQueueContainerRateLimit(t *testing.T) {
+	rev := &v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+			Name:      "bar",
+			Annotations: map[string]string{
+				serving.RateLimitAnnotationKey: "100",
+			},
+		},
+		Spec: v1alpha1.RevisionSpec{
+			TimeoutSeconds: &metav1.Duration{
+				Duration: 45 * time.Second,
+			},
+		},
+	}
+	got := makeQueueContainer(rev, &logging.Config{}, &autoscaler.Config{}, &config.Controller{})
+	want := corev1.EnvVar{
+		Name:  "RATE_LIMIT_REQUESTS_PER_SECOND",
+		Value: "100",
+	}
+	if diff := cmp.Diff(want, got.Env[len(got.Env)-1]); diff != "" {
+		t.Errorf("Last env var (-want, +got) = %v", diff)
+	}
+}
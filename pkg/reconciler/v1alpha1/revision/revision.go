@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn/k8schain"
 	cachinginformers "github.com/knative/caching/pkg/client/informers/externalversions/caching/v1alpha1"
@@ -54,6 +55,20 @@ import (
 
 const (
 	controllerAgentName = "revision-controller"
+
+	// maxRevisionLabels is the number of labels above which a revision is
+	// considered to put undue pressure on the Kubernetes API server.
+	maxRevisionLabels = 50
+
+	// maxRevisionAnnotations is the number of annotations above which a
+	// revision risks growing past the etcd object size limit.
+	maxRevisionAnnotations = 100
+
+	// degradedEndpointFraction and criticalEndpointFraction are the
+	// fractions of ready pods below which a revision is considered degraded
+	// and critically degraded.
+	degradedEndpointFraction = 0.5
+	criticalEndpointFraction = 0.2
 )
 
 var (
@@ -92,12 +107,17 @@ type Reconciler struct {
 	serviceLister    corev1listers.ServiceLister
 	endpointsLister  corev1listers.EndpointsLister
 	configMapLister  corev1listers.ConfigMapLister
+	podLister        corev1listers.PodLister
 
 	buildInformerFactory duck.InformerFactory
 
-	tracker     tracker.Interface
-	resolver    resolver
-	configStore configStore
+	tracker       tracker.Interface
+	resolver      resolver
+	configStore   configStore
+	statsReporter StatsReporter
+	readyFlaps    *flapTracker
+	warnings      *warningTracker
+	startups      *startupTracker
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -117,6 +137,7 @@ func NewController(
 	serviceInformer corev1informers.ServiceInformer,
 	endpointsInformer corev1informers.EndpointsInformer,
 	configMapInformer corev1informers.ConfigMapInformer,
+	podInformer corev1informers.PodInformer,
 	buildInformerFactory duck.InformerFactory,
 ) *controller.Impl {
 
@@ -129,24 +150,38 @@ func NewController(
 		serviceLister:    serviceInformer.Lister(),
 		endpointsLister:  endpointsInformer.Lister(),
 		configMapLister:  configMapInformer.Lister(),
+		podLister:        podInformer.Lister(),
 		resolver: &digestResolver{
 			client:    opt.KubeClientSet,
 			transport: http.DefaultTransport,
 		},
+		statsReporter: NewStatsReporter(),
+		readyFlaps:    newFlapTracker(),
+		warnings:      newWarningTracker(),
+		startups:      newStartupTracker(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "Revisions", reconciler.MustNewStatsReporter("Revisions", c.Logger))
+	reconciler.CountRequeues(impl, v1alpha1.SchemeGroupVersion.WithKind("Revision"), c.Logger)
+	reconciler.NewGlobalResyncer(impl, "Revision", c.Logger).
+		ResyncPeriodically(revisionInformer.Informer(), opt.ResyncPeriod, opt.StopChannel)
 
 	// Set up an event handler for when the resource types of interest change
 	c.Logger.Info("Setting up event handlers")
 	revisionInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    impl.Enqueue,
-		UpdateFunc: controller.PassNew(impl.Enqueue),
+		AddFunc: impl.Enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.reportRevisionUpdate(oldObj, newObj)
+			impl.Enqueue(newObj)
+		},
 		DeleteFunc: impl.Enqueue,
 	})
 
 	endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    impl.EnqueueLabelOfNamespaceScopedResource("", serving.RevisionLabelKey),
-		UpdateFunc: controller.PassNew(impl.EnqueueLabelOfNamespaceScopedResource("", serving.RevisionLabelKey)),
+		AddFunc: impl.EnqueueLabelOfNamespaceScopedResource("", serving.RevisionLabelKey),
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.reportEndpointUpdateLatency(oldObj, newObj)
+			impl.EnqueueLabelOfNamespaceScopedResource("", serving.RevisionLabelKey)(newObj)
+		},
 		DeleteFunc: impl.EnqueueLabelOfNamespaceScopedResource("", serving.RevisionLabelKey),
 	})
 
@@ -168,6 +203,27 @@ func NewController(
 		},
 	})
 
+	// We don't reconcile pods, we only observe them to report how long user
+	// containers take to become ready, their containers killed for running
+	// out of memory and their evictions by nodes under pressure. Their ready
+	// time is also looked up to report how long the endpoints take to be
+	// updated.
+	podInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: hasRevisionLabel,
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				c.reportUserContainerStartup(oldObj, newObj)
+				c.reportContainerOOM(oldObj, newObj)
+				c.reportNodePressureEviction(oldObj, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if pod, ok := obj.(*corev1.Pod); ok {
+					c.startups.forget(pod.UID)
+				}
+			},
+		},
+	})
+
 	c.tracker = tracker.New(impl.EnqueueKey, opt.GetTrackerLease())
 
 	// We don't watch for changes to Image because we don't incorporate any of its
@@ -216,10 +272,64 @@ func newDuckInformerFactory(t tracker.Interface, delegate duck.InformerFactory)
 	}
 }
 
+func hasRevisionLabel(obj interface{}) bool {
+	if object, ok := obj.(metav1.Object); ok {
+		_, ok := object.GetLabels()[serving.RevisionLabelKey]
+		return ok
+	}
+	return false
+}
+
+// reportUserContainerStartup records the user container startup latency
+// when a revision pod transitions to ready for the first time. Later
+// transitions, after a readiness probe failure or a restart of the user
+// container, are not startups of the pod.
+func (c *Reconciler) reportUserContainerStartup(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if getIsPodReady(oldPod) || !getIsPodReady(newPod) {
+		return
+	}
+	latency, ok := getUserContainerStartupLatency(newPod)
+	if !ok || !c.startups.firstReady(newPod.UID) {
+		return
+	}
+	if err := c.statsReporter.ReportUserContainerStartupLatency(newPod.Namespace,
+		newPod.Labels[serving.RevisionLabelKey], getStartupCommandHash(newPod), latency); err != nil {
+		c.Logger.Errorf("Failed to report user container startup latency for pod %q: %v", newPod.Name, err)
+	}
+}
+
+// reportRevisionUpdate counts the updates of revisions by what triggered
+// them.
+func (c *Reconciler) reportRevisionUpdate(oldObj, newObj interface{}) {
+	oldRev, ok := oldObj.(*v1alpha1.Revision)
+	if !ok {
+		return
+	}
+	newRev, ok := newObj.(*v1alpha1.Revision)
+	if !ok {
+		return
+	}
+	trigger, ok := getUpdateTrigger(oldRev, newRev)
+	if !ok {
+		return
+	}
+	if err := c.statsReporter.ReportRevisionUpdate(trigger); err != nil {
+		c.Logger.Errorf("Failed to report update of revision %q: %v", newRev.Name, err)
+	}
+}
+
 // Reconcile compares the actual state with the desired, and attempts to
 // converge the two. It then updates the Status block of the Revision resource
 // with the current status of the resource.
-func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
+func (c *Reconciler) Reconcile(ctx context.Context, key string) (err error) {
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -229,6 +339,11 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	logger := commonlogging.FromContext(ctx)
 	logger.Info("Running reconcile Revision")
 
+	start, notFound := time.Now(), false
+	defer func() {
+		c.reportReconcileDuration(ctx, reconcileResult(err, notFound), time.Since(start))
+	}()
+
 	ctx = c.configStore.ToContext(ctx)
 
 	// Get the Revision resource with this namespace/name
@@ -236,6 +351,12 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	// The resource may no longer exist, in which case we stop processing.
 	if apierrs.IsNotFound(err) {
 		logger.Errorf("revision %q in work queue no longer exists", key)
+		notFound = true
+		c.readyFlaps.forget(key)
+		c.warnings.forget(key)
+		if err := c.statsReporter.DeleteRevisionGauges(namespace, name); err != nil {
+			logger.Error("Failed to delete the revision gauges", zap.Error(err))
+		}
 		return nil
 	} else if err != nil {
 		return err
@@ -256,6 +377,8 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 		c.Recorder.Eventf(rev, corev1.EventTypeWarning, "UpdateFailed",
 			"Failed to update status for Revision %q: %v", rev.Name, err)
 		return err
+	} else {
+		c.reportConditionChanges(ctx, key, original, rev)
 	}
 	return err
 }
@@ -338,6 +461,8 @@ func (c *Reconciler) reconcile(ctx context.Context, rev *v1alpha1.Revision) erro
 
 	rev.Status.InitializeConditions()
 	c.updateRevisionLoggingURL(ctx, rev)
+	c.reportRevisionLabelCount(ctx, rev)
+	c.reportRevisionAnnotationCount(ctx, rev)
 
 	if err := c.reconcileBuild(ctx, rev); err != nil {
 		return err
@@ -396,6 +521,77 @@ func (c *Reconciler) updateRevisionLoggingURL(
 		"${REVISION_UID}", uid, -1)
 }
 
+// reportRevisionLabelCount records the number of labels on the revision and
+// warns when it comes to exceed maxRevisionLabels.
+func (c *Reconciler) reportRevisionLabelCount(ctx context.Context, rev *v1alpha1.Revision) {
+	logger := commonlogging.FromContext(ctx)
+
+	count := len(rev.Labels)
+	if err := c.statsReporter.ReportRevisionLabelCount(rev.Namespace, rev.Name, count); err != nil {
+		logger.Errorf("Failed to report label count: %v", err)
+	}
+	warning := ""
+	if count > maxRevisionLabels {
+		warning = "TooManyLabels"
+	}
+	if c.warnings.set(revisionKey(rev), "labels", warning) {
+		c.Recorder.Eventf(rev, corev1.EventTypeWarning, "TooManyLabels",
+			"Revision has %d labels, more than the recommended maximum of %d", count, maxRevisionLabels)
+	}
+}
+
+// reportRevisionAnnotationCount records the number of annotations on the
+// revision and warns when it comes to exceed maxRevisionAnnotations.
+func (c *Reconciler) reportRevisionAnnotationCount(ctx context.Context, rev *v1alpha1.Revision) {
+	logger := commonlogging.FromContext(ctx)
+
+	count := len(rev.Annotations)
+	if err := c.statsReporter.ReportRevisionAnnotationCount(rev.Namespace, rev.Name, count); err != nil {
+		logger.Errorf("Failed to report annotation count: %v", err)
+	}
+	warning := ""
+	if count > maxRevisionAnnotations {
+		warning = "TooManyAnnotations"
+	}
+	if c.warnings.set(revisionKey(rev), "annotations", warning) {
+		c.Recorder.Eventf(rev, corev1.EventTypeWarning, "TooManyAnnotations",
+			"Revision has %d annotations, more than the recommended maximum of %d", count, maxRevisionAnnotations)
+	}
+}
+
+// reportReconcileDuration records how long a reconcile took and its result.
+func (c *Reconciler) reportReconcileDuration(ctx context.Context, result string, d time.Duration) {
+	if err := c.statsReporter.ReportReconcileDuration(result, d); err != nil {
+		commonlogging.FromContext(ctx).Errorf("Failed to report reconcile duration: %v", err)
+	}
+}
+
+// reportReadyEndpointFraction records the fraction of the pods of the
+// revision that are ready and warns when it drops below the degraded or the
+// critical threshold.
+func (c *Reconciler) reportReadyEndpointFraction(ctx context.Context, rev *v1alpha1.Revision, endpoints *corev1.Endpoints) {
+	logger := commonlogging.FromContext(ctx)
+
+	fraction, ok := getReadyEndpointFraction(endpoints)
+	if !ok {
+		return
+	}
+	if err := c.statsReporter.ReportRevisionReadyEndpointFraction(rev.Namespace, rev.Name, fraction); err != nil {
+		logger.Errorf("Failed to report ready endpoint fraction: %v", err)
+	}
+	warning := ""
+	switch {
+	case fraction < criticalEndpointFraction:
+		warning = "EndpointsCritical"
+	case fraction < degradedEndpointFraction:
+		warning = "EndpointsDegraded"
+	}
+	if c.warnings.set(revisionKey(rev), "endpoints", warning) {
+		c.Recorder.Eventf(rev, corev1.EventTypeWarning, warning,
+			"Only %.0f%% of the revision pods are ready", fraction*100)
+	}
+}
+
 func (c *Reconciler) updateStatus(desired *v1alpha1.Revision) (*v1alpha1.Revision, error) {
 	rev, err := c.revisionLister.Revisions(desired.Namespace).Get(desired.Name)
 	if err != nil {
@@ -25,6 +25,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -49,6 +50,7 @@ import (
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources"
 	resourcenames "github.com/knative/serving/pkg/reconciler/v1alpha1/revision/resources/names"
 	"github.com/knative/serving/pkg/system"
+	"go.opencensus.io/stats/view"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
@@ -57,6 +59,7 @@ import (
 	fakedynamic "k8s.io/client-go/dynamic/fake"
 	kubeinformers "k8s.io/client-go/informers"
 	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 
 	. "github.com/knative/serving/pkg/reconciler/v1alpha1/testing"
 )
@@ -168,6 +171,7 @@ func newTestControllerWithConfig(t *testing.T, controllerConfig *config.Controll
 		kubeInformer.Core().V1().Services(),
 		kubeInformer.Core().V1().Endpoints(),
 		kubeInformer.Core().V1().ConfigMaps(),
+		kubeInformer.Core().V1().Pods(),
 		buildInformerFactory,
 	)
 
@@ -394,6 +398,180 @@ func TestResolutionFailed(t *testing.T) {
 	}
 }
 
+func TestRevisionLabelCount(t *testing.T) {
+	kubeClient, servingClient, cachingClient, _, controller, kubeInformer, servingInformer, cachingInformer, _, _ := newTestController(t, nil)
+	recorder := record.NewFakeRecorder(100)
+	controller.Reconciler.(*Reconciler).Recorder = recorder
+
+	rev := getTestRevision()
+	rev.Name = "test-rev-many-labels"
+	for i := 0; len(rev.Labels) < 60; i++ {
+		rev.Labels[fmt.Sprintf("label-%d", i)] = "value"
+	}
+
+	createRevision(t, kubeClient, kubeInformer, servingClient, servingInformer, cachingClient, cachingInformer, controller, rev)
+
+	rows, err := view.RetrieveData("revision_label_count")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	found := false
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Value != rev.Name {
+				continue
+			}
+			found = true
+			if got, want := row.Data.(*view.LastValueData).Value, float64(60); got != want {
+				t.Errorf("revision_label_count = %v, want %v", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("No revision_label_count reported for %q", rev.Name)
+	}
+
+	gotEvent := false
+	for len(recorder.Events) > 0 {
+		if strings.Contains(<-recorder.Events, "TooManyLabels") {
+			gotEvent = true
+		}
+	}
+	if !gotEvent {
+		t.Error("Expected a TooManyLabels event, got none")
+	}
+
+	// The revision still has too many labels, but it is only warned about
+	// once.
+	if err := controller.Reconciler.Reconcile(context.TODO(), KeyOrDie(rev)); err != nil {
+		t.Errorf("Reconcile() = %v", err)
+	}
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, "TooManyLabels") {
+			t.Errorf("Unexpected event %q", event)
+		}
+	}
+}
+
+func TestRevisionAnnotationCount(t *testing.T) {
+	kubeClient, servingClient, cachingClient, _, controller, kubeInformer, servingInformer, cachingInformer, _, _ := newTestController(t, nil)
+	recorder := record.NewFakeRecorder(100)
+	controller.Reconciler.(*Reconciler).Recorder = recorder
+
+	rev := getTestRevision()
+	rev.Name = "test-rev-many-annotations"
+	if rev.Annotations == nil {
+		rev.Annotations = make(map[string]string)
+	}
+	for i := 0; len(rev.Annotations) < 110; i++ {
+		rev.Annotations[fmt.Sprintf("ci.example.com/annotation-%d", i)] = "value"
+	}
+
+	createRevision(t, kubeClient, kubeInformer, servingClient, servingInformer, cachingClient, cachingInformer, controller, rev)
+
+	rows, err := view.RetrieveData("revision_annotation_count")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	found := false
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Value != rev.Name {
+				continue
+			}
+			found = true
+			if got, want := row.Data.(*view.LastValueData).Value, float64(110); got != want {
+				t.Errorf("revision_annotation_count = %v, want %v", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("No revision_annotation_count reported for %q", rev.Name)
+	}
+
+	gotEvent := false
+	for len(recorder.Events) > 0 {
+		if strings.Contains(<-recorder.Events, "TooManyAnnotations") {
+			gotEvent = true
+		}
+	}
+	if !gotEvent {
+		t.Error("Expected a TooManyAnnotations event, got none")
+	}
+
+	// The revision still has too many annotations, but it is only warned
+	// about once.
+	if err := controller.Reconciler.Reconcile(context.TODO(), KeyOrDie(rev)); err != nil {
+		t.Errorf("Reconcile() = %v", err)
+	}
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, "TooManyAnnotations") {
+			t.Errorf("Unexpected event %q", event)
+		}
+	}
+}
+
+func TestRevisionReadyEndpointFraction(t *testing.T) {
+	kubeClient, servingClient, cachingClient, _, controller, kubeInformer, servingInformer, cachingInformer, _, _ := newTestController(t, nil)
+	recorder := record.NewFakeRecorder(100)
+	controller.Reconciler.(*Reconciler).Recorder = recorder
+
+	rev := getTestRevision()
+	rev.Name = "test-rev-unready-pods"
+	createRevision(t, kubeClient, kubeInformer, servingClient, servingInformer, cachingClient, cachingInformer, controller, rev)
+
+	// Five pods, of which four are not ready.
+	endpoints := getTestReadyEndpoints(rev.Name)
+	for i := 0; i < 4; i++ {
+		endpoints.Subsets[0].NotReadyAddresses = append(endpoints.Subsets[0].NotReadyAddresses,
+			corev1.EndpointAddress{IP: fmt.Sprintf("10.0.0.%d", i)})
+	}
+	kubeInformer.Core().V1().Endpoints().Informer().GetIndexer().Add(endpoints)
+	if err := controller.Reconciler.Reconcile(context.TODO(), KeyOrDie(rev)); err != nil {
+		t.Errorf("Reconcile() = %v", err)
+	}
+
+	rows, err := view.RetrieveData("revision_ready_endpoint_fraction")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	found := false
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Value != rev.Name {
+				continue
+			}
+			found = true
+			if got, want := row.Data.(*view.LastValueData).Value, 0.2; got != want {
+				t.Errorf("revision_ready_endpoint_fraction = %v, want %v", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("No revision_ready_endpoint_fraction reported for %q", rev.Name)
+	}
+
+	gotEvent := false
+	for len(recorder.Events) > 0 {
+		if strings.Contains(<-recorder.Events, "EndpointsDegraded") {
+			gotEvent = true
+		}
+	}
+	if !gotEvent {
+		t.Error("Expected an EndpointsDegraded event, got none")
+	}
+
+	// The revision is still degraded, but it is only warned about once.
+	if err := controller.Reconciler.Reconcile(context.TODO(), KeyOrDie(rev)); err != nil {
+		t.Errorf("Reconcile() = %v", err)
+	}
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, "Endpoints") {
+			t.Errorf("Unexpected event %q", event)
+		}
+	}
+}
+
 // TODO(mattmoor): add coverage of a Reconcile fixing a stale logging URL
 func TestUpdateRevWithWithUpdatedLoggingURL(t *testing.T) {
 	controllerConfig := getTestControllerConfig()
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// startupTracker remembers the pods whose user container startup latency was
+// reported. The latency is measured to the first time a pod becomes ready, so
+// readiness probe flaps must not report it again.
+type startupTracker struct {
+	mu       sync.Mutex
+	reported map[types.UID]bool
+}
+
+func newStartupTracker() *startupTracker {
+	return &startupTracker{
+		reported: make(map[types.UID]bool),
+	}
+}
+
+// firstReady records that the pod became ready and returns whether it was
+// the first time.
+func (s *startupTracker) firstReady(uid types.UID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reported[uid] {
+		return false
+	}
+	s.reported[uid] = true
+	return true
+}
+
+// forget stops tracking the pod.
+func (s *startupTracker) forget(uid types.UID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reported, uid)
+}
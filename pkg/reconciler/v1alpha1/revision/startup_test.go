@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/knative/pkg/logging/testing"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type startupReporter struct {
+	StatsReporter
+	startups int
+}
+
+func (r *startupReporter) ReportUserContainerStartupLatency(ns, revision, commandHash string, d time.Duration) error {
+	r.startups++
+	return nil
+}
+
+func startupPod(uid string, ready bool, startedAt time.Time) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "rev-pod",
+			UID:       types.UID(uid),
+			Labels:    map[string]string{serving.RevisionLabelKey: "rev"},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(startedAt.Add(time.Second)),
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "user-container",
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)},
+				},
+			}},
+		},
+	}
+}
+
+func TestReportUserContainerStartup(t *testing.T) {
+	reporter := &startupReporter{}
+	c := &Reconciler{
+		Base:          &reconciler.Base{Logger: TestLogger(t)},
+		statsReporter: reporter,
+		startups:      newStartupTracker(),
+	}
+	startedAt := time.Now()
+
+	c.reportUserContainerStartup(startupPod("1", false, startedAt), startupPod("1", true, startedAt))
+	if got, want := reporter.startups, 1; got != want {
+		t.Fatalf("Reported %d startups, want %d", got, want)
+	}
+
+	// The readiness probe fails and passes again.
+	c.reportUserContainerStartup(startupPod("1", true, startedAt), startupPod("1", false, startedAt))
+	c.reportUserContainerStartup(startupPod("1", false, startedAt), startupPod("1", true, startedAt))
+	if got, want := reporter.startups, 1; got != want {
+		t.Errorf("Reported %d startups after a readiness flap, want %d", got, want)
+	}
+
+	// Another pod becomes ready.
+	c.reportUserContainerStartup(startupPod("2", false, startedAt), startupPod("2", true, startedAt))
+	if got, want := reporter.startups, 2; got != want {
+		t.Errorf("Reported %d startups after another pod became ready, want %d", got, want)
+	}
+}
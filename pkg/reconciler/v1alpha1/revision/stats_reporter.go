@@ -0,0 +1,480 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"context"
+	"time"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Measurement represents the type of the revision metric to be reported
+type Measurement int
+
+const (
+	// UserContainerStartupLatencyM is the time between the user container
+	// starting and the pod first passing its readiness probe.
+	UserContainerStartupLatencyM Measurement = iota
+	// RevisionLabelCountM is the number of labels set on a revision.
+	RevisionLabelCountM
+	// RevisionAnnotationCountM is the number of annotations set on a revision.
+	RevisionAnnotationCountM
+	// RevisionReadyEndpointFractionM is the fraction of the pods of a
+	// revision that are ready.
+	RevisionReadyEndpointFractionM
+	// RevisionReconcileDurationM is the time it took to reconcile a revision.
+	RevisionReconcileDurationM
+	// RevisionUpdateCountM is the number of updates of revisions.
+	RevisionUpdateCountM
+	// RevisionConditionChangeCountM is the number of transitions of the
+	// status conditions of revisions.
+	RevisionConditionChangeCountM
+	// RevisionNodePressureEvictionCountM is the number of revision pods
+	// evicted by the kubelet of a node under pressure.
+	RevisionNodePressureEvictionCountM
+	// RevisionEndpointUpdateLatencyM is the time between a revision pod
+	// becoming ready and its address being added to the endpoints.
+	RevisionEndpointUpdateLatencyM
+	// RevisionContainerOOMCountM is the number of containers of revision
+	// pods killed for running out of memory.
+	RevisionContainerOOMCountM
+)
+
+// The results a revision reconcile is tagged with.
+const (
+	reconcileResultSuccess  = "success"
+	reconcileResultRetry    = "retry"
+	reconcileResultError    = "error"
+	reconcileResultNotFound = "not-found"
+)
+
+// The triggers a revision update is tagged with.
+const (
+	// updateTriggerUserInitiated is a change of the spec, which the
+	// Configuration only sets when creating the revision.
+	updateTriggerUserInitiated = "user_initiated"
+	// updateTriggerAnnotationUpdate is a change of the annotations.
+	updateTriggerAnnotationUpdate = "annotation_update"
+	// updateTriggerConfigChange is a change of the labels, kept up to date
+	// by the Configuration and Route controllers.
+	updateTriggerConfigChange = "config_change"
+	// updateTriggerScaleEvent is the revision becoming active or inactive.
+	updateTriggerScaleEvent = "scale_event"
+)
+
+var (
+	measurements = []*stats.Float64Measure{
+		UserContainerStartupLatencyM: stats.Float64(
+			"user_container_startup_latency_ms",
+			"Time from the user container starting to the pod first becoming ready in milliseconds",
+			stats.UnitMilliseconds),
+		RevisionLabelCountM: stats.Float64(
+			"revision_label_count",
+			"Number of labels set on the revision",
+			stats.UnitDimensionless),
+		RevisionAnnotationCountM: stats.Float64(
+			"revision_annotation_count",
+			"Number of annotations set on the revision",
+			stats.UnitDimensionless),
+		RevisionReadyEndpointFractionM: stats.Float64(
+			"revision_ready_endpoint_fraction",
+			"Fraction of the pods of the revision that are ready",
+			stats.UnitDimensionless),
+		RevisionReconcileDurationM: stats.Float64(
+			"revision_reconcile_duration_ms",
+			"Time it took to reconcile a revision in milliseconds",
+			stats.UnitMilliseconds),
+		RevisionUpdateCountM: stats.Float64(
+			"revision_update_total",
+			"Number of updates of revisions",
+			stats.UnitDimensionless),
+		RevisionConditionChangeCountM: stats.Float64(
+			"revision_status_condition_change_total",
+			"Number of transitions of the status conditions of revisions",
+			stats.UnitDimensionless),
+		RevisionNodePressureEvictionCountM: stats.Float64(
+			"node_pressure_eviction_total",
+			"Number of revision pods evicted by a node under disk, memory or PID pressure",
+			stats.UnitDimensionless),
+		RevisionEndpointUpdateLatencyM: stats.Float64(
+			"endpoint_update_latency_ms",
+			"Time from a revision pod becoming ready to its address being added to the endpoints in milliseconds",
+			stats.UnitMilliseconds),
+		RevisionContainerOOMCountM: stats.Float64(
+			"container_oom_total",
+			"Number of containers of revision pods killed for running out of memory",
+			stats.UnitDimensionless),
+	}
+
+	// startupLatencyDistribution defines the bucket boundaries for the user
+	// container startup latency histogram. Bucket boundaries are 100ms, 500ms,
+	// 1s, 2s, 5s, 10s, 30s, 60s and 120s.
+	startupLatencyDistribution = view.Distribution(100, 500, 1000, 2000, 5000, 10000, 30000, 60000, 120000)
+
+	// reconcileDurationDistribution defines the bucket boundaries for the
+	// revision reconcile duration histogram. Reconciles range from a cache
+	// hit to many API calls, so the buckets are fine-grained: 1ms, 5ms, 10ms,
+	// 50ms, 100ms, 500ms, 1s, 5s and 30s.
+	reconcileDurationDistribution = view.Distribution(1, 5, 10, 50, 100, 500, 1000, 5000, 30000)
+
+	// endpointUpdateLatencyDistribution defines the bucket boundaries for the
+	// endpoint update latency histogram. The endpoints are usually updated
+	// within a second, so the buckets are 10ms, 50ms, 100ms, 250ms, 500ms,
+	// 1s, 2.5s, 5s, 10s and 30s.
+	endpointUpdateLatencyDistribution = view.Distribution(10, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000)
+
+	namespaceTagKey       tag.Key
+	revisionTagKey        tag.Key
+	startupCommandTagKey  tag.Key
+	reconcileResultTagKey tag.Key
+	updateTriggerTagKey   tag.Key
+	conditionTypeTagKey   tag.Key
+	transitionTagKey      tag.Key
+	pressureTypeTagKey    tag.Key
+	nodeNameTagKey        tag.Key
+	containerNameTagKey   tag.Key
+)
+
+func init() {
+	var err error
+	// Create the tag keys that will be used to add tags to our measurements.
+	// Tag keys must conform to the restrictions described in
+	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
+	// - length between 1 and 255 inclusive
+	// - characters are printable US-ASCII
+	namespaceTagKey, err = tag.NewKey(metricskey.LabelNamespaceName)
+	if err != nil {
+		panic(err)
+	}
+	revisionTagKey, err = tag.NewKey(metricskey.LabelRevisionName)
+	if err != nil {
+		panic(err)
+	}
+	startupCommandTagKey, err = tag.NewKey("startup_command")
+	if err != nil {
+		panic(err)
+	}
+	reconcileResultTagKey, err = tag.NewKey("reconcile_result")
+	if err != nil {
+		panic(err)
+	}
+	updateTriggerTagKey, err = tag.NewKey("trigger")
+	if err != nil {
+		panic(err)
+	}
+	conditionTypeTagKey, err = tag.NewKey("condition_type")
+	if err != nil {
+		panic(err)
+	}
+	transitionTagKey, err = tag.NewKey("transition")
+	if err != nil {
+		panic(err)
+	}
+	pressureTypeTagKey, err = tag.NewKey("pressure_type")
+	if err != nil {
+		panic(err)
+	}
+	nodeNameTagKey, err = tag.NewKey("node_name")
+	if err != nil {
+		panic(err)
+	}
+	containerNameTagKey, err = tag.NewKey("container_name")
+	if err != nil {
+		panic(err)
+	}
+
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	err = metrics.RegisterViews(
+		&view.View{
+			Description: "Time from the user container starting to the pod first becoming ready in milliseconds",
+			Measure:     measurements[UserContainerStartupLatencyM],
+			Aggregation: startupLatencyDistribution,
+			TagKeys:     []tag.Key{namespaceTagKey, revisionTagKey, startupCommandTagKey},
+		},
+		&view.View{
+			Description: "Number of labels set on the revision",
+			Measure:     measurements[RevisionLabelCountM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of annotations set on the revision",
+			Measure:     measurements[RevisionAnnotationCountM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Fraction of the pods of the revision that are ready",
+			Measure:     measurements[RevisionReadyEndpointFractionM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Time it took to reconcile a revision in milliseconds",
+			Measure:     measurements[RevisionReconcileDurationM],
+			Aggregation: reconcileDurationDistribution,
+			TagKeys:     []tag.Key{reconcileResultTagKey},
+		},
+		&view.View{
+			Description: "Number of updates of revisions",
+			Measure:     measurements[RevisionUpdateCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{updateTriggerTagKey},
+		},
+		&view.View{
+			Description: "Number of transitions of the status conditions of revisions",
+			Measure:     measurements[RevisionConditionChangeCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{conditionTypeTagKey, transitionTagKey},
+		},
+		&view.View{
+			Description: "Number of revision pods evicted by a node under disk, memory or PID pressure",
+			Measure:     measurements[RevisionNodePressureEvictionCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceTagKey, pressureTypeTagKey, nodeNameTagKey},
+		},
+		&view.View{
+			Description: "Time from a revision pod becoming ready to its address being added to the endpoints in milliseconds",
+			Measure:     measurements[RevisionEndpointUpdateLatencyM],
+			Aggregation: endpointUpdateLatencyDistribution,
+			TagKeys:     []tag.Key{namespaceTagKey, revisionTagKey},
+		},
+		&view.View{
+			Description: "Number of containers of revision pods killed for running out of memory",
+			Measure:     measurements[RevisionContainerOOMCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceTagKey, revisionTagKey, containerNameTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// StatsReporter defines the interface for sending revision metrics
+type StatsReporter interface {
+	// ReportUserContainerStartupLatency captures the time it took the user
+	// container of a revision pod to pass its first readiness probe.
+	ReportUserContainerStartupLatency(ns, revision, commandHash string, d time.Duration) error
+
+	// ReportRevisionLabelCount captures the number of labels on a revision.
+	ReportRevisionLabelCount(ns, revision string, count int) error
+
+	// ReportRevisionAnnotationCount captures the number of annotations on a
+	// revision.
+	ReportRevisionAnnotationCount(ns, revision string, count int) error
+
+	// ReportRevisionReadyEndpointFraction captures the fraction of the pods
+	// of a revision that are ready.
+	ReportRevisionReadyEndpointFraction(ns, revision string, fraction float64) error
+
+	// ReportReconcileDuration captures the time it took to reconcile a
+	// revision, and its result.
+	ReportReconcileDuration(result string, d time.Duration) error
+
+	// ReportRevisionUpdate counts an update of a revision by its trigger.
+	ReportRevisionUpdate(trigger string) error
+
+	// ReportConditionChange counts a transition of a status condition of a
+	// revision, e.g. "false_to_true".
+	ReportConditionChange(conditionType, transition string) error
+
+	// ReportNodePressureEviction counts a revision pod evicted by the given
+	// node under the given pressure, e.g. "MemoryPressure".
+	ReportNodePressureEviction(ns, pressureType, node string) error
+
+	// ReportEndpointUpdateLatency captures the time it took the address of a
+	// ready revision pod to be added to the endpoints of its revision.
+	ReportEndpointUpdateLatency(ns, revision string, d time.Duration) error
+
+	// ReportContainerOOM counts a container of a revision pod killed for
+	// running out of memory.
+	ReportContainerOOM(ns, revision, container string) error
+
+	// DeleteRevisionGauges stops reporting the gauges of a deleted revision.
+	DeleteRevisionGauges(ns, revision string) error
+}
+
+// Reporter holds cached metric objects to report revision metrics
+type Reporter struct{}
+
+// NewStatsReporter creates a reporter that collects and reports revision metrics
+func NewStatsReporter() *Reporter {
+	return &Reporter{}
+}
+
+// ReportUserContainerStartupLatency captures the user container startup latency.
+func (r *Reporter) ReportUserContainerStartupLatency(ns, revision, commandHash string, d time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(revisionTagKey, revision),
+		tag.Insert(startupCommandTagKey, commandHash))
+	if err != nil {
+		return err
+	}
+
+	// convert time.Duration in nanoseconds to milliseconds
+	stats.Record(ctx, measurements[UserContainerStartupLatencyM].M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportRevisionLabelCount captures the number of labels on a revision.
+func (r *Reporter) ReportRevisionLabelCount(ns, revision string, count int) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(revisionTagKey, revision))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionLabelCountM].M(float64(count)))
+	return nil
+}
+
+// ReportRevisionAnnotationCount captures the number of annotations on a
+// revision.
+func (r *Reporter) ReportRevisionAnnotationCount(ns, revision string, count int) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(revisionTagKey, revision))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionAnnotationCountM].M(float64(count)))
+	return nil
+}
+
+// ReportRevisionReadyEndpointFraction captures the fraction of the pods of a
+// revision that are ready.
+func (r *Reporter) ReportRevisionReadyEndpointFraction(ns, revision string, fraction float64) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(revisionTagKey, revision))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionReadyEndpointFractionM].M(fraction))
+	return nil
+}
+
+// ReportReconcileDuration captures the duration of a revision reconcile.
+func (r *Reporter) ReportReconcileDuration(result string, d time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(reconcileResultTagKey, result))
+	if err != nil {
+		return err
+	}
+
+	// convert time.Duration in nanoseconds to milliseconds
+	stats.Record(ctx, measurements[RevisionReconcileDurationM].M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportRevisionUpdate counts an update of a revision.
+func (r *Reporter) ReportRevisionUpdate(trigger string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(updateTriggerTagKey, trigger))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionUpdateCountM].M(1))
+	return nil
+}
+
+// ReportConditionChange counts a transition of a revision status condition.
+func (r *Reporter) ReportConditionChange(conditionType, transition string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(conditionTypeTagKey, conditionType),
+		tag.Insert(transitionTagKey, transition))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionConditionChangeCountM].M(1))
+	return nil
+}
+
+// ReportNodePressureEviction counts a revision pod evicted by a node under
+// pressure.
+func (r *Reporter) ReportNodePressureEviction(ns, pressureType, node string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(pressureTypeTagKey, pressureType),
+		tag.Insert(nodeNameTagKey, node))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionNodePressureEvictionCountM].M(1))
+	return nil
+}
+
+// ReportEndpointUpdateLatency captures the endpoint update latency.
+func (r *Reporter) ReportEndpointUpdateLatency(ns, revision string, d time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(revisionTagKey, revision))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionEndpointUpdateLatencyM].M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportContainerOOM counts a container of a revision pod killed for running
+// out of memory.
+func (r *Reporter) ReportContainerOOM(ns, revision, container string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(revisionTagKey, revision),
+		tag.Insert(containerNameTagKey, container))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionContainerOOMCountM].M(1))
+	return nil
+}
+
+// DeleteRevisionGauges deletes the label count, annotation count and ready
+// endpoint fraction of a deleted revision.
+func (r *Reporter) DeleteRevisionGauges(ns, revision string) error {
+	return metrics.DeleteLastValueRows(
+		tag.Tag{Key: namespaceTagKey, Value: ns},
+		tag.Tag{Key: revisionTagKey, Value: revision})
+}
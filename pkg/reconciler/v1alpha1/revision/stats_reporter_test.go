@@ -0,0 +1,228 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"go.opencensus.io/stats/view"
+)
+
+func TestReportUserContainerStartupLatency(t *testing.T) {
+	r := NewStatsReporter()
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelRevisionName:  "testrev",
+		"startup_command":             "0123456789ab",
+	}
+	expectSuccess(t, func() error {
+		return r.ReportUserContainerStartupLatency("testns", "testrev", "0123456789ab", 1500*time.Millisecond)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportUserContainerStartupLatency("testns", "testrev", "0123456789ab", 4500*time.Millisecond)
+	})
+	checkDistributionData(t, "user_container_startup_latency_ms", wantTags, 2, 1500, 4500)
+}
+
+func TestReportReconcileDuration(t *testing.T) {
+	r := NewStatsReporter()
+
+	// The reconciles of the other tests are recorded in the same view, so
+	// only the buckets' growth is checked.
+	before := reconcileDurationBuckets(t, "success")
+	expectSuccess(t, func() error { return r.ReportReconcileDuration("success", time.Millisecond) })
+	expectSuccess(t, func() error { return r.ReportReconcileDuration("success", 10*time.Second) })
+	after := reconcileDurationBuckets(t, "success")
+
+	// Buckets are [0, 1), [1, 5), [5, 10), [10, 50), [50, 100), [100, 500),
+	// [500, 1000), [1000, 5000), [5000, 30000) and [30000, +Inf).
+	want := []int64{0, 1, 0, 0, 0, 0, 0, 0, 1, 0}
+	for i := range want {
+		if got := after[i] - before[i]; got != want[i] {
+			t.Errorf("Bucket %d got %d reconciles, want %d", i, got, want[i])
+		}
+	}
+}
+
+func TestReportRevisionUpdate(t *testing.T) {
+	r := NewStatsReporter()
+
+	expectSuccess(t, func() error { return r.ReportRevisionUpdate("scale_event") })
+	expectSuccess(t, func() error { return r.ReportRevisionUpdate("scale_event") })
+	rows, err := view.RetrieveData("revision_update_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	found := false
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == "scale_event" {
+			found = true
+			if got := row.Data.(*view.CountData).Value; got != 2 {
+				t.Errorf("revision_update_total = %d, want 2", got)
+			}
+		}
+	}
+	if !found {
+		t.Error("No revision_update_total reported for scale_event")
+	}
+}
+
+func TestReportConditionChange(t *testing.T) {
+	r := NewStatsReporter()
+
+	// The reconciler tests report the changes of the real conditions.
+	expectSuccess(t, func() error { return r.ReportConditionChange("TestCondition", "false_to_true") })
+	expectSuccess(t, func() error { return r.ReportConditionChange("TestCondition", "false_to_true") })
+	expectSuccess(t, func() error { return r.ReportConditionChange("TestCondition", "true_to_false") })
+	rows, err := view.RetrieveData("revision_status_condition_change_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	got := map[string]int64{}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["condition_type"] == "TestCondition" {
+			got[tags["transition"]] = row.Data.(*view.CountData).Value
+		}
+	}
+	if want := map[string]int64{"false_to_true": 2, "true_to_false": 1}; !reflect.DeepEqual(want, got) {
+		t.Errorf("revision_status_condition_change_total = %v, want %v", got, want)
+	}
+}
+
+func TestReportNodePressureEviction(t *testing.T) {
+	r := NewStatsReporter()
+
+	expectSuccess(t, func() error { return r.ReportNodePressureEviction("testns", "DiskPressure", "node-1") })
+	expectSuccess(t, func() error { return r.ReportNodePressureEviction("testns", "DiskPressure", "node-1") })
+	rows, err := view.RetrieveData("node_pressure_eviction_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags[metricskey.LabelNamespaceName] != "testns" || tags["pressure_type"] != "DiskPressure" || tags["node_name"] != "node-1" {
+			continue
+		}
+		if got := row.Data.(*view.CountData).Value; got != 2 {
+			t.Errorf("node_pressure_eviction_total = %d, want 2", got)
+		}
+		return
+	}
+	t.Errorf("No row for namespace testns, DiskPressure and node-1 in %v", rows)
+}
+
+func TestReportEndpointUpdateLatency(t *testing.T) {
+	r := NewStatsReporter()
+
+	wantTags := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelRevisionName:  "testrev",
+	}
+	expectSuccess(t, func() error { return r.ReportEndpointUpdateLatency("testns", "testrev", 200*time.Millisecond) })
+	expectSuccess(t, func() error { return r.ReportEndpointUpdateLatency("testns", "testrev", 1200*time.Millisecond) })
+	checkDistributionData(t, "endpoint_update_latency_ms", wantTags, 2, 200, 1200)
+}
+
+func TestReportContainerOOM(t *testing.T) {
+	r := NewStatsReporter()
+
+	expectSuccess(t, func() error { return r.ReportContainerOOM("testns", "testrev", "user-container") })
+	expectSuccess(t, func() error { return r.ReportContainerOOM("testns", "testrev", "user-container") })
+	rows, err := view.RetrieveData("container_oom_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags[metricskey.LabelNamespaceName] != "testns" || tags[metricskey.LabelRevisionName] != "testrev" || tags["container_name"] != "user-container" {
+			continue
+		}
+		if got := row.Data.(*view.CountData).Value; got != 2 {
+			t.Errorf("container_oom_total = %d, want 2", got)
+		}
+		return
+	}
+	t.Errorf("No row for namespace testns, revision testrev and user-container in %v", rows)
+}
+
+// reconcileDurationBuckets returns the count of each bucket of the reconcile
+// duration histogram for the given result.
+func reconcileDurationBuckets(t *testing.T, result string) []int64 {
+	rows, err := view.RetrieveData("revision_reconcile_duration_ms")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == result {
+			return append([]int64(nil), row.Data.(*view.DistributionData).CountPerBucket...)
+		}
+	}
+	return make([]int64, 10)
+}
+
+func expectSuccess(t *testing.T, f func() error) {
+	if err := f(); err != nil {
+		t.Errorf("Reporter expected success but got error %v", err)
+	}
+}
+
+func checkDistributionData(t *testing.T, name string, wantTags map[string]string, expectedCount int, expectedMin float64, expectedMax float64) {
+	if d, err := view.RetrieveData(name); err != nil {
+		t.Errorf("Reporter error = %v, wantErr %v", err, false)
+	} else {
+		if len(d) != 1 {
+			t.Errorf("Reporter len(d) %v, want %v", len(d), 1)
+		}
+		for _, got := range d[0].Tags {
+			if want, ok := wantTags[got.Key.Name()]; !ok {
+				t.Errorf("Reporter got an extra tag %v: %v", got.Key.Name(), got.Value)
+			} else {
+				if got.Value != want {
+					t.Errorf("Reporter expected a different tag value. key:%v, got: %v, want: %v", got.Key.Name(), got.Value, want)
+				}
+			}
+		}
+
+		if s, ok := d[0].Data.(*view.DistributionData); !ok {
+			t.Error("Reporter expected a DistributionData type")
+		} else {
+			if s.Count != int64(expectedCount) {
+				t.Errorf("Reporter expected count %v got %v. metric: %v", (int64)(expectedCount), s.Count, name)
+			}
+			if s.Min != float64(expectedMin) {
+				t.Errorf("Reporter expected min %v got %v. metric: %v", expectedMin, s.Min, name)
+			}
+			if s.Max != float64(expectedMax) {
+				t.Errorf("Reporter expected max %v got %v. metric: %v", expectedMax, s.Max, name)
+			}
+		}
+	}
+}
@@ -515,6 +515,9 @@ func TestReconcile(t *testing.T) {
 			resolver:         &nopResolver{},
 			tracker:          t,
 			configStore:      &testConfigStore{config: ReconcilerTestConfig()},
+			statsReporter:    NewStatsReporter(),
+			readyFlaps:       newFlapTracker(),
+			warnings:         newWarningTracker(),
 
 			buildInformerFactory: newDuckInformerFactory(t, buildInformerFactory),
 		}
@@ -652,6 +655,9 @@ func TestReconcileWithVarLogEnabled(t *testing.T) {
 			resolver:         &nopResolver{},
 			tracker:          &rtesting.NullTracker{},
 			configStore:      &testConfigStore{config: config},
+			statsReporter:    NewStatsReporter(),
+			readyFlaps:       newFlapTracker(),
+			warnings:         newWarningTracker(),
 		}
 	}))
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"sync"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// revisionKey returns the work queue key of the revision.
+func revisionKey(rev *v1alpha1.Revision) string {
+	return rev.Namespace + "/" + rev.Name
+}
+
+// warningTracker remembers the warning each revision is in for each of the
+// signals the reconciler warns about, so that a warning is only recorded when
+// a revision enters it rather than on every reconcile.
+type warningTracker struct {
+	mu       sync.Mutex
+	warnings map[string]map[string]string
+}
+
+func newWarningTracker() *warningTracker {
+	return &warningTracker{
+		warnings: make(map[string]map[string]string),
+	}
+}
+
+// set records the warning the keyed revision is in for the signal, or ""
+// when it is in none, and returns whether the revision just entered it.
+func (w *warningTracker) set(key, signal, warning string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.warnings[key] == nil {
+		w.warnings[key] = make(map[string]string)
+	}
+	prev := w.warnings[key][signal]
+	w.warnings[key][signal] = warning
+	return warning != "" && warning != prev
+}
+
+// forget stops tracking the keyed revision.
+func (w *warningTracker) forget(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.warnings, key)
+}
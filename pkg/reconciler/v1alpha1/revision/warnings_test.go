@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+)
+
+func TestWarningTracker(t *testing.T) {
+	w := newWarningTracker()
+
+	tests := []struct {
+		name    string
+		key     string
+		signal  string
+		warning string
+		want    bool
+	}{{
+		name:   "no warning",
+		key:    "ns/rev",
+		signal: "labels",
+	}, {
+		name:    "enters a warning",
+		key:     "ns/rev",
+		signal:  "labels",
+		warning: "TooManyLabels",
+		want:    true,
+	}, {
+		name:    "stays in the warning",
+		key:     "ns/rev",
+		signal:  "labels",
+		warning: "TooManyLabels",
+	}, {
+		name:    "other signal",
+		key:     "ns/rev",
+		signal:  "endpoints",
+		warning: "EndpointsDegraded",
+		want:    true,
+	}, {
+		name:    "worse warning",
+		key:     "ns/rev",
+		signal:  "endpoints",
+		warning: "EndpointsCritical",
+		want:    true,
+	}, {
+		name:    "other revision",
+		key:     "ns/other",
+		signal:  "labels",
+		warning: "TooManyLabels",
+		want:    true,
+	}, {
+		name:   "leaves the warning",
+		key:    "ns/rev",
+		signal: "labels",
+	}, {
+		name:    "enters the warning again",
+		key:     "ns/rev",
+		signal:  "labels",
+		warning: "TooManyLabels",
+		want:    true,
+	}}
+	for _, test := range tests {
+		if got := w.set(test.key, test.signal, test.warning); got != test.want {
+			t.Errorf("%s: set() = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	w.forget("ns/rev")
+	if !w.set("ns/rev", "endpoints", "EndpointsCritical") {
+		t.Error("set() after forget() = false, want true")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"time"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// The types of traffic migration reported for a revision.
+const (
+	migrationIncrease        = "increase"
+	migrationDecrease        = "decrease"
+	migrationCompleteRollout = "complete_rollout"
+	migrationRollback        = "rollback"
+)
+
+// trafficMigrations compares the traffic assigned to each revision before and
+// after a reconcile and returns the migration type of every revision whose
+// percentage changed. created returns the creation time of a revision, if
+// known, and is used to tell rollbacks apart: a revision gaining traffic from
+// a newer revision is being rolled back to.
+func trafficMigrations(before, after []v1alpha1.TrafficTarget, created func(string) (time.Time, bool)) map[string]string {
+	// The first traffic assignment of a route is not a migration.
+	if len(before) == 0 {
+		return nil
+	}
+
+	oldPercents := revisionPercents(before)
+	newPercents := revisionPercents(after)
+
+	var losing []string
+	for name, old := range oldPercents {
+		if newPercents[name] < old {
+			losing = append(losing, name)
+		}
+	}
+
+	migrations := make(map[string]string)
+	for _, name := range losing {
+		migrations[name] = migrationDecrease
+	}
+	for name, percent := range newPercents {
+		if percent <= oldPercents[name] {
+			continue
+		}
+		switch {
+		case isOlderThanAny(name, losing, created):
+			migrations[name] = migrationRollback
+		case percent == 100:
+			migrations[name] = migrationCompleteRollout
+		default:
+			migrations[name] = migrationIncrease
+		}
+	}
+	return migrations
+}
+
+// revisionPercents sums the traffic percentage per revision, as a revision
+// may be the target of several named traffic targets.
+func revisionPercents(targets []v1alpha1.TrafficTarget) map[string]int {
+	percents := make(map[string]int, len(targets))
+	for _, tt := range targets {
+		percents[tt.RevisionName] += tt.Percent
+	}
+	return percents
+}
+
+func isOlderThanAny(name string, others []string, created func(string) (time.Time, bool)) bool {
+	t, ok := created(name)
+	if !ok {
+		return false
+	}
+	for _, other := range others {
+		if ot, ok := created(other); ok && t.Before(ot) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestTrafficMigrations(t *testing.T) {
+	now := time.Now()
+	creationTimes := map[string]time.Time{
+		"rev-1": now.Add(-2 * time.Hour),
+		"rev-2": now.Add(-time.Hour),
+		"rev-3": now,
+	}
+	created := func(name string) (time.Time, bool) {
+		t, ok := creationTimes[name]
+		return t, ok
+	}
+
+	tests := []struct {
+		name   string
+		before []v1alpha1.TrafficTarget
+		after  []v1alpha1.TrafficTarget
+		want   map[string]string
+	}{{
+		name:  "first assignment",
+		after: []v1alpha1.TrafficTarget{{RevisionName: "rev-1", Percent: 100}},
+	}, {
+		name:   "no change",
+		before: []v1alpha1.TrafficTarget{{RevisionName: "rev-1", Percent: 100}},
+		after:  []v1alpha1.TrafficTarget{{RevisionName: "rev-1", Percent: 100}},
+		want:   map[string]string{},
+	}, {
+		name:   "partial rollout",
+		before: []v1alpha1.TrafficTarget{{RevisionName: "rev-1", Percent: 100}},
+		after: []v1alpha1.TrafficTarget{
+			{RevisionName: "rev-1", Percent: 80},
+			{RevisionName: "rev-2", Percent: 20},
+		},
+		want: map[string]string{
+			"rev-1": migrationDecrease,
+			"rev-2": migrationIncrease,
+		},
+	}, {
+		name: "complete rollout",
+		before: []v1alpha1.TrafficTarget{
+			{RevisionName: "rev-1", Percent: 80},
+			{RevisionName: "rev-2", Percent: 20},
+		},
+		after: []v1alpha1.TrafficTarget{{RevisionName: "rev-2", Percent: 100}},
+		want: map[string]string{
+			"rev-1": migrationDecrease,
+			"rev-2": migrationCompleteRollout,
+		},
+	}, {
+		name:   "rollback",
+		before: []v1alpha1.TrafficTarget{{RevisionName: "rev-3", Percent: 100}},
+		after:  []v1alpha1.TrafficTarget{{RevisionName: "rev-2", Percent: 100}},
+		want: map[string]string{
+			"rev-3": migrationDecrease,
+			"rev-2": migrationRollback,
+		},
+	}, {
+		name: "named targets are summed",
+		before: []v1alpha1.TrafficTarget{
+			{Name: "current", RevisionName: "rev-2", Percent: 50},
+			{Name: "candidate", RevisionName: "rev-2", Percent: 50},
+		},
+		after: []v1alpha1.TrafficTarget{
+			{Name: "current", RevisionName: "rev-2", Percent: 90},
+			{Name: "candidate", RevisionName: "rev-3", Percent: 10},
+		},
+		want: map[string]string{
+			"rev-2": migrationDecrease,
+			"rev-3": migrationIncrease,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := trafficMigrations(test.before, test.after, created)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("trafficMigrations() (-want +got): %v", diff)
+			}
+		})
+	}
+}
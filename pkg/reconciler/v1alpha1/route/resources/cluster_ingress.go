@@ -67,7 +67,11 @@ func makeClusterIngressSpec(r *servingv1alpha1.Route, targets map[string][]traff
 	// The routes are matching rule based on domain name to traffic split targets.
 	rules := []v1alpha1.ClusterIngressRule{}
 	for _, name := range names {
-		rules = append(rules, *makeClusterIngressRule(getRouteDomains(name, r, domain), r.Namespace, targets[name]))
+		rule := makeClusterIngressRule(getRouteDomains(name, r, domain), r.Namespace, targets[name])
+		if name != "" {
+			addTagHeaders(rule, r.Name, name)
+		}
+		rules = append(rules, *rule)
 	}
 	return v1alpha1.IngressSpec{
 		Rules: rules,
@@ -164,6 +168,17 @@ func addInactive(r *v1alpha1.HTTPClusterIngressPath, ns string, inactive []traff
 	return r
 }
 
+// addTagHeaders tells the activator which route and traffic tag the
+// requests it receives through the rule were routed through.
+func addTagHeaders(rule *v1alpha1.ClusterIngressRule, route, tag string) {
+	for i := range rule.HTTP.Paths {
+		if path := &rule.HTTP.Paths[i]; path.AppendHeaders != nil {
+			path.AppendHeaders[activator.RouteHeaderName] = route
+			path.AppendHeaders[activator.RouteTagHeaderName] = tag
+		}
+	}
+}
+
 func dedup(strs []string) []string {
 	existed := make(map[string]struct{})
 	unique := []string{}
@@ -141,6 +141,56 @@ func TestMakeClusterIngressSpec_CorrectRules(t *testing.T) {
 	}
 }
 
+func TestMakeClusterIngressSpec_InactiveNamedTarget(t *testing.T) {
+	targets := map[string][]traffic.RevisionTarget{
+		"stable": {{
+			TrafficTarget: v1alpha1.TrafficTarget{
+				ConfigurationName: "config",
+				RevisionName:      "v1",
+				Percent:           100,
+			},
+			Active: false,
+		}},
+	}
+	r := &v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "test-ns",
+		},
+		Status: v1alpha1.RouteStatus{Domain: "domain.com"},
+	}
+	expected := []netv1alpha1.ClusterIngressRule{{
+		Hosts: []string{"stable.domain.com"},
+		HTTP: &netv1alpha1.HTTPClusterIngressRuleValue{
+			Paths: []netv1alpha1.HTTPClusterIngressPath{{
+				Splits: []netv1alpha1.ClusterIngressBackendSplit{{
+					ClusterIngressBackend: netv1alpha1.ClusterIngressBackend{
+						ServiceNamespace: "knative-serving",
+						ServiceName:      "activator-service",
+						ServicePort:      intstr.FromInt(80),
+					},
+					Percent: 100,
+				}},
+				AppendHeaders: map[string]string{
+					"knative-serving-revision":  "v1",
+					"knative-serving-namespace": "test-ns",
+					"knative-serving-route":     "test-route",
+					"knative-serving-route-tag": "stable",
+				},
+				Timeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+				Retries: &netv1alpha1.HTTPRetry{
+					PerTryTimeout: &metav1.Duration{Duration: netv1alpha1.DefaultTimeout},
+					Attempts:      netv1alpha1.DefaultRetryCount,
+				},
+			}},
+		},
+	}}
+	rules := makeClusterIngressSpec(r, targets).Rules
+	if diff := cmp.Diff(expected, rules); diff != "" {
+		t.Errorf("Unexpected rules (-want +got): %v", diff)
+	}
+}
+
 func TestGetRouteDomains_NamelessTarget(t *testing.T) {
 	r := &v1alpha1.Route{
 		ObjectMeta: metav1.ObjectMeta{
@@ -19,6 +19,7 @@ package route
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
@@ -71,6 +72,8 @@ type Reconciler struct {
 	tracker              tracker.Interface
 
 	clock system.Clock
+
+	statsReporter StatsReporter
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -113,8 +116,12 @@ func NewControllerWithClock(
 		serviceLister:        serviceInformer.Lister(),
 		clusterIngressLister: clusterIngressInformer.Lister(),
 		clock:                clock,
+		statsReporter:        NewStatsReporter(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "Routes", reconciler.MustNewStatsReporter("Routes", c.Logger))
+	reconciler.CountRequeues(impl, v1alpha1.SchemeGroupVersion.WithKind("Route"), c.Logger)
+	resyncer := reconciler.NewGlobalResyncer(impl, "Route", c.Logger)
+	resyncer.ResyncPeriodically(routeInformer.Informer(), opt.ResyncPeriod, opt.StopChannel)
 
 	c.Logger.Info("Setting up event handlers")
 	routeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -157,13 +164,53 @@ func NewControllerWithClock(
 
 	c.Logger.Info("Setting up ConfigMap receivers")
 	resyncRoutesOnConfigDomainChange := configmap.TypeFilter(&config.Domain{})(func(string, interface{}) {
-		impl.GlobalResync(routeInformer.Informer())
+		resyncer.Resync(routeInformer.Informer(), c.reportGlobalResync)
 	})
 	c.configStore = config.NewStore(c.Logger.Named("config-store"), resyncRoutesOnConfigDomainChange)
 	c.configStore.WatchConfigs(opt.ConfigMapWatcher)
 	return impl
 }
 
+// reportGlobalResync emits a Warning event when a global resync of the
+// Routes, triggered by a change of the domain config, took long enough to
+// delay real events.
+func (c *Reconciler) reportGlobalResync(duration time.Duration) {
+	if duration > reconciler.SlowResyncDuration {
+		c.Recorder.Eventf(&corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Namespace:  system.Namespace,
+			Name:       config.DomainConfigName,
+		}, corev1.EventTypeWarning, "SlowGlobalResync", "Global resync of Routes took %v", duration)
+	}
+}
+
+// reportTrafficMigrations records a migration for every revision whose share
+// of the Route's traffic differs between its status and newTraffic.
+func (c *Reconciler) reportTrafficMigrations(ctx context.Context, r *v1alpha1.Route, newTraffic []v1alpha1.TrafficTarget) {
+	logger := logging.FromContext(ctx)
+
+	created := func(name string) (time.Time, bool) {
+		rev, err := c.revisionLister.Revisions(r.Namespace).Get(name)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return rev.CreationTimestamp.Time, true
+	}
+	migrations := trafficMigrations(r.Status.Traffic, newTraffic, created)
+	if len(migrations) == 0 {
+		return
+	}
+	for revision, migrationType := range migrations {
+		if err := c.statsReporter.ReportTrafficMigration(r.Namespace, r.Name, revision, migrationType); err != nil {
+			logger.Errorf("Failed to report traffic migration: %v", err)
+		}
+	}
+	if err := c.statsReporter.ReportTrafficMigrationInProgress(r.Namespace, r.Name, true); err != nil {
+		logger.Errorf("Failed to report traffic migration: %v", err)
+	}
+}
+
 /////////////////////////////////////////
 //  Event handlers
 /////////////////////////////////////////
@@ -243,6 +290,11 @@ func (c *Reconciler) reconcile(ctx context.Context, r *v1alpha1.Route) error {
 		return err
 	}
 	r.Status.PropagateClusterIngressStatus(clusterIngress.Status)
+	if r.Status.IsReady() {
+		if err := c.statsReporter.ReportTrafficMigrationInProgress(r.Namespace, r.Name, false); err != nil {
+			logger.Errorf("Failed to report traffic migration: %v", err)
+		}
+	}
 
 	logger.Info("Creating/Updating placeholder k8s services")
 	if err := c.reconcilePlaceholderService(ctx, r, clusterIngress); err != nil {
@@ -298,7 +350,9 @@ func (c *Reconciler) configureTraffic(ctx context.Context, r *v1alpha1.Route) (*
 	}
 
 	logger.Info("All referred targets are routable, marking AllTrafficAssigned with traffic information.")
-	r.Status.Traffic = t.GetRevisionTrafficTargets()
+	newTraffic := t.GetRevisionTrafficTargets()
+	c.reportTrafficMigrations(ctx, r, newTraffic)
+	r.Status.Traffic = newTraffic
 	r.Status.MarkTrafficAssigned()
 
 	return t, nil
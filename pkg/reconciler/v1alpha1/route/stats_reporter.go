@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Measurement represents the type of the route metric to be reported
+type Measurement int
+
+const (
+	// RevisionTrafficMigrationCountM is the number of times the traffic
+	// percentage of a revision has been modified.
+	RevisionTrafficMigrationCountM Measurement = iota
+	// TrafficMigrationInProgressM is 1 while a route is rolling out a traffic
+	// change, 0 otherwise.
+	TrafficMigrationInProgressM
+)
+
+var (
+	measurements = []*stats.Int64Measure{
+		RevisionTrafficMigrationCountM: stats.Int64(
+			"revision_traffic_migration_total",
+			"Number of times the traffic percentage of a revision has been modified",
+			stats.UnitDimensionless),
+		TrafficMigrationInProgressM: stats.Int64(
+			"traffic_migration_in_progress",
+			"1 while a route is rolling out a traffic change, 0 otherwise",
+			stats.UnitDimensionless),
+	}
+
+	namespaceTagKey     tag.Key
+	routeTagKey         tag.Key
+	revisionTagKey      tag.Key
+	migrationTypeTagKey tag.Key
+)
+
+func init() {
+	var err error
+	// Create the tag keys that will be used to add tags to our measurements.
+	// Tag keys must conform to the restrictions described in
+	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
+	// - length between 1 and 255 inclusive
+	// - characters are printable US-ASCII
+	namespaceTagKey, err = tag.NewKey(metricskey.LabelNamespaceName)
+	if err != nil {
+		panic(err)
+	}
+	routeTagKey, err = tag.NewKey("route_name")
+	if err != nil {
+		panic(err)
+	}
+	revisionTagKey, err = tag.NewKey(metricskey.LabelRevisionName)
+	if err != nil {
+		panic(err)
+	}
+	migrationTypeTagKey, err = tag.NewKey("migration_type")
+	if err != nil {
+		panic(err)
+	}
+
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	err = metrics.RegisterViews(
+		&view.View{
+			Description: "Number of times the traffic percentage of a revision has been modified",
+			Measure:     measurements[RevisionTrafficMigrationCountM],
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceTagKey, routeTagKey, revisionTagKey, migrationTypeTagKey},
+		},
+		&view.View{
+			Description: "1 while a route is rolling out a traffic change, 0 otherwise",
+			Measure:     measurements[TrafficMigrationInProgressM],
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceTagKey, routeTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// StatsReporter defines the interface for sending route metrics
+type StatsReporter interface {
+	// ReportTrafficMigration captures a change to the traffic percentage of
+	// a revision.
+	ReportTrafficMigration(ns, route, revision, migrationType string) error
+
+	// ReportTrafficMigrationInProgress captures whether a route is rolling
+	// out a traffic change.
+	ReportTrafficMigrationInProgress(ns, route string, inProgress bool) error
+}
+
+// Reporter holds cached metric objects to report route metrics
+type Reporter struct{}
+
+// NewStatsReporter creates a reporter that collects and reports route metrics
+func NewStatsReporter() *Reporter {
+	return &Reporter{}
+}
+
+// ReportTrafficMigration captures a traffic migration of a revision.
+func (r *Reporter) ReportTrafficMigration(ns, route, revision, migrationType string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(routeTagKey, route),
+		tag.Insert(revisionTagKey, revision),
+		tag.Insert(migrationTypeTagKey, migrationType))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, measurements[RevisionTrafficMigrationCountM].M(1))
+	return nil
+}
+
+// ReportTrafficMigrationInProgress captures whether a route is migrating traffic.
+func (r *Reporter) ReportTrafficMigrationInProgress(ns, route string, inProgress bool) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(routeTagKey, route))
+	if err != nil {
+		return err
+	}
+
+	v := int64(0)
+	if inProgress {
+		v = 1
+	}
+	stats.Record(ctx, measurements[TrafficMigrationInProgressM].M(v))
+	return nil
+}
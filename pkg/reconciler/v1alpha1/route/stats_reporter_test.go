@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"testing"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"go.opencensus.io/stats/view"
+)
+
+func TestReportTrafficMigration(t *testing.T) {
+	r := NewStatsReporter()
+
+	expectSuccess(t, func() error {
+		return r.ReportTrafficMigration("testns", "testroute", "testrev", migrationRollback)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportTrafficMigration("testns", "testroute", "testrev", migrationRollback)
+	})
+	checkData(t, "revision_traffic_migration_total", map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		"route_name":                  "testroute",
+		metricskey.LabelRevisionName:  "testrev",
+		"migration_type":              migrationRollback,
+	}, func(d view.AggregationData) bool {
+		return d.(*view.CountData).Value == 2
+	})
+
+	expectSuccess(t, func() error {
+		return r.ReportTrafficMigrationInProgress("testns", "testroute", true)
+	})
+	checkData(t, "traffic_migration_in_progress", map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		"route_name":                  "testroute",
+	}, func(d view.AggregationData) bool {
+		return d.(*view.LastValueData).Value == 1
+	})
+}
+
+func expectSuccess(t *testing.T, f func() error) {
+	t.Helper()
+	if err := f(); err != nil {
+		t.Errorf("Reporter expected success but got error %v", err)
+	}
+}
+
+// checkData verifies that the row of the named view with exactly wantTags
+// holds data satisfying wantData.
+func checkData(t *testing.T, name string, wantTags map[string]string, wantData func(view.AggregationData) bool) {
+	t.Helper()
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		t.Fatalf("RetrieveData(%q) = %v", name, err)
+	}
+	for _, row := range rows {
+		if len(row.Tags) != len(wantTags) {
+			continue
+		}
+		match := true
+		for _, tag := range row.Tags {
+			if wantTags[tag.Key.Name()] != tag.Value {
+				match = false
+			}
+		}
+		if !match {
+			continue
+		}
+		if !wantData(row.Data) {
+			t.Errorf("Unexpected data for %s%v: %v", name, wantTags, row.Data)
+		}
+		return
+	}
+	t.Errorf("No %s row with tags %v", name, wantTags)
+}
@@ -1663,7 +1663,8 @@ func TestReconcile(t *testing.T) {
 			configStore: &testConfigStore{
 				config: ReconcilerTestConfig(),
 			},
-			clock: FakeClock{Time: fakeCurTime},
+			clock:         FakeClock{Time: fakeCurTime},
+			statsReporter: NewStatsReporter(),
 		}
 	}))
 }
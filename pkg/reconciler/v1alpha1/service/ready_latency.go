@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// readinessTracker remembers which Services have already been ready, so that
+// only their first transition to ready is measured.
+type readinessTracker struct {
+	mu       sync.Mutex
+	services map[string]*trackedService
+}
+
+type trackedService struct {
+	uid   types.UID
+	ready bool
+}
+
+func newReadinessTracker() *readinessTracker {
+	return &readinessTracker{
+		services: make(map[string]*trackedService),
+	}
+}
+
+// observe records the named Service. A Service seen for the first time
+// while already ready became ready before we started watching it, so it is
+// not measured. A Service recreated under the same name is tracked anew.
+func (r *readinessTracker) observe(key string, uid types.UID, ready bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ts, ok := r.services[key]; ok && ts.uid == uid {
+		return
+	}
+	r.services[key] = &trackedService{uid: uid, ready: ready}
+}
+
+// becameReady marks the named Service as ready. It returns true the first
+// time the Service becomes ready only.
+func (r *readinessTracker) becameReady(key string, uid types.UID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts, ok := r.services[key]
+	if !ok || ts.uid != uid || ts.ready {
+		return false
+	}
+	ts.ready = true
+	return true
+}
+
+// forget stops tracking the named Service.
+func (r *readinessTracker) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.services, key)
+}
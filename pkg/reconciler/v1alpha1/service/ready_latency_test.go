@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+)
+
+func TestReadinessTracker(t *testing.T) {
+	r := newReadinessTracker()
+	r.observe("ns/svc", "uid-1", false)
+	// Observing the same Service again keeps the first observation.
+	r.observe("ns/svc", "uid-1", true)
+	if !r.becameReady("ns/svc", "uid-1") {
+		t.Error("becameReady() = false, want true")
+	}
+	if r.becameReady("ns/svc", "uid-1") {
+		t.Error("becameReady() of a Service already ready = true, want false")
+	}
+
+	// A Service recreated under the same name is tracked anew.
+	r.observe("ns/svc", "uid-2", false)
+	if r.becameReady("ns/svc", "uid-1") {
+		t.Error("becameReady() of a deleted Service = true, want false")
+	}
+	if !r.becameReady("ns/svc", "uid-2") {
+		t.Error("becameReady() of a recreated Service = false, want true")
+	}
+
+	// A Service that is ready when first seen became ready earlier.
+	r.observe("ns/ready", "uid-3", true)
+	if r.becameReady("ns/ready", "uid-3") {
+		t.Error("becameReady() of a Service first seen ready = true, want false")
+	}
+
+	r.observe("ns/forgotten", "uid-4", false)
+	r.forget("ns/forgotten")
+	if r.becameReady("ns/forgotten", "uid-4") {
+		t.Error("becameReady() of a forgotten Service = true, want false")
+	}
+}
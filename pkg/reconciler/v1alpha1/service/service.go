@@ -19,6 +19,7 @@ package service
 import (
 	"context"
 	"reflect"
+	"sort"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/knative/pkg/controller"
@@ -29,6 +30,7 @@ import (
 	"github.com/knative/serving/pkg/reconciler"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/service/resources"
 	resourcenames "github.com/knative/serving/pkg/reconciler/v1alpha1/service/resources/names"
+	"github.com/knative/serving/pkg/system"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -47,6 +49,10 @@ type Reconciler struct {
 	serviceLister       listers.ServiceLister
 	configurationLister listers.ConfigurationLister
 	routeLister         listers.RouteLister
+
+	clock         system.Clock
+	readiness     *readinessTracker
+	statsReporter StatsReporter
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -66,8 +72,14 @@ func NewController(
 		serviceLister:       serviceInformer.Lister(),
 		configurationLister: configurationInformer.Lister(),
 		routeLister:         routeInformer.Lister(),
+		clock:               system.RealClock{},
+		readiness:           newReadinessTracker(),
+		statsReporter:       NewStatsReporter(),
 	}
 	impl := controller.NewImpl(c, c.Logger, "Services", reconciler.MustNewStatsReporter("Services", c.Logger))
+	reconciler.CountRequeues(impl, v1alpha1.SchemeGroupVersion.WithKind("Service"), c.Logger)
+	reconciler.NewGlobalResyncer(impl, "Service", c.Logger).
+		ResyncPeriodically(serviceInformer.Informer(), opt.ResyncPeriod, opt.StopChannel)
 
 	c.Logger.Info("Setting up event handlers")
 	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -114,6 +126,7 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 	if apierrs.IsNotFound(err) {
 		// The resource may no longer exist, in which case we stop processing.
 		logger.Errorf("service %q in work queue no longer exists", key)
+		c.readiness.forget(key)
 		return nil
 	} else if err != nil {
 		return err
@@ -121,6 +134,7 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 
 	// Don't modify the informers copy
 	service := original.DeepCopy()
+	c.readiness.observe(key, service.UID, service.Status.IsReady())
 
 	if service.Spec.Manual != nil {
 		// We do not know the status when in manual mode. The Route can be
@@ -143,9 +157,24 @@ func (c *Reconciler) Reconcile(ctx context.Context, key string) error {
 			"Failed to update status for Service %q: %v", service.Name, err)
 		return err
 	}
+	if service.Status.IsReady() {
+		c.reportCreationToReadyLatency(ctx, key, service)
+	}
 	return err
 }
 
+// reportCreationToReadyLatency reports how long the Service took to become
+// ready since its creation, the first time it is seen ready.
+func (c *Reconciler) reportCreationToReadyLatency(ctx context.Context, key string, service *v1alpha1.Service) {
+	if !c.readiness.becameReady(key, service.UID) {
+		return
+	}
+	latency := c.clock.Now().Sub(service.CreationTimestamp.Time)
+	if err := c.statsReporter.ReportCreationToReadyLatency(service.Namespace, latency); err != nil {
+		logging.FromContext(ctx).Errorf("Failed to report creation to ready latency: %v", err)
+	}
+}
+
 func (c *Reconciler) reconcile(ctx context.Context, service *v1alpha1.Service) error {
 	logger := logging.FromContext(ctx)
 	service.Status.InitializeConditions()
@@ -231,6 +260,8 @@ func (c *Reconciler) reconcileConfiguration(ctx context.Context, service *v1alph
 		return nil, err
 	}
 
+	c.checkLabels(ctx, service, "Configuration", desiredConfig.Labels, config.Labels)
+
 	// TODO(#642): Remove this (needed to avoid continuous updates)
 	desiredConfig.Spec.Generation = config.Spec.Generation
 
@@ -266,6 +297,8 @@ func (c *Reconciler) reconcileRoute(ctx context.Context, service *v1alpha1.Servi
 		return nil, err
 	}
 
+	c.checkLabels(ctx, service, "Route", desiredRoute.Labels, route.Labels)
+
 	// TODO(#642): Remove this (needed to avoid continuous updates)
 	desiredRoute.Spec.Generation = route.Spec.Generation
 
@@ -281,3 +314,36 @@ func (c *Reconciler) reconcileRoute(ctx context.Context, service *v1alpha1.Servi
 	existing.Spec = desiredRoute.Spec
 	return c.ServingClientSet.ServingV1alpha1().Routes(service.Namespace).Update(existing)
 }
+
+// checkLabels reports the child resource of the given type if its labels,
+// which select it as the Service's, are out of sync with those expected.
+func (c *Reconciler) checkLabels(ctx context.Context, service *v1alpha1.Service, resourceType string, expected, actual map[string]string) {
+	stale := staleLabels(expected, actual)
+	if len(stale) == 0 {
+		return
+	}
+	logger := logging.FromContext(ctx)
+	logger.Infof("Labels of the %s of Service %q are stale: %v", resourceType, service.Name, stale)
+	if err := c.statsReporter.ReportStaleLabelSelector(service.Namespace, resourceType); err != nil {
+		logger.Errorf("Failed to report stale label selector: %v", err)
+	}
+}
+
+// staleLabels returns, sorted, the keys of the symmetric difference between
+// the expected and actual labels: those only in one of them, or with
+// different values.
+func staleLabels(expected, actual map[string]string) []string {
+	var stale []string
+	for k, v := range expected {
+		if av, ok := actual[k]; !ok || av != v {
+			stale = append(stale, k)
+		}
+	}
+	for k := range actual {
+		if _, ok := expected[k]; !ok {
+			stale = append(stale, k)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
@@ -17,14 +17,18 @@ limitations under the License.
 package service
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
 	clientgotesting "k8s.io/client-go/testing"
 
+	"github.com/google/go-cmp/cmp"
 	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
 	fakesharedclientset "github.com/knative/pkg/client/clientset/versioned/fake"
 	"github.com/knative/pkg/controller"
@@ -33,6 +37,7 @@ import (
 	informers "github.com/knative/serving/pkg/client/informers/externalversions"
 	"github.com/knative/serving/pkg/reconciler"
 	"github.com/knative/serving/pkg/reconciler/v1alpha1/service/resources"
+	"github.com/knative/serving/pkg/system"
 
 	. "github.com/knative/serving/pkg/reconciler/v1alpha1/testing"
 )
@@ -306,10 +311,124 @@ func TestReconcile(t *testing.T) {
 			serviceLister:       listers.GetServiceLister(),
 			configurationLister: listers.GetConfigurationLister(),
 			routeLister:         listers.GetRouteLister(),
+			clock:               system.RealClock{},
+			readiness:           newReadinessTracker(),
+			statsReporter:       NewStatsReporter(),
 		}
 	}))
 }
 
+type fakeStatsReporter struct {
+	latencies map[string][]time.Duration
+	stale     []string
+}
+
+func (r *fakeStatsReporter) ReportCreationToReadyLatency(ns string, d time.Duration) error {
+	r.latencies[ns] = append(r.latencies[ns], d)
+	return nil
+}
+
+func (r *fakeStatsReporter) ReportStaleLabelSelector(ns, resourceType string) error {
+	r.stale = append(r.stale, ns+"/"+resourceType)
+	return nil
+}
+
+func TestReportCreationToReadyLatency(t *testing.T) {
+	reporter := &fakeStatsReporter{latencies: make(map[string][]time.Duration)}
+	c := &Reconciler{
+		clock:         system.RealClock{},
+		readiness:     newReadinessTracker(),
+		statsReporter: reporter,
+	}
+
+	created := time.Now().Add(-5 * time.Second)
+	s := svc("ready", "foo")
+	s.UID = "ready-uid"
+	s.CreationTimestamp = metav1.NewTime(created)
+	c.readiness.observe("foo/ready", s.UID, false)
+
+	// Simulate the Service becoming ready now.
+	ready := time.Now()
+	c.reportCreationToReadyLatency(context.Background(), "foo/ready", s)
+	// Only the first time the Service is seen ready is reported.
+	c.reportCreationToReadyLatency(context.Background(), "foo/ready", s)
+
+	got := reporter.latencies["foo"]
+	if len(got) != 1 {
+		t.Fatalf("Reported latencies = %v, want one", got)
+	}
+	if want := ready.Sub(created); got[0] < want || got[0]-want > 100*time.Millisecond {
+		t.Errorf("Reported latency = %v, want within 100ms of %v", got[0], want)
+	}
+}
+
+func TestStaleLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected map[string]string
+		actual   map[string]string
+		want     []string
+	}{{
+		name:     "in sync",
+		expected: map[string]string{"serving.knative.dev/service": "svc", "team": "a"},
+		actual:   map[string]string{"serving.knative.dev/service": "svc", "team": "a"},
+	}, {
+		name:     "missing",
+		expected: map[string]string{"serving.knative.dev/service": "svc", "team": "a"},
+		actual:   map[string]string{"serving.knative.dev/service": "svc"},
+		want:     []string{"team"},
+	}, {
+		name:     "extra and changed",
+		expected: map[string]string{"serving.knative.dev/service": "svc", "team": "a"},
+		actual:   map[string]string{"serving.knative.dev/service": "other", "team": "a", "tier": "gold"},
+		want:     []string{"serving.knative.dev/service", "tier"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, staleLabels(test.expected, test.actual)); diff != "" {
+				t.Errorf("staleLabels (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+func TestCheckLabels(t *testing.T) {
+	reporter := &fakeStatsReporter{}
+	c := &Reconciler{statsReporter: reporter}
+	s := svc("stale", "foo")
+
+	c.checkLabels(context.Background(), s, "Configuration", map[string]string{"team": "a"}, map[string]string{"team": "a"})
+	c.checkLabels(context.Background(), s, "Route", map[string]string{"team": "a"}, map[string]string{"team": "b"})
+
+	if want := []string{"foo/Route"}; !cmp.Equal(reporter.stale, want) {
+		t.Errorf("Reported stale label selectors = %v, want %v", reporter.stale, want)
+	}
+}
+
+func BenchmarkReportCreationToReadyLatency(b *testing.B) {
+	reporter := &fakeStatsReporter{latencies: make(map[string][]time.Duration)}
+	c := &Reconciler{
+		clock:         system.RealClock{},
+		readiness:     newReadinessTracker(),
+		statsReporter: reporter,
+	}
+	s := svc("ready", "foo")
+	s.CreationTimestamp = metav1.NewTime(time.Now())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.UID = types.UID(fmt.Sprintf("uid-%d", i))
+		c.readiness.observe("foo/ready", s.UID, false)
+		ready := time.Now()
+		c.reportCreationToReadyLatency(context.Background(), "foo/ready", s)
+		latencies := reporter.latencies["foo"]
+		if got, want := latencies[len(latencies)-1], ready.Sub(s.CreationTimestamp.Time); got-want > 100*time.Millisecond {
+			b.Fatalf("Reported latency = %v, want within 100ms of %v", got, want)
+		}
+	}
+}
+
 func TestNew(t *testing.T) {
 	kubeClient := fakekubeclientset.NewSimpleClientset()
 	sharedClient := fakesharedclientset.NewSimpleClientset()
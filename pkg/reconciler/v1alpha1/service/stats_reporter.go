@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	creationToReadyLatencyM = stats.Float64(
+		"service_creation_to_ready_latency_ms",
+		"Time from the creation of a Service until it first becomes ready in milliseconds",
+		stats.UnitMilliseconds)
+	staleLabelSelectorM = stats.Int64(
+		"stale_label_selector_total",
+		"Number of reconciliations finding the labels of a Configuration or Route out of sync with its Service",
+		stats.UnitDimensionless)
+
+	namespaceTagKey    tag.Key
+	resourceTypeTagKey tag.Key
+)
+
+func init() {
+	var err error
+	// Create the tag keys that will be used to add tags to our measurements.
+	// Tag keys must conform to the restrictions described in
+	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
+	// - length between 1 and 255 inclusive
+	// - characters are printable US-ASCII
+	namespaceTagKey, err = tag.NewKey(metricskey.LabelNamespaceName)
+	if err != nil {
+		panic(err)
+	}
+	resourceTypeTagKey, err = tag.NewKey("resource_type")
+	if err != nil {
+		panic(err)
+	}
+
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	err = metrics.RegisterViews(
+		&view.View{
+			Description: "Time from the creation of a Service until it first becomes ready in milliseconds",
+			Measure:     creationToReadyLatencyM,
+			Aggregation: view.Distribution(1000, 2000, 5000, 10000, 20000, 30000, 60000, 120000, 300000, 600000),
+			TagKeys:     []tag.Key{namespaceTagKey},
+		},
+		&view.View{
+			Description: "Number of reconciliations finding the labels of a Configuration or Route out of sync with its Service",
+			Measure:     staleLabelSelectorM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceTagKey, resourceTypeTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// StatsReporter defines the interface for sending Service metrics
+type StatsReporter interface {
+	// ReportCreationToReadyLatency captures the time it took a Service to
+	// become ready for the first time since it was created.
+	ReportCreationToReadyLatency(ns string, d time.Duration) error
+
+	// ReportStaleLabelSelector captures that the labels of a child resource
+	// of the given type, Configuration or Route, no longer match its Service.
+	ReportStaleLabelSelector(ns, resourceType string) error
+}
+
+// Reporter holds cached metric objects to report Service metrics
+type Reporter struct{}
+
+// NewStatsReporter creates a reporter that collects and reports Service metrics
+func NewStatsReporter() *Reporter {
+	return &Reporter{}
+}
+
+// ReportCreationToReadyLatency captures the creation to ready latency of a
+// Service.
+func (r *Reporter) ReportCreationToReadyLatency(ns string, d time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns))
+	if err != nil {
+		return err
+	}
+
+	// convert time.Duration in nanoseconds to milliseconds
+	stats.Record(ctx, creationToReadyLatencyM.M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportStaleLabelSelector counts a child resource whose labels are out of
+// sync with its Service.
+func (r *Reporter) ReportStaleLabelSelector(ns, resourceType string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceTagKey, ns),
+		tag.Insert(resourceTypeTagKey, resourceType))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, staleLabelSelectorM.M(1))
+	return nil
+}
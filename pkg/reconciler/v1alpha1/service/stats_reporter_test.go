@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/knative/pkg/metrics/metricskey"
+	"go.opencensus.io/stats/view"
+)
+
+func TestReportCreationToReadyLatencyView(t *testing.T) {
+	r := NewStatsReporter()
+
+	for _, d := range []time.Duration{3 * time.Second, 7 * time.Second} {
+		if err := r.ReportCreationToReadyLatency("testns", d); err != nil {
+			t.Errorf("ReportCreationToReadyLatency() = %v", err)
+		}
+	}
+
+	rows, err := view.RetrieveData("service_creation_to_ready_latency_ms")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		if len(row.Tags) != 1 || row.Tags[0].Key.Name() != metricskey.LabelNamespaceName || row.Tags[0].Value != "testns" {
+			continue
+		}
+		d := row.Data.(*view.DistributionData)
+		if d.Count != 2 || d.Min != 3000 || d.Max != 7000 {
+			t.Errorf("Distribution count, min, max = %d, %v, %v, want 2, 3000, 7000", d.Count, d.Min, d.Max)
+		}
+		return
+	}
+	t.Errorf("No row for namespace testns in %v", rows)
+}
+
+func TestReportStaleLabelSelectorView(t *testing.T) {
+	r := NewStatsReporter()
+
+	for i := 0; i < 2; i++ {
+		if err := r.ReportStaleLabelSelector("testns", "Route"); err != nil {
+			t.Errorf("ReportStaleLabelSelector() = %v", err)
+		}
+	}
+
+	rows, err := view.RetrieveData("stale_label_selector_total")
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, t := range row.Tags {
+			tags[t.Key.Name()] = t.Value
+		}
+		if tags[metricskey.LabelNamespaceName] != "testns" || tags["resource_type"] != "Route" {
+			continue
+		}
+		if got := row.Data.(*view.CountData).Value; got != 2 {
+			t.Errorf("Count = %d, want 2", got)
+		}
+		return
+	}
+	t.Errorf("No row for namespace testns and resource type Route in %v", rows)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+// DefaultAdmissionTimeout is how long the API server waits for an admission
+// webhook before giving up on it.
+const DefaultAdmissionTimeout = 30 * time.Second
+
+// MetricsHandler wraps an admission webhook handler and reports whether each
+// admission request succeeded, or why it failed.
+type MetricsHandler struct {
+	// Next is the admission webhook handler.
+	Next http.Handler
+	// Timeout is the time after which the API server gave up on a request.
+	Timeout  time.Duration
+	Reporter StatsReporter
+	Logger   *zap.SugaredLogger
+}
+
+// NewMetricsHandler creates a MetricsHandler using the DefaultAdmissionTimeout.
+func NewMetricsHandler(next http.Handler, logger *zap.SugaredLogger) *MetricsHandler {
+	return &MetricsHandler{
+		Next:     next,
+		Timeout:  DefaultAdmissionTimeout,
+		Reporter: NewStatsReporter(),
+		Logger:   logger,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// Requests that are not admission reviews are left for Next to reject.
+	var review admissionv1beta1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		h.Next.ServeHTTP(w, r)
+		return
+	}
+	resource := review.Request.Kind.Kind
+	operation := string(review.Request.Operation)
+
+	start := time.Now()
+	rw := &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+	if h.serve(rw, r) {
+		h.report(resource, operation, FailureReasonPanic)
+		http.Error(w, "admission webhook panicked", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case time.Since(start) >= h.Timeout:
+		h.report(resource, operation, FailureReasonTimeout)
+	case !isAllowed(rw.body.Bytes()):
+		h.report(resource, operation, FailureReasonValidation)
+	default:
+		if err := h.Reporter.ReportAdmissionSuccess(resource, operation); err != nil {
+			h.Logger.Errorf("Failed to report admission success: %v", err)
+		}
+	}
+
+	for k, v := range rw.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rw.statusCode)
+	w.Write(rw.body.Bytes())
+}
+
+// serve calls Next and returns whether it panicked.
+func (h *MetricsHandler) serve(w http.ResponseWriter, r *http.Request) (panicked bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			h.Logger.Errorf("Admission webhook panicked: %v", err)
+			panicked = true
+		}
+	}()
+	h.Next.ServeHTTP(w, r)
+	return false
+}
+
+func (h *MetricsHandler) report(resource, operation, reason string) {
+	if err := h.Reporter.ReportAdmissionFailure(resource, operation, reason); err != nil {
+		h.Logger.Errorf("Failed to report admission failure: %v", err)
+	}
+}
+
+// isAllowed returns whether the body is an AdmissionReview allowing the request.
+func isAllowed(body []byte) bool {
+	var review admissionv1beta1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		return false
+	}
+	return review.Response != nil && review.Response.Allowed
+}
+
+// bufferedResponseWriter holds the response of the admission webhook until
+// its outcome is known.
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/knative/pkg/logging/testing"
+)
+
+type fakeStatsReporter struct {
+	failures  []string
+	successes []string
+}
+
+func (r *fakeStatsReporter) ReportAdmissionFailure(resource, operation, reason string) error {
+	r.failures = append(r.failures, resource+"/"+operation+"/"+reason)
+	return nil
+}
+
+func (r *fakeStatsReporter) ReportAdmissionSuccess(resource, operation string) error {
+	r.successes = append(r.successes, resource+"/"+operation)
+	return nil
+}
+
+func respond(allowed bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(admissionv1beta1.AdmissionReview{
+			Response: &admissionv1beta1.AdmissionResponse{Allowed: allowed},
+		})
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		next          http.HandlerFunc
+		timeout       time.Duration
+		wantStatus    int
+		wantFailures  []string
+		wantSuccesses []string
+	}{{
+		name:          "allowed",
+		next:          respond(true),
+		timeout:       time.Minute,
+		wantStatus:    http.StatusOK,
+		wantSuccesses: []string{"Revision/CREATE"},
+	}, {
+		name:         "denied",
+		next:         respond(false),
+		timeout:      time.Minute,
+		wantStatus:   http.StatusOK,
+		wantFailures: []string{"Revision/CREATE/validation"},
+	}, {
+		name:         "timed out",
+		next:         respond(true),
+		wantStatus:   http.StatusOK,
+		wantFailures: []string{"Revision/CREATE/timeout"},
+	}, {
+		name: "panicked",
+		next: func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		},
+		timeout:      time.Minute,
+		wantStatus:   http.StatusInternalServerError,
+		wantFailures: []string{"Revision/CREATE/panic"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &fakeStatsReporter{}
+			h := &MetricsHandler{
+				Next:     test.next,
+				Timeout:  test.timeout,
+				Reporter: reporter,
+				Logger:   TestLogger(t),
+			}
+
+			body, _ := json.Marshal(admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Kind: "Revision"},
+					Operation: admissionv1beta1.Create,
+				},
+			})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewReader(body)))
+
+			if w.Code != test.wantStatus {
+				t.Errorf("Status = %d, want %d", w.Code, test.wantStatus)
+			}
+			if !reflect.DeepEqual(reporter.failures, test.wantFailures) {
+				t.Errorf("Reported failures = %v, want %v", reporter.failures, test.wantFailures)
+			}
+			if !reflect.DeepEqual(reporter.successes, test.wantSuccesses) {
+				t.Errorf("Reported successes = %v, want %v", reporter.successes, test.wantSuccesses)
+			}
+		})
+	}
+}
+
+func TestMetricsHandlerPassesThroughOtherRequests(t *testing.T) {
+	reporter := &fakeStatsReporter{}
+	h := &MetricsHandler{
+		Next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}),
+		Timeout:  time.Minute,
+		Reporter: reporter,
+		Logger:   TestLogger(t),
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", bytes.NewBufferString("not json")))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if len(reporter.failures) != 0 || len(reporter.successes) != 0 {
+		t.Errorf("Reported %v and %v, want nothing", reporter.failures, reporter.successes)
+	}
+}
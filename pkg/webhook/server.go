@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// InternalPort is the port the admission controller of knative/pkg listens on
+// when its handler is served through Serve. Its server cannot be given another
+// handler, so Serve takes over the port the webhook is registered on.
+const InternalPort = 8443
+
+// The keys of the certificates in the secret of the admission controller.
+const (
+	secretServerKey  = "server-key.pem"
+	secretServerCert = "server-cert.pem"
+)
+
+// Serve serves handler over TLS on port until stop is closed. It uses the
+// certificates the admission controller stores in the secret, which it
+// creates when it starts.
+func Serve(client kubernetes.Interface, namespace, secretName string, port int,
+	handler http.Handler, stop <-chan struct{}, logger *zap.SugaredLogger) error {
+	certs := &secretCertificate{
+		client:     client,
+		namespace:  namespace,
+		secretName: secretName,
+	}
+	server := &http.Server{
+		Handler:   handler,
+		Addr:      fmt.Sprintf(":%d", port),
+		TLSConfig: &tls.Config{GetCertificate: certs.get},
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServeTLS("", "")
+	}()
+	select {
+	case <-stop:
+		return server.Close()
+	case err := <-errCh:
+		logger.Errorw("ListenAndServeTLS for the admission webhook returned an error", zap.Error(err))
+		return err
+	}
+}
+
+// secretCertificate loads the certificate of the admission controller from
+// its secret on the first TLS handshake, as the secret may not exist yet
+// when the server starts.
+type secretCertificate struct {
+	client     kubernetes.Interface
+	namespace  string
+	secretName string
+
+	mu   sync.Mutex
+	cert *tls.Certificate
+}
+
+func (s *secretCertificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cert != nil {
+		return s.cert, nil
+	}
+
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(s.secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	serverKey, ok := secret.Data[secretServerKey]
+	if !ok {
+		return nil, errors.New("server key missing")
+	}
+	serverCert, ok := secret.Data[secretServerCert]
+	if !ok {
+		return nil, errors.New("server cert missing")
+	}
+	cert, err := tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		return nil, err
+	}
+	s.cert = &cert
+	return s.cert, nil
+}
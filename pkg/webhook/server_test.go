@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	pkgwebhook "github.com/knative/pkg/webhook"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretCertificate(t *testing.T) {
+	client := fakekubeclientset.NewSimpleClientset()
+	certs := &secretCertificate{
+		client:     client,
+		namespace:  "knative-serving",
+		secretName: "webhook-certs",
+	}
+
+	// The admission controller has not created its secret yet.
+	if _, err := certs.get(nil); err == nil {
+		t.Error("get() = nil, want an error before the secret exists")
+	}
+
+	serverKey, serverCert, caCert, err := pkgwebhook.CreateCerts(context.Background(), "webhook", "knative-serving")
+	if err != nil {
+		t.Fatalf("CreateCerts() = %v", err)
+	}
+	client.CoreV1().Secrets("knative-serving").Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook-certs",
+			Namespace: "knative-serving",
+		},
+		Data: map[string][]byte{
+			secretServerKey:  serverKey,
+			secretServerCert: serverCert,
+			"ca-cert.pem":    caCert,
+		},
+	})
+	cert, err := certs.get(nil)
+	if err != nil {
+		t.Fatalf("get() = %v", err)
+	}
+
+	// The certificate is only loaded once.
+	client.CoreV1().Secrets("knative-serving").Delete("webhook-certs", &metav1.DeleteOptions{})
+	if got, err := certs.get(nil); err != nil || got != cert {
+		t.Errorf("get() = %v, %v, want the loaded certificate", got, err)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/knative/serving/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// The reasons an admission request fails.
+const (
+	FailureReasonTimeout    = "timeout"
+	FailureReasonPanic      = "panic"
+	FailureReasonValidation = "validation"
+)
+
+var (
+	admissionFailureCountM = stats.Int64(
+		"admission_failure_total",
+		"Number of admission requests that failed",
+		stats.UnitDimensionless)
+	admissionSuccessCountM = stats.Int64(
+		"admission_success_total",
+		"Number of admission requests that succeeded",
+		stats.UnitDimensionless)
+
+	resourceTagKey      tag.Key
+	operationTagKey     tag.Key
+	failureReasonTagKey tag.Key
+)
+
+func init() {
+	var err error
+	// Create the tag keys that will be used to add tags to our measurements.
+	// Tag keys must conform to the restrictions described in
+	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
+	// - length between 1 and 255 inclusive
+	// - characters are printable US-ASCII
+	resourceTagKey, err = tag.NewKey("resource")
+	if err != nil {
+		panic(err)
+	}
+	operationTagKey, err = tag.NewKey("operation")
+	if err != nil {
+		panic(err)
+	}
+	failureReasonTagKey, err = tag.NewKey("failure_reason")
+	if err != nil {
+		panic(err)
+	}
+
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	err = metrics.RegisterViews(
+		&view.View{
+			Description: "Number of admission requests that failed",
+			Measure:     admissionFailureCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{resourceTagKey, operationTagKey, failureReasonTagKey},
+		},
+		&view.View{
+			Description: "Number of admission requests that succeeded",
+			Measure:     admissionSuccessCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{resourceTagKey, operationTagKey},
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// StatsReporter defines the interface for sending admission metrics
+type StatsReporter interface {
+	// ReportAdmissionFailure captures a failed admission request.
+	ReportAdmissionFailure(resource, operation, reason string) error
+
+	// ReportAdmissionSuccess captures a successful admission request.
+	ReportAdmissionSuccess(resource, operation string) error
+}
+
+// Reporter holds cached metric objects to report admission metrics
+type Reporter struct{}
+
+// NewStatsReporter creates a reporter that collects and reports admission
+// metrics
+func NewStatsReporter() *Reporter {
+	return &Reporter{}
+}
+
+// ReportAdmissionFailure captures a failed admission request.
+func (r *Reporter) ReportAdmissionFailure(resource, operation, reason string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(resourceTagKey, resource),
+		tag.Insert(operationTagKey, operation),
+		tag.Insert(failureReasonTagKey, reason))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, admissionFailureCountM.M(1))
+	return nil
+}
+
+// ReportAdmissionSuccess captures a successful admission request.
+func (r *Reporter) ReportAdmissionSuccess(resource, operation string) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(resourceTagKey, resource),
+		tag.Insert(operationTagKey, operation))
+	if err != nil {
+		return err
+	}
+
+	stats.Record(ctx, admissionSuccessCountM.M(1))
+	return nil
+}